@@ -123,6 +123,11 @@ const (
 	METHOD_REPLICATION Method = "replication"
 	METHOD_STATS       Method = "stats"
 	METHOD_BYE         Method = "bye"
+
+	METHOD_MIGRATION_CREATE  Method = "migration-create"
+	METHOD_MIGRATION_STATUS  Method = "migration-status"
+	METHOD_MIGRATION_PROMOTE Method = "migration-promote"
+	METHOD_MIGRATION_ABORT   Method = "migration-abort"
 )
 
 type ResponseStatus struct {
@@ -196,6 +201,37 @@ type ByeRequest struct {
 	CID string `json:"cid"`
 }
 
+type MigrationCreateRequest struct {
+	Meta      *CORE.InstanceMeta `json:"meta"`
+	Port      int                `json:"port"`
+	Version   string             `json:"version"`
+	Initiator string             `json:"initiator"`
+}
+
+type MigrationCreateResponse struct {
+	Status  ResponseStatus `json:"status"`
+	ID      int            `json:"id"`
+	Port    int            `json:"port"`
+	TLSPort int            `json:"tls_port,omitempty"`
+}
+
+type MigrationRequest struct {
+	ID   int    `json:"id"`
+	UUID string `json:"uuid"`
+}
+
+type MigrationStatusResponse struct {
+	Status ResponseStatus `json:"status"`
+	Info   *MigrationInfo `json:"info"`
+}
+
+type MigrationInfo struct {
+	LinkStatus     string `json:"link_status"`
+	SyncInProgress bool   `json:"sync_in_progress"`
+	SyncLeftBytes  int64  `json:"sync_left_bytes"`
+	Offset         int64  `json:"offset"`
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // GetAuthHeader return API authentication header
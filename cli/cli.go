@@ -64,31 +64,65 @@ const EMPTY_RESULT = "-none-"
 
 // Command line options list
 const (
-	OPT_PRIVATE       = "p:private"
-	OPT_EXTRA         = "x:extra"
-	OPT_TAGS          = "t:tags"
-	OPT_FORMAT        = "f:format"
-	OPT_SECURE        = "s:secure"
-	OPT_DISABLE_SAVES = "ds:disable-saves"
-	OPT_YES           = "y:yes"
-	OPT_PAGER         = "P:pager"
-	OPT_SIMPLE        = "S:simple"
-	OPT_RAW           = "R:raw"
-	OPT_NO_COLOR      = "nc:no-color"
-	OPT_HELP          = "h:help"
-	OPT_VERSION       = "v:version"
+	OPT_PRIVATE        = "p:private"
+	OPT_EXTRA          = "x:extra"
+	OPT_TAGS           = "t:tags"
+	OPT_FORMAT         = "f:format"
+	OPT_SECURE         = "s:secure"
+	OPT_DISABLE_SAVES  = "ds:disable-saves"
+	OPT_YES            = "y:yes"
+	OPT_FROM           = "F:from"
+	OPT_INDEX          = "i:index"
+	OPT_MATCH          = "m:match"
+	OPT_REPLACE        = "r:replace"
+	OPT_DESTROY_SOURCE = "D:destroy-source"
+	OPT_DRY_RUN        = "dry-run"
+	OPT_PRUNE          = "prune"
+	OPT_FROM_RDB       = "from-rdb"
+	OPT_REPLICATE_FROM = "replicate-from"
+	OPT_DESC           = "desc"
+	OPT_OWNER          = "owner"
+	OPT_PASSWORD       = "password"
+	OPT_SERVICE_PASS   = "service-password"
+	OPT_REPLICATION    = "replication-type"
+	OPT_PROFILE        = "profile"
+	OPT_MEMORY_MAX     = "memory-max"
+	OPT_CPU_MAX        = "cpu-max"
+	OPT_IO_WEIGHT      = "io-weight"
+	OPT_STDIN          = "stdin"
+	OPT_SORT           = "sort"
+	OPT_COLUMNS        = "columns"
+	OPT_RECORD         = "record"
+	OPT_PAGER          = "P:pager"
+	OPT_SIMPLE         = "S:simple"
+	OPT_RAW            = "R:raw"
+	OPT_TLS            = "T:tls"
+	OPT_SOCKET         = "u:socket"
+	OPT_NO_COLOR       = "nc:no-color"
+	OPT_HELP           = "h:help"
+	OPT_VERSION        = "v:version"
 
 	OPT_VERBOSE_VERSION = "vv:verbose-version"
 	OPT_GENERATE_MAN    = "generate-man"
 	OPT_COMPLETION      = "completion"
 )
 
+// Help topics
+const (
+	HELP_TOPIC_QUERY     = "query"
+	HELP_TOPIC_SELECTORS = "selectors"
+)
+
 // Supported commands
 const (
+	COMMAND_ADOPT                = "adopt"
+	COMMAND_APPLY                = "apply"
 	COMMAND_BACKUP_CREATE        = "backup-create"
 	COMMAND_BACKUP_RESTORE       = "backup-restore"
 	COMMAND_BACKUP_CLEAN         = "backup-clean"
 	COMMAND_BACKUP_LIST          = "backup-list"
+	COMMAND_BACKUP_VERIFY        = "backup-verify"
+	COMMAND_BACKUP_ROTATE_KEY    = "backup-rotate-key"
 	COMMAND_BATCH_CREATE         = "batch-create"
 	COMMAND_BATCH_EDIT           = "batch-edit"
 	COMMAND_CHECK                = "check"
@@ -97,12 +131,15 @@ const (
 	COMMAND_CPU                  = "cpu"
 	COMMAND_CONF                 = "conf"
 	COMMAND_CREATE               = "create"
+	COMMAND_DASHBOARD            = "dashboard"
 	COMMAND_DELETE               = "delete"
 	COMMAND_DESTROY              = "destroy"
 	COMMAND_EDIT                 = "edit"
+	COMMAND_EXPORT               = "export"
 	COMMAND_GEN_TOKEN            = "gen-token"
 	COMMAND_GO                   = "go"
 	COMMAND_HELP                 = "help"
+	COMMAND_IMPORT               = "import"
 	COMMAND_INFO                 = "info"
 	COMMAND_INIT                 = "init"
 	COMMAND_KILL                 = "kill"
@@ -110,6 +147,7 @@ const (
 	COMMAND_LOG                  = "log"
 	COMMAND_MAINTENANCE          = "maintenance"
 	COMMAND_MEMORY               = "memory"
+	COMMAND_MIGRATE              = "migrate"
 	COMMAND_REGEN                = "regen"
 	COMMAND_RELEASE              = "release"
 	COMMAND_RELOAD               = "reload"
@@ -141,6 +179,7 @@ const (
 	COMMAND_STATS_COMMAND        = "stats-command"
 	COMMAND_STATS_LATENCY        = "stats-latency"
 	COMMAND_STATS_ERROR          = "stats-error"
+	COMMAND_STATS_HISTORY        = "stats-history"
 	COMMAND_STATUS               = "status"
 	COMMAND_STOP                 = "stop"
 	COMMAND_STOP_ALL             = "stop-all"
@@ -152,6 +191,7 @@ const (
 	COMMAND_TOP_DIFF             = "top-diff"
 	COMMAND_TOP_DUMP             = "top-dump"
 	COMMAND_TRACK                = "track"
+	COMMAND_TRACK_REPLAY         = "track-replay"
 	COMMAND_VALIDATE_TEMPLATES   = "validate-templates"
 	COMMAND_UPTIME               = "uptime"
 )
@@ -160,6 +200,7 @@ const (
 	FORMAT_TEXT = "text"
 	FORMAT_JSON = "json"
 	FORMAT_XML  = "xml"
+	FORMAT_CSV  = "csv"
 )
 
 const (
@@ -192,19 +233,43 @@ type CommandRoutine struct {
 
 // optMap is map with options data
 var optMap = options.Map{
-	OPT_PRIVATE:       {Type: options.BOOL},
-	OPT_EXTRA:         {Type: options.BOOL},
-	OPT_TAGS:          {},
-	OPT_FORMAT:        {},
-	OPT_SECURE:        {Type: options.BOOL},
-	OPT_DISABLE_SAVES: {Type: options.BOOL},
-	OPT_PAGER:         {Type: options.BOOL},
-	OPT_SIMPLE:        {Type: options.BOOL},
-	OPT_RAW:           {Type: options.BOOL},
-	OPT_YES:           {Type: options.BOOL},
-	OPT_NO_COLOR:      {Type: options.BOOL},
-	OPT_HELP:          {Type: options.BOOL},
-	OPT_VERSION:       {Type: options.MIXED},
+	OPT_PRIVATE:        {Type: options.BOOL},
+	OPT_EXTRA:          {Type: options.BOOL},
+	OPT_TAGS:           {},
+	OPT_FORMAT:         {},
+	OPT_SECURE:         {Type: options.BOOL},
+	OPT_DISABLE_SAVES:  {Type: options.BOOL},
+	OPT_PAGER:          {Type: options.BOOL},
+	OPT_SIMPLE:         {Type: options.BOOL},
+	OPT_RAW:            {Type: options.BOOL},
+	OPT_TLS:            {Type: options.BOOL, Conflicts: OPT_SOCKET},
+	OPT_SOCKET:         {Type: options.BOOL},
+	OPT_YES:            {Type: options.BOOL},
+	OPT_FROM:           {},
+	OPT_INDEX:          {Type: options.INT, Min: 1},
+	OPT_MATCH:          {},
+	OPT_REPLACE:        {Type: options.BOOL},
+	OPT_DESTROY_SOURCE: {Type: options.BOOL},
+	OPT_DRY_RUN:        {Type: options.BOOL},
+	OPT_PRUNE:          {Type: options.BOOL},
+	OPT_FROM_RDB:       {Conflicts: OPT_REPLICATE_FROM},
+	OPT_REPLICATE_FROM: {},
+	OPT_DESC:           {},
+	OPT_OWNER:          {},
+	OPT_PASSWORD:       {},
+	OPT_SERVICE_PASS:   {},
+	OPT_REPLICATION:    {},
+	OPT_PROFILE:        {},
+	OPT_MEMORY_MAX:     {},
+	OPT_CPU_MAX:        {Type: options.INT, Min: 0},
+	OPT_IO_WEIGHT:      {Type: options.INT, Min: 0},
+	OPT_STDIN:          {Type: options.BOOL},
+	OPT_SORT:           {},
+	OPT_COLUMNS:        {},
+	OPT_RECORD:         {},
+	OPT_NO_COLOR:       {Type: options.BOOL},
+	OPT_HELP:           {Type: options.BOOL},
+	OPT_VERSION:        {Type: options.MIXED},
 
 	OPT_VERBOSE_VERSION: {Type: options.BOOL},
 	OPT_GENERATE_MAN:    {Type: options.BOOL},
@@ -422,7 +487,7 @@ func disableProxy() {
 func validateOptions() {
 	if options.Has(OPT_FORMAT) {
 		switch options.GetS(OPT_FORMAT) {
-		case FORMAT_JSON, FORMAT_TEXT, FORMAT_XML:
+		case FORMAT_CSV, FORMAT_JSON, FORMAT_TEXT, FORMAT_XML:
 			// nop
 		default:
 			terminal.Error("Format %s is not supported", options.GetS(OPT_FORMAT))
@@ -463,7 +528,10 @@ func initCommands() {
 		commands[COMMAND_BACKUP_CLEAN] = &CommandRoutine{BackupCleanCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
 		commands[COMMAND_BACKUP_CREATE] = &CommandRoutine{BackupCreateCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
 		commands[COMMAND_BACKUP_LIST] = &CommandRoutine{BackupListCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
+		commands[COMMAND_BACKUP_VERIFY] = &CommandRoutine{BackupVerifyCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
+		commands[COMMAND_BACKUP_ROTATE_KEY] = &CommandRoutine{BackupRotateKeyCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_BACKUP_RESTORE] = &CommandRoutine{BackupRestoreCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
+		commands[COMMAND_IMPORT] = &CommandRoutine{ImportCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
 		commands[COMMAND_KILL] = &CommandRoutine{KillCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_MAINTENANCE] = &CommandRoutine{MaintenanceCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_REGEN] = &CommandRoutine{RegenCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
@@ -478,6 +546,8 @@ func initCommands() {
 		commands[COMMAND_BACKUP_CLEAN] = &CommandRoutine{BackupCleanCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_BACKUP_CREATE] = &CommandRoutine{BackupCreateCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_BACKUP_LIST] = &CommandRoutine{BackupListCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_BACKUP_VERIFY] = &CommandRoutine{BackupVerifyCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_BACKUP_ROTATE_KEY] = &CommandRoutine{BackupRotateKeyCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_BACKUP_RESTORE] = &CommandRoutine{BackupRestoreCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_KILL] = &CommandRoutine{KillCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_MAINTENANCE] = &CommandRoutine{MaintenanceCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
@@ -492,11 +562,14 @@ func initCommands() {
 	}
 
 	if isMaster {
-		commands[COMMAND_BATCH_CREATE] = &CommandRoutine{BatchCreateCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_ADOPT] = &CommandRoutine{AdoptCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_APPLY] = &CommandRoutine{ApplyCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_BATCH_CREATE] = &CommandRoutine{BatchCreateCommand, AUTH_SUPERUSER | AUTH_STRICT, options.GetS(OPT_FORMAT) == ""}
 		commands[COMMAND_BATCH_EDIT] = &CommandRoutine{BatchEditCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
-		commands[COMMAND_CREATE] = &CommandRoutine{CreateCommand, AUTH_NO, true}
+		commands[COMMAND_CREATE] = &CommandRoutine{CreateCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
 		commands[COMMAND_DESTROY] = &CommandRoutine{DestroyCommand, AUTH_INSTANCE | AUTH_SUPERUSER | AUTH_STRICT, true}
-		commands[COMMAND_EDIT] = &CommandRoutine{EditCommand, AUTH_INSTANCE | AUTH_SUPERUSER | AUTH_STRICT, true}
+		commands[COMMAND_EDIT] = &CommandRoutine{EditCommand, AUTH_INSTANCE | AUTH_SUPERUSER | AUTH_STRICT, options.GetS(OPT_FORMAT) == ""}
+		commands[COMMAND_MIGRATE] = &CommandRoutine{MigrateCommand, AUTH_INSTANCE | AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_STATE_RESTORE] = &CommandRoutine{RestoreStateCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_STATE_SAVE] = &CommandRoutine{SaveStateCommand, AUTH_SUPERUSER | AUTH_STRICT, true}
 		commands[COMMAND_TAG_ADD] = &CommandRoutine{TagAddCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
@@ -533,25 +606,29 @@ func initCommands() {
 
 	if !isSentinel {
 		commands[COMMAND_CHECK] = &CommandRoutine{CheckCommand, AUTH_NO, true}
-		commands[COMMAND_CLIENTS] = &CommandRoutine{ClientsCommand, AUTH_NO, true}
-		commands[COMMAND_CPU] = &CommandRoutine{CPUCommand, AUTH_NO, true}
+		commands[COMMAND_CLIENTS] = &CommandRoutine{ClientsCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
+		commands[COMMAND_CPU] = &CommandRoutine{CPUCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
+		commands[COMMAND_DASHBOARD] = &CommandRoutine{DashboardCommand, AUTH_NO, false}
+		commands[COMMAND_EXPORT] = &CommandRoutine{ExportCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
 		commands[COMMAND_GO] = &CommandRoutine{GoCommand, AUTH_NO, true}
 		commands[COMMAND_INFO] = &CommandRoutine{InfoCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
-		commands[COMMAND_LIST] = &CommandRoutine{ListCommand, AUTH_NO, !useRawOutput}
+		commands[COMMAND_LIST] = &CommandRoutine{ListCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
 		commands[COMMAND_LOG] = &CommandRoutine{LogCommand, AUTH_NO, !useRawOutput}
-		commands[COMMAND_MEMORY] = &CommandRoutine{MemoryCommand, AUTH_NO, !useRawOutput}
-		commands[COMMAND_SLOWLOG_GET] = &CommandRoutine{SlowlogGetCommand, AUTH_NO, true}
+		commands[COMMAND_MEMORY] = &CommandRoutine{MemoryCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
+		commands[COMMAND_SLOWLOG_GET] = &CommandRoutine{SlowlogGetCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
 		commands[COMMAND_SLOWLOG_RESET] = &CommandRoutine{SlowlogResetCommand, AUTH_INSTANCE | AUTH_SUPERUSER, true}
 		commands[COMMAND_STATS] = &CommandRoutine{StatsCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
 		commands[COMMAND_STATS_COMMAND] = &CommandRoutine{StatsCommandCommand, AUTH_NO, true}
 		commands[COMMAND_STATS_ERROR] = &CommandRoutine{StatsErrorCommand, AUTH_NO, true}
+		commands[COMMAND_STATS_HISTORY] = &CommandRoutine{StatsHistoryCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
 		commands[COMMAND_STATS_LATENCY] = &CommandRoutine{StatsLatencyCommand, AUTH_NO, true}
 		commands[COMMAND_STATUS] = &CommandRoutine{StatusCommand, AUTH_NO, true}
-		commands[COMMAND_TOP] = &CommandRoutine{TopCommand, AUTH_NO, !useRawOutput}
-		commands[COMMAND_TOP_DIFF] = &CommandRoutine{TopDiffCommand, AUTH_NO, true}
+		commands[COMMAND_TOP] = &CommandRoutine{TopCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
+		commands[COMMAND_TOP_DIFF] = &CommandRoutine{TopDiffCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
 		commands[COMMAND_TOP_DUMP] = &CommandRoutine{TopDumpCommand, AUTH_NO, true}
 		commands[COMMAND_TRACK] = &CommandRoutine{TrackCommand, AUTH_NO, true}
-		commands[COMMAND_UPTIME] = &CommandRoutine{UptimeCommand, AUTH_NO, true}
+		commands[COMMAND_TRACK_REPLAY] = &CommandRoutine{TrackReplayCommand, AUTH_NO, options.GetS(OPT_FORMAT) == "" && !useRawOutput}
+		commands[COMMAND_UPTIME] = &CommandRoutine{UptimeCommand, AUTH_NO, options.GetS(OPT_FORMAT) == ""}
 	}
 
 	if isSentinelFailover {
@@ -629,6 +706,11 @@ func runCommand(args options.Arguments) {
 
 	cr := commands[cmd]
 
+	// Restoring data from other instance or file requires superuser password
+	if cmd == COMMAND_BACKUP_RESTORE && options.Has(OPT_FROM) && cr.Auth.Has(AUTH_INSTANCE) {
+		cr = &CommandRoutine{cr.Handler, AUTH_SUPERUSER, cr.PrettyOutput}
+	}
+
 	if isMaintenanceLockSet() {
 		fmtc.NewLine()
 		panel.Warn(
@@ -652,6 +734,11 @@ with {*}extreme care{!}.`,
 
 	isTipsEnabled = checkForTips(cmd)
 
+	if slices.Contains(selectorCommands, cmd) && isInstanceSelector(args.Get(1).String()) {
+		executeCommandRoutineForSelector(cmd, cr, args.Strings()[1:])
+		return
+	}
+
 	executeCommandRoutine(cr, args.Strings()[1:])
 }
 
@@ -801,7 +888,7 @@ func checkForTips(cmd string) bool {
 	}
 
 	switch cmd {
-	case COMMAND_CREATE, COMMAND_DESTROY, COMMAND_EDIT,
+	case COMMAND_ADOPT, COMMAND_CREATE, COMMAND_DESTROY, COMMAND_EDIT,
 		COMMAND_START, COMMAND_STOP, COMMAND_RESTART, COMMAND_KILL,
 		COMMAND_STATUS, COMMAND_CPU, COMMAND_MEMORY, COMMAND_INFO,
 		COMMAND_CLIENTS, COMMAND_CONF, COMMAND_LIST, COMMAND_STATS,
@@ -809,7 +896,8 @@ func checkForTips(cmd string) bool {
 		COMMAND_TOP, COMMAND_TOP_DIFF, COMMAND_TOP_DUMP, COMMAND_SLOWLOG_GET,
 		COMMAND_SLOWLOG_RESET, COMMAND_TAG_ADD, COMMAND_TAG_REMOVE,
 		COMMAND_CHECK, COMMAND_BACKUP_CREATE, COMMAND_BACKUP_RESTORE,
-		COMMAND_BACKUP_CLEAN, COMMAND_BACKUP_LIST:
+		COMMAND_BACKUP_CLEAN, COMMAND_BACKUP_LIST, COMMAND_BACKUP_VERIFY,
+		COMMAND_EXPORT, COMMAND_IMPORT, COMMAND_MIGRATE:
 		return true
 	}
 
@@ -819,12 +907,14 @@ func checkForTips(cmd string) bool {
 // getSpellcheckModel train spellchecker with supported commands
 func getSpellcheckModel() *spellcheck.Model {
 	return spellcheck.Train([]string{
-		COMMAND_BACKUP_CREATE, COMMAND_BACKUP_RESTORE, COMMAND_BACKUP_CLEAN,
-		COMMAND_BACKUP_LIST, COMMAND_BATCH_CREATE, COMMAND_BATCH_EDIT, COMMAND_CHECK,
-		COMMAND_CLI, COMMAND_CPU, COMMAND_CONF, COMMAND_CREATE, COMMAND_DELETE,
-		COMMAND_DESTROY, COMMAND_EDIT, COMMAND_GEN_TOKEN, COMMAND_GO, COMMAND_HELP,
-		COMMAND_INFO, COMMAND_INIT, COMMAND_KILL, COMMAND_LIST, COMMAND_MAINTENANCE,
-		COMMAND_MEMORY, COMMAND_REGEN, COMMAND_RELEASE, COMMAND_RELOAD,
+		COMMAND_ADOPT, COMMAND_APPLY, COMMAND_BACKUP_CREATE, COMMAND_BACKUP_RESTORE, COMMAND_BACKUP_CLEAN,
+		COMMAND_BACKUP_LIST, COMMAND_BACKUP_VERIFY, COMMAND_BACKUP_ROTATE_KEY,
+		COMMAND_BATCH_CREATE,
+		COMMAND_BATCH_EDIT, COMMAND_CHECK,
+		COMMAND_CLI, COMMAND_CPU, COMMAND_CONF, COMMAND_CREATE, COMMAND_DASHBOARD,
+		COMMAND_DELETE, COMMAND_DESTROY, COMMAND_EDIT, COMMAND_EXPORT, COMMAND_GEN_TOKEN, COMMAND_GO,
+		COMMAND_HELP, COMMAND_IMPORT, COMMAND_INFO, COMMAND_INIT, COMMAND_KILL, COMMAND_LIST, COMMAND_MAINTENANCE,
+		COMMAND_MEMORY, COMMAND_MIGRATE, COMMAND_REGEN, COMMAND_RELEASE, COMMAND_RELOAD,
 		COMMAND_REMOVE, COMMAND_REPLICATION, COMMAND_REPLICATION_ROLE_SET,
 		COMMAND_RESTART, COMMAND_RESTART_ALL, COMMAND_RESTART_ALL_PROP,
 		COMMAND_RESTART_PROP, COMMAND_SENTINEL_CHECK, COMMAND_SENTINEL_INFO,
@@ -834,10 +924,11 @@ func getSpellcheckModel() *spellcheck.Model {
 		COMMAND_START_ALL, COMMAND_START_ALL_PROP, COMMAND_START_PROP,
 		COMMAND_STATE_RESTORE, COMMAND_STATE_SAVE, COMMAND_STATS,
 		COMMAND_STATS_COMMAND, COMMAND_STATS_LATENCY, COMMAND_STATS_ERROR,
+		COMMAND_STATS_HISTORY,
 		COMMAND_STATUS, COMMAND_STOP, COMMAND_STOP_ALL, COMMAND_STOP_ALL_PROP,
 		COMMAND_STOP_PROP, COMMAND_TAG_ADD, COMMAND_TAG_REMOVE, COMMAND_TOP,
-		COMMAND_TOP_DIFF, COMMAND_TOP_DUMP, COMMAND_TRACK, COMMAND_VALIDATE_TEMPLATES,
-		COMMAND_UPTIME,
+		COMMAND_TOP_DIFF, COMMAND_TOP_DUMP, COMMAND_TRACK, COMMAND_TRACK_REPLAY,
+		COMMAND_VALIDATE_TEMPLATES, COMMAND_UPTIME,
 	})
 }
 
@@ -977,8 +1068,11 @@ func showSmartUsage() {
 
 	if isMaster {
 		info.AddCommand(COMMAND_CREATE, "Create new Redis instance")
+		info.AddCommand(COMMAND_ADOPT, "Move unmanaged Redis server under RDS control", "config")
+		info.AddCommand(COMMAND_APPLY, "Converge instances state to the spec", "spec")
 		info.AddCommand(COMMAND_DESTROY, "Destroy {s}(delete){!} Redis instance", "id")
 		info.AddCommand(COMMAND_EDIT, "Edit metadata for instance", "id")
+		info.AddCommand(COMMAND_MIGRATE, "Migrate instance to another RDS node", "id", "node")
 	}
 
 	if isMaster || (isMinion && allowCommands) {
@@ -996,6 +1090,8 @@ func showSmartUsage() {
 		info.AddCommand(COMMAND_INFO, "Show system info about Redis instance", "id", "?section…")
 		info.AddCommand(COMMAND_CLIENTS, "Show list of connected clients", "id", "?filter")
 		info.AddCommand(COMMAND_TRACK, "Show interactive info about Redis instance", "id", "?interval")
+		info.AddCommand(COMMAND_TRACK_REPLAY, "Show metrics recorded by track command", "file")
+		info.AddCommand(COMMAND_DASHBOARD, "Show interactive dashboard with all instances", "?interval", "?query…")
 		info.AddCommand(COMMAND_CONF, "Show configuration of Redis instance", "id", "?filter…")
 		info.AddCommand(COMMAND_LIST, "Show list of all Redis instances", "?filter…")
 		info.AddCommand(COMMAND_LOG, "Show RDS or Redis instance logs", "source")
@@ -1003,9 +1099,10 @@ func showSmartUsage() {
 		info.AddCommand(COMMAND_STATS_COMMAND, "Show statistics based on the command type", "id")
 		info.AddCommand(COMMAND_STATS_LATENCY, "Show latency statistics based on the command type", "id")
 		info.AddCommand(COMMAND_STATS_ERROR, "Show error statistics", "id")
+		info.AddCommand(COMMAND_STATS_HISTORY, "Show instance or node metrics history", "id|node", "metric", "?period")
 		info.AddCommand(COMMAND_UPTIME, "Show instances uptime info")
 		info.AddCommand(COMMAND_TOP, "Show instances top", "?field", "?num")
-		info.AddCommand(COMMAND_TOP_DIFF, "Compare current and dumped top data", "file", "?field", "?num")
+		info.AddCommand(COMMAND_TOP_DIFF, "Compare top data from dumps or current data", "file…", "?field", "?num")
 		info.AddCommand(COMMAND_TOP_DUMP, "Dump top data to file", "file")
 		info.AddCommand(COMMAND_SLOWLOG_GET, "Show last entries from slow log", "id", "?num")
 		info.AddCommand(COMMAND_SLOWLOG_RESET, "Clear slow log", "id")
@@ -1024,12 +1121,19 @@ func showSmartUsage() {
 	info.AddCommand(COMMAND_BACKUP_RESTORE, "Restore instance data from snapshot", "id")
 	info.AddCommand(COMMAND_BACKUP_CLEAN, "Remove all backup snapshots", "id")
 	info.AddCommand(COMMAND_BACKUP_LIST, "List backup snapshots", "id")
+	info.AddCommand(COMMAND_BACKUP_VERIFY, "Verify backup snapshot", "id", "?index")
+	info.AddCommand(COMMAND_BACKUP_ROTATE_KEY, "Re-encrypt all backups with a new key")
+	info.AddCommand(COMMAND_EXPORT, "Export keys from instance to file", "id:db", "file")
+
+	if isMaster {
+		info.AddCommand(COMMAND_IMPORT, "Import keys from file to instance", "id:db", "file")
+	}
 
 	info.AddGroup("Superuser commands")
 
 	if isMaster {
 		info.AddCommand(COMMAND_GO, "Generate superuser access credentials")
-		info.AddCommand(COMMAND_BATCH_CREATE, "Create many instances at once", "csv-file")
+		info.AddCommand(COMMAND_BATCH_CREATE, "Create many instances at once", "file")
 		info.AddCommand(COMMAND_BATCH_EDIT, "Edit many instances at once", "id…")
 	}
 
@@ -1090,40 +1194,77 @@ func showSmartUsage() {
 
 	if isMaster {
 		info.AddOption(OPT_SECURE, "Create secure Redis instance with auth support ({y}create{!})")
-		info.AddOption(OPT_DISABLE_SAVES, "Disable saves for created instance ({y}create{!})")
+		info.AddOption(OPT_DISABLE_SAVES, "Disable saves for created instance ({y}create{!}/{y}batch-create{!})")
+		info.AddOption(OPT_FROM_RDB, "Seed instance with data from RDB file ({y}create{!})", "file")
+		info.AddOption(OPT_REPLICATE_FROM, "Seed instance with data from external Redis server ({y}create{!})", "host:port")
+		info.AddOption(OPT_DESC, "Instance description ({y}create{!}/{y}edit{!})", "desc")
+		info.AddOption(OPT_OWNER, "Instance owner ({y}create{!}/{y}edit{!})", "owner")
+		info.AddOption(OPT_PASSWORD, "Instance password ({y}create{!}/{y}edit{!})", "password")
+		info.AddOption(OPT_SERVICE_PASS, "Service password ({y}create{!})", "password")
+		info.AddOption(OPT_REPLICATION, "Replication type ({y}create{!}/{y}edit{!})", "type")
+		info.AddOption(OPT_PROFILE, "Template profile ({y}create{!}/{y}edit{!})", "name")
+		info.AddOption(OPT_MEMORY_MAX, "Memory limit ({y}create{!}/{y}edit{!})", "size")
+		info.AddOption(OPT_CPU_MAX, "CPU limit in percents of one core ({y}create{!}/{y}edit{!})", "percent")
+		info.AddOption(OPT_IO_WEIGHT, "IO weight ({y}create{!}/{y}edit{!})", "weight")
+		info.AddOption(OPT_STDIN, "Read instance properties in JSON format from stdin ({y}create{!}/{y}edit{!})")
 	}
 
 	info.AddOption(OPT_PRIVATE, "Force access to private data ({y}conf{!}/{y}cli{!}/{y}settings{!})")
 	info.AddOption(OPT_EXTRA, "Print extra info ({y}list{!})")
+	info.AddOption(OPT_SORT, "Sort instances by given column ({y}list{!})", "column")
+	info.AddOption(OPT_COLUMNS, "List of columns to show ({y}list{!})", "column…")
+	info.AddOption(OPT_RECORD, "Record metrics to file ({y}track{!})", "file")
 	info.AddOption(OPT_TAGS, "List of tags ({y}create{!})", "tag…")
-	info.AddOption(OPT_FORMAT, "Output format {s-}(text/json/xml){!}", "format")
+	info.AddOption(OPT_FORMAT, "Output format {s-}(text/json/xml/csv){!}", "format")
 	info.AddOption(OPT_YES, "Automatically answer yes for all questions")
+	info.AddOption(OPT_FROM, "Snapshot source instance ID or file ({y}backup-restore{!})", "id|file")
+	info.AddOption(OPT_INDEX, "Snapshot index ({y}backup-restore{!})", "index")
+	info.AddOption(OPT_MATCH, "Key pattern ({y}export{!}/{y}import{!})", "pattern")
+	info.AddOption(OPT_REPLACE, "Replace existing keys ({y}import{!})")
+	info.AddOption(OPT_DESTROY_SOURCE, "Destroy source instance after migration ({y}migrate{!})")
+	info.AddOption(OPT_DRY_RUN, "Show plan without making changes ({y}adopt{!}/{y}apply{!})")
+	info.AddOption(OPT_PRUNE, "Allow destroying all instances by spec without instances ({y}apply{!})")
 	info.AddOption(OPT_PAGER, "Enable pager for long output")
 	info.AddOption(OPT_SIMPLE, "Simplify output {s-}(useful for copy-paste){!}")
 	info.AddOption(OPT_RAW, "Force raw output {s-}(useful for scripts){!}")
+	info.AddOption(OPT_TLS, "Connect to instance using TLS ({y}cli{!})")
+	info.AddOption(OPT_SOCKET, "Connect to instance using unix socket ({y}cli{!})")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VERSION, "Show information about version")
 	info.AddOption(OPT_VERBOSE_VERSION, "Show verbose information about version")
 
-	info.BoundOptions(COMMAND_CLI, OPT_TAGS)
-	info.BoundOptions(COMMAND_CLIENTS, OPT_PAGER)
+	info.BoundOptions(COMMAND_BACKUP_RESTORE, OPT_FROM, OPT_INDEX, OPT_TAGS, OPT_YES)
+	info.BoundOptions(COMMAND_BATCH_CREATE, OPT_DISABLE_SAVES, OPT_FORMAT, OPT_PRIVATE, OPT_YES)
+	info.BoundOptions(COMMAND_CLI, OPT_TAGS, OPT_TLS, OPT_SOCKET)
+	info.BoundOptions(COMMAND_CLIENTS, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_CONF, OPT_TAGS, OPT_PAGER)
-	info.BoundOptions(COMMAND_CREATE, OPT_SECURE, OPT_DISABLE_SAVES, OPT_TAGS)
+	info.BoundOptions(COMMAND_CPU, OPT_FORMAT)
+	info.BoundOptions(COMMAND_EXPORT, OPT_MATCH, OPT_FORMAT, OPT_YES)
+	info.BoundOptions(COMMAND_IMPORT, OPT_MATCH, OPT_REPLACE, OPT_YES)
+	info.BoundOptions(COMMAND_MIGRATE, OPT_DESTROY_SOURCE, OPT_YES)
+	info.BoundOptions(COMMAND_ADOPT, OPT_DRY_RUN, OPT_TAGS, OPT_YES)
+	info.BoundOptions(COMMAND_APPLY, OPT_DRY_RUN, OPT_PRUNE, OPT_YES)
+	info.BoundOptions(COMMAND_CREATE, OPT_SECURE, OPT_DISABLE_SAVES, OPT_TAGS, OPT_FROM_RDB, OPT_REPLICATE_FROM, OPT_DESC, OPT_OWNER, OPT_PASSWORD, OPT_SERVICE_PASS, OPT_REPLICATION, OPT_PROFILE, OPT_MEMORY_MAX, OPT_CPU_MAX, OPT_IO_WEIGHT, OPT_STDIN, OPT_FORMAT)
+	info.BoundOptions(COMMAND_EDIT, OPT_DESC, OPT_OWNER, OPT_PASSWORD, OPT_REPLICATION, OPT_PROFILE, OPT_TAGS, OPT_DISABLE_SAVES, OPT_MEMORY_MAX, OPT_CPU_MAX, OPT_IO_WEIGHT, OPT_STDIN, OPT_FORMAT)
 	info.BoundOptions(COMMAND_HELP, OPT_PAGER)
 	info.BoundOptions(COMMAND_INFO, OPT_FORMAT, OPT_PAGER)
-	info.BoundOptions(COMMAND_LIST, OPT_EXTRA, OPT_PAGER)
-	info.BoundOptions(COMMAND_MEMORY, OPT_FORMAT)
+	info.BoundOptions(COMMAND_LIST, OPT_EXTRA, OPT_SORT, OPT_COLUMNS, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_MEMORY, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_REPLICATION, OPT_FORMAT)
 	info.BoundOptions(COMMAND_SENTINEL_INFO, OPT_PAGER)
 	info.BoundOptions(COMMAND_SETTINGS, OPT_TAGS, OPT_PAGER)
-	info.BoundOptions(COMMAND_SLOWLOG_GET, OPT_PAGER)
+	info.BoundOptions(COMMAND_SLOWLOG_GET, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_STATS, OPT_FORMAT)
 	info.BoundOptions(COMMAND_STATS_COMMAND, OPT_PAGER)
 	info.BoundOptions(COMMAND_STATS_ERROR, OPT_PAGER)
+	info.BoundOptions(COMMAND_STATS_HISTORY, OPT_FORMAT)
 	info.BoundOptions(COMMAND_STATS_LATENCY, OPT_PAGER)
-	info.BoundOptions(COMMAND_TOP, OPT_PAGER)
-	info.BoundOptions(COMMAND_TOP_DIFF, OPT_PAGER)
+	info.BoundOptions(COMMAND_TOP, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_TOP_DIFF, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_TRACK, OPT_RECORD)
+	info.BoundOptions(COMMAND_TRACK_REPLAY, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_UPTIME, OPT_FORMAT)
 
 	info.Print()
 
@@ -1139,8 +1280,11 @@ func genUsage() *usage.Info {
 	info.AddGroup("Basic commands")
 
 	info.AddCommand(COMMAND_CREATE, "Create new Redis instance")
+	info.AddCommand(COMMAND_ADOPT, "Move unmanaged Redis server under RDS control", "config")
+	info.AddCommand(COMMAND_APPLY, "Converge instances state to the spec", "spec")
 	info.AddCommand(COMMAND_DESTROY, "Destroy {s}(delete){!} Redis instance", "id")
 	info.AddCommand(COMMAND_EDIT, "Edit metadata for instance", "id")
+	info.AddCommand(COMMAND_MIGRATE, "Migrate instance to another RDS node", "id", "node")
 	info.AddCommand(COMMAND_START, "Start Redis instance", "id")
 	info.AddCommand(COMMAND_STOP, "Stop Redis instance", "id", "?force")
 	info.AddCommand(COMMAND_RESTART, "Restart Redis instance", "id")
@@ -1153,14 +1297,17 @@ func genUsage() *usage.Info {
 	info.AddCommand(COMMAND_STATS_COMMAND, "Show statistics based on the command type", "id")
 	info.AddCommand(COMMAND_STATS_LATENCY, "Show latency statistics based on the command type", "id")
 	info.AddCommand(COMMAND_STATS_ERROR, "Show error statistics", "id")
+	info.AddCommand(COMMAND_STATS_HISTORY, "Show instance or node metrics history", "id|node", "metric", "?period")
 	info.AddCommand(COMMAND_CLIENTS, "Show list of connected clients", "id", "?filter")
 	info.AddCommand(COMMAND_TRACK, "Show interactive info about Redis instance", "id", "?interval")
+	info.AddCommand(COMMAND_TRACK_REPLAY, "Show metrics recorded by track command", "file")
+	info.AddCommand(COMMAND_DASHBOARD, "Show interactive dashboard with all instances", "?interval", "?query…")
 	info.AddCommand(COMMAND_CONF, "Show configuration of Redis instance", "id", "?filter…")
 	info.AddCommand(COMMAND_LIST, "Show list of all Redis instances", "?filter…")
 	info.AddCommand(COMMAND_LOG, "Show RDS or Redis instance logs", "source")
 	info.AddCommand(COMMAND_STATS, "Show overall statistics")
 	info.AddCommand(COMMAND_TOP, "Show instances top", "?field", "?num")
-	info.AddCommand(COMMAND_TOP_DIFF, "Compare current and dumped top data", "file", "?field", "?num")
+	info.AddCommand(COMMAND_TOP_DIFF, "Compare top data from dumps or current data", "file…", "?field", "?num")
 	info.AddCommand(COMMAND_TOP_DUMP, "Dump top data to file", "file")
 	info.AddCommand(COMMAND_UPTIME, "Show instances uptime info")
 	info.AddCommand(COMMAND_SLOWLOG_GET, "Show last entries from slow log", "id", "?num")
@@ -1175,11 +1322,15 @@ func genUsage() *usage.Info {
 	info.AddCommand(COMMAND_BACKUP_RESTORE, "Restore instance data from snapshot", "id")
 	info.AddCommand(COMMAND_BACKUP_CLEAN, "Remove all backup snapshots", "id")
 	info.AddCommand(COMMAND_BACKUP_LIST, "List backup snapshots", "id")
+	info.AddCommand(COMMAND_BACKUP_VERIFY, "Verify backup snapshot", "id", "?index")
+	info.AddCommand(COMMAND_BACKUP_ROTATE_KEY, "Re-encrypt all backups with a new key")
+	info.AddCommand(COMMAND_EXPORT, "Export keys from instance to file", "id:db", "file")
+	info.AddCommand(COMMAND_IMPORT, "Import keys from file to instance", "id:db", "file")
 
 	info.AddGroup("Superuser commands")
 
 	info.AddCommand(COMMAND_GO, "Generate superuser access credentials")
-	info.AddCommand(COMMAND_BATCH_CREATE, "Create many instances at once", "csv-file")
+	info.AddCommand(COMMAND_BATCH_CREATE, "Create many instances at once", "file")
 	info.AddCommand(COMMAND_BATCH_EDIT, "Edit many instances at once", "id…")
 	info.AddCommand(COMMAND_STOP_ALL, "Stop all instances")
 	info.AddCommand(COMMAND_START_ALL, "Start all instances")
@@ -1214,38 +1365,72 @@ func genUsage() *usage.Info {
 	info.AddCommand(COMMAND_VALIDATE_TEMPLATES, "Validate Redis and Sentinel templates")
 
 	info.AddOption(OPT_SECURE, "Create secure Redis instance with auth support ({y}create{!})")
-	info.AddOption(OPT_DISABLE_SAVES, "Disable saves for created instance ({y}create{!})")
+	info.AddOption(OPT_DISABLE_SAVES, "Disable saves for created instance ({y}create{!}/{y}batch-create{!})")
+	info.AddOption(OPT_FROM_RDB, "Seed instance with data from RDB file ({y}create{!})", "file")
+	info.AddOption(OPT_REPLICATE_FROM, "Seed instance with data from external Redis server ({y}create{!})", "host:port")
+	info.AddOption(OPT_DESC, "Instance description ({y}create{!}/{y}edit{!})", "desc")
+	info.AddOption(OPT_OWNER, "Instance owner ({y}create{!}/{y}edit{!})", "owner")
+	info.AddOption(OPT_PASSWORD, "Instance password ({y}create{!}/{y}edit{!})", "password")
+	info.AddOption(OPT_SERVICE_PASS, "Service password ({y}create{!})", "password")
+	info.AddOption(OPT_REPLICATION, "Replication type ({y}create{!}/{y}edit{!})", "type")
+	info.AddOption(OPT_PROFILE, "Template profile ({y}create{!}/{y}edit{!})", "name")
+	info.AddOption(OPT_STDIN, "Read instance properties in JSON format from stdin ({y}create{!}/{y}edit{!})")
 	info.AddOption(OPT_PRIVATE, "Force access to private data ({y}conf{!}/{y}cli{!}/{y}settings{!})")
 	info.AddOption(OPT_EXTRA, "Print extra info ({y}list{!})")
+	info.AddOption(OPT_SORT, "Sort instances by given column ({y}list{!})", "column")
+	info.AddOption(OPT_COLUMNS, "List of columns to show ({y}list{!})", "column…")
+	info.AddOption(OPT_RECORD, "Record metrics to file ({y}track{!})", "file")
 	info.AddOption(OPT_TAGS, "List of tags ({y}create{!})", "tag…")
-	info.AddOption(OPT_FORMAT, "Output format {s-}(text/json/xml){!}", "format")
+	info.AddOption(OPT_FORMAT, "Output format {s-}(text/json/xml/csv){!}", "format")
 	info.AddOption(OPT_YES, "Automatically answer yes for all questions")
+	info.AddOption(OPT_FROM, "Snapshot source instance ID or file ({y}backup-restore{!})", "id|file")
+	info.AddOption(OPT_INDEX, "Snapshot index ({y}backup-restore{!})", "index")
+	info.AddOption(OPT_MATCH, "Key pattern ({y}export{!}/{y}import{!})", "pattern")
+	info.AddOption(OPT_REPLACE, "Replace existing keys ({y}import{!})")
+	info.AddOption(OPT_DESTROY_SOURCE, "Destroy source instance after migration ({y}migrate{!})")
+	info.AddOption(OPT_DRY_RUN, "Show plan without making changes ({y}adopt{!}/{y}apply{!})")
+	info.AddOption(OPT_PRUNE, "Allow destroying all instances by spec without instances ({y}apply{!})")
 	info.AddOption(OPT_PAGER, "Enable pager for long output")
 	info.AddOption(OPT_SIMPLE, "Simplify output {s-}(useful for copy-paste){!}")
 	info.AddOption(OPT_RAW, "Force raw output {s-}(useful for scripts){!}")
+	info.AddOption(OPT_TLS, "Connect to instance using TLS ({y}cli{!})")
+	info.AddOption(OPT_SOCKET, "Connect to instance using unix socket ({y}cli{!})")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VERSION, "Show information about version")
 	info.AddOption(OPT_VERBOSE_VERSION, "Show verbose information about version")
 
-	info.BoundOptions(COMMAND_CLI, OPT_TAGS)
-	info.BoundOptions(COMMAND_CLIENTS, OPT_PAGER)
+	info.BoundOptions(COMMAND_BACKUP_RESTORE, OPT_FROM, OPT_INDEX, OPT_TAGS, OPT_YES)
+	info.BoundOptions(COMMAND_BATCH_CREATE, OPT_DISABLE_SAVES, OPT_FORMAT, OPT_PRIVATE, OPT_YES)
+	info.BoundOptions(COMMAND_CLI, OPT_TAGS, OPT_TLS, OPT_SOCKET)
+	info.BoundOptions(COMMAND_CLIENTS, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_CONF, OPT_TAGS, OPT_PAGER)
-	info.BoundOptions(COMMAND_CREATE, OPT_SECURE, OPT_DISABLE_SAVES, OPT_TAGS)
+	info.BoundOptions(COMMAND_CPU, OPT_FORMAT)
+	info.BoundOptions(COMMAND_EXPORT, OPT_MATCH, OPT_FORMAT, OPT_YES)
+	info.BoundOptions(COMMAND_IMPORT, OPT_MATCH, OPT_REPLACE, OPT_YES)
+	info.BoundOptions(COMMAND_MIGRATE, OPT_DESTROY_SOURCE, OPT_YES)
+	info.BoundOptions(COMMAND_ADOPT, OPT_DRY_RUN, OPT_TAGS, OPT_YES)
+	info.BoundOptions(COMMAND_APPLY, OPT_DRY_RUN, OPT_PRUNE, OPT_YES)
+	info.BoundOptions(COMMAND_CREATE, OPT_SECURE, OPT_DISABLE_SAVES, OPT_TAGS, OPT_FROM_RDB, OPT_REPLICATE_FROM, OPT_DESC, OPT_OWNER, OPT_PASSWORD, OPT_SERVICE_PASS, OPT_REPLICATION, OPT_PROFILE, OPT_MEMORY_MAX, OPT_CPU_MAX, OPT_IO_WEIGHT, OPT_STDIN, OPT_FORMAT)
+	info.BoundOptions(COMMAND_EDIT, OPT_DESC, OPT_OWNER, OPT_PASSWORD, OPT_REPLICATION, OPT_PROFILE, OPT_TAGS, OPT_DISABLE_SAVES, OPT_MEMORY_MAX, OPT_CPU_MAX, OPT_IO_WEIGHT, OPT_STDIN, OPT_FORMAT)
 	info.BoundOptions(COMMAND_HELP, OPT_PAGER)
 	info.BoundOptions(COMMAND_INFO, OPT_FORMAT, OPT_PAGER)
-	info.BoundOptions(COMMAND_LIST, OPT_EXTRA, OPT_PAGER)
-	info.BoundOptions(COMMAND_MEMORY, OPT_FORMAT)
+	info.BoundOptions(COMMAND_LIST, OPT_EXTRA, OPT_SORT, OPT_COLUMNS, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_MEMORY, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_REPLICATION, OPT_FORMAT)
 	info.BoundOptions(COMMAND_SENTINEL_INFO, OPT_PAGER)
 	info.BoundOptions(COMMAND_SETTINGS, OPT_TAGS, OPT_PAGER)
-	info.BoundOptions(COMMAND_SLOWLOG_GET, OPT_PAGER)
+	info.BoundOptions(COMMAND_SLOWLOG_GET, OPT_FORMAT, OPT_PAGER)
 	info.BoundOptions(COMMAND_STATS, OPT_FORMAT)
 	info.BoundOptions(COMMAND_STATS_COMMAND, OPT_PAGER)
 	info.BoundOptions(COMMAND_STATS_ERROR, OPT_PAGER)
+	info.BoundOptions(COMMAND_STATS_HISTORY, OPT_FORMAT)
 	info.BoundOptions(COMMAND_STATS_LATENCY, OPT_PAGER)
-	info.BoundOptions(COMMAND_TOP, OPT_PAGER)
-	info.BoundOptions(COMMAND_TOP_DIFF, OPT_PAGER)
+	info.BoundOptions(COMMAND_TOP, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_TOP_DIFF, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_TRACK, OPT_RECORD)
+	info.BoundOptions(COMMAND_TRACK_REPLAY, OPT_FORMAT, OPT_PAGER)
+	info.BoundOptions(COMMAND_UPTIME, OPT_FORMAT)
 
 	return info
 }
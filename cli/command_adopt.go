@@ -0,0 +1,247 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/spinner"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	API "github.com/essentialkaos/rds/api"
+	CORE "github.com/essentialkaos/rds/core"
+	SC "github.com/essentialkaos/rds/sync/client"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AdoptCommand is "adopt" command handler
+func AdoptCommand(args CommandArgs) int {
+	if len(args) == 0 {
+		terminal.Error("You must define path to Redis configuration file")
+		return EC_ERROR
+	}
+
+	if CORE.GetAvailableInstanceID() == -1 {
+		terminal.Warn("No available ID for usage")
+		return EC_WARN
+	}
+
+	if !checkVirtualIP() {
+		return EC_WARN
+	}
+
+	if !isSystemConfigured() {
+		return EC_WARN
+	}
+
+	tags, err := parseTagsOption(options.GetS(OPT_TAGS))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	instancePassword := CORE.GenPassword()
+	plan, err := CORE.PlanAdoption(args.Get(0), instancePassword)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	plan.Meta.Tags = tags
+
+	printAdoptionPlan(plan)
+
+	if options.GetB(OPT_DRY_RUN) {
+		fmtc.Println("{s}Dry run mode, no changes were made{!}")
+		return EC_OK
+	}
+
+	plan.Meta.Desc, err = input.Read(
+		"Please enter the description for your instance",
+		input.NotEmpty, inputValidatorDesc{},
+	)
+
+	if err != nil {
+		return EC_CANCEL
+	}
+
+	fmtc.NewLine()
+
+	ok, err := input.ReadAnswer("Stop server and move it under RDS control?", "N")
+
+	if err != nil || !ok {
+		return EC_CANCEL
+	}
+
+	fmtc.NewLine()
+
+	id := plan.Meta.ID
+
+	spinner.Show("Adopting server from {*}%s{!}", plan.ConfigFile)
+	err = CORE.AdoptInstance(plan)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	logger.Info(id, "Instance created by adoption of server with configuration %s", plan.ConfigFile)
+
+	err = SC.PropagateCommand(API.COMMAND_CREATE, id, plan.Meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	spinner.Show("Starting instance {*}%d{!} {s}(%s){!}", id, plan.Meta.Desc)
+	err = CORE.StartInstance(id, true)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error(err)
+		logger.Error(id, "Instance starting error: %v", err)
+		rollbackAdoption(plan)
+		return EC_ERROR
+	}
+
+	logger.Info(id, "Instance started")
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	fmtc.NewLine()
+	fmtc.Println("{*}Done, server has been successfully moved under RDS control.{!}")
+
+	showInstanceInfo(
+		plan.Meta, &instanceBasicInfo{InstancePassword: instancePassword}, tags,
+	)
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// rollbackAdoption destroys adopted instance and starts unmanaged server
+func rollbackAdoption(plan *CORE.AdoptionPlan) {
+	id := plan.Meta.ID
+
+	fmtc.NewLine()
+	spinner.Show("Returning server from {*}%s{!} to original state", plan.ConfigFile)
+	err := CORE.RollbackAdoption(plan)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't rollback adoption: %v", err)
+		terminal.Warn("Data files of server may be located in %s", CORE.GetInstanceDataDirPath(id))
+		logger.Error(id, "Adoption rollback error: %v", err)
+		return
+	}
+
+	logger.Info(id, "Adoption rolled back, instance destroyed")
+
+	err = SC.PropagateCommand(API.COMMAND_DESTROY, id, plan.Meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+}
+
+// printAdoptionPlan prints info about adoption plan
+func printAdoptionPlan(plan *CORE.AdoptionPlan) {
+	t := table.NewTable().SetSizes(17, 64)
+
+	fmtc.NewLine()
+
+	t.Border()
+	fmtc.Println(" ▾ {*}ADOPTION PLAN{!}")
+	t.Border()
+
+	t.Print("Configuration", plan.ConfigFile)
+
+	if plan.PID > 0 {
+		t.Print("Server", fmtc.Sprintf("{g}works{!} {s-}(PID: %d | Port: %d){!}", plan.PID, plan.Port))
+	} else {
+		t.Print("Server", fmtc.Sprintf("{s}stopped{!} {s-}(Port: %d){!}", plan.Port))
+	}
+
+	t.Print("Instance ID", plan.Meta.ID)
+	t.Print("Instance Port", CORE.GetInstancePort(plan.Meta.ID))
+	t.Print("Secure", fmtutil.PrettyBool(plan.Meta.Preferencies.ServicePassword != ""))
+	t.Print("Saves Disabled", fmtutil.PrettyBool(plan.Meta.Preferencies.IsSaveDisabled))
+
+	if len(plan.Meta.Tags) != 0 {
+		t.Print("Tags", renderTags(plan.Meta.Tags...))
+	}
+
+	t.Border()
+
+	if len(plan.DataFiles) == 0 {
+		fmtc.Println(" {s}No data files found{!}")
+	} else {
+		for _, file := range plan.DataFiles {
+			fmtc.Printf(
+				" {s}•{!} %s {s}→{!} %s {s-}(%s){!}\n",
+				file.Source, file.Target, fmtutil.PrettySize(file.Size),
+			)
+		}
+	}
+
+	t.Border()
+
+	if len(plan.Directives) == 0 {
+		fmtc.Println(" {s}No custom directives found{!}")
+	} else {
+		fmtc.Println(" {*}Custom directives:{!}")
+
+		for _, directive := range plan.Directives {
+			fmtc.Printf(" {g}+{!} %s\n", directive)
+		}
+	}
+
+	if len(plan.Dropped) != 0 {
+		fmtc.Println(" {*}Directives replaced by RDS configuration:{!}")
+
+		for _, directive := range plan.Dropped {
+			fmtc.Printf(" {r}-{!} {s}%s{!}\n", directive)
+		}
+	}
+
+	t.Border()
+	fmtc.NewLine()
+
+	if plan.Port != CORE.GetInstancePort(plan.Meta.ID) {
+		terminal.Warn(
+			"After adoption server will listen on port %d instead of %d. Don't forget to update clients configuration.",
+			CORE.GetInstancePort(plan.Meta.ID), plan.Port,
+		)
+		fmtc.NewLine()
+	}
+
+	for _, directive := range plan.Dropped {
+		if strings.HasPrefix(directive, "user ") || strings.HasPrefix(directive, "aclfile ") {
+			terminal.Warn("ACL users from configuration will be replaced by RDS users.")
+			fmtc.NewLine()
+			break
+		}
+	}
+}
@@ -0,0 +1,952 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/passwd"
+	"github.com/essentialkaos/ek/v13/spinner"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	"gopkg.in/yaml.v3"
+
+	API "github.com/essentialkaos/rds/api"
+	CORE "github.com/essentialkaos/rds/core"
+	SC "github.com/essentialkaos/rds/sync/client"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	APPLY_STATE_RUNNING = "running"
+	APPLY_STATE_STOPPED = "stopped"
+)
+
+const (
+	APPLY_OP_NONE    = ""
+	APPLY_OP_CREATE  = "create"
+	APPLY_OP_UPDATE  = "update"
+	APPLY_OP_DESTROY = "destroy"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applySpec contains desired state of instances on the node
+type applySpec struct {
+	Instances []*applyInstanceSpec `json:"instances" yaml:"instances"`
+}
+
+// applyInstanceSpec contains desired state of instance
+type applyInstanceSpec struct {
+	instanceSpec `yaml:",inline"`
+
+	Name      string         `json:"name" yaml:"name"`
+	ID        int            `json:"id" yaml:"id"`
+	State     string         `json:"state" yaml:"state"`
+	Overrides applyOverrides `json:"overrides" yaml:"overrides"`
+}
+
+// applyOverrides contains custom configuration directives, every property can
+// have one value or list of values (e.g. "save" or "rename-command")
+type applyOverrides map[string][]string
+
+// applyAction contains info about actions required to converge instance state
+type applyAction struct {
+	Op              string
+	Name            string
+	ID              int
+	Spec            *applyInstanceSpec
+	Changes         []string
+	Start           bool
+	Stop            bool
+	IsConfigChanged bool
+	IsLimitsChanged bool
+}
+
+// applyResult contains info about instance created by apply command
+type applyResult struct {
+	Name     string
+	ID       int
+	Password string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applyNameRegex is regex pattern for instance name validation
+var applyNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-]{0,63}$`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ApplyCommand is "apply" command handler
+func ApplyCommand(args CommandArgs) int {
+	if len(args) == 0 {
+		terminal.Error("You must define path to spec file")
+		return EC_ERROR
+	}
+
+	if !isSystemConfigured() {
+		return EC_WARN
+	}
+
+	if !checkVirtualIP() {
+		return EC_WARN
+	}
+
+	spec, err := readApplySpec(args.Get(0))
+
+	if err == nil {
+		err = spec.Validate()
+	}
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	plan, err := planApply(spec)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if len(plan) == 0 {
+		fmtc.Println("{g}Nothing to do, instances state matches the spec{!}")
+		return EC_OK
+	}
+
+	printApplyPlan(plan)
+
+	if options.GetB(OPT_DRY_RUN) {
+		fmtc.Println("{s}Dry run mode, no changes were made{!}")
+		return EC_OK
+	}
+
+	if slices.ContainsFunc(plan, func(a *applyAction) bool { return a.Op == APPLY_OP_CREATE }) &&
+		!isEnoughMemoryToCreate() {
+		return EC_ERROR
+	}
+
+	destroyed := getApplyDestroyActions(plan)
+
+	if len(spec.Instances) == 0 && len(destroyed) != 0 && !options.GetB(OPT_PRUNE) {
+		terminal.Error(
+			"Spec doesn't contain any instances, so all %d named instances will be destroyed. Use --%s option if you really want to do this.",
+			len(destroyed), OPT_PRUNE,
+		)
+		return EC_ERROR
+	}
+
+	if len(destroyed) != 0 {
+		terminal.Warn("Warning! These instances will be destroyed and will lose ALL data (configuration file, data, logs):\n")
+
+		for _, action := range destroyed {
+			fmtc.Printf("  {r}•{!} {*}%s{!} {s-}(ID: %d){!}\n", action.Name, action.ID)
+		}
+
+		fmtc.NewLine()
+	}
+
+	ok, err := input.ReadAnswer("Apply these changes?", "N")
+
+	if err != nil || !ok {
+		return EC_CANCEL
+	}
+
+	fmtc.NewLine()
+
+	var hasErrors bool
+	var results []*applyResult
+
+	for _, action := range plan {
+		var err error
+
+		switch action.Op {
+		case APPLY_OP_CREATE:
+			var result *applyResult
+			result, err = applyCreate(action)
+
+			if result != nil {
+				results = append(results, result)
+			}
+
+		case APPLY_OP_UPDATE:
+			err = applyUpdate(action)
+
+		case APPLY_OP_DESTROY:
+			err = applyDestroy(action)
+		}
+
+		if err == nil {
+			err = applyState(action)
+		}
+
+		if err != nil {
+			terminal.Error(err)
+			hasErrors = true
+		}
+	}
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	fmtc.NewLine()
+
+	if len(results) != 0 {
+		printApplyResults(results)
+	}
+
+	if hasErrors {
+		terminal.Warn("Some changes were not applied. Fix errors and run apply again.")
+		return EC_ERROR
+	}
+
+	fmtc.Println("{g}Done. Instances state now matches the spec.{!}")
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readApplySpec reads spec with desired instances state from YAML or JSON file
+func readApplySpec(file string) (*applySpec, error) {
+	err := fsutil.ValidatePerms("FRS", file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	spec := &applySpec{}
+
+	if strings.HasSuffix(strings.ToLower(file), ".json") {
+		decoder := json.NewDecoder(fd)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(spec)
+	} else {
+		decoder := yaml.NewDecoder(fd)
+		decoder.KnownFields(true)
+		err = decoder.Decode(spec)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse spec file %s: %w", file, err)
+	}
+
+	return spec, nil
+}
+
+// Validate validates spec
+func (s *applySpec) Validate() error {
+	names := make(map[string]bool)
+
+	for index, inst := range s.Instances {
+		if inst == nil || inst.Name == "" {
+			return fmt.Errorf("Instance #%d: name is required", index+1)
+		}
+
+		if !applyNameRegex.MatchString(inst.Name) {
+			return fmt.Errorf("Instance %q: name contains unsupported symbols", inst.Name)
+		}
+
+		if names[inst.Name] {
+			return fmt.Errorf("Instance %q: name is used more than once", inst.Name)
+		}
+
+		names[inst.Name] = true
+
+		err := inst.Validate()
+
+		if err != nil {
+			return fmt.Errorf("Instance %q: %w", inst.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates instance spec
+func (s *applyInstanceSpec) Validate() error {
+	if s.Desc == "" {
+		return fmt.Errorf("Instance description is required")
+	}
+
+	err := s.instanceSpec.Validate(false)
+
+	if err != nil {
+		return err
+	}
+
+	switch s.State {
+	case "":
+		s.State = APPLY_STATE_RUNNING
+	case APPLY_STATE_RUNNING, APPLY_STATE_STOPPED:
+		// ok
+	default:
+		return fmt.Errorf("Unsupported state %q (\"running\" or \"stopped\")", s.State)
+	}
+
+	// Redis reads property names case-insensitively, so we use lowercase
+	// names for all checks and for generated directives
+	overrides := make(applyOverrides, len(s.Overrides))
+
+	for prop, values := range s.Overrides {
+		name := strings.ToLower(prop)
+
+		switch _, isDefined := overrides[name]; {
+		case prop == "" || strings.ContainsAny(prop, " \t\n"):
+			return fmt.Errorf("Invalid configuration property name %q", prop)
+		case len(values) == 0:
+			return fmt.Errorf("Configuration property %q has no values", prop)
+		case slices.ContainsFunc(values, func(v string) bool { return strings.Contains(v, "\n") }):
+			return fmt.Errorf("Value of configuration property %q contains new line", prop)
+		case CORE.IsManagedConfigProp(name):
+			return fmt.Errorf("Configuration property %q is managed by RDS and can't be overridden", prop)
+		case isDefined:
+			return fmt.Errorf("Configuration property %q is defined more than once", name)
+		}
+
+		overrides[name] = values
+	}
+
+	if s.Overrides != nil {
+		s.Overrides = overrides
+	}
+
+	return nil
+}
+
+// GetDirectives returns overrides as a list of custom configuration directives
+func (s *applyInstanceSpec) GetDirectives() string {
+	return s.Overrides.String()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// UnmarshalJSON parses overrides with one value (string) or many values (array
+// of strings) for every property
+func (o *applyOverrides) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &raw)
+
+	if err != nil {
+		return err
+	}
+
+	*o = make(applyOverrides, len(raw))
+
+	for prop, rawValue := range raw {
+		var value string
+		var values []string
+
+		if json.Unmarshal(rawValue, &value) == nil {
+			values = []string{value}
+		} else if json.Unmarshal(rawValue, &values) != nil {
+			return fmt.Errorf("Value of configuration property %q must be a string or an array of strings", prop)
+		}
+
+		(*o)[prop] = values
+	}
+
+	return nil
+}
+
+// UnmarshalYAML parses overrides with one value (scalar) or many values (list
+// of scalars) for every property
+func (o *applyOverrides) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+
+	err := node.Decode(&raw)
+
+	if err != nil {
+		return err
+	}
+
+	*o = make(applyOverrides, len(raw))
+
+	for prop, valueNode := range raw {
+		var values []string
+
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			values = []string{valueNode.Value}
+		case yaml.SequenceNode:
+			err = valueNode.Decode(&values)
+		default:
+			err = fmt.Errorf("must be a scalar or a list of scalars")
+		}
+
+		if err != nil {
+			return fmt.Errorf("line %d: value of configuration property %q %v", valueNode.Line, prop, err)
+		}
+
+		(*o)[prop] = values
+	}
+
+	return nil
+}
+
+// String returns overrides as a list of custom configuration directives sorted
+// by property name
+func (o applyOverrides) String() string {
+	var props, result []string
+
+	for prop := range o {
+		props = append(props, prop)
+	}
+
+	slices.Sort(props)
+
+	for _, prop := range props {
+		for _, value := range o[prop] {
+			result = append(result, prop+" "+value)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// parseApplyOverrides parses custom configuration directives from instance meta
+func parseApplyOverrides(directives string) applyOverrides {
+	result := applyOverrides{}
+
+	for _, line := range strings.Split(directives, "\n") {
+		prop, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		if prop != "" {
+			prop = strings.ToLower(prop)
+			result[prop] = append(result[prop], value)
+		}
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// planApply compares spec with current instances state and returns list of
+// actions required to converge state
+func planApply(spec *applySpec) ([]*applyAction, error) {
+	var plan []*applyAction
+
+	named := make(map[string]int)
+
+	for _, id := range CORE.GetInstanceIDList() {
+		meta, err := CORE.GetInstanceMeta(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.Storage.Get(CORE.META_NAME) != "" {
+			named[meta.Storage.Get(CORE.META_NAME)] = id
+		}
+	}
+
+	for _, inst := range spec.Instances {
+		id, ok := named[inst.Name]
+
+		if !ok && inst.ID > 0 && CORE.IsInstanceExist(inst.ID) {
+			id = inst.ID
+		}
+
+		if id <= 0 {
+			plan = append(plan, &applyAction{
+				Op:    APPLY_OP_CREATE,
+				Name:  inst.Name,
+				ID:    -1,
+				Spec:  inst,
+				Start: inst.State == APPLY_STATE_RUNNING,
+			})
+
+			continue
+		}
+
+		delete(named, inst.Name)
+
+		action, err := planInstanceUpdate(id, inst)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if action != nil {
+			plan = append(plan, action)
+		}
+	}
+
+	// Instances with names which are not present in spec must be destroyed
+	for _, id := range CORE.GetInstanceIDList() {
+		for name, namedID := range named {
+			if id == namedID {
+				plan = append(plan, &applyAction{Op: APPLY_OP_DESTROY, Name: name, ID: id})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// planInstanceUpdate compares instance spec with current instance meta and state
+func planInstanceUpdate(id int, inst *applyInstanceSpec) (*applyAction, error) {
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := meta.Storage.Get(CORE.META_NAME)
+
+	if name != "" && name != inst.Name {
+		return nil, fmt.Errorf(
+			"Instance %q: instance with ID %d already has name %q", inst.Name, id, name,
+		)
+	}
+
+	state, err := CORE.GetInstanceState(id, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	action := &applyAction{Name: inst.Name, ID: id, Spec: inst}
+
+	if name == "" {
+		action.Changes = append(action.Changes, fmt.Sprintf("name set to %q", inst.Name))
+	}
+
+	if inst.Desc != meta.Desc {
+		action.Changes = append(action.Changes, fmt.Sprintf("description %q → %q", meta.Desc, inst.Desc))
+	}
+
+	if inst.Owner != "" && inst.Owner != meta.Auth.User {
+		action.Changes = append(action.Changes, fmt.Sprintf("owner %q → %q", meta.Auth.User, inst.Owner))
+	}
+
+	if inst.Password != "" && !passwd.Check(inst.Password, meta.Auth.Pepper, meta.Auth.Hash) {
+		action.Changes = append(action.Changes, "password updated")
+	}
+
+	if inst.ReplicationType != "" && inst.ReplicationType != string(meta.Preferencies.ReplicationType) {
+		action.Changes = append(action.Changes, fmt.Sprintf(
+			"replication type %q → %q", meta.Preferencies.ReplicationType, inst.ReplicationType,
+		))
+	}
+
+	if strings.Join(inst.Tags, ",") != strings.Join(meta.Tags, ",") {
+		action.Changes = append(action.Changes, fmt.Sprintf(
+			"tags %q → %q", strings.Join(meta.Tags, ","), strings.Join(inst.Tags, ","),
+		))
+	}
+
+	if inst.DisableSaves != nil && *inst.DisableSaves != meta.Preferencies.IsSaveDisabled {
+		action.Changes = append(action.Changes, fmt.Sprintf(
+			"saves disabled %t → %t", meta.Preferencies.IsSaveDisabled, *inst.DisableSaves,
+		))
+		action.IsConfigChanged = true
+	}
+
+	if inst.TemplateProfile != meta.Preferencies.TemplateProfile {
+		action.Changes = append(action.Changes, fmt.Sprintf(
+			"template profile %q → %q", meta.Preferencies.TemplateProfile, inst.TemplateProfile,
+		))
+		action.IsConfigChanged = true
+	}
+
+	prefs := *meta.Preferencies
+	limitsChanges := inst.setLimits(&prefs)
+
+	if len(limitsChanges) != 0 {
+		action.Changes = append(action.Changes, limitsChanges...)
+		action.IsLimitsChanged = true
+	}
+
+	if inst.GetDirectives() != parseApplyOverrides(meta.Storage.Get(CORE.META_CUSTOM_DIRECTIVES)).String() {
+		action.Changes = append(action.Changes, "configuration overrides updated")
+		action.IsConfigChanged = true
+	}
+
+	if len(action.Changes) != 0 {
+		action.Op = APPLY_OP_UPDATE
+	}
+
+	switch {
+	case inst.State == APPLY_STATE_RUNNING && !state.IsWorks():
+		action.Start = true
+	case inst.State == APPLY_STATE_STOPPED && state.IsWorks():
+		action.Stop = true
+	}
+
+	if action.Op == APPLY_OP_NONE && !action.Start && !action.Stop {
+		return nil, nil
+	}
+
+	return action, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applyCreate creates new instance
+func applyCreate(action *applyAction) (*applyResult, error) {
+	inst := action.Spec
+	id := CORE.GetAvailableInstanceID()
+
+	if id == -1 {
+		return nil, fmt.Errorf("Can't create instance %q: no available ID for usage", action.Name)
+	}
+
+	password := inst.Password
+
+	if password == "" {
+		password = CORE.GenPassword()
+	}
+
+	servicePassword := inst.ServicePassword
+
+	if inst.Secure && servicePassword == "" {
+		servicePassword = CORE.GenPassword()
+	}
+
+	spinner.Show("Creating instance {*}%s{!}", action.Name)
+
+	meta, err := CORE.NewInstanceMeta(password, servicePassword)
+
+	if err != nil {
+		spinner.Done(false)
+		return nil, fmt.Errorf("Can't create instance %q: %w", action.Name, err)
+	}
+
+	meta.Desc = inst.Desc
+	meta.Tags = inst.Tags
+	meta.Preferencies.ReplicationType = CORE.ReplicationType(inst.ReplicationType)
+	meta.Preferencies.TemplateProfile = inst.TemplateProfile
+
+	if meta.Preferencies.ReplicationType == "" {
+		meta.Preferencies.ReplicationType = CORE.ReplicationType(CORE.Config.GetS(CORE.REPLICATION_DEFAULT_ROLE))
+	}
+
+	if inst.Owner != "" {
+		meta.Auth.User = inst.Owner
+	}
+
+	if inst.DisableSaves != nil {
+		meta.Preferencies.IsSaveDisabled = *inst.DisableSaves
+	}
+
+	inst.setLimits(meta.Preferencies)
+
+	meta.Storage.Set(CORE.META_NAME, action.Name)
+
+	if len(inst.Overrides) != 0 {
+		meta.Storage.Set(CORE.META_CUSTOM_DIRECTIVES, inst.GetDirectives())
+	}
+
+	err = CORE.CreateInstance(meta)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create instance %q: %w", action.Name, err)
+	}
+
+	action.ID = meta.ID
+
+	logger.Info(meta.ID, "Instance created (apply: %s)", action.Name)
+
+	err = SC.PropagateCommand(API.COMMAND_CREATE, meta.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	result := &applyResult{Name: action.Name, ID: meta.ID}
+
+	if inst.Password == "" {
+		result.Password = password
+	}
+
+	return result, nil
+}
+
+// applyUpdate updates instance meta
+func applyUpdate(action *applyAction) error {
+	inst := action.Spec
+
+	spinner.Show("Updating instance {*}%d{!} {s}(%s){!}", action.ID, action.Name)
+
+	meta, err := CORE.GetInstanceMeta(action.ID)
+
+	if err != nil {
+		spinner.Done(false)
+		return err
+	}
+
+	meta.Desc = inst.Desc
+
+	if inst.Owner != "" {
+		meta.Auth.User = inst.Owner
+	}
+
+	if inst.Password != "" && !passwd.Check(inst.Password, meta.Auth.Pepper, meta.Auth.Hash) {
+		auth, err := CORE.NewInstanceAuth(inst.Password)
+
+		if err != nil {
+			spinner.Done(false)
+			return err
+		}
+
+		meta.Auth.Pepper, meta.Auth.Hash = auth.Pepper, auth.Hash
+	}
+
+	if inst.ReplicationType != "" {
+		meta.Preferencies.ReplicationType = CORE.ReplicationType(inst.ReplicationType)
+	}
+
+	// Tags, template profile and overrides which are not set in spec must
+	// be reset to defaults
+	meta.Tags = inst.Tags
+	meta.Preferencies.TemplateProfile = inst.TemplateProfile
+
+	if inst.DisableSaves != nil {
+		meta.Preferencies.IsSaveDisabled = *inst.DisableSaves
+	}
+
+	inst.setLimits(meta.Preferencies)
+
+	if meta.Storage == nil {
+		meta.Storage = CORE.Storage{}
+	}
+
+	meta.Storage.Set(CORE.META_NAME, action.Name)
+
+	if len(inst.Overrides) != 0 {
+		meta.Storage.Set(CORE.META_CUSTOM_DIRECTIVES, inst.GetDirectives())
+	} else {
+		meta.Storage.Delete(CORE.META_CUSTOM_DIRECTIVES)
+	}
+
+	err = CORE.UpdateInstance(meta)
+
+	if err == nil && action.IsConfigChanged {
+		err = CORE.RegenerateInstanceConfig(action.ID)
+	}
+
+	if err == nil && action.IsLimitsChanged {
+		err = CORE.UpdateInstanceLimits(action.ID)
+	}
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		return fmt.Errorf("Can't update instance %q: %w", action.Name, err)
+	}
+
+	for _, c := range action.Changes {
+		logger.Info(action.ID, "Instance meta updated (apply): %s", c)
+	}
+
+	if action.IsConfigChanged && !action.Start && !action.Stop {
+		state, err := CORE.GetInstanceState(action.ID, false)
+
+		if err == nil && state.IsWorks() {
+			for _, err := range CORE.ReloadInstanceConfig(action.ID) {
+				terminal.Warn("Instance %d: %v", action.ID, err)
+			}
+		}
+	}
+
+	err = SC.PropagateCommand(API.COMMAND_EDIT, meta.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	return nil
+}
+
+// applyDestroy destroys instance
+func applyDestroy(action *applyAction) error {
+	meta, err := CORE.GetInstanceMeta(action.ID)
+
+	if err != nil {
+		return err
+	}
+
+	spinner.Show("Destroying instance {*}%d{!} {s}(%s){!}", action.ID, action.Name)
+	err = CORE.DestroyInstance(action.ID)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		return fmt.Errorf("Can't destroy instance %q: %w", action.Name, err)
+	}
+
+	logger.Info(action.ID, "Instance destroyed (apply: %s)", action.Name)
+
+	err = SC.PropagateCommand(API.COMMAND_DESTROY, action.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	return nil
+}
+
+// applyState starts or stops instance
+func applyState(action *applyAction) error {
+	var err error
+
+	switch {
+	case action.Start:
+		spinner.Show("Starting instance {*}%d{!} {s}(%s){!}", action.ID, action.Name)
+		err = CORE.StartInstance(action.ID, false)
+		spinner.Done(err == nil)
+
+		if err != nil {
+			logger.Error(action.ID, "Instance starting error: %v", err)
+			return fmt.Errorf("Can't start instance %q: %w", action.Name, err)
+		}
+
+		logger.Info(action.ID, "Instance started")
+
+	case action.Stop:
+		spinner.Show("Stopping instance {*}%d{!} {s}(%s){!}", action.ID, action.Name)
+		err = CORE.StopInstance(action.ID, false)
+		spinner.Done(err == nil)
+
+		if err != nil {
+			return fmt.Errorf("Can't stop instance %q: %w", action.Name, err)
+		}
+
+		logger.Info(action.ID, "Instance stopped (force: false)")
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getApplyDestroyActions returns list of actions which destroy instances
+func getApplyDestroyActions(plan []*applyAction) []*applyAction {
+	var result []*applyAction
+
+	for _, action := range plan {
+		if action.Op == APPLY_OP_DESTROY {
+			result = append(result, action)
+		}
+	}
+
+	return result
+}
+
+// printApplyPlan prints info about actions required to converge instances state
+func printApplyPlan(plan []*applyAction) {
+	var created, updated, destroyed int
+
+	t := table.NewTable().SetSizes(17, 64)
+
+	t.Border()
+	fmtc.Println(" ▾ {*}APPLY PLAN{!}")
+	t.Border()
+
+	for _, action := range plan {
+		switch action.Op {
+		case APPLY_OP_CREATE:
+			created++
+			fmtc.Printf(" {g}+{!} {*}%s{!} {s-}(new){!}\n", action.Name)
+			fmtc.Printf("   {s}description:{!} %s\n", action.Spec.Desc)
+
+			if action.Spec.Owner != "" {
+				fmtc.Printf("   {s}owner:{!} %s\n", action.Spec.Owner)
+			}
+
+			if len(action.Spec.Tags) != 0 {
+				fmtc.Printf("   {s}tags:{!} %s\n", renderTags(action.Spec.Tags...))
+			}
+
+			if action.Spec.TemplateProfile != "" {
+				fmtc.Printf("   {s}template profile:{!} %s\n", action.Spec.TemplateProfile)
+			}
+
+			if len(action.Spec.Overrides) != 0 {
+				fmtc.Printf("   {s}overrides:{!} %d\n", len(action.Spec.Overrides))
+			}
+
+		case APPLY_OP_UPDATE:
+			updated++
+			fmtc.Printf(" {y}~{!} {*}%s{!} {s-}(ID: %d){!}\n", action.Name, action.ID)
+
+			for _, change := range action.Changes {
+				fmtc.Printf("   %s\n", change)
+			}
+
+		case APPLY_OP_DESTROY:
+			destroyed++
+			fmtc.Printf(" {r}-{!} {*}%s{!} {s-}(ID: %d){!}\n", action.Name, action.ID)
+			fmtc.Println("   {r}destroy{!}")
+
+		default:
+			fmtc.Printf(" {c}•{!} {*}%s{!} {s-}(ID: %d){!}\n", action.Name, action.ID)
+		}
+
+		switch {
+		case action.Start:
+			fmtc.Println("   {g}start{!}")
+		case action.Stop:
+			fmtc.Println("   {y}stop{!}")
+		}
+	}
+
+	t.Border()
+
+	fmtc.Printf(
+		" {*}Plan:{!} %d to create, %d to update, %d to destroy\n",
+		created, updated, destroyed,
+	)
+
+	t.Border()
+	fmtc.NewLine()
+}
+
+// printApplyResults prints info about created instances
+func printApplyResults(results []*applyResult) {
+	t := table.NewTable("NAME", "ID", "PORT", "PASSWORD")
+
+	for _, result := range results {
+		password := result.Password
+
+		if password == "" {
+			password = "{s-}(from spec){!}"
+		}
+
+		t.Add(result.Name, result.ID, CORE.GetInstancePort(result.ID), password)
+	}
+
+	t.Render()
+	fmtc.NewLine()
+}
@@ -0,0 +1,140 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essentialkaos/ek/v13/knf"
+
+	. "github.com/essentialkaos/check"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type ApplySuite struct {
+	dir string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+var _ = Suite(&ApplySuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *ApplySuite) SetUpSuite(c *C) {
+	var err error
+
+	s.dir = c.MkDir()
+	CORE.Config, err = knf.Parse([]byte("[main]\n  max-instances: 32\n"))
+
+	c.Assert(err, IsNil)
+}
+
+func (s *ApplySuite) TestOverridesYAML(c *C) {
+	spec := s.readSpec(c, "spec.yml", `instances:
+  - name: test
+    desc: Test instance
+    overrides:
+      MaxMemory-Policy: allkeys-lru
+      maxmemory: 1024
+      client-output-buffer-limit:
+        - replica 512mb 128mb 60
+        - pubsub 64mb 16mb 60
+`)
+
+	c.Assert(spec.Validate(), IsNil)
+	c.Assert(spec.Instances[0].GetDirectives(), Equals,
+		"client-output-buffer-limit replica 512mb 128mb 60\n"+
+			"client-output-buffer-limit pubsub 64mb 16mb 60\n"+
+			"maxmemory 1024\n"+
+			"maxmemory-policy allkeys-lru",
+	)
+
+	_, err := readApplySpec(s.writeSpec(c, "broken.yml", `instances:
+  - name: test
+    desc: Test instance
+    overrides:
+      maxmemory:
+        size: 1024
+`))
+
+	c.Assert(err, ErrorMatches, `.*value of configuration property "maxmemory" must be a scalar or a list of scalars`)
+}
+
+func (s *ApplySuite) TestOverridesJSON(c *C) {
+	spec := s.readSpec(c, "spec.json", `{"instances": [{
+  "name": "test", "desc": "Test instance",
+  "overrides": {"maxmemory-policy": "allkeys-lru", "loadmodule": ["/opt/a.so", "/opt/b.so"]}
+}]}`)
+
+	c.Assert(spec.Validate(), IsNil)
+	c.Assert(spec.Instances[0].GetDirectives(), Equals,
+		"loadmodule /opt/a.so\nloadmodule /opt/b.so\nmaxmemory-policy allkeys-lru",
+	)
+
+	_, err := readApplySpec(s.writeSpec(c, "broken.json", `{"instances": [{
+  "name": "test", "desc": "Test instance", "overrides": {"maxmemory": 1024}
+}]}`))
+
+	c.Assert(err, ErrorMatches, `.*Value of configuration property "maxmemory" must be a string or an array of strings`)
+}
+
+func (s *ApplySuite) TestMixedCaseOverrides(c *C) {
+	for _, prop := range []string{"PORT", "Dir", "INCLUDE", "Rename-Command"} {
+		spec := &applySpec{Instances: []*applyInstanceSpec{{
+			instanceSpec: instanceSpec{Desc: "Test instance"},
+			Name:         "test",
+			Overrides:    applyOverrides{prop: {"value"}},
+		}}}
+
+		c.Assert(spec.Validate(), ErrorMatches, `Instance "test": Configuration property ".*" is managed by RDS and can't be overridden`)
+	}
+
+	spec := &applySpec{Instances: []*applyInstanceSpec{{
+		instanceSpec: instanceSpec{Desc: "Test instance"},
+		Name:         "test",
+		Overrides:    applyOverrides{"MAXMEMORY": {"1gb"}, "maxmemory": {"2gb"}},
+	}}}
+
+	c.Assert(spec.Validate(), ErrorMatches, `Instance "test": Configuration property "maxmemory" is defined more than once`)
+}
+
+func (s *ApplySuite) TestParseOverrides(c *C) {
+	overrides := parseApplyOverrides("save 900 1\nMaxMemory 1gb\nsave 300 10\n")
+
+	c.Assert(overrides, DeepEquals, applyOverrides{
+		"save":      {"900 1", "300 10"},
+		"maxmemory": {"1gb"},
+	})
+
+	c.Assert(overrides.String(), Equals, "maxmemory 1gb\nsave 900 1\nsave 300 10")
+	c.Assert(parseApplyOverrides("").String(), Equals, "")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readSpec writes spec data to file and reads it
+func (s *ApplySuite) readSpec(c *C, name, data string) *applySpec {
+	spec, err := readApplySpec(s.writeSpec(c, name, data))
+	c.Assert(err, IsNil)
+	return spec
+}
+
+// writeSpec writes spec data to file
+func (s *ApplySuite) writeSpec(c *C, name, data string) string {
+	file := filepath.Join(s.dir, name)
+	c.Assert(os.WriteFile(file, []byte(data), 0644), IsNil)
+	return file
+}
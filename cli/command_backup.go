@@ -9,33 +9,32 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"sort"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/fmtutil"
 	"github.com/essentialkaos/ek/v13/fmtutil/table"
 	"github.com/essentialkaos/ek/v13/fsutil"
-	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/path"
-	"github.com/essentialkaos/ek/v13/sortutil"
 	"github.com/essentialkaos/ek/v13/spinner"
 	"github.com/essentialkaos/ek/v13/strutil"
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/terminal/input"
 	"github.com/essentialkaos/ek/v13/timeutil"
 
+	API "github.com/essentialkaos/rds/api"
 	CORE "github.com/essentialkaos/rds/core"
-	REDIS "github.com/essentialkaos/rds/redis"
+	RDB "github.com/essentialkaos/rds/redis/rdb"
+	SC "github.com/essentialkaos/rds/sync/client"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-const (
-	BACKUP_PERMS = 0600 // Default permissions for backups
-	MAX_BACKUPS  = 10   // Maximum number of backups
-)
+// RESTORE_TARGET_NEW is restore target for restoring snapshot to a new instance
+const RESTORE_TARGET_NEW = "new"
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
@@ -62,27 +61,10 @@ func BackupCreateCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	backupName := fmt.Sprintf("backup-%d.rdb", time.Now().Unix())
-	rdbFile := CORE.GetInstanceRDBPath(id)
-	backupFile := path.Join(CORE.GetInstanceDataDirPath(id), backupName)
-
 	if state.IsWorks() {
 		spinner.Show("Saving instance data in background")
-
-		_, err := CORE.ExecCommand(id, &REDIS.Request{
-			Command: []string{"BGSAVE"},
-		})
-
-		if err != nil {
-			spinner.Done(false)
-			fmtc.NewLine()
-			terminal.Error("Can't exec BGSAVE command: %v", err)
-			return EC_ERROR
-		}
-
-		waitForDump(id)
 	} else {
-		if !fsutil.IsExist(rdbFile) {
+		if !CORE.IsInstanceAOFEnabled(id) && !fsutil.IsExist(CORE.GetInstanceRDBPath(id)) {
 			terminal.Warn("There is no RDB snapshot of instance data")
 			return EC_ERROR
 		}
@@ -90,115 +72,98 @@ func BackupCreateCommand(args CommandArgs) int {
 		spinner.Show("Creating snapshot of instance data")
 	}
 
-	if getBackupNum(id) == MAX_BACKUPS {
-		err := cleanOldBackups(id, 1)
-
-		if err != nil {
-			terminal.Error(err)
-			return EC_ERROR
-		}
-	}
+	backup, err := CORE.CreateInstanceBackup(id, backupProgressHandler)
 
-	rdbFileSize := fsutil.GetSize(rdbFile)
+	spinner.Done(backup != nil)
 
-	if rdbFileSize > 0 {
-		spinner.Update(
-			"Copying snapshot of RDB file {s}(%s){!}",
-			fmtutil.PrettySize(rdbFileSize),
-		)
-	} else {
-		spinner.Update("Copying snapshot of RDB file")
+	if backup == nil {
+		fmtc.NewLine()
+		terminal.Error(err)
+		return EC_ERROR
 	}
 
-	err = fsutil.CopyFile(rdbFile, backupFile, BACKUP_PERMS)
-
-	spinner.Done(err == nil)
+	logger.Info(id, "Created %s backup", strings.ToUpper(backup.Type))
 
 	if err != nil {
-		fmtc.NewLine()
-		terminal.Error("Can't copy snapshot file: %v", err)
-		return EC_ERROR
+		terminal.Warn("Can't apply backups retention policy: %v", err)
+		return EC_WARN
 	}
 
-	logger.Info(id, "Created RDB backup")
-
 	return EC_OK
 }
 
 // BackupRestoreCommand is "backup-restore" command handler
 func BackupRestoreCommand(args CommandArgs) int {
-	var index int
+	var id int
+	var err error
 
-	err := args.Check(false)
+	isNewTarget := args.Get(0) == RESTORE_TARGET_NEW
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
-	}
+	if isNewTarget {
+		if !options.Has(OPT_FROM) {
+			terminal.Error("You must define snapshot source using --from option")
+			return EC_ERROR
+		}
+	} else {
+		err = args.Check(false)
 
-	id, _, err := CORE.ParseIDDBPair(args.Get(0))
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
+		id, _, err = CORE.ParseIDDBPair(args.Get(0))
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		state, err := CORE.GetInstanceState(id, false)
+
+		if err != nil {
+			terminal.Error("Can't check instance state: %v", err)
+			return EC_ERROR
+		}
+
+		if state.IsWorks() {
+			terminal.Warn("Instance must be stopped for restoring data from snapshot")
+			return EC_WARN
+		}
 	}
 
-	state, err := CORE.GetInstanceState(id, false)
+	backup, interactive, err := selectBackupToRestore(id)
 
 	if err != nil {
-		terminal.Error("Can't check instance state: %v", err)
+		terminal.Error(err)
 		return EC_ERROR
 	}
 
-	if state.IsWorks() {
-		terminal.Warn("Instance must be stopped for restoring data from snapshot")
-		return EC_WARN
+	if backup == nil {
+		return EC_OK
 	}
 
-	numBackups := getBackupNum(id)
-
-	if numBackups == 0 {
-		terminal.Warn("There are no snapshots of given instance data")
-		return EC_WARN
+	if !checkBackupCompatibility(backup) {
+		return EC_ERROR
 	}
 
-	listBackups(id)
-
-	fmtc.NewLine()
+	if !interactive {
+		fmtc.NewLine()
 
-	for {
-		inputTitle := fmt.Sprintf("Enter index of snapshot to restore (1-%d)", numBackups)
-		selectedIndex, err := input.Read(inputTitle, input.NotEmpty, input.IsNumber)
+		ok, err := input.ReadAnswer(getRestoreQuestion(id, backup), "N")
 
-		if err != nil {
+		if err != nil || !ok {
 			return EC_OK
 		}
-
-		index, err = strconv.Atoi(selectedIndex)
-
-		if err != nil {
-			terminal.Error("Invalid snapshot index: %v\n", err)
-			continue
-		}
-
-		if index > numBackups || index < 1 {
-			terminal.Error("There is no backup with index %d\n", index)
-			continue
-		}
-
-		break
 	}
 
-	snapshots := getBackupFiles(id)
-
-	if len(snapshots) == 0 {
-		terminal.Error("Can't get list of snapshots")
-		return EC_ERROR
+	if isNewTarget {
+		return restoreBackupToNewInstance(backup)
 	}
 
 	spinner.Show("Restoring instance data from snapshot")
 
-	err = restoreBackupSnapshot(id, snapshots[index-1])
+	err = CORE.RestoreInstanceBackup(id, backup)
 
 	spinner.Done(err == nil)
 
@@ -209,7 +174,7 @@ func BackupRestoreCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	logger.Info(id, "Restored RDB backup")
+	logger.Info(id, "Restored %s backup %s", strings.ToUpper(backup.Type), getBackupSourceName(backup))
 
 	return EC_OK
 }
@@ -230,14 +195,21 @@ func BackupCleanCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	numBackups := getBackupNum(id)
+	backups, err := CORE.GetInstanceBackups(id)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	numBackups := len(backups)
 
 	if numBackups == 0 {
 		terminal.Warn("There are no snapshots of given instance data")
 		return EC_WARN
 	}
 
-	listBackups(id)
+	listBackups(backups)
 
 	fmtc.NewLine()
 
@@ -249,7 +221,7 @@ func BackupCleanCommand(args CommandArgs) int {
 
 	spinner.Show("Removing instance backups {s}(%d){!}", numBackups)
 
-	err = cleanOldBackups(id, MAX_BACKUPS)
+	err = CORE.RemoveInstanceBackups(id)
 
 	spinner.Done(err == nil)
 
@@ -281,160 +253,531 @@ func BackupListCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	if getBackupNum(id) == 0 {
+	backups, err := CORE.GetInstanceBackups(id)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if len(backups) == 0 {
 		terminal.Warn("There are no snapshots of given instance data")
 		return EC_WARN
 	}
 
-	listBackups(id)
+	listBackups(backups)
 
 	return EC_OK
 }
 
-// ////////////////////////////////////////////////////////////////////////////////// //
+// BackupVerifyCommand is "backup-verify" command handler
+func BackupVerifyCommand(args CommandArgs) int {
+	err := args.Check(false)
 
-// getBackupNum returns number of backups for instance with given ID
-func getBackupNum(id int) int {
-	return len(getBackupFiles(id))
-}
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
 
-// getBackupFiles returns slice with paths to backup files
-func getBackupFiles(id int) []string {
-	files := fsutil.List(
-		CORE.GetInstanceDataDirPath(id), false,
-		fsutil.ListingFilter{MatchPatterns: []string{"backup-*.rdb"}},
-	)
+	id, _, err := CORE.ParseIDDBPair(args.Get(0))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	backups, err := CORE.GetInstanceBackups(id)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if len(backups) == 0 {
+		terminal.Warn("There are no snapshots of given instance data")
+		return EC_WARN
+	}
+
+	index := len(backups)
+
+	if args.Has(1) {
+		index, err = args.GetI(1)
+
+		if err != nil || index < 1 || index > len(backups) {
+			terminal.Error("There is no backup with index %s", args.Get(1))
+			return EC_ERROR
+		}
+	}
+
+	backup := backups[index-1]
+
+	spinner.Show("Verifying snapshot {s}(%s){!}", backup.File)
 
-	sortutil.StringsNatural(files)
+	verifyInfo, err := CORE.VerifyBackup(backup)
 
-	return files
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Snapshot is corrupted: %v", err)
+		return EC_ERROR
+	}
+
+	fmtc.NewLine()
+
+	printBackupVerifyInfo(backup, verifyInfo)
+
+	return EC_OK
 }
 
-// listBackups shows table with information about all backups of given instance
-func listBackups(id int) {
-	if getBackupNum(id) == 0 {
-		return
+// BackupRotateKeyCommand is "backup-rotate-key" command handler
+func BackupRotateKeyCommand(args CommandArgs) int {
+	var err error
+	var oldPassphrase string
+
+	if !CORE.IsBackupEncryptionEnabled() {
+		terminal.Warn("Backup encryption is disabled")
+		return EC_WARN
 	}
 
-	dataDir := CORE.GetInstanceDataDirPath(id)
-	t := table.NewTable().SetHeaders("#", "SIZE", "DATE")
+	if CORE.Config.GetS(CORE.BACKUP_ENCRYPTION_KEY_FILE) == "" {
+		oldPassphrase, err = input.ReadPassword(
+			"Please enter previous encryption passphrase (or leave blank if it wasn't changed)",
+		)
 
-	for index, file := range getBackupFiles(id) {
-		t.Add(
-			fmt.Sprintf("{s}%d{!}", index+1),
-			fmtutil.PrettySize(fsutil.GetSize(path.Join(dataDir, file))),
-			extractBackupDateFromFilename(file),
+		if err != nil {
+			return EC_OK
+		}
+
+		fmtc.NewLine()
+	}
+
+	ok, err := input.ReadAnswer("Re-encrypt backups of all instances with a new key?", "N")
+
+	if err != nil || !ok {
+		return EC_OK
+	}
+
+	spinner.Show("Re-encrypting backups")
+
+	num, err := CORE.RotateBackupKey(oldPassphrase, func(backup *CORE.BackupInfo) {
+		spinner.Update("Re-encrypting backup {s}(%s){!}", getBackupSourceName(backup))
+	})
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't rotate encryption key: %v", err)
+		logger.Error(-1, "Tried to rotate backup encryption key, but got error: %v", err)
+		return EC_ERROR
+	}
+
+	logger.Info(-1, "Rotated backup encryption key (re-encrypted backups: %d)", num)
+
+	fmtc.NewLine()
+	fmtc.Printf("{g}Backups re-encrypted: {g*}%d{!}\n", num)
+
+	keys, err := CORE.GetBackupKeys()
+
+	if err == nil && len(keys) > 1 {
+		fmtc.Printf(
+			"{s}%d old keys were kept because they are still used by backups of other nodes{!}\n",
+			len(keys)-1,
 		)
 	}
 
-	t.Render()
+	return EC_OK
 }
 
-// cleanOldBackups remove old backups for instance with given ID
-func cleanOldBackups(id, numOldBackups int) error {
-	numOldBackups = mathutil.Between(numOldBackups, 1, MAX_BACKUPS)
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// selectBackupToRestore returns backup selected for restoring and flag if backup
+// was selected interactively
+func selectBackupToRestore(id int) (*CORE.BackupInfo, bool, error) {
+	var err error
+	var backups []*CORE.BackupInfo
+
+	sourceID := id
+
+	if options.Has(OPT_FROM) {
+		source := options.GetS(OPT_FROM)
+		sourceID, _, err = CORE.ParseIDDBPair(source)
+
+		if err != nil || !CORE.IsInstanceExist(sourceID) {
+			if !fsutil.IsExist(source) {
+				return nil, false, fmt.Errorf("There is no instance or file %q", source)
+			}
+
+			backup, err := CORE.GetFileBackup(source)
+
+			return backup, false, err
+		}
+	}
+
+	backups, err = CORE.GetInstanceBackups(sourceID)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	numBackups := len(backups)
+
+	if numBackups == 0 {
+		return nil, false, fmt.Errorf("There are no snapshots of instance %d data", sourceID)
+	}
+
+	if options.Has(OPT_INDEX) {
+		index := options.GetI(OPT_INDEX)
+
+		if index > numBackups || index < 1 {
+			return nil, false, fmt.Errorf("There is no backup with index %d", index)
+		}
+
+		return backups[index-1], false, nil
+	}
+
+	listBackups(backups)
+
+	fmtc.NewLine()
 
-	dataDir := CORE.GetInstanceDataDirPath(id)
+	for {
+		inputTitle := fmt.Sprintf("Enter index of snapshot to restore (1-%d)", numBackups)
+		selectedIndex, err := input.Read(inputTitle, input.NotEmpty, input.IsNumber)
+
+		if err != nil {
+			return nil, true, nil
+		}
 
-	for index, file := range getBackupFiles(id) {
-		err := os.Remove(path.Join(dataDir, file))
+		index, err := strconv.Atoi(selectedIndex)
 
 		if err != nil {
-			return fmt.Errorf("Can't remove backup file: %w", err)
+			terminal.Error("Invalid snapshot index: %v\n", err)
+			continue
 		}
 
-		if index+1 == numOldBackups {
-			break
+		if index > numBackups || index < 1 {
+			terminal.Error("There is no backup with index %d\n", index)
+			continue
 		}
+
+		return backups[index-1], true, nil
 	}
+}
+
+// checkBackupCompatibility verifies backup and checks if it can be loaded by
+// current version of Redis
+func checkBackupCompatibility(backup *CORE.BackupInfo) bool {
+	spinner.Show("Verifying snapshot {s}(%s){!}", getBackupSourceName(backup))
 
-	return nil
+	verifyInfo, err := CORE.VerifyBackup(backup)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Snapshot is corrupted: %v", err)
+		return false
+	}
+
+	redisVer, err := CORE.GetRedisVersion()
+
+	if err == nil && verifyInfo.RDB != nil {
+		err = CORE.CheckRDBCompatibility(verifyInfo.RDB, redisVer)
+	}
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Snapshot can't be restored: %v", err)
+		return false
+	}
+
+	return true
 }
 
-// restoreBackupSnapshot replaces default dump file with snapshot
-func restoreBackupSnapshot(id int, snapshot string) error {
-	rdbFile := CORE.GetInstanceRDBPath(id)
-	dataDir := CORE.GetInstanceDataDirPath(id)
-	snapshotFile := path.Join(dataDir, snapshot)
+// restoreBackupToNewInstance creates new instance and restores given backup
+func restoreBackupToNewInstance(backup *CORE.BackupInfo) int {
+	if CORE.GetAvailableInstanceID() == -1 {
+		terminal.Warn("No available ID for usage")
+		return EC_WARN
+	}
+
+	if !isEnoughMemoryToCreate() {
+		return EC_ERROR
+	}
 
-	uid, gid, err := fsutil.GetOwner(dataDir)
+	tags, err := parseTagsOption(options.GetS(OPT_TAGS))
 
 	if err != nil {
-		return fmt.Errorf("Can't get data directory owner: %v", err)
+		terminal.Error(err)
+		return EC_ERROR
 	}
 
-	err = fsutil.CopyFile(snapshotFile, rdbFile, 0640)
+	info := &instanceBasicInfo{
+		Desc:             "Restored from " + getBackupSourceName(backup),
+		InstancePassword: CORE.GenPassword(),
+		ReplicationType:  CORE.Config.GetS(CORE.REPLICATION_DEFAULT_ROLE),
+	}
+
+	meta, err := CORE.NewInstanceMeta(info.InstancePassword, "")
 
 	if err != nil {
-		return fmt.Errorf("Can't copy snapshot: %v", err)
+		terminal.Error(err)
+		return EC_ERROR
 	}
 
-	err = os.Chown(rdbFile, uid, gid)
+	meta.Desc = strutil.Head(info.Desc, MAX_DESC_LENGTH)
+	meta.Preferencies.ReplicationType = CORE.ReplicationType(info.ReplicationType)
+	meta.Tags = tags
+
+	fmtc.NewLine()
+
+	err = CORE.CreateInstance(meta)
 
 	if err != nil {
-		return fmt.Errorf("Can't change RDB file owner: %v", err)
+		terminal.Error(err)
+		return EC_ERROR
 	}
 
-	return nil
+	logger.Info(meta.ID, "Instance created for restoring snapshot")
+
+	spinner.Show("Restoring instance data from snapshot")
+
+	err = CORE.RestoreInstanceBackup(meta.ID, backup)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't restore snapshot: %v", err)
+		logger.Error(meta.ID, "Tried to restore snapshot, but got error: %v", err)
+		return EC_ERROR
+	}
+
+	logger.Info(meta.ID, "Restored %s backup %s", strings.ToUpper(backup.Type), getBackupSourceName(backup))
+
+	spinner.Show("Starting instance {s}(ID: %d){!}", meta.ID)
+
+	err = CORE.StartInstance(meta.ID, true)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't start instance: %v", err)
+		logger.Error(meta.ID, "Tried to start instance, but got error: %v", err)
+		return EC_ERROR
+	}
+
+	logger.Info(meta.ID, "Started instance")
+
+	showInstanceInfo(meta, info, tags)
+
+	err = SC.PropagateCommand(API.COMMAND_CREATE, meta.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	err = SC.PropagateCommand(API.COMMAND_START, meta.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	return EC_OK
+}
+
+// getRestoreQuestion returns confirmation question for restoring given backup
+func getRestoreQuestion(id int, backup *CORE.BackupInfo) string {
+	if id == 0 {
+		return fmt.Sprintf(
+			"Restore snapshot %s to a new instance?",
+			getBackupSourceName(backup),
+		)
+	}
+
+	return fmt.Sprintf(
+		"Restore snapshot %s to instance %d? All current instance data will be lost.",
+		getBackupSourceName(backup), id,
+	)
+}
+
+// getBackupSourceName returns human-readable name of backup source
+func getBackupSourceName(backup *CORE.BackupInfo) string {
+	if backup.Storage == CORE.BACKUP_STORAGE_FILE {
+		return backup.File
+	}
+
+	return fmt.Sprintf("%d/%s/%s", backup.InstanceID, backup.Storage, backup.File)
+}
+
+// listBackups shows table with information about given backups
+func listBackups(backups []*CORE.BackupInfo) {
+	if len(backups) == 0 {
+		return
+	}
+
+	t := table.NewTable().SetHeaders("#", "TYPE", "SIZE", "DATE", "STORAGE", "COMPRESSION", "ENCRYPTION")
+
+	for index, backup := range backups {
+		t.Add(
+			fmt.Sprintf("{s}%d{!}", index+1),
+			strings.ToUpper(backup.Type),
+			fmtutil.PrettySize(backup.Size),
+			timeutil.Format(backup.Date, "%Y/%m/%d %H:%M:%S"),
+			strings.Join(backup.Storages, ","),
+			strutil.Q(backup.Compression, CORE.BACKUP_COMPRESSION_NONE),
+			strutil.Q(backup.Encryption, "none"),
+		)
+	}
+
+	t.Render()
 }
 
-// waitForDump waits until RDB file updated
-func waitForDump(id int) {
-	var tempRDB string
+// printBackupVerifyInfo prints info about verified backup
+func printBackupVerifyInfo(backup *CORE.BackupInfo, verifyInfo *CORE.BackupVerifyInfo) {
+	rdbInfo := verifyInfo.RDB
+	t := table.NewTable().SetSizes(24, 96)
 
-	rdbFile := CORE.GetInstanceRDBPath(id)
-	modTime, _ := fsutil.GetMTime(rdbFile)
+	t.Border()
+	t.Print("Snapshot", backup.File)
+	t.Print("Type", strings.ToUpper(backup.Type))
+	t.Print("Storage", backup.Storage)
 
-	for range time.NewTicker(time.Second).C {
-		curModTime, _ := fsutil.GetMTime(rdbFile)
+	if backup.Checksum != "" {
+		t.Print("SHA-256", backup.Checksum)
+	}
 
-		if !curModTime.IsZero() && !curModTime.Equal(modTime) {
-			return
+	switch {
+	case backup.EncryptionKey != "":
+		t.Print("Encryption", fmt.Sprintf("%s {s-}(key: %s){!}", backup.Encryption, backup.EncryptionKey))
+	case backup.Encryption != "":
+		t.Print("Encryption", backup.Encryption)
+	}
+
+	if rdbInfo != nil {
+		t.Print("RDB version", fmt.Sprintf(
+			"%d {s-}(Redis %s or greater required){!}",
+			rdbInfo.Version, RDB.MinRedisVersion(rdbInfo.Version),
+		))
+
+		if rdbInfo.RedisVersion() != "" {
+			t.Print("Created by", "Redis "+rdbInfo.RedisVersion())
 		}
 
-		if tempRDB == "" {
-			tempRDB = getTemporaryRDBPath(id)
+		if !rdbInfo.Created.IsZero() {
+			t.Print("Created", timeutil.Format(rdbInfo.Created, "%Y/%m/%d %H:%M:%S"))
 		}
 
-		if tempRDB != "" {
-			tempRDBSize := fsutil.GetSize(tempRDB)
+		t.Print("Size", fmtutil.PrettySize(rdbInfo.Size))
 
-			if tempRDBSize > 0 {
-				spinner.Update(
-					"Saving instance data in background {s}(%s){!}",
-					fmtutil.PrettySize(tempRDBSize),
-				)
-			}
+		if rdbInfo.Checksum == 0 {
+			t.Print("Checksum", "{s}disabled{!}")
+		} else {
+			t.Print("Checksum", fmt.Sprintf("%016x {g}✔ {!}", rdbInfo.Checksum))
 		}
 	}
-}
 
-// extractBackupDateFromFilename extracts backup creation date from filename
-func extractBackupDateFromFilename(file string) string {
-	ts := strutil.Exclude(file, "backup-")
-	ts = strutil.Exclude(ts, ".rdb")
-	tsi, _ := strconv.ParseInt(ts, 10, 64)
+	if verifyInfo.CheckBinary != "" {
+		t.Print(path.Base(verifyInfo.CheckBinary), "{g}✔ {!}")
+	}
+
+	if len(verifyInfo.AOFFiles) != 0 {
+		t.Border()
+		fmtc.Printf(" ▾ {*}AOF FILES{!}\n")
+		t.Border()
 
-	if tsi == 0 {
-		return "unknown"
+		for index, file := range verifyInfo.AOFFiles {
+			t.Print(fmt.Sprintf("%d", index+1), file)
+		}
 	}
 
-	return timeutil.Format(time.Unix(tsi, 0), "%Y/%m/%d %H:%M:%S")
-}
+	if rdbInfo == nil {
+		t.Border()
+		return
+	}
 
-// getTemporaryRDB returns path to temporary RDB file
-func getTemporaryRDBPath(id int) string {
-	files := fsutil.List(
-		CORE.GetInstanceDataDirPath(id), false,
-		fsutil.ListingFilter{
-			MatchPatterns: []string{"temp-*.rdb"},
-			MTimeYounger:  time.Now().Unix() - 60,
-		},
-	)
+	t.Border()
+	fmtc.Printf(" ▾ {*}KEYSPACE{!}\n")
+	t.Border()
 
-	if len(files) == 0 {
-		return ""
+	if len(rdbInfo.Keys) == 0 {
+		t.Print("Keys", "{s}empty{!}")
 	}
 
-	return path.Join(CORE.GetInstanceDataDirPath(id), files[0])
+	dbs := make([]int, 0, len(rdbInfo.Keys))
+
+	for db := range rdbInfo.Keys {
+		dbs = append(dbs, db)
+	}
+
+	sort.Ints(dbs)
+
+	for _, db := range dbs {
+		t.Print(
+			fmt.Sprintf("db%d", db),
+			fmt.Sprintf(
+				"%s {s-}(with TTL: %s){!}",
+				fmtutil.PrettyNum(rdbInfo.Keys[db]),
+				fmtutil.PrettyNum(rdbInfo.Expires[db]),
+			),
+		)
+	}
+
+	if len(rdbInfo.Aux) != 0 {
+		t.Border()
+		fmtc.Printf(" ▾ {*}AUX FIELDS{!}\n")
+		t.Border()
+
+		fields := make([]string, 0, len(rdbInfo.Aux))
+
+		for field := range rdbInfo.Aux {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			t.Print(field, rdbInfo.Aux[field])
+		}
+	}
+
+	t.Border()
+}
+
+// backupProgressHandler shows backup progress using spinner
+func backupProgressHandler(stage CORE.BackupStage, size int64) {
+	switch stage {
+	case CORE.BACKUP_STAGE_DUMP:
+		spinner.Update(
+			"Saving instance data in background {s}(%s){!}",
+			fmtutil.PrettySize(size),
+		)
+
+	case CORE.BACKUP_STAGE_COPY:
+		if size > 0 {
+			spinner.Update(
+				"Copying snapshot of RDB file {s}(%s){!}",
+				fmtutil.PrettySize(size),
+			)
+		} else {
+			spinner.Update("Archiving instance data")
+		}
+
+	case CORE.BACKUP_STAGE_REWRITE:
+		spinner.Update("Rewriting append only file in background")
+
+	case CORE.BACKUP_STAGE_STORE:
+		spinner.Update(
+			"Saving backup to storage {s}(%s){!}",
+			fmtutil.PrettySize(size),
+		)
+	}
 }
@@ -8,10 +8,14 @@ package cli
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/essentialkaos/ek/v13/csv"
@@ -25,6 +29,8 @@ import (
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/terminal/input"
 
+	"gopkg.in/yaml.v3"
+
 	API "github.com/essentialkaos/rds/api"
 	CORE "github.com/essentialkaos/rds/core"
 	SC "github.com/essentialkaos/rds/sync/client"
@@ -32,12 +38,31 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// batchInstance contains info about instance from batch file
+type batchInstance struct {
+	Line int
+	Spec *instanceSpec
+}
+
+// batchResult contains info about instance creation result
+type batchResult struct {
+	Line            int    `json:"line"`
+	Desc            string `json:"desc"`
+	ID              int    `json:"id,omitempty"`
+	Port            int    `json:"port,omitempty"`
+	Password        string `json:"password,omitempty"`
+	ServicePassword string `json:"service_password,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // BatchCreateCommand is "batch-create" command handler
 func BatchCreateCommand(args CommandArgs) int {
 	var err error
 
 	if len(args) == 0 {
-		terminal.Error("You must define path to CSV file")
+		terminal.Error("You must define path to CSV, JSON or YAML file")
 		return EC_ERROR
 	}
 
@@ -49,68 +74,77 @@ func BatchCreateCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	infoList, err := readInstanceList(args.Get(0))
+	format := options.GetS(OPT_FORMAT)
 
-	if err != nil {
-		terminal.Error(err)
+	if format == FORMAT_XML || format == FORMAT_CSV {
+		terminal.Error("Format %s is not supported by this command", format)
 		return EC_ERROR
 	}
 
-	showInstanceList(infoList)
+	if format != "" && !options.GetB(OPT_YES) {
+		terminal.Error("You must use --yes option with machine-readable output")
+		return EC_ERROR
+	}
 
-	ok, err := input.ReadAnswer("Create these instances?", "N")
+	instances, errs := readInstanceList(args.Get(0))
 
-	if !ok || err != nil {
-		return EC_CANCEL
-	}
+	if len(errs) != 0 {
+		terminal.Error("File %s contains errors:\n", args.Get(0))
 
-	fmtc.NewLine()
+		for _, err := range errs {
+			terminal.Error("  %v", err)
+		}
 
-	var hasErrors bool
+		terminal.Error("\nNo instances were created")
 
-	for _, info := range infoList {
-		id := CORE.GetAvailableInstanceID()
+		return EC_ERROR
+	}
 
-		spinner.Show("Creating instance {*}%s{!}", info.Desc)
+	if format == "" {
+		showInstanceList(instances)
 
-		if id == -1 {
-			spinner.Done(false)
-			hasErrors = true
-			continue
+		ok, err := input.ReadAnswer("Create these instances?", "N")
+
+		if !ok || err != nil {
+			return EC_CANCEL
 		}
 
-		meta, err := CORE.NewInstanceMeta(info.InstancePassword, info.ServicePassword)
+		fmtc.NewLine()
+	}
 
-		if err != nil {
-			spinner.Done(false)
-			hasErrors = true
-			continue
+	var hasErrors bool
+	var results []*batchResult
+
+	for _, inst := range instances {
+		if format == "" {
+			spinner.Show("Creating instance {*}%s{!}", inst.Spec.Desc)
 		}
 
-		meta.Desc = info.Desc
-		meta.Auth.User = info.Owner
-		meta.Preferencies.ReplicationType = CORE.ReplicationType(info.ReplicationType)
-		meta.Preferencies.IsSaveDisabled = options.GetB(OPT_DISABLE_SAVES)
+		result := createBatchInstance(inst)
 
-		err = CORE.CreateInstance(meta)
+		if format == "" {
+			spinner.Done(result.Error == "")
+		}
 
-		if err != nil {
-			spinner.Done(false)
+		if result.Error != "" {
 			hasErrors = true
-			continue
 		}
 
-		logger.Info(meta.ID, "Instance created (batch)")
+		results = append(results, result)
+	}
 
-		err = SC.PropagateCommand(API.COMMAND_CREATE, meta.ID, meta.UUID)
+	if format == "" {
+		fmtc.NewLine()
+		showBatchResults(results)
+	} else {
+		jsonData, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(jsonData))
+	}
 
-		if err != nil {
-			spinner.Done(false)
-			hasErrors = true
-			continue
-		}
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
 
-		spinner.Done(true)
+	if err != nil {
+		terminal.Error(err)
 	}
 
 	if hasErrors {
@@ -122,20 +156,120 @@ func BatchCreateCommand(args CommandArgs) int {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// readInstanceList read instances info from csv file
-func readInstanceList(file string) ([]*instanceBasicInfo, error) {
-	var result []*instanceBasicInfo
+// createBatchInstance creates instance using info from batch file
+func createBatchInstance(inst *batchInstance) *batchResult {
+	spec := inst.Spec
+	result := &batchResult{Line: inst.Line, Desc: spec.Desc}
+
+	if CORE.GetAvailableInstanceID() == -1 {
+		result.Error = "No available ID for usage"
+		return result
+	}
+
+	password := spec.Password
+
+	if password == "" {
+		password = CORE.GenPassword()
+		result.Password = password
+	}
+
+	servicePassword := spec.ServicePassword
+
+	if spec.Secure && servicePassword == "" {
+		servicePassword = CORE.GenPassword()
+		result.ServicePassword = servicePassword
+	}
+
+	meta, err := CORE.NewInstanceMeta(password, servicePassword)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	meta.Desc = spec.Desc
+	meta.Tags = spec.Tags
+	meta.Preferencies.ReplicationType = CORE.ReplicationType(spec.ReplicationType)
+	meta.Preferencies.IsSaveDisabled = options.GetB(OPT_DISABLE_SAVES)
+	meta.Preferencies.TemplateProfile = spec.TemplateProfile
+
+	if spec.Owner != "" {
+		meta.Auth.User = spec.Owner
+	}
+
+	if spec.DisableSaves != nil {
+		meta.Preferencies.IsSaveDisabled = *spec.DisableSaves
+	}
+
+	spec.setLimits(meta.Preferencies)
+
+	err = CORE.CreateInstance(meta)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ID = meta.ID
+	result.Port = CORE.GetInstancePort(meta.ID)
+
+	logger.Info(meta.ID, "Instance created (batch)")
+
+	err = SC.PropagateCommand(API.COMMAND_CREATE, meta.ID, meta.UUID)
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// readInstanceList reads instances info from CSV, JSON or YAML file and validates it
+func readInstanceList(file string) ([]*batchInstance, []error) {
+	var errs []error
+	var instances []*batchInstance
 
 	err := fsutil.ValidatePerms("FRS", file)
 
 	if err != nil {
-		return nil, err
+		return nil, []error{err}
 	}
 
+	fileName := strings.ToLower(file)
+
+	switch {
+	case strings.HasSuffix(fileName, ".json"):
+		instances, errs = readInstanceListJSON(file)
+	case strings.HasSuffix(fileName, ".yml"), strings.HasSuffix(fileName, ".yaml"):
+		instances, errs = readInstanceListYAML(file)
+	default:
+		instances, errs = readInstanceListCSV(file)
+	}
+
+	for _, inst := range instances {
+		err = inst.Spec.Validate(true)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Line %d: %v", inst.Line, err))
+		}
+	}
+
+	if len(instances) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("File doesn't contain any instances"))
+	}
+
+	return instances, errs
+}
+
+// readInstanceListCSV reads instances info from CSV file
+func readInstanceListCSV(file string) ([]*batchInstance, []error) {
+	var errs []error
+	var result []*batchInstance
+
 	fd, err := os.OpenFile(file, os.O_RDONLY, 0)
 
 	if err != nil {
-		return nil, err
+		return nil, []error{err}
 	}
 
 	defer fd.Close()
@@ -148,25 +282,187 @@ func readInstanceList(file string) ([]*instanceBasicInfo, error) {
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return nil, err
+			return nil, append(errs, err)
 		}
 
 		err = validateInstanceListRow(row)
 
 		if err != nil {
-			return nil, fmt.Errorf("Can't parse row %d: %v", r.Line(), err)
+			errs = append(errs, fmt.Errorf("Line %d: %v", r.Line(), err))
+			continue
 		}
 
-		result = append(result, &instanceBasicInfo{
-			Owner:            row.Get(0),
-			InstancePassword: row.Get(1),
-			ReplicationType:  row.Get(2),
-			ServicePassword:  row.Get(3),
-			Desc:             row.Get(4),
+		result = append(result, &batchInstance{
+			Line: r.Line(),
+			Spec: &instanceSpec{
+				Owner:           row.Get(0),
+				Password:        row.Get(1),
+				ReplicationType: row.Get(2),
+				ServicePassword: row.Get(3),
+				Desc:            row.Get(4),
+			},
 		})
 	}
 
-	return result, nil
+	return result, errs
+}
+
+// readInstanceListJSON reads instances info from JSON file with array of objects
+func readInstanceListJSON(file string) ([]*batchInstance, []error) {
+	var errs []error
+	var result []*batchInstance
+
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+
+	if err != nil || token != json.Delim('[') {
+		return nil, []error{fmt.Errorf("Can't parse file %s: JSON array expected", file)}
+	}
+
+	for decoder.More() {
+		var raw json.RawMessage
+
+		line := getJSONLine(data, decoder.InputOffset())
+		err = decoder.Decode(&raw)
+
+		if err != nil {
+			return nil, append(errs, fmt.Errorf("Can't parse file %s (line %d): %v", file, line, err))
+		}
+
+		spec := &instanceSpec{}
+		specDecoder := json.NewDecoder(bytes.NewReader(raw))
+		specDecoder.DisallowUnknownFields()
+		err = specDecoder.Decode(spec)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Line %d: %s", line, strings.TrimPrefix(err.Error(), "json: ")))
+			continue
+		}
+
+		result = append(result, &batchInstance{Line: line, Spec: spec})
+	}
+
+	return result, errs
+}
+
+// readInstanceListYAML reads instances info from YAML file with list of objects
+func readInstanceListYAML(file string) ([]*batchInstance, []error) {
+	var doc yaml.Node
+	var errs []error
+	var result []*batchInstance
+
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	err = yaml.Unmarshal(data, &doc)
+
+	if err != nil {
+		return nil, []error{fmt.Errorf("Can't parse file %s: %v", file, err)}
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	if doc.Content[0].Kind != yaml.SequenceNode {
+		return nil, []error{fmt.Errorf("Can't parse file %s: YAML list expected", file)}
+	}
+
+	knownFields := getYAMLFields(instanceSpec{})
+
+	for _, node := range doc.Content[0].Content {
+		fieldErrs := checkYAMLFields(node, knownFields)
+
+		if len(fieldErrs) != 0 {
+			errs = append(errs, fieldErrs...)
+			continue
+		}
+
+		spec := &instanceSpec{}
+		err = node.Decode(spec)
+
+		if err != nil {
+			errs = append(errs, getYAMLDecodeErrors(node, err)...)
+			continue
+		}
+
+		result = append(result, &batchInstance{Line: node.Line, Spec: spec})
+	}
+
+	return result, errs
+}
+
+// getYAMLFields returns names of YAML fields of given struct
+func getYAMLFields(v any) []string {
+	var result []string
+
+	t := reflect.TypeOf(v)
+
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+
+		if name != "" && name != "-" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// getYAMLDecodeErrors returns list of errors from YAML decoding error
+func getYAMLDecodeErrors(node *yaml.Node, err error) []error {
+	var errs []error
+	var typeErr *yaml.TypeError
+
+	if !errors.As(err, &typeErr) {
+		return []error{fmt.Errorf("Line %d: %v", node.Line, err)}
+	}
+
+	// Type errors already contain line numbers ("line 5: cannot unmarshal…")
+	for _, e := range typeErr.Errors {
+		errs = append(errs, errors.New(strings.ToUpper(e[:1])+e[1:]))
+	}
+
+	return errs
+}
+
+// checkYAMLFields checks YAML object for unknown fields (we can't use KnownFields
+// here because node decoding doesn't support it)
+func checkYAMLFields(node *yaml.Node, knownFields []string) []error {
+	var errs []error
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+
+		if !slices.Contains(knownFields, key.Value) {
+			errs = append(errs, fmt.Errorf("Line %d: unknown field %q", key.Line, key.Value))
+		}
+	}
+
+	return errs
+}
+
+// getJSONLine returns number of line with the first non-space symbol after
+// given offset
+func getJSONLine(data []byte, offset int64) int {
+	for int(offset) < len(data) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+		offset++
+	}
+
+	return bytes.Count(data[:offset], []byte("\n")) + 1
 }
 
 // validateInstanceListRow validate CSV record values
@@ -226,26 +522,69 @@ func validateInstanceListRow(row csv.Row) error {
 }
 
 // showInstanceList show table with instances info
-func showInstanceList(infoList []*instanceBasicInfo) {
+func showInstanceList(instances []*batchInstance) {
 	t := table.NewTable(
-		"OWNER", "PASSWORD", "REPLICATION TYPE",
-		"AUTH PASSWORD", "DESCRIPTION",
+		"LINE", "OWNER", "PASSWORD", "REPLICATION TYPE",
+		"AUTH PASSWORD", "DESCRIPTION", "TAGS",
 	)
 
-	t.SetAlignments(table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT)
+	t.SetAlignments(
+		table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT,
+		table.ALIGN_RIGHT, table.ALIGN_RIGHT,
+	)
+
+	for _, inst := range instances {
+		spec := inst.Spec
+		password := "{s-}[hidden]{!}"
+
+		switch {
+		case spec.Password == "":
+			password = "{s-}[generated]{!}"
+		case options.GetB(OPT_PRIVATE):
+			password = spec.Password
+		}
+
+		servicePassword := spec.ServicePassword
+
+		switch {
+		case servicePassword == "" && spec.Secure:
+			servicePassword = "{s-}[generated]{!}"
+		case servicePassword != "" && !options.GetB(OPT_PRIVATE):
+			servicePassword = "{s-}[hidden]{!}"
+		}
+
+		t.Add(
+			inst.Line, strutil.Q(spec.Owner, "{s-}—{!}"), password, spec.ReplicationType,
+			strutil.Q(servicePassword, "{s-}—{!}"), spec.Desc,
+			strutil.Q(renderTags(spec.Tags...), "{s-}—{!}"),
+		)
+	}
+
+	t.Render()
+	fmtc.NewLine()
+}
+
+// showBatchResults shows table with info about created instances
+func showBatchResults(results []*batchResult) {
+	t := table.NewTable("LINE", "ID", "PORT", "PASSWORD", "DESCRIPTION", "STATUS")
 
-	for _, info := range infoList {
-		if !options.GetB(OPT_PRIVATE) {
-			t.Add(
-				info.Owner, "{s-}[hidden]{!}", info.ReplicationType,
-				strutil.Q(info.ServicePassword, "{s-}—{!}"), info.Desc,
-			)
-		} else {
-			t.Add(
-				info.Owner, info.InstancePassword, info.ReplicationType,
-				strutil.Q(info.ServicePassword, "{s-}—{!}"), info.Desc,
-			)
+	t.SetAlignments(table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT)
+
+	for _, result := range results {
+		id, port, status := "{s-}—{!}", "{s-}—{!}", "{g}created{!}"
+
+		if result.ID != 0 {
+			id, port = fmt.Sprint(result.ID), fmt.Sprint(result.Port)
 		}
+
+		if result.Error != "" {
+			status = "{r}" + result.Error + "{!}"
+		}
+
+		t.Add(
+			result.Line, id, port, strutil.Q(result.Password, "{s-}—{!}"),
+			result.Desc, status,
+		)
 	}
 
 	t.Render()
@@ -74,6 +74,30 @@ func CliCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
+	switch {
+	case options.GetB(OPT_SOCKET) || CORE.IsSocketOnly():
+		if !CORE.IsInstanceSocketAvailable(id) {
+			terminal.Error("Instance with ID %d doesn't listen unix socket", id)
+			return EC_ERROR
+		}
+
+		cliCfg.Socket = CORE.GetInstanceSocketFilePath(id)
+
+	case options.GetB(OPT_TLS) || CORE.IsTLSOnly():
+		if !CORE.IsTLSEnabled() {
+			terminal.Error("TLS is not configured on this node")
+			return EC_ERROR
+		}
+
+		cliCfg.Port = CORE.GetInstanceTLSPort(id)
+		cliCfg.TLS, err = CORE.GetTLSConfig()
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+	}
+
 	if options.GetB(OPT_PRIVATE) {
 		cliCfg.User = CORE.REDIS_USER_ADMIN
 		cliCfg.Password = meta.Preferencies.AdminPassword
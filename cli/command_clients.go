@@ -9,6 +9,7 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -43,16 +44,43 @@ func ClientsCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	meta, err := CORE.GetInstanceMeta(id)
+	clientsData, err := getInstanceClientsData(id)
 
 	if err != nil {
 		terminal.Error(err)
 		return EC_ERROR
 	}
 
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" {
+		renderClientsInfo(clientsData, args.Get(1), format)
+		return EC_OK
+	}
+
+	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
+		if pager.Setup() == nil {
+			defer pager.Complete()
+		}
+	}
+
+	printClientsInfo(clientsData, args.Get(1))
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getInstanceClientsData returns raw CLIENT LIST command output
+func getInstanceClientsData(id int) (string, error) {
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil {
+		return "", err
+	}
+
 	req := &REDIS.Request{
 		Command: []string{"CLIENT", "LIST", "TYPE", "NORMAL"},
-		Port:    CORE.GetInstancePort(id),
 		Auth: REDIS.Auth{
 			User:     CORE.REDIS_USER_ADMIN,
 			Password: meta.Preferencies.AdminPassword,
@@ -60,27 +88,23 @@ func ClientsCommand(args CommandArgs) int {
 		Timeout: time.Second,
 	}
 
-	resp, err := REDIS.ExecCommand(req)
+	err = CORE.ConfigureInstanceRequest(id, req)
 
 	if err != nil {
-		terminal.Error("Error while executing request: %v", err)
-		return EC_ERROR
+		return "", err
 	}
 
-	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
-		if pager.Setup() == nil {
-			defer pager.Complete()
-		}
+	resp, err := REDIS.ExecCommand(req)
+
+	if err != nil {
+		return "", fmt.Errorf("Error while executing request: %w", err)
 	}
 
 	clientsData, _ := resp.Str()
-	printClientsInfo(clientsData, args.Get(1))
 
-	return EC_OK
+	return clientsData, nil
 }
 
-// ////////////////////////////////////////////////////////////////////////////////// //
-
 // printClientsInfo prints info about connected clients
 func printClientsInfo(clientsData, filter string) {
 	buf := bytes.NewBufferString(clientsData)
@@ -103,14 +127,8 @@ func printClientsInfo(clientsData, filter string) {
 		idle, _ := strconv.Atoi(info["idle"])
 		cmd := strings.ToUpper(strings.ReplaceAll(info["cmd"], "|", " "))
 
-		if filter != "" {
-			switch {
-			case info["name"] == filter, info["user"] == filter,
-				strings.HasPrefix(info["addr"], filter+":"):
-				// passthru
-			default:
-				continue
-			}
+		if !isClientFit(info, filter) {
+			continue
 		}
 
 		t.Add(
@@ -137,3 +155,58 @@ func printClientsInfo(clientsData, filter string) {
 
 	t.Render()
 }
+
+// renderClientsInfo prints info about connected clients in given format
+func renderClientsInfo(clientsData, filter, format string) {
+	buf := bytes.NewBufferString(clientsData)
+	data := newOutputList(
+		"clients", "client",
+		"id", "name", "user", "addr", "fd", "db", "flags",
+		"sub", "psub", "events", "age", "idle", "cmd",
+	)
+
+	for {
+		line, err := buf.ReadString('\n')
+
+		if err != nil {
+			break
+		}
+
+		info := parseFieldsLine(line, ' ')
+
+		if !isClientFit(info, filter) {
+			continue
+		}
+
+		id, _ := strconv.ParseInt(info["id"], 10, 64)
+		fd, _ := strconv.Atoi(info["fd"])
+		db, _ := strconv.Atoi(info["db"])
+		sub, _ := strconv.Atoi(info["sub"])
+		psub, _ := strconv.Atoi(info["psub"])
+		age, _ := strconv.Atoi(info["age"])
+		idle, _ := strconv.Atoi(info["idle"])
+
+		data.Add(
+			id, info["name"], info["user"], info["addr"], fd, db, info["flags"],
+			sub, psub, info["events"], age, idle,
+			strings.ToUpper(strings.ReplaceAll(info["cmd"], "|", " ")),
+		)
+	}
+
+	data.Render(format)
+}
+
+// isClientFit returns true if client info fits given filter
+func isClientFit(info map[string]string, filter string) bool {
+	if filter == "" {
+		return true
+	}
+
+	switch {
+	case info["name"] == filter, info["user"] == filter,
+		strings.HasPrefix(info["addr"], filter+":"):
+		return true
+	}
+
+	return false
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/essentialkaos/ek/v13/fmtutil"
 	"github.com/essentialkaos/ek/v13/fmtutil/table"
 	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/terminal"
 
 	CORE "github.com/essentialkaos/rds/core"
@@ -71,6 +72,8 @@ func getInstanceCPUUsage(id, period int) int {
 		return EC_ERROR
 	}
 
+	r1, _ := CORE.GetInstanceResourceUsage(id)
+
 	time.Sleep(time.Second * time.Duration(period))
 
 	i2, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
@@ -80,12 +83,19 @@ func getInstanceCPUUsage(id, period int) int {
 		return EC_ERROR
 	}
 
+	r2, _ := CORE.GetInstanceResourceUsage(id)
+
 	u1 := extractCPUUsageInfo(i1)
 	u2 := extractCPUUsageInfo(i2)
 
 	usage := calculateInstanceCPUUsage(u1, u2, period)
+	format := options.GetS(OPT_FORMAT)
 
-	printInstanceCPUUsage(usage)
+	if format != "" {
+		renderInstanceCPUUsage(usage, r1, r2, period, format)
+	} else {
+		printInstanceCPUUsage(usage, r1, r2, period)
+	}
 
 	return EC_OK
 }
@@ -108,7 +118,7 @@ func calculateInstanceCPUUsage(u1, u2 []float64, period int) []float64 {
 }
 
 // printInstanceCPUUsage print info about cpu usage
-func printInstanceCPUUsage(usage []float64) {
+func printInstanceCPUUsage(usage []float64, r1, r2 *CORE.ResourceUsage, period int) {
 	sysStr := fmtutil.PrettyPerc(mathutil.Between(fmtutil.Float(usage[0]), 0.0, 100.0))
 	usrStr := fmtutil.PrettyPerc(mathutil.Between(fmtutil.Float(usage[1]), 0.0, 100.0))
 	sysChStr := fmtutil.PrettyPerc(mathutil.Between(fmtutil.Float(usage[2]), 0.0, 100.0))
@@ -121,9 +131,40 @@ func printInstanceCPUUsage(usage []float64) {
 	t.Add("Sys (Children)", sysChStr)
 	t.Add("User (Children)", usrChStr)
 
+	if r1 != nil && r2 != nil && r2.Limits.CPUMax != 0 {
+		throttled := float64(r2.CPUThrottled-r1.CPUThrottled) / float64(period*10000)
+
+		t.Add("Limit", fmtutil.PrettyPerc(float64(r2.Limits.CPUMax)))
+		t.Add("Throttled", fmtutil.PrettyPerc(mathutil.Between(fmtutil.Float(throttled), 0.0, 100.0)))
+	}
+
 	t.Render()
 }
 
+// renderInstanceCPUUsage prints info about cpu usage in given format
+func renderInstanceCPUUsage(usage []float64, r1, r2 *CORE.ResourceUsage, period int, format string) {
+	var limit, throttled any
+
+	if r1 != nil && r2 != nil && r2.Limits.CPUMax != 0 {
+		limit = float64(r2.Limits.CPUMax)
+		throttled = mathutil.Between(fmtutil.Float(
+			float64(r2.CPUThrottled-r1.CPUThrottled)/float64(period*10000),
+		), 0.0, 100.0)
+	}
+
+	newOutputRecord(
+		"cpu", "period", "sys", "user", "sys_children", "user_children",
+		"limit", "throttled",
+	).Add(
+		period,
+		mathutil.Between(fmtutil.Float(usage[0]), 0.0, 100.0),
+		mathutil.Between(fmtutil.Float(usage[1]), 0.0, 100.0),
+		mathutil.Between(fmtutil.Float(usage[2]), 0.0, 100.0),
+		mathutil.Between(fmtutil.Float(usage[3]), 0.0, 100.0),
+		limit, throttled,
+	).Render(format)
+}
+
 // extractCPUUsageInfo extrtacts cpu usage from redis info
 func extractCPUUsageInfo(info *REDIS.Info) []float64 {
 	return []float64{
@@ -10,11 +10,13 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/fmtutil"
 	"github.com/essentialkaos/ek/v13/fmtutil/table"
 	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/spinner"
 	"github.com/essentialkaos/ek/v13/strutil"
 	"github.com/essentialkaos/ek/v13/system"
 	"github.com/essentialkaos/ek/v13/terminal"
@@ -67,24 +69,112 @@ func CreateCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	info, err := readBasicInstanceInfo()
+	var spec *instanceSpec
 
-	if err != nil {
-		if err == input.ErrKillSignal {
-			return EC_OK
+	format := options.GetS(OPT_FORMAT)
+
+	if isNonInteractiveInput() {
+		spec, err = readInstanceSpec()
+
+		if err == nil {
+			err = spec.Validate(true)
 		}
 
-		terminal.Error(err)
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		tags = spec.Tags
+	}
+
+	limits := spec
 
+	// Limits can be defined using options in interactive mode
+	if limits == nil {
+		limits = &instanceSpec{}
+		limits.readLimitsOptions()
+
+		err = limits.validateLimits()
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+	}
+
+	if format != "" && (options.Has(OPT_FROM_RDB) || options.Has(OPT_REPLICATE_FROM)) {
+		terminal.Error("Machine-readable output is not supported with --from-rdb or --replicate-from")
 		return EC_ERROR
 	}
 
-	if !info.CustomInstancePassword {
-		info.InstancePassword = CORE.GenPassword()
+	var backup *CORE.BackupInfo
+	var source *CORE.ReplicationSource
+
+	switch {
+	case options.Has(OPT_FROM_RDB):
+		backup, err = CORE.GetFileBackup(options.GetS(OPT_FROM_RDB))
+
+		if err != nil {
+			terminal.Error("Can't use file %s as data source: %v", options.GetS(OPT_FROM_RDB), err)
+			return EC_ERROR
+		}
+
+		if !checkBackupCompatibility(backup) {
+			return EC_ERROR
+		}
+
+		fmtc.NewLine()
+
+	case options.Has(OPT_REPLICATE_FROM):
+		source, err = readReplicationSource(args)
+
+		if err != nil {
+			if err == input.ErrKillSignal {
+				return EC_OK
+			}
+
+			terminal.Error(err)
+			return EC_ERROR
+		}
 	}
 
-	if options.GetB(OPT_SECURE) && !info.CustomServicePassword {
-		info.ServicePassword = CORE.GenPassword()
+	var info *instanceBasicInfo
+
+	if spec != nil {
+		info = &instanceBasicInfo{
+			Owner:                  spec.Owner,
+			Desc:                   spec.Desc,
+			InstancePassword:       spec.Password,
+			ServicePassword:        spec.ServicePassword,
+			ReplicationType:        spec.ReplicationType,
+			CustomInstancePassword: spec.Password != "",
+			CustomServicePassword:  spec.ServicePassword != "",
+		}
+
+		if spec.Secure && !info.CustomServicePassword {
+			info.ServicePassword = CORE.GenPassword()
+		}
+	} else {
+		info, err = readBasicInstanceInfo()
+
+		if err != nil {
+			if err == input.ErrKillSignal {
+				return EC_OK
+			}
+
+			terminal.Error(err)
+
+			return EC_ERROR
+		}
+
+		if options.GetB(OPT_SECURE) && !info.CustomServicePassword {
+			info.ServicePassword = CORE.GenPassword()
+		}
+	}
+
+	if !info.CustomInstancePassword {
+		info.InstancePassword = CORE.GenPassword()
 	}
 
 	meta, err := CORE.NewInstanceMeta(
@@ -102,6 +192,20 @@ func CreateCommand(args CommandArgs) int {
 	meta.Preferencies.IsSaveDisabled = options.GetB(OPT_DISABLE_SAVES)
 	meta.Tags = tags
 
+	limits.setLimits(meta.Preferencies)
+
+	if spec != nil {
+		if spec.Owner != "" {
+			meta.Auth.User = spec.Owner
+		}
+
+		if spec.DisableSaves != nil {
+			meta.Preferencies.IsSaveDisabled = *spec.DisableSaves
+		}
+
+		meta.Preferencies.TemplateProfile = spec.TemplateProfile
+	}
+
 	err = CORE.CreateInstance(meta)
 
 	if err != nil {
@@ -109,15 +213,21 @@ func CreateCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	fmtc.Println("{*}Done, a new Redis instance has been successfully created. Just for you.{!}")
-
 	if len(tags) == 0 {
 		logger.Info(meta.ID, "Instance created")
 	} else {
 		logger.Info(meta.ID, "Instance created (tags: %s)", strings.Join(tags, ","))
 	}
 
-	showInstanceInfo(meta, info, tags)
+	if format == "" {
+		fmtc.Println("{*}Done, a new Redis instance has been successfully created. Just for you.{!}")
+		showInstanceInfo(meta, info, tags)
+	} else {
+		result := newInstanceSpecResult(meta)
+		result.Password = info.InstancePassword
+		result.ServicePassword = info.ServicePassword
+		renderInstanceSpecResult(result, format)
+	}
 
 	err = SC.PropagateCommand(API.COMMAND_CREATE, meta.ID, meta.UUID)
 
@@ -125,13 +235,19 @@ func CreateCommand(args CommandArgs) int {
 		terminal.Error(err)
 	}
 
+	ec := EC_OK
+
+	if backup != nil || source != nil {
+		ec = seedInstanceData(meta, backup, source)
+	}
+
 	err = CORE.SaveStates(CORE.GetStatesFilePath())
 
 	if err != nil {
 		terminal.Error(err)
 	}
 
-	return EC_OK
+	return ec
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -199,7 +315,16 @@ func showInstanceInfo(meta *CORE.InstanceMeta, info *instanceBasicInfo, tags []s
 	t.Border()
 
 	t.Print("ID", meta.ID)
-	t.Print("Port", CORE.GetInstancePort(meta.ID))
+
+	if CORE.IsSocketOnly() {
+		t.Print("Socket", CORE.GetInstanceSocketFilePath(meta.ID))
+	} else {
+		t.Print("Port", CORE.GetInstancePort(meta.ID))
+	}
+
+	if CORE.IsTLSEnabled() {
+		t.Print("TLS Port", CORE.GetInstanceTLSPort(meta.ID))
+	}
 	t.Print("Replication Type", meta.Preferencies.ReplicationType)
 	t.Print(
 		"Description",
@@ -226,6 +351,155 @@ func showInstanceInfo(meta *CORE.InstanceMeta, info *instanceBasicInfo, tags []s
 	fmtc.Println("{y}▲ Please save your passwords in a safe place!{!}")
 }
 
+// readReplicationSource reads and checks external replication source info
+func readReplicationSource(args CommandArgs) (*CORE.ReplicationSource, error) {
+	var err error
+
+	auth := args.Get(0)
+
+	if !args.Has(0) {
+		auth, err = input.ReadPassword(
+			"Please enter the password for replication source (password or user:password, leave blank if auth is not required)",
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fmtc.NewLine()
+	}
+
+	source, err := CORE.ParseReplicationSource(options.GetS(OPT_REPLICATE_FROM), auth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	spinner.Show("Checking replication source {s}(%s:%d){!}", source.Host, source.Port)
+	err = CORE.CheckReplicationSource(source)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmtc.NewLine()
+
+	return source, nil
+}
+
+// seedInstanceData restores data from snapshot or replicates it from external
+// source and starts instance
+func seedInstanceData(meta *CORE.InstanceMeta, backup *CORE.BackupInfo, source *CORE.ReplicationSource) int {
+	fmtc.NewLine()
+
+	if backup != nil {
+		spinner.Show("Restoring instance data from snapshot")
+		err := CORE.RestoreInstanceBackup(meta.ID, backup)
+		spinner.Done(err == nil)
+
+		if err != nil {
+			fmtc.NewLine()
+			terminal.Error("Can't restore snapshot: %v", err)
+			logger.Error(meta.ID, "Tried to restore snapshot, but got error: %v", err)
+			return EC_ERROR
+		}
+
+		logger.Info(meta.ID, "Restored %s backup %s", strings.ToUpper(backup.Type), getBackupSourceName(backup))
+	}
+
+	spinner.Show("Starting instance {s}(ID: %d){!}", meta.ID)
+	err := CORE.StartInstance(meta.ID, true)
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't start instance: %v", err)
+		logger.Error(meta.ID, "Tried to start instance, but got error: %v", err)
+		return EC_ERROR
+	}
+
+	logger.Info(meta.ID, "Started instance")
+
+	if source != nil {
+		err = replicateFromSource(meta.ID, source)
+
+		if err != nil {
+			fmtc.NewLine()
+			terminal.Error(err)
+			logger.Error(meta.ID, "Replication from %s:%d failed: %v", source.Host, source.Port, err)
+			return EC_ERROR
+		}
+
+		logger.Info(meta.ID, "Data replicated from %s:%d", source.Host, source.Port)
+	}
+
+	err = SC.PropagateCommand(API.COMMAND_START, meta.ID, meta.UUID)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	return EC_OK
+}
+
+// replicateFromSource replicates data from external source and detaches
+// instance from it after initial sync
+func replicateFromSource(id int, source *CORE.ReplicationSource) error {
+	spinner.Show("Replicating data from %s:%d", source.Host, source.Port)
+
+	err := CORE.StartExternalReplication(id, source)
+
+	if err != nil {
+		spinner.Done(false)
+		return err
+	}
+
+	maxWait := time.Duration(CORE.Config.GetI(CORE.REPLICATION_MAX_SYNC_WAIT)) * time.Second
+	deadline := time.Now().Add(maxWait)
+	ticker := time.NewTicker(time.Second)
+
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		if time.Now().After(deadline) {
+			spinner.Done(false)
+			CORE.StopExternalReplication(id)
+			return fmt.Errorf("Instance didn't finish syncing in %v", maxWait)
+		}
+
+		info, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
+
+		if err != nil {
+			continue
+		}
+
+		if info.Get("replication", "master_sync_in_progress") == "1" {
+			spinner.Update(
+				"Replicating data from %s:%d {s}(%s received){!}",
+				source.Host, source.Port,
+				fmtutil.PrettySize(info.GetU("replication", "master_sync_read_bytes")),
+			)
+
+			continue
+		}
+
+		if info.Get("replication", "master_link_status") == "up" {
+			break
+		}
+	}
+
+	spinner.Update("Detaching instance from %s:%d", source.Host, source.Port)
+
+	err = CORE.StopExternalReplication(id)
+
+	spinner.Done(err == nil)
+
+	return err
+}
+
 // parseTagsOption parses tags option
 func parseTagsOption(tags string) ([]string, error) {
 	if tags == "" {
@@ -0,0 +1,727 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/strutil"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/tty"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	CORE "github.com/essentialkaos/rds/core"
+	REDIS "github.com/essentialkaos/rds/redis"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Dashboard drill-down views
+const (
+	DASHBOARD_VIEW_INFO    = "info"
+	DASHBOARD_VIEW_SLOWLOG = "slowlog"
+	DASHBOARD_VIEW_CLIENTS = "clients"
+)
+
+// DASHBOARD_HIGHLIGHT_PERIODS is number of refresh periods while state change
+// is highlighted
+const DASHBOARD_HIGHLIGHT_PERIODS = 3
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dashboard contains dashboard state
+type dashboard struct {
+	Interval int
+	Filter   string
+	Query    queryMatcher
+	Sort     int
+	Reverse  bool
+	Selected int // ID of selected instance
+	Offset   int // scroll offset
+
+	items        []*dashboardItem
+	states       map[int]string
+	changes      map[int]time.Time
+	cpu          map[int]dashboardCPUSample
+	keys         chan string
+	updates      chan []*dashboardItem
+	status       string
+	input        string
+	isInput      bool
+	isRefreshing bool
+	updated      time.Time
+	hostname     string
+}
+
+// dashboardItem contains instance info for dashboard
+type dashboardItem struct {
+	*queryInstance
+
+	CPU    float64   // CPU usage (-1 if unknown)
+	LastIO int64     // Seconds since last interaction with master or replicas (-1 if unknown)
+	Date   time.Time // Date when info was collected
+}
+
+// dashboardCPUSample contains sample of instance CPU usage
+type dashboardCPUSample struct {
+	Value float64
+	Date  time.Time
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dashboardSortColumns is a list of columns supported for sorting
+var dashboardSortColumns = []string{"id", "state", "mem", "ops", "clients", "cpu", "io"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// DashboardCommand is "dashboard" command handler
+func DashboardCommand(args CommandArgs) int {
+	if !CORE.HasInstances() {
+		terminal.Warn("No instances are created")
+		return EC_WARN
+	}
+
+	if useRawOutput || !tty.IsTTY() {
+		terminal.Error("Dashboard can be used only in interactive terminal")
+		return EC_ERROR
+	}
+
+	interval := 3 // 3 seconds by default
+
+	if args.Has(0) {
+		num, err := strconv.Atoi(args.Get(0))
+
+		if err == nil {
+			interval = mathutil.Between(num, 1, 300)
+			args = args[1:]
+		}
+	}
+
+	query, err := parseInstanceQuery(args)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	d := &dashboard{
+		Interval: interval,
+		Filter:   strings.Join(args, " "),
+		Query:    query,
+		Selected: -1,
+		states:   make(map[int]string),
+		changes:  make(map[int]time.Time),
+		cpu:      make(map[int]dashboardCPUSample),
+		keys:     make(chan string, 32),
+		updates:  make(chan []*dashboardItem, 1),
+	}
+
+	d.hostname, _ = os.Hostname()
+
+	return d.Run()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Run runs dashboard main loop
+func (d *dashboard) Run() int {
+	termMode, err := enableDashboardTerminalMode()
+
+	if err != nil {
+		terminal.Error("Can't configure terminal: %v", err)
+		return EC_ERROR
+	}
+
+	// Switch to alternate screen and hide cursor
+	fmt.Print("\033[?1049h\033[?25l")
+
+	defer func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		restoreDashboardTerminalMode(termMode)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGWINCH)
+	defer signal.Stop(signals)
+
+	go readDashboardKeys(d.keys)
+
+	d.Refresh()
+	d.Render()
+
+	timer := time.NewTimer(time.Duration(d.Interval) * time.Second)
+
+	for {
+		select {
+		case items := <-d.updates:
+			d.Update(items)
+			d.Render()
+
+		case <-timer.C:
+			d.Refresh()
+			timer.Reset(time.Duration(d.Interval) * time.Second)
+
+		case key := <-d.keys:
+			if !d.HandleKey(key) {
+				return EC_OK
+			}
+
+			d.Render()
+
+		case sig := <-signals:
+			if sig != syscall.SIGWINCH {
+				return EC_OK
+			}
+
+			d.Render()
+		}
+	}
+}
+
+// Refresh starts collecting info about all instances in background. Info is
+// sent to updates channel, so dashboard keeps handling keys while instances
+// info is collected.
+func (d *dashboard) Refresh() {
+	if d.isRefreshing {
+		return
+	}
+
+	d.isRefreshing = true
+
+	go collectDashboardItems(d.updates)
+}
+
+// Update updates dashboard with collected info about instances
+func (d *dashboard) Update(items []*dashboardItem) {
+	now := time.Now()
+
+	for _, item := range items {
+		id := item.ID
+		stateName := getStateName(item.State)
+		prevStateName, isKnown := d.states[id]
+
+		// Highlight instances with changed state and new instances
+		if (isKnown && prevStateName != stateName) || (!isKnown && !d.updated.IsZero()) {
+			d.changes[id] = now
+		}
+
+		d.states[id] = stateName
+
+		info := item.Info()
+
+		if info == nil {
+			delete(d.cpu, id)
+			continue
+		}
+
+		cpuUsage := info.GetF("cpu", "used_cpu_sys") + info.GetF("cpu", "used_cpu_user")
+		prevUsage, hasUsage := d.cpu[id]
+
+		if hasUsage && item.Date.After(prevUsage.Date) {
+			item.CPU = mathutil.Max((cpuUsage-prevUsage.Value)/item.Date.Sub(prevUsage.Date).Seconds()*100, 0)
+		}
+
+		d.cpu[id] = dashboardCPUSample{cpuUsage, item.Date}
+	}
+
+	d.items = items
+	d.updated = now
+	d.isRefreshing = false
+}
+
+// Render renders dashboard
+func (d *dashboard) Render() {
+	if d.updated.IsZero() {
+		fmt.Print("\033[H\033[2J")
+		fmtc.Printf("{s-}Collecting data…{!}")
+		return
+	}
+
+	width, height := tty.GetSize()
+
+	if width <= 0 || height <= 0 {
+		width, height = 120, 40
+	}
+
+	items := d.getVisibleItems()
+	rowsNum := mathutil.Max(height-7, 1)
+	selectedIndex := d.fixSelection(items)
+
+	switch {
+	case selectedIndex < d.Offset:
+		d.Offset = selectedIndex
+	case selectedIndex >= d.Offset+rowsNum:
+		d.Offset = selectedIndex - rowsNum + 1
+	}
+
+	d.Offset = mathutil.Between(d.Offset, 0, mathutil.Max(len(items)-rowsNum, 0))
+
+	var lines []string
+
+	sortOrder := "↑"
+
+	if d.Reverse {
+		sortOrder = "↓"
+	}
+
+	lines = append(lines,
+		fmtc.Sprintf(
+			" {*}RDS Dashboard{!} {s}·{!} %s {s}·{!} %d/%d instances {s-}(updated %s){!}",
+			d.hostname, len(items), len(d.items),
+			timeutil.Format(d.updated, "%H:%M:%S"),
+		),
+		fmtc.Sprintf(
+			" {s}Refresh:{!} %ds {s}·{!} {s}Sort:{!} %s %s {s}·{!} {s}Filter:{!} %s",
+			d.Interval, dashboardSortColumns[d.Sort], sortOrder,
+			strutil.Q(d.Filter, "—"),
+		),
+		"",
+		d.renderHeader(width),
+	)
+
+	for index := d.Offset; index < len(items) && index < d.Offset+rowsNum; index++ {
+		lines = append(lines, d.renderItem(items[index], width))
+	}
+
+	for len(lines) < rowsNum+4 {
+		lines = append(lines, "")
+	}
+
+	switch {
+	case d.isInput:
+		lines = append(lines, fmtc.Sprintf(" {c}Filter:{!} %s{s}_{!}", d.input))
+	case d.status != "":
+		lines = append(lines, fmtc.Sprintf(" {r}%s{!}", d.status))
+	default:
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, fmtc.Render(
+		" {*}q{!} quit  {*}↑↓{!} select  {*}s{!} sort  {*}r{!} reverse  {*}/{!} filter  "+
+			"{*}i{!} info  {*}l{!} slowlog  {*}c{!} clients  {*}+/-{!} refresh interval",
+	))
+
+	fmt.Print("\033[H" + strings.Join(lines, "\033[K\n") + "\033[K\033[J")
+}
+
+// HandleKey handles key press and returns false if dashboard must be closed
+func (d *dashboard) HandleKey(key string) bool {
+	if d.isInput {
+		d.handleInputKey(key)
+		return true
+	}
+
+	d.status = ""
+
+	switch key {
+	case "q", "Q", "\x1b":
+		return false
+	case "\x1b[A", "\x1bOA", "k":
+		d.moveSelection(-1)
+	case "\x1b[B", "\x1bOB", "j":
+		d.moveSelection(1)
+	case "\x1b[5~":
+		d.moveSelection(-10)
+	case "\x1b[6~":
+		d.moveSelection(10)
+	case "s":
+		d.Sort = (d.Sort + 1) % len(dashboardSortColumns)
+	case "r":
+		d.Reverse = !d.Reverse
+	case "/", "f":
+		d.isInput, d.input = true, d.Filter
+	case "+":
+		d.Interval = mathutil.Min(d.Interval+1, 300)
+	case "-":
+		d.Interval = mathutil.Max(d.Interval-1, 1)
+	case " ":
+		d.Refresh()
+	case "\r", "\n", "i":
+		d.showDetails(DASHBOARD_VIEW_INFO)
+	case "l":
+		d.showDetails(DASHBOARD_VIEW_SLOWLOG)
+	case "c":
+		d.showDetails(DASHBOARD_VIEW_CLIENTS)
+	}
+
+	return true
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// handleInputKey handles key press while filter is edited
+func (d *dashboard) handleInputKey(key string) {
+	switch key {
+	case "\x1b":
+		d.isInput = false
+
+	case "\r", "\n":
+		d.isInput = false
+		query, err := parseInstanceQuery([]string{d.input})
+
+		if err != nil {
+			d.status = err.Error()
+			return
+		}
+
+		d.Filter, d.Query, d.Offset = strings.TrimSpace(d.input), query, 0
+
+	case "\x7f", "\b":
+		if d.input != "" {
+			runes := []rune(d.input)
+			d.input = string(runes[:len(runes)-1])
+		}
+
+	default:
+		for _, r := range key {
+			if !unicode.IsPrint(r) {
+				return
+			}
+		}
+
+		d.input += key
+	}
+}
+
+// getVisibleItems returns filtered and sorted list of items
+func (d *dashboard) getVisibleItems() []*dashboardItem {
+	var result []*dashboardItem
+
+	for _, item := range d.items {
+		if d.Query(item.queryInstance) {
+			result = append(result, item)
+		}
+	}
+
+	column := dashboardSortColumns[d.Sort]
+
+	sort.SliceStable(result, func(i, j int) bool {
+		i1, i2 := result[i], result[j]
+
+		if d.Reverse {
+			i1, i2 = i2, i1
+		}
+
+		switch column {
+		case "state":
+			return getStateName(i1.State) < getStateName(i2.State)
+		case "cpu":
+			return i1.CPU < i2.CPU
+		case "io":
+			return i1.LastIO < i2.LastIO
+		}
+
+		v1, ok1 := i1.Metric(column)
+		v2, ok2 := i2.Metric(column)
+
+		if !ok1 {
+			v1 = -1
+		}
+
+		if !ok2 {
+			v2 = -1
+		}
+
+		return v1 < v2
+	})
+
+	return result
+}
+
+// fixSelection checks that selected instance is visible and returns its index
+func (d *dashboard) fixSelection(items []*dashboardItem) int {
+	for index, item := range items {
+		if item.ID == d.Selected {
+			return index
+		}
+	}
+
+	if len(items) == 0 {
+		d.Selected = -1
+		return 0
+	}
+
+	d.Selected = items[0].ID
+
+	return 0
+}
+
+// moveSelection moves selection by given number of rows
+func (d *dashboard) moveSelection(shift int) {
+	items := d.getVisibleItems()
+
+	if len(items) == 0 {
+		return
+	}
+
+	index := mathutil.Between(d.fixSelection(items)+shift, 0, len(items)-1)
+	d.Selected = items[index].ID
+}
+
+// showDetails shows details about selected instance
+func (d *dashboard) showDetails(view string) {
+	if d.Selected == -1 {
+		return
+	}
+
+	fmt.Print("\033[H\033[2J\033[?25h")
+	fmtc.Printf("{*}Instance %d{!} {s}·{!} %s\n\n", d.Selected, view)
+
+	switch view {
+	case DASHBOARD_VIEW_INFO:
+		showDashboardInstanceInfo(d.Selected)
+	case DASHBOARD_VIEW_SLOWLOG:
+		slowlogGet(d.Selected, 10, "")
+	case DASHBOARD_VIEW_CLIENTS:
+		clientsData, err := getInstanceClientsData(d.Selected)
+
+		if err != nil {
+			terminal.Error(err)
+		} else {
+			printClientsInfo(clientsData, "")
+		}
+	}
+
+	fmtc.Printf("\n{s-}Press any key to return to dashboard…{!}")
+
+	<-d.keys
+
+	fmt.Print("\033[?25l\033[H\033[2J")
+}
+
+// renderHeader renders table header
+func (d *dashboard) renderHeader(width int) string {
+	return fmtc.Sprintf("{*}%s{!}", formatDashboardRow(
+		width, "ID", "STATE", "MEMORY", "OPS", "CLIENTS",
+		"CPU", "LAST IO", "OWNER", "DESCRIPTION",
+	))
+}
+
+// renderItem renders table row with info about instance
+func (d *dashboard) renderItem(item *dashboardItem, width int) string {
+	memory, ops, clients, cpu, lastIO := "{s-}—{!}", "{s-}—{!}", "{s-}—{!}", "{s-}—{!}", "{s-}—{!}"
+	state := getInstanceStateWithColor(item.State)
+
+	if !d.changes[item.ID].IsZero() &&
+		time.Since(d.changes[item.ID]) < time.Duration(d.Interval*DASHBOARD_HIGHLIGHT_PERIODS)*time.Second {
+		state = "{@}" + state
+	}
+
+	if item.State.IsWorks() {
+		memory = getInstanceMemoryUsageWithColor(item.queryInstance)
+
+		if v, ok := item.Metric("ops"); ok {
+			ops = fmtutil.PrettyNum(v)
+		}
+
+		if v, ok := item.Metric("clients"); ok {
+			clients = fmtutil.PrettyNum(v)
+		}
+
+		if item.CPU >= 0 {
+			cpu = fmtutil.PrettyPerc(fmtutil.Float(item.CPU))
+		}
+
+		if item.LastIO >= 0 {
+			lastIO = timeutil.MiniDuration(time.Duration(item.LastIO) * time.Second)
+		}
+	}
+
+	desc := item.Meta.Desc
+
+	if !item.State.IsWorks() {
+		desc = "{s-}" + desc + "{!}"
+	}
+
+	row := formatDashboardRow(
+		width, getInstanceIDWithColor(item.ID, item.State), state, memory,
+		ops, clients, cpu, lastIO, item.Meta.Auth.User, desc,
+	)
+
+	if item.ID == d.Selected {
+		return fmtc.Render("{c}▸{!}") + row[1:]
+	}
+
+	return row
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// formatDashboardRow formats table row
+func formatDashboardRow(width int, values ...string) string {
+	sizes := []int{5, 9, 10, 8, 8, 7, 7, 14}
+	aligns := []fmtutil.Alignment{
+		fmtutil.RIGHT, fmtutil.LEFT, fmtutil.RIGHT, fmtutil.RIGHT, fmtutil.RIGHT,
+		fmtutil.RIGHT, fmtutil.RIGHT, fmtutil.LEFT,
+	}
+
+	var result strings.Builder
+
+	used := 0
+
+	for index, value := range values {
+		if index == len(values)-1 {
+			descSize := mathutil.Max(width-used-2, 0)
+
+			if strutil.Len(fmtc.Clean(value)) > descSize {
+				value = strutil.Substr(fmtc.Clean(value), 0, descSize)
+			}
+
+			result.WriteString(" " + fmtc.Render(value))
+			break
+		}
+
+		result.WriteString(" " + fmtutil.Align(fmtc.Render(value), aligns[index], sizes[index]))
+		used += sizes[index] + 1
+	}
+
+	return result.String()
+}
+
+// collectDashboardItems collects info about all instances and sends it to
+// given channel
+func collectDashboardItems(updates chan []*dashboardItem) {
+	var items []*dashboardItem
+
+	for _, id := range CORE.GetInstanceIDList() {
+		inst, err := newQueryInstance(id)
+
+		if err != nil {
+			continue
+		}
+
+		item := &dashboardItem{queryInstance: inst, CPU: -1, LastIO: -1}
+		info := inst.Info()
+
+		if info != nil {
+			inst.Memory()
+			item.LastIO = getDashboardLastIO(info)
+		}
+
+		item.Date = time.Now()
+		items = append(items, item)
+	}
+
+	updates <- items
+}
+
+// getDashboardLastIO returns number of seconds since the last interaction with
+// master (for replicas) or the longest time since the last ACK from replicas
+// (for masters)
+func getDashboardLastIO(info *REDIS.Info) int64 {
+	switch info.Get("replication", "role") {
+	case "slave", "replica":
+		if info.Get("replication", "master_link_status") != "up" {
+			return -1
+		}
+
+		return int64(info.GetI("replication", "master_last_io_seconds_ago"))
+
+	case "master":
+		var lastIO int64 = -1
+
+		for i := 0; i < info.GetI("replication", "connected_slaves"); i++ {
+			replicaInfo := info.GetReplicaInfo(i)
+
+			if replicaInfo != nil {
+				lastIO = mathutil.Max(lastIO, replicaInfo.Lag)
+			}
+		}
+
+		return lastIO
+	}
+
+	return -1
+}
+
+// showDashboardInstanceInfo prints info about instance
+func showDashboardInstanceInfo(id int) {
+	t := table.NewTable().SetSizes(33, 96)
+	state, _ := CORE.GetInstanceState(id, true)
+
+	if !state.IsWorks() {
+		if showInstanceBasicInfo(t, id, nil, state) {
+			t.Border()
+		}
+
+		return
+	}
+
+	info, err := CORE.GetInstanceInfo(id, 3*time.Second, true)
+
+	if err != nil {
+		terminal.Error(err)
+		return
+	}
+
+	showInstanceBasicInfo(t, id, info, state)
+	renderInfoData(t, info, []string{"clients", "memory", "persistence", "stats", "replication", "cpu", "keyspace"})
+	t.Border()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readDashboardKeys reads pressed keys from stdin
+func readDashboardKeys(keys chan string) {
+	buf := make([]byte, 32)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+
+		if err != nil {
+			keys <- "q"
+			return
+		}
+
+		keys <- string(buf[:n])
+	}
+}
+
+// enableDashboardTerminalMode disables canonical mode and echo for terminal
+// and returns original terminal mode
+func enableDashboardTerminalMode() (*unix.Termios, error) {
+	fd := int(os.Stdin.Fd())
+	origMode, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mode := *origMode
+	mode.Lflag &^= unix.ICANON | unix.ECHO
+	mode.Cc[unix.VMIN] = 1
+	mode.Cc[unix.VTIME] = 0
+
+	err = unix.IoctlSetTermios(fd, unix.TCSETS, &mode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return origMode, nil
+}
+
+// restoreDashboardTerminalMode restores original terminal mode
+func restoreDashboardTerminalMode(mode *unix.Termios) {
+	unix.IoctlSetTermios(int(os.Stdin.Fd()), unix.TCSETS, mode)
+}
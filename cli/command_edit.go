@@ -9,8 +9,10 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/terminal/input"
 
@@ -37,47 +39,53 @@ func EditCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
-	state, err := CORE.GetInstanceState(id, true)
+	var spec *instanceSpec
+	var info *instanceBasicInfo
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
-	}
+	if isNonInteractiveInput() || options.Has(OPT_TAGS) || options.GetB(OPT_DISABLE_SAVES) || hasLimitsOptions() {
+		spec, err = readInstanceSpec()
 
-	err = showInstanceBasicInfoCard(id, state)
+		if err == nil {
+			err = spec.Validate(false)
+		}
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
-	}
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
 
-	ok, err := input.ReadAnswer(
-		"Do you want to modify meta for this instance?", "Y",
-	)
+		info = &instanceBasicInfo{
+			Owner:            spec.Owner,
+			Desc:             spec.Desc,
+			InstancePassword: spec.Password,
+			ReplicationType:  spec.ReplicationType,
+		}
+	} else {
+		info, err = readInteractiveEditInfo(id)
 
-	if err != nil || !ok {
-		return EC_CANCEL
-	}
+		if err != nil {
+			if err == input.ErrKillSignal {
+				return EC_OK
+			}
 
-	meta, err := CORE.GetInstanceMeta(id)
+			terminal.Error(err)
+			return EC_ERROR
+		}
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
+		if info == nil {
+			return EC_CANCEL
+		}
 	}
 
-	info, err := readEditInfo(true, true, true, true)
+	meta, err := CORE.GetInstanceMeta(id)
 
 	if err != nil {
-		if err == input.ErrKillSignal {
-			return EC_OK
-		}
-
 		terminal.Error(err)
 		return EC_ERROR
 	}
 
-	var changes []string
+	var changes, limitsChanges []string
+	var isConfigChanged bool
 
 	// It's safe to modify this metadata, because GetInstanceMeta returns
 	// copy of metadata
@@ -114,6 +122,37 @@ func EditCommand(args CommandArgs) int {
 		meta.Preferencies.ReplicationType = CORE.ReplicationType(info.ReplicationType)
 	}
 
+	if spec != nil {
+		if spec.Tags != nil {
+			changes = append(changes, fmt.Sprintf(
+				"tags changed %q → %q",
+				strings.Join(meta.Tags, ","), strings.Join(spec.Tags, ","),
+			))
+			meta.Tags = spec.Tags
+		}
+
+		if spec.DisableSaves != nil && *spec.DisableSaves != meta.Preferencies.IsSaveDisabled {
+			changes = append(changes, fmt.Sprintf(
+				"saves disabled flag changed %t → %t",
+				meta.Preferencies.IsSaveDisabled, *spec.DisableSaves,
+			))
+			meta.Preferencies.IsSaveDisabled = *spec.DisableSaves
+			isConfigChanged = true
+		}
+
+		if spec.TemplateProfile != "" && spec.TemplateProfile != meta.Preferencies.TemplateProfile {
+			changes = append(changes, fmt.Sprintf(
+				"template profile changed %q → %q",
+				meta.Preferencies.TemplateProfile, spec.TemplateProfile,
+			))
+			meta.Preferencies.TemplateProfile = spec.TemplateProfile
+			isConfigChanged = true
+		}
+
+		limitsChanges = spec.setLimits(meta.Preferencies)
+		changes = append(changes, limitsChanges...)
+	}
+
 	err = CORE.UpdateInstance(meta)
 
 	if err != nil {
@@ -125,7 +164,46 @@ func EditCommand(args CommandArgs) int {
 		logger.Info(id, "Instance meta updated: %s", c)
 	}
 
-	fmtc.Printf("{g}Done. Data for instance with ID %d successfully updated.{!}\n", id)
+	if isConfigChanged {
+		err = CORE.RegenerateInstanceConfig(id)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		logger.Info(id, "Configuration regenerated")
+	}
+
+	if len(limitsChanges) != 0 {
+		err = CORE.UpdateInstanceLimits(id)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+	}
+
+	format := options.GetS(OPT_FORMAT)
+
+	if format == "" {
+		fmtc.Printf("{g}Done. Data for instance with ID %d successfully updated.{!}\n", id)
+
+		if isConfigChanged {
+			fmtc.Printf("{s}Configuration was regenerated, use {*}reload{!*} command to apply changes.{!}\n")
+		}
+	} else {
+		meta, err = CORE.GetInstanceMeta(id)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		result := newInstanceSpecResult(meta)
+		result.Changes = changes
+		renderInstanceSpecResult(result, format)
+	}
 
 	err = SC.PropagateCommand(API.COMMAND_EDIT, meta.ID, meta.UUID)
 
@@ -139,6 +217,32 @@ func EditCommand(args CommandArgs) int {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// readInteractiveEditInfo shows instance info and reads new instance properties.
+// Returns nil if user cancels editing.
+func readInteractiveEditInfo(id int) (*instanceBasicInfo, error) {
+	state, err := CORE.GetInstanceState(id, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = showInstanceBasicInfoCard(id, state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := input.ReadAnswer(
+		"Do you want to modify meta for this instance?", "Y",
+	)
+
+	if err != nil || !ok {
+		return nil, nil
+	}
+
+	return readEditInfo(true, true, true, true)
+}
+
 // Read user input for edit command
 func readEditInfo(readDesc, readPass, readOwner, readReplType bool) (*instanceBasicInfo, error) {
 	var err error
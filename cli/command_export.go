@@ -0,0 +1,249 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/spinner"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	CORE "github.com/essentialkaos/rds/core"
+	EXPORT "github.com/essentialkaos/rds/redis/export"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EXPORT_TIMEOUT is timeout for export and import commands
+const EXPORT_TIMEOUT = 30 * time.Second
+
+// EXPORT_PERMS is permissions for export files
+const EXPORT_PERMS = 0600
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ExportCommand is "export" command handler
+func ExportCommand(args CommandArgs) int {
+	err := args.Check(true)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	id, db, err := CORE.ParseIDDBPair(args.Get(0))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if !args.Has(1) {
+		terminal.Error("You must define path to output file")
+		return EC_ERROR
+	}
+
+	format := EXPORT.FORMAT_DUMP
+
+	switch options.GetS(OPT_FORMAT) {
+	case "":
+		// use default format
+	case FORMAT_JSON:
+		format = EXPORT.FORMAT_JSON
+	default:
+		terminal.Error("Format %s is not supported by export", options.GetS(OPT_FORMAT))
+		return EC_ERROR
+	}
+
+	file := args.Get(1)
+
+	if fsutil.IsExist(file) {
+		ok, err := input.ReadAnswer("File "+file+" already exists. Overwrite it?", "N")
+
+		if err != nil || !ok {
+			return EC_OK
+		}
+	}
+
+	rc, err := CORE.ConnectToInstance(id, db, EXPORT_TIMEOUT)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	defer rc.Close()
+
+	fd, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, EXPORT_PERMS)
+
+	if err != nil {
+		terminal.Error("Can't create output file: %v", err)
+		return EC_ERROR
+	}
+
+	spinner.Show("Exporting keys from DB %d", db)
+
+	stats, err := EXPORT.Export(
+		rc, fd, EXPORT.Options{Match: options.GetS(OPT_MATCH), Format: format},
+		exportProgressHandler("Exporting keys from DB %d", db),
+	)
+
+	if err == nil {
+		err = fd.Close()
+	} else {
+		fd.Close()
+	}
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		os.Remove(file)
+		fmtc.NewLine()
+		terminal.Error("Can't export data: %v", err)
+		return EC_ERROR
+	}
+
+	printExportStats(stats, "exported")
+
+	logger.Info(
+		id, "Exported %d keys from DB %d to %s (format: %s)",
+		stats.Keys, db, file, format,
+	)
+
+	return EC_OK
+}
+
+// ImportCommand is "import" command handler
+func ImportCommand(args CommandArgs) int {
+	err := args.Check(true)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	id, db, err := CORE.ParseIDDBPair(args.Get(0))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if !args.Has(1) {
+		terminal.Error("You must define path to file with exported data")
+		return EC_ERROR
+	}
+
+	file := args.Get(1)
+	err = fsutil.ValidatePerms("FRS", file)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if options.GetB(OPT_REPLACE) {
+		ok, err := input.ReadAnswer("Existing keys will be replaced by imported data. Continue?", "N")
+
+		if err != nil || !ok {
+			return EC_OK
+		}
+	}
+
+	rc, err := CORE.ConnectToInstance(id, db, EXPORT_TIMEOUT)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	defer rc.Close()
+
+	fd, err := os.Open(file)
+
+	if err != nil {
+		terminal.Error("Can't open file: %v", err)
+		return EC_ERROR
+	}
+
+	defer fd.Close()
+
+	spinner.Show("Importing keys to DB %d", db)
+
+	stats, err := EXPORT.Import(
+		rc, fd, EXPORT.Options{Match: options.GetS(OPT_MATCH), Replace: options.GetB(OPT_REPLACE)},
+		exportProgressHandler("Importing keys to DB %d", db),
+	)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error("Can't import data: %v", err)
+
+		if stats.Keys != 0 {
+			terminal.Warn("%s keys were imported before error", fmtutil.PrettyNum(stats.Keys))
+		}
+
+		if strings.Contains(err.Error(), "payload version") {
+			terminal.Warn("Data was exported from newer version of Redis. Use JSON format for export to move data to older versions.")
+		}
+
+		logger.Error(id, "Tried to import data from %s to DB %d, but got error: %v", file, db, err)
+
+		return EC_ERROR
+	}
+
+	printExportStats(stats, "imported")
+
+	logger.Info(
+		id, "Imported %d keys from %s to DB %d (skipped: %d)",
+		stats.Keys, file, db, stats.Skipped,
+	)
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// exportProgressHandler returns handler for export and import progress
+func exportProgressHandler(message string, db int) EXPORT.ProgressHandler {
+	return func(stats EXPORT.Stats) {
+		spinner.Update(
+			message+" {s}(%s keys | %s){!}", db,
+			fmtutil.PrettyNum(stats.Keys), fmtutil.PrettySize(stats.Size),
+		)
+	}
+}
+
+// printExportStats prints export or import statistics
+func printExportStats(stats EXPORT.Stats, action string) {
+	fmtc.NewLine()
+
+	if stats.Skipped == 0 {
+		fmtc.Printf(
+			"{g}%s keys %s {s-}(%s){!}\n",
+			fmtutil.PrettyNum(stats.Keys), action,
+			fmtutil.PrettySize(stats.Size),
+		)
+	} else {
+		fmtc.Printf(
+			"{g}%s keys %s{!}, {y}%s skipped {s-}(%s){!}\n",
+			fmtutil.PrettyNum(stats.Keys), action,
+			fmtutil.PrettyNum(stats.Skipped),
+			fmtutil.PrettySize(stats.Size),
+		)
+	}
+}
@@ -39,6 +39,7 @@ type helpInfo struct {
 	desc      string
 	arguments []helpInfoArgument
 	options   []helpInfoArgument
+	fields    []helpInfoArgument
 	examples  []helpInfoExample
 }
 
@@ -48,10 +49,14 @@ type helpInfo struct {
 func HelpCommand(args CommandArgs) int {
 	commandName := args.Get(0)
 	commandList := map[string]func(){
+		COMMAND_ADOPT:                helpCommandAdopt,
+		COMMAND_APPLY:                helpCommandApply,
 		COMMAND_BACKUP_CREATE:        helpCommandBackupCreate,
 		COMMAND_BACKUP_RESTORE:       helpCommandBackupRestore,
 		COMMAND_BACKUP_CLEAN:         helpCommandBackupClean,
 		COMMAND_BACKUP_LIST:          helpCommandBackupList,
+		COMMAND_BACKUP_VERIFY:        helpCommandBackupVerify,
+		COMMAND_BACKUP_ROTATE_KEY:    helpCommandBackupRotateKey,
 		COMMAND_BATCH_CREATE:         helpCommandBatchCreate,
 		COMMAND_BATCH_EDIT:           helpCommandBatchEdit,
 		COMMAND_CHECK:                helpCommandCheck,
@@ -60,11 +65,14 @@ func HelpCommand(args CommandArgs) int {
 		COMMAND_CONF:                 helpCommandConf,
 		COMMAND_CPU:                  helpCommandCPU,
 		COMMAND_CREATE:               helpCommandCreate,
+		COMMAND_DASHBOARD:            helpCommandDashboard,
 		COMMAND_DELETE:               helpCommandDestroy,
 		COMMAND_DESTROY:              helpCommandDestroy,
 		COMMAND_EDIT:                 helpCommandEdit,
+		COMMAND_EXPORT:               helpCommandExport,
 		COMMAND_GEN_TOKEN:            helpCommandGenToken,
 		COMMAND_GO:                   helpCommandGo,
+		COMMAND_IMPORT:               helpCommandImport,
 		COMMAND_INFO:                 helpCommandInfo,
 		COMMAND_INIT:                 helpCommandCreate,
 		COMMAND_KILL:                 helpCommandKill,
@@ -72,6 +80,7 @@ func HelpCommand(args CommandArgs) int {
 		COMMAND_LOG:                  helpCommandLog,
 		COMMAND_MAINTENANCE:          helpCommandMaintenance,
 		COMMAND_MEMORY:               helpCommandMemory,
+		COMMAND_MIGRATE:              helpCommandMigrate,
 		COMMAND_REGEN:                helpCommandRegen,
 		COMMAND_RELEASE:              helpCommandDestroy,
 		COMMAND_RELOAD:               helpCommandReload,
@@ -103,6 +112,7 @@ func HelpCommand(args CommandArgs) int {
 		COMMAND_STATS_COMMAND:        helpCommandStatsCommand,
 		COMMAND_STATS_LATENCY:        helpCommandStatsLatency,
 		COMMAND_STATS_ERROR:          helpCommandStatsError,
+		COMMAND_STATS_HISTORY:        helpCommandStatsHistory,
 		COMMAND_STATUS:               helpCommandStatus,
 		COMMAND_STOP:                 helpCommandStop,
 		COMMAND_STOP_ALL:             helpCommandStopAll,
@@ -114,8 +124,11 @@ func HelpCommand(args CommandArgs) int {
 		COMMAND_TOP_DIFF:             helpCommandTopDiff,
 		COMMAND_TOP_DUMP:             helpCommandTopDump,
 		COMMAND_TRACK:                helpCommandTrack,
+		COMMAND_TRACK_REPLAY:         helpCommandTrackReplay,
 		COMMAND_VALIDATE_TEMPLATES:   helpCommandValidateTemplates,
 		COMMAND_UPTIME:               helpCommandUptime,
+		HELP_TOPIC_QUERY:             helpTopicQuery,
+		HELP_TOPIC_SELECTORS:         helpTopicSelectors,
 	}
 
 	helpFunc, hasInfo := commandList[commandName]
@@ -138,20 +151,114 @@ func HelpCommand(args CommandArgs) int {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// helpTopicSelectors prints info about instance selectors
+func helpTopicSelectors() {
+	fmtc.Println("{*}Instance selectors{!}\n")
+	fmtc.Println("  Commands {y}start{!}, {y}stop{!}, {y}restart{!}, {y}kill{!}, {y}reload{!}, {y}regen{!} and {y}backup-create{!} accept")
+	fmtc.Println("  instance selector instead of instance ID. Selector is a comma-separated list of terms,")
+	fmtc.Println("  instance is selected if it fits at least one of them. Before execution, command shows")
+	fmtc.Println("  the list of selected instances and asks for confirmation. After execution, command shows")
+	fmtc.Println("  the table with result for every instance.\n")
+
+	fmtc.Println("  Supported terms:\n")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "{id}", "Instance with given ID")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "{id}-{id}", "Instances with ID in given range")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "tag:{tag}", "Instances tagged by given tag")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "owner:{user}", "Instances owned by given user")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "state:{state}", "Instances in given state")
+	fmtc.Printf("    {b}%-16s{!} %s\n", "outdated", "Instances which require restart for update")
+	fmtc.NewLine()
+
+	fmtc.Printf("  Supported states: {s}%s{!}\n\n", strings.Join(selectorStates, ", "))
+
+	fmtc.Println("  Also, selector can be an instances query {s-}(see \"help query\"){!}. Query must be passed")
+	fmtc.Println("  as a single argument.\n")
+
+	helpInfo{
+		examples: []helpInfoExample{
+			{COMMAND_RESTART, "10-20,35", "Restart instances with ID from 10 to 20 and instance with ID 35"},
+			{COMMAND_BACKUP_CREATE, "tag:cache", "Create snapshots of all instances with tag cache"},
+			{COMMAND_START, "state:dead", "Start all dead instances"},
+			{COMMAND_RESTART, `"owner=bob AND mem>2GB"`, "Restart all instances owned by bob which use more than 2GB of memory"},
+		},
+	}.renderExamples()
+}
+
+// helpTopicQuery prints info about instances query language
+func helpTopicQuery() {
+	fmtc.Println("{*}Instances query{!}\n")
+	fmtc.Println("  Commands {y}list{!} and all commands which support instance selectors {s-}(see \"help selectors\"){!}")
+	fmtc.Println("  accept instances query. Query is a list of field predicates and filters {s-}(see \"help list\"){!}")
+	fmtc.Println("  joined by {b}AND{!}, {b}OR{!} and {b}NOT{!} operators. Terms without operator between them are joined")
+	fmtc.Println("  by {b}AND{!}. Parentheses can be used for grouping. Values with spaces must be quoted")
+	fmtc.Println("  {s-}(desc~\"my cache\"){!}. Don't forget to quote query in the shell, because {b}<{!} and {b}>{!} are")
+	fmtc.Println("  redirection operators.\n")
+
+	fmtc.Println("  Supported fields:\n")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "id", "= != > < ≥", "Instance ID")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "owner", "= != ~", "Instance owner")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "tag", "= !=", "Instance tag")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "desc", "= != ~", "Instance description")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "state", "= !=", "Instance state {s-}(see \"help selectors\"){!}")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "repl", "= !=", "Replication type (replica/standby)")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "mem", "= != > < ≥", "Memory usage (RSS) {s-}(2GB, 512MB…){!}")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "ops", "= != > < ≥", "Number of operations per second")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "input", "= != > < ≥", "Input traffic per second {s-}(1MB, 512KB…){!}")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "output", "= != > < ≥", "Output traffic per second {s-}(1MB, 512KB…){!}")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "clients", "= != > < ≥", "Number of connected clients")
+	fmtc.Printf("    {b}%-10s{!} %-12s %s\n", "created", "> < ≥", "Instance age {s-}(30d, 2w, 12h…){!}")
+	fmtc.NewLine()
+
+	fmtc.Println("  Operator {b}~{!} checks if value contains given substring (case-insensitive). Operators {b}>={!}")
+	fmtc.Println("  and {b}<={!} are also supported. Instances which don't work never fit predicates for mem, ops,")
+	fmtc.Println("  input, output and clients fields.\n")
+
+	helpInfo{
+		examples: []helpInfoExample{
+			{COMMAND_LIST, `"owner=bob AND tag=cache"`, `Show list of instances with tag "cache" owned by user bob`},
+			{COMMAND_LIST, `"mem>2GB OR ops>1000"`, "Show list of instances which use more than 2GB of memory or process more than 1000 op/s"},
+			{COMMAND_LIST, `"state=syncing NOT repl=standby"`, "Show list of syncing instances with real replicas"},
+			{COMMAND_LIST, `"created<30d" --sort ^mem`, "Show list of instances created less than 30 days ago sorted by memory usage"},
+			{COMMAND_STOP, `"(tag=test OR tag=staging) AND created>90d"`, "Stop all test and staging instances created more than 90 days ago"},
+		},
+	}.renderExamples()
+}
+
 // helpCommandCreate prints info about "create" command usage
 func helpCommandCreate() {
 	helpInfo{
 		command: COMMAND_CREATE,
-		desc:    "Command read user input and create a new instance.",
+		desc:    "Command read user input and create a new instance. New instance can be seeded with data from RDB file or by replication from external Redis server. In the last case, instance will be detached from the source (REPLICAOF NO ONE) after initial sync. If any of instance properties is defined using options or stdin (JSON object with fields desc, owner, password, service_password, replication_type, template_profile, tags, disable_saves, secure, memory_max, cpu_max and io_weight), command works in non-interactive mode. Resource limits override global limits from the configuration file, zero value resets limit to the global value.",
+		arguments: []helpInfoArgument{
+			{"auth", "Replication source password or user and password (user:password)", true},
+		},
 		options: []helpInfoArgument{
 			{getNiceOptions(OPT_TAGS), "List of tags", false},
 			{getNiceOptions(OPT_SECURE), "Create instance with service ACL", false},
 			{getNiceOptions(OPT_DISABLE_SAVES), "Disable saving for created instance", false},
+			{getNiceOptions(OPT_FROM_RDB), "Path to RDB file or snapshot archive with data", false},
+			{getNiceOptions(OPT_REPLICATE_FROM), "Address of external Redis server with data (host:port)", false},
+			{getNiceOptions(OPT_DESC), "Instance description", false},
+			{getNiceOptions(OPT_OWNER), "Instance owner", false},
+			{getNiceOptions(OPT_PASSWORD), "Instance password (generated if not set)", false},
+			{getNiceOptions(OPT_SERVICE_PASS), "Service password", false},
+			{getNiceOptions(OPT_REPLICATION), "Replication type (replica/standby)", false},
+			{getNiceOptions(OPT_PROFILE), "Template profile", false},
+			{getNiceOptions(OPT_MEMORY_MAX), "Memory limit (e.g. 4GB)", false},
+			{getNiceOptions(OPT_CPU_MAX), "CPU limit in percents of one CPU core (e.g. 200 for two cores)", false},
+			{getNiceOptions(OPT_IO_WEIGHT), "IO weight (1-10000)", false},
+			{getNiceOptions(OPT_STDIN), "Read instance properties in JSON format from stdin", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (text/json/xml/csv)", false},
 		},
 		examples: []helpInfoExample{
 			{"", "", "Create new instance"},
+			{"", "--memory-max 4GB --cpu-max 200", "Create new instance with resource limits"},
 			{"", "--disable-saves", "Create new instance with saves disabled"},
 			{"", "--tags r:important,myapp", "Create new instance with tags"},
+			{"", "--from-rdb /tmp/dump.rdb", "Create new instance with data from RDB file"},
+			{"", "--replicate-from 192.168.1.20:6379 MySuppaPassword", "Create new instance with data from external server"},
+			{"", "--desc 'Cache for MyApp' --owner bob --format json", "Create new instance without prompts"},
+			{"", "--stdin --format json < instance.json", "Create new instance with properties from JSON"},
 		},
 	}.render()
 }
@@ -174,12 +281,29 @@ func helpCommandDestroy() {
 func helpCommandEdit() {
 	helpInfo{
 		command: COMMAND_EDIT,
-		desc:    "This command allows you to change some information about the instance. At the moment you can change the owner, description and password.",
+		desc:    "This command allows you to change some information about the instance. At the moment you can change the owner, description, password and replication type. Using options or stdin (JSON object with the same fields as for create command) you can also change tags, template profile, save mode and resource limits without any prompts.",
 		arguments: []helpInfoArgument{
 			{"id", "Instance unique ID", false},
 		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_DESC), "Instance description", false},
+			{getNiceOptions(OPT_OWNER), "Instance owner", false},
+			{getNiceOptions(OPT_PASSWORD), "Instance password", false},
+			{getNiceOptions(OPT_REPLICATION), "Replication type (replica/standby)", false},
+			{getNiceOptions(OPT_PROFILE), "Template profile", false},
+			{getNiceOptions(OPT_TAGS), "List of tags (replaces current tags)", false},
+			{getNiceOptions(OPT_DISABLE_SAVES), "Disable saving", false},
+			{getNiceOptions(OPT_MEMORY_MAX), "Memory limit (0 for global limit)", false},
+			{getNiceOptions(OPT_CPU_MAX), "CPU limit in percents of one CPU core (0 for global limit)", false},
+			{getNiceOptions(OPT_IO_WEIGHT), "IO weight (0 for global limit)", false},
+			{getNiceOptions(OPT_STDIN), "Read instance properties in JSON format from stdin", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (text/json/xml/csv)", false},
+		},
 		examples: []helpInfoExample{
 			{"", "1", "Edit metadata for instance with ID 1"},
+			{"", "1 --owner john --format json", "Change owner of instance with ID 1 without prompts"},
+			{"", "1 --stdin < instance.json", "Change instance properties using data from JSON"},
+			{"", "1 --memory-max 8GB", "Change memory limit of instance with ID 1"},
 		},
 	}.render()
 }
@@ -189,10 +313,17 @@ func helpCommandBatchCreate() {
 	info := helpInfo{
 		command: COMMAND_BATCH_CREATE,
 		arguments: []helpInfoArgument{
-			{"csv-file", "CSV file with instances data", false},
+			{"file", "CSV, JSON or YAML file with instances data", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_DISABLE_SAVES), "Disable saving for instances without disable_saves field", false},
+			{getNiceOptions(OPT_FORMAT), "Output format for summary (text/json)", false},
+			{getNiceOptions(OPT_PRIVATE), "Show passwords in instances list", false},
+			{getNiceOptions(OPT_YES), "Create instances without confirmation", false},
 		},
 		examples: []helpInfoExample{
 			{"", "instances.csv", "Create instances with data from instances.csv"},
+			{"", "instances.yml --yes --format json", "Create instances with data from instances.yml and print summary in JSON"},
 		},
 	}
 
@@ -209,6 +340,23 @@ func helpCommandBatchCreate() {
   {s}┃ bob;test1234!;replica;;Instance for Bob{!}
   {s}┃ bob;test1234!;replica;redisAuth1234;Instance for Bob with auth{!}
   {s}┃{!}
+
+  JSON ({m}.json{!}) and YAML ({m}.yml{!}/{m}.yaml{!}) files must contain a list of objects with fields
+  {m}desc{!}, {m}owner{!}, {m}password{!}, {m}service_password{!}, {m}replication_type{!}, {m}template_profile{!},
+  {m}tags{!}, {m}disable_saves{!} and {m}secure{!}. Only {m}desc{!} is required, passwords are generated if not set.
+
+  {*s@} example.yml {!}
+  {s}┃{!}
+  {s}┃ - desc: Instance for John{!}
+  {s}┃   owner: john{!}
+  {s}┃   tags: [cache]{!}
+  {s}┃ - desc: Instance for Bob{!}
+  {s}┃   owner: bob{!}
+  {s}┃   disable_saves: true{!}
+  {s}┃   template_profile: small{!}
+  {s}┃{!}
+
+  All records are validated before creation, if any record contains errors no instances will be created.
 `)
 
 	info.renderArguments()
@@ -236,11 +384,12 @@ func helpCommandStart() {
 		command: COMMAND_START,
 		desc:    "Start (run) instance.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{COMMAND_START, "1", "Start instance with ID 1 (only on master)"},
 			{COMMAND_START_PROP, "1", "Start instance with ID 1 (on master and all minions)"},
+			{COMMAND_START, "tag:cache,20-25", "Start all instances with tag cache and instances with ID from 20 to 25"},
 		},
 	}.render()
 }
@@ -251,13 +400,14 @@ func helpCommandStop() {
 		command: COMMAND_STOP,
 		desc:    "Stop (shutdown) instance.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 			{"force", fmtc.Sprintf("Kill instance if it not stop after %d seconds", CORE.Config.GetI(CORE.DELAY_STOP)), true},
 		},
 		examples: []helpInfoExample{
 			{COMMAND_STOP, "1", "Stop instance with ID 1 (only on master)"},
 			{COMMAND_STOP, "1 force", "Force stop instance with ID 1 (only on master)"},
 			{COMMAND_STOP_PROP, "1", "Stop instance with ID 1 (on master and all minions)"},
+			{COMMAND_STOP, "owner:bob", "Stop all instances owned by user bob"},
 		},
 	}.render()
 }
@@ -268,10 +418,11 @@ func helpCommandKill() {
 		command: COMMAND_KILL,
 		desc:    "Kill (force shutdown) instance.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{"", "1", "Kill instance with ID 1"},
+			{"", "state:hang", "Kill all hang instances"},
 		},
 	}.render()
 }
@@ -282,11 +433,12 @@ func helpCommandRestart() {
 		command: COMMAND_RESTART,
 		desc:    "Restart (stop + start) instance.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{COMMAND_RESTART, "1", "Restart instance with ID 1 (only on master)"},
 			{COMMAND_RESTART_PROP, "1", "Restart instance with ID 1 (on master and all minions)"},
+			{COMMAND_RESTART, "outdated", "Restart all instances which require restart for update"},
 		},
 	}.render()
 }
@@ -316,6 +468,8 @@ func helpCommandCli() {
 		},
 		options: []helpInfoArgument{
 			{getNiceOptions(OPT_PRIVATE), "Enable \"private\" features (auto authentication & renamed commands support)", false},
+			{getNiceOptions(OPT_TLS), "Connect to instance TLS port", false},
+			{getNiceOptions(OPT_SOCKET), "Connect to instance unix socket (always used in socket-only mode)", false},
 		},
 		examples: []helpInfoExample{
 			{"", "1", "Start interactive shell for instance with ID 1"},
@@ -336,8 +490,24 @@ func helpCommandClients() {
 			{"filter", "Clients filter", true},
 		},
 		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
+		fields: []helpInfoArgument{
+			{"id", "Client ID", false},
+			{"name", "Client name", false},
+			{"user", "Authenticated user", false},
+			{"addr", "Client address and port", false},
+			{"fd", "File descriptor", false},
+			{"db", "Current database ID", false},
+			{"flags", "Client flags", false},
+			{"sub", "Number of channel subscriptions", false},
+			{"psub", "Number of pattern subscriptions", false},
+			{"events", "File descriptor events", false},
+			{"age", "Connection age in seconds", false},
+			{"idle", "Idle time in seconds", false},
+			{"cmd", "Last command", false},
+		},
 		examples: []helpInfoExample{
 			{"", "1", "Show all clients connected to instance with ID 1"},
 			{"", "1 test1", `Show all clients with name "test1" connected to instance with ID 1`},
@@ -356,6 +526,18 @@ func helpCommandCPU() {
 			{"id", "Instance unique ID", false},
 			{"period", "Period for calculation in seconds (1-3600)", true},
 		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
+		},
+		fields: []helpInfoArgument{
+			{"period", "Period for calculation in seconds", false},
+			{"sys", "System CPU usage in percent", false},
+			{"user", "User CPU usage in percent", false},
+			{"sys_children", "System CPU usage by child processes in percent", false},
+			{"user_children", "User CPU usage by child processes in percent", false},
+			{"limit", "CPU limit in percent", false},
+			{"throttled", "Throttled time in percent", false},
+		},
 		examples: []helpInfoExample{
 			{"", "1", "Calculate instance CPU for default 3 second period"},
 			{"", "1 60", "Calculate instance CPU for 1 minute period"},
@@ -373,7 +555,7 @@ func helpCommandInfo() {
 			{"section…", "Info section", true},
 		},
 		options: []helpInfoArgument{
-			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml)", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 		},
 		examples: []helpInfoExample{
 			{"", "1", "Show basic info about instance with ID 1"},
@@ -395,10 +577,83 @@ func helpCommandTrack() {
 		examples: []helpInfoExample{
 			{"", "1", "Show interactive info about instance 1"},
 			{"", "1 10", "Show interactive info about instance 1 and update info every 10 seconds"},
+			{"", "1 5 --record /tmp/track-1.log", "Show interactive info about instance 1 and record metrics to file"},
+			{"", "1 --record track-1-%Y%m%d-%H%M.log", "Record metrics to file with current date and time as part of the name"},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_RECORD), "Record metrics to file", false},
+		},
+	}.render()
+}
+
+// helpCommandTrackReplay prints info about "track-replay" command usage
+func helpCommandTrackReplay() {
+	helpInfo{
+		command: COMMAND_TRACK_REPLAY,
+		desc:    "Show metrics (CPU usage, clients, operations, memory, I/O) recorded by \"track\" command.",
+		arguments: []helpInfoArgument{
+			{"file", "Path to file with recorded metrics", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (text/json/xml/csv)", false},
+			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
+		},
+		fields: []helpInfoArgument{
+			{"timestamp", "Sample date as Unix timestamp", false},
+			{"state", "Instance state", false},
+			{"cpu", "CPU usage in percents", false},
+			{"clients", "Number of connected clients", false},
+			{"ops", "Number of operations per second", false},
+			{"mem", "Used memory in bytes", false},
+			{"mem_rss", "Used memory (RSS) in bytes", false},
+			{"mem_lua", "Memory used by Lua engine in bytes", false},
+			{"swap", "Swapped memory in bytes", false},
+			{"input", "Incoming traffic in bytes per second", false},
+			{"output", "Outgoing traffic in bytes per second", false},
+		},
+		examples: []helpInfoExample{
+			{"", "/tmp/track-1.log", "Show metrics recorded to file /tmp/track-1.log"},
+			{"", "/tmp/track-1.log --format csv", "Export recorded metrics in CSV format"},
 		},
 	}.render()
 }
 
+// helpCommandDashboard prints info about "dashboard" command usage
+func helpCommandDashboard() {
+	info := helpInfo{
+		command: COMMAND_DASHBOARD,
+		desc:    "Show interactive dashboard with state, memory usage, operations, clients, CPU usage and time since the last interaction with master or replicas of all instances. Info about instances is collected in background, so dashboard keeps responding to keys while instances are queried. State changes are highlighted.",
+		arguments: []helpInfoArgument{
+			{"interval", "Update interval in seconds (3 by default)", true},
+			{"query", "Instances query (see \"rds help query\")", true},
+		},
+		examples: []helpInfoExample{
+			{"", "", "Show dashboard with all instances"},
+			{"", "10", "Show dashboard and update info every 10 seconds"},
+			{"", "5 owner=john tag:cache", "Show dashboard with instances owned by john with tag \"cache\""},
+		},
+	}
+
+	info.renderUsage()
+	info.renderDescription()
+	info.renderArguments()
+
+	fmtc.Println("  Keys:\n")
+	fmtc.Println("    {y}↑/↓{!} or {y}k/j{!}    Select instance")
+	fmtc.Println("    {y}s{!}              Change sort column (id, state, mem, ops, clients, cpu, io)")
+	fmtc.Println("    {y}r{!}              Reverse sort order")
+	fmtc.Println("    {y}/{!} or {y}f{!}         Change filter (instances query)")
+	fmtc.Println("    {y}i{!} or {y}Enter{!}     Show info about selected instance")
+	fmtc.Println("    {y}l{!}              Show slowlog of selected instance")
+	fmtc.Println("    {y}c{!}              Show clients of selected instance")
+	fmtc.Println("    {y}+/-{!}            Increase/decrease update interval")
+	fmtc.Println("    {y}Space{!}          Update info")
+	fmtc.Println("    {y}q{!} or {y}Esc{!}       Quit")
+	fmtc.NewLine()
+
+	info.renderExamples()
+}
+
 // helpCommandTagAdd prints info about "tag-add" command usage
 func helpCommandTagAdd() {
 	info := helpInfo{
@@ -471,11 +726,12 @@ func helpCommandReload() {
 		command: COMMAND_RELOAD,
 		desc:    "Reload the configuration for one or all instances. Use this command if the configuration file has been updated. Use this command with care.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{"", "1", "Reload configuration for instance with ID 1"},
 			{"", "all", "Reload configuration for all instances"},
+			{"", "10-20", "Reload configuration for instances with ID from 10 to 20"},
 		},
 	}.render()
 }
@@ -484,20 +740,40 @@ func helpCommandReload() {
 func helpCommandList() {
 	info := helpInfo{
 		command: COMMAND_LIST,
-		desc:    "Show list of all Redis instances.",
+		desc:    "Show list of all Redis instances. Instances can be filtered using listing filters or instances query (see \"help query\").",
 		arguments: []helpInfoArgument{
-			{"filter…", "Listing filters", true},
+			{"query…", "Listing filters or instances query", true},
 		},
 		options: []helpInfoArgument{
 			{getNiceOptions(OPT_EXTRA), "Print extra info", false},
+			{getNiceOptions(OPT_SORT), "Sort instances by given column (use ^ prefix for reverse order)", false},
+			{getNiceOptions(OPT_COLUMNS), "Comma-separated list of columns to show", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
+		fields: []helpInfoArgument{
+			{"id", "Instance ID", false},
+			{"state", "Instance state (stopped/dead/hang/loading/saving/syncing/idle/active/unknown)", false},
+			{"memory", "Memory usage (RSS) in bytes", false},
+			{"ops", "Number of operations per second", false},
+			{"input", "Input traffic in bytes per second", false},
+			{"output", "Output traffic in bytes per second", false},
+			{"clients", "Number of connected clients", false},
+			{"owner", "Instance owner", false},
+			{"description", "Instance description", false},
+			{"tags", "List of tags", false},
+			{"replication_type", "Replication type (replica/standby)", false},
+			{"created", "Unix timestamp of instance creation", false},
+		},
 		examples: []helpInfoExample{
 			{"", "", "Show list of all instances"},
 			{"", "--extra", "Show list of all instances with extra info"},
 			{"", "my", "Show list of your instances"},
 			{"", "bob active", "Show list of active instances owned by user bob"},
 			{"", "bob active @staging", `Show list of active instances with tag "staging" owned by user bob`},
+			{"", "--format json my", "Show list of your instances in JSON format"},
+			{"", `"owner=bob AND (mem>2GB OR ops>1000)"`, "Show list of heavily loaded instances owned by user bob"},
+			{"", "--sort ^mem --columns id,state,mem,owner,desc", "Show list of all instances sorted by memory usage with given columns"},
 		},
 	}
 
@@ -534,7 +810,10 @@ func helpCommandList() {
 	fmtc.Println("  a full-text search of instance descriptions.")
 	fmtc.NewLine()
 
+	fmtc.Printf("  Available columns: {b}%s{!}\n\n", strings.Join(getListColumnNames(), ", "))
+
 	info.renderOptions()
+	info.renderFields()
 	info.renderExamples()
 }
 
@@ -542,7 +821,7 @@ func helpCommandList() {
 func helpCommandLog() {
 	info := helpInfo{
 		command: COMMAND_LOG,
-		desc:    "Show RDS CLI, RDS Sync Daemon or Redis instance logs.",
+		desc:    "Show RDS CLI, RDS Sync Daemon or Redis instance logs. If instances are managed by systemd, instance log is read from journald.",
 		arguments: []helpInfoArgument{
 			{"source", "Log source (cli/sync/instance-id)", false},
 		},
@@ -583,10 +862,36 @@ func helpCommandMemory() {
 			{"id", "Instance unique ID", false},
 		},
 		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
+		fields: []helpInfoArgument{
+			{"metric", "Metric name (as in MEMORY STATS command output)", false},
+			{"value", "Metric value", false},
+		},
 		examples: []helpInfoExample{
 			{"", "1", "Show memory usage of instance with ID 1"},
+			{"", "1 --format csv", "Show memory usage of instance with ID 1 in CSV format"},
+		},
+	}.render()
+}
+
+// helpCommandMigrate prints info about "migrate" command usage
+func helpCommandMigrate() {
+	helpInfo{
+		command: COMMAND_MIGRATE,
+		desc:    "Migrate instance to another RDS node without downtime. Instance on target node is created as a replica, and after initial sync roles are switched and source instance becomes a replica of new master. Migration API must be configured on both nodes (section \"migration\" in RDS configuration file).",
+		arguments: []helpInfoArgument{
+			{"id", "Instance unique ID", false},
+			{"node", "Target node host with optional migration API port (host:port)", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_DESTROY_SOURCE), "Destroy source instance after migration", false},
+			{getNiceOptions(OPT_YES), "Migrate instance without confirmation", false},
+		},
+		examples: []helpInfoExample{
+			{"", "1 192.168.1.12", "Migrate instance with ID 1 to node 192.168.1.12"},
+			{"", "1 rds2.domain.com:64100 --destroy-source", "Migrate instance and destroy it on this node"},
 		},
 	}.render()
 }
@@ -597,7 +902,7 @@ func helpCommandStats() {
 		command: COMMAND_STATS,
 		desc:    "Show overall statistics.",
 		options: []helpInfoArgument{
-			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml)", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 		},
 		examples: []helpInfoExample{
 			{"", "", "Show all available statistics info"},
@@ -647,6 +952,62 @@ func helpCommandStatsError() {
 	}.render()
 }
 
+// helpCommandStatsHistory prints info about "stats-history" command usage
+func helpCommandStatsHistory() {
+	info := helpInfo{
+		command: COMMAND_STATS_HISTORY,
+		desc:    "Show metrics history of instance or node collected by RDS Sync daemon. Resolution and retention of history can be configured in \"history\" section of configuration file.",
+		arguments: []helpInfoArgument{
+			{"id|node", "Instance unique ID or \"node\" for node metrics", false},
+			{"metric", "Metric name", false},
+			{"period", "Period of time (24h by default)", true},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (text/json/xml/csv)", false},
+		},
+		fields: []helpInfoArgument{
+			{"timestamp", "Sample date as Unix timestamp", false},
+			{"value", "Metric value", false},
+		},
+		examples: []helpInfoExample{
+			{"", "1 mem", "Show memory usage of instance with ID 1 for the last 24 hours"},
+			{"", "1 ops 3d", "Show operations per second of instance with ID 1 for the last 3 days"},
+			{"", "node swap 12h", "Show swap usage on node for the last 12 hours"},
+			{"", "1 cpu 1w --format csv", "Export CPU usage of instance with ID 1 for the last week in CSV format"},
+		},
+	}
+
+	info.renderUsage()
+	info.renderDescription()
+	info.renderArguments()
+	info.renderOptions()
+	info.renderFields()
+
+	fmtc.Println("{*}Instance metrics{!}\n")
+	fmtc.Println("  {c}mem{!}       Used memory")
+	fmtc.Println("  {c}rss{!}       Used memory (RSS)")
+	fmtc.Println("  {c}ops{!}       Operations per second")
+	fmtc.Println("  {c}clients{!}   Number of connected clients")
+	fmtc.Println("  {c}keys{!}      Number of keys")
+	fmtc.Println("  {c}cpu{!}       CPU usage")
+	fmtc.Println("  {c}input{!}     Incoming traffic per second")
+	fmtc.Println("  {c}output{!}    Outgoing traffic per second")
+	fmtc.NewLine()
+
+	fmtc.Println("{*}Node metrics{!}\n")
+	fmtc.Println("  {c}mem{!}         System memory usage")
+	fmtc.Println("  {c}swap{!}        System swap usage")
+	fmtc.Println("  {c}ops{!}         Operations per second (all instances)")
+	fmtc.Println("  {c}clients{!}     Number of connected clients (all instances)")
+	fmtc.Println("  {c}keys{!}        Number of keys (all instances)")
+	fmtc.Println("  {c}input{!}       Incoming traffic per second (all instances)")
+	fmtc.Println("  {c}output{!}      Outgoing traffic per second (all instances)")
+	fmtc.Println("  {c}instances{!}   Number of working instances")
+	fmtc.NewLine()
+
+	info.renderExamples()
+}
+
 // helpCommandTop prints info about "top" command usage
 func helpCommandTop() {
 	helpInfo{
@@ -657,8 +1018,16 @@ func helpCommandTop() {
 			{"num", "Number of results", true},
 		},
 		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
+		fields: []helpInfoArgument{
+			{"rank", "Position in top", false},
+			{"id", "Instance ID", false},
+			{"field", "Field name", false},
+			{"value", "Field value", false},
+			{"description", "Instance description", false},
+		},
 		examples: []helpInfoExample{
 			{"", "", "Show top 10 by memory usage"},
 			{"", "- 20", "Show top 20 by memory usage"},
@@ -673,7 +1042,7 @@ func helpCommandTop() {
 func helpCommandTopDump() {
 	helpInfo{
 		command: COMMAND_TOP_DUMP,
-		desc:    "Dump top data to file. The output file must have a .gz extension (all data saved as a gzipped JSON file) and must not exist before saving. Date control sequences can be used for the output name (see 'man date').",
+		desc:    "Dump top data to file. The output file must have a .gz extension (all data saved as a gzipped JSON file) and must not exist before saving. Date control sequences can be used for the output name (see 'man date'). Dump also contains the date of creation and the hostname of the node, so several dumps can be compared with 'top-diff' command.",
 		arguments: []helpInfoArgument{
 			{"file", "Output file", false},
 		},
@@ -688,19 +1057,23 @@ func helpCommandTopDump() {
 func helpCommandTopDiff() {
 	helpInfo{
 		command: COMMAND_TOP_DIFF,
-		desc:    "Show the difference between current and dumped top data.",
+		desc:    "Show the difference between top data from dumps. If only one dump is given, it will be compared with current data. If dumps were created on the same node, the change and change rate (per second and per hour) will be calculated for every instance. If dumps were created on two different nodes (e.g. master and minion), the latest dumps from each node will be compared with each other.",
 		arguments: []helpInfoArgument{
-			{"file", "Dump file", false},
+			{"file…", "Dump file or files", false},
 			{"field", "Field  name", true},
 			{"num", "Number of results", true},
 		},
 		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (text/json/xml/csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
 		examples: []helpInfoExample{
 			{"", "rds-top.gz", "Compare data and show top 10 by increased memory usage"},
 			{"", "rds-top.gz - 5", "Compare data and show top 5 by increased memory usage"},
 			{"", "rds-top.gz ^connected_clients 5", "Compare data and show top 5 by decreased number of connected clients"},
+			{"", "rds-top-*.gz keys", "Compare all dumps and show top 10 by keys growth rate"},
+			{"", "master-top.gz minion-top.gz", "Compare memory usage on master and minion"},
+			{"", "rds-top-*.gz - 50 --format csv", "Compare all dumps and show top 50 by memory growth rate in CSV format"},
 		},
 	}.render()
 }
@@ -734,8 +1107,16 @@ func helpCommandSlowlogGet() {
 			{"num", "Number of results", true},
 		},
 		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 			{getNiceOptions(OPT_PAGER), "Enable pager for long output", false},
 		},
+		fields: []helpInfoArgument{
+			{"id", "Entry ID", false},
+			{"timestamp", "Unix timestamp of command execution", false},
+			{"duration", "Execution time in microseconds", false},
+			{"client", "Client address and port", false},
+			{"command", "Command with arguments", false},
+		},
 		examples: []helpInfoExample{
 			{"", "1", "Show last 10 entries from instance 1 slow log"},
 			{"", "1 30", "Show last 30 entries from instance 1 slow log"},
@@ -850,11 +1231,12 @@ func helpCommandRegen() {
 		command: COMMAND_REGEN,
 		desc:    "Regenerate the configuration file for one or all instances. Use this command if the configuration template has been updated. Use this command with care.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{"", "1", "Regenerate configuration file for instance with ID 1"},
 			{"", "all", "Regenerate configuration files for all instances"},
+			{"", "1,3,7", "Regenerate configuration files for instances with ID 1, 3 and 7"},
 		},
 	}.render()
 }
@@ -865,7 +1247,7 @@ func helpCommandReplication() {
 		command: COMMAND_REPLICATION,
 		desc:    "Show information about RDS replication with other nodes in the cluster.",
 		options: []helpInfoArgument{
-			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml)", false},
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
 		},
 		examples: []helpInfoExample{
 			{"", "", "Show info about master, minions and sentinel nodes"},
@@ -1017,22 +1399,117 @@ func helpCommandUptime() {
 	helpInfo{
 		command: COMMAND_UPTIME,
 		desc:    "Show information about instances uptime and the last save date.",
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FORMAT), "Output format (json|text|xml|csv)", false},
+		},
+		fields: []helpInfoArgument{
+			{"id", "Instance ID", false},
+			{"state", "Instance state", false},
+			{"uptime", "Uptime in seconds", false},
+			{"last_save", "Unix timestamp of the last save", false},
+			{"description", "Instance description", false},
+		},
 		examples: []helpInfoExample{
 			{"", "", "Show uptime information"},
+			{"", "--format json", "Show uptime information in JSON format"},
+		},
+	}.render()
+}
+
+// helpCommandAdopt prints info about "adopt" command usage
+func helpCommandAdopt() {
+	helpInfo{
+		command: COMMAND_ADOPT,
+		desc:    "Move unmanaged Redis server under RDS control. Command creates new instance with generated ACL users, moves data files to instance data directory, generates configuration with all custom directives from original configuration and starts instance. Running server will be stopped with data saving (adoption is aborted before server is stopped if its data can't be loaded by installed version of Redis). If instance can't be created or started, data files will be moved back and server will be started with original configuration.",
+		arguments: []helpInfoArgument{
+			{"config", "Path to Redis configuration file", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_DRY_RUN), "Show adoption plan without making any changes", false},
+			{getNiceOptions(OPT_TAGS), "List of tags", false},
+			{getNiceOptions(OPT_YES), "Adopt server without confirmation", false},
+		},
+		examples: []helpInfoExample{
+			{"", "/etc/redis/redis.conf --dry-run", "Show adoption plan for server"},
+			{"", "/etc/redis/redis.conf --tags legacy", "Adopt server and add tag \"legacy\" to instance"},
 		},
 	}.render()
 }
 
+// helpCommandApply prints info about "apply" command usage
+func helpCommandApply() {
+	info := helpInfo{
+		command: COMMAND_APPLY,
+		arguments: []helpInfoArgument{
+			{"spec", "Path to YAML or JSON file with spec", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_DRY_RUN), "Show plan without making any changes", false},
+			{getNiceOptions(OPT_PRUNE), "Allow destroying all named instances if spec doesn't contain instances", false},
+			{getNiceOptions(OPT_YES), "Apply changes without confirmation", false},
+		},
+		examples: []helpInfoExample{
+			{"", "node1.yml --dry-run", "Show changes required to converge instances state"},
+			{"", "node1.yml", "Converge instances state to the spec from node1.yml"},
+		},
+	}
+
+	info.renderUsage()
+
+	fmtc.Println("{*}Description{!}\n")
+	fmtc.Println(`  This command compares the spec with current instances state, shows the plan and
+  creates, updates, starts, stops and destroys instances to converge the state. Instances
+  are matched by name, so you can run command many times with the same spec. Instances
+  created by other commands are not affected unless they are bound to the spec using ID.
+  Instances with names which are not present in the spec will be {r}destroyed{!}. If the spec
+  doesn't contain any instances, command refuses to destroy them unless --prune option is set.
+
+  Fields {m}tags{!}, {m}template_profile{!} and {m}overrides{!} are optional, if a field is not set
+  the value is reset to default (no tags, default profile, no overrides). Fields {m}owner{!},
+  {m}password{!}, {m}replication_type{!}, {m}disable_saves{!}, {m}memory_max{!}, {m}cpu_max{!} and {m}io_weight{!}
+  are optional too, but if a field is not set the current value is kept. Fields {m}secure{!}
+  and {m}service_password{!} are used only for instance creation. Passwords for created
+  instances will be generated if not set and shown only once. Overrides can contain one
+  value or a list of values for properties which can be defined many times.
+
+  {*s@} node1.yml {!}
+  {s}┃{!}
+  {s}┃ instances:{!}
+  {s}┃   - name: myapp-cache{!}
+  {s}┃     desc: Cache for MyApp{!}
+  {s}┃     owner: john{!}
+  {s}┃     tags: [cache, myapp]{!}
+  {s}┃     replication_type: replica{!}
+  {s}┃     disable_saves: true{!}
+  {s}┃     memory_max: 4GB{!}
+  {s}┃     overrides:{!}
+  {s}┃       maxmemory-policy: allkeys-lru{!}
+  {s}┃       client-output-buffer-limit:{!}
+  {s}┃         - replica 512mb 128mb 60{!}
+  {s}┃         - pubsub 64mb 16mb 60{!}
+  {s}┃   - name: legacy-queue{!}
+  {s}┃     id: 12{!}
+  {s}┃     desc: Queue for legacy app{!}
+  {s}┃     state: stopped{!}
+  {s}┃{!}
+`)
+
+	info.renderArguments()
+	info.renderOptions()
+	info.renderExamples()
+}
+
 // helpCommandBackupCreate prints info about "backup-create" command usage
 func helpCommandBackupCreate() {
 	helpInfo{
 		command: COMMAND_BACKUP_CREATE,
-		desc:    "Create snapshot of RDB file.",
+		desc:    "Create snapshot of instance data and save it to all configured backup destinations. If instance uses append only file, backup will contain all AOF files (including multi-part AOF manifest for Redis 7+) instead of RDB file.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or selector (see \"help selectors\")", false},
 		},
 		examples: []helpInfoExample{
 			{"", "7", "Create an RDB file snapshot of the instance with the ID 7"},
+			{"", "tag:important", "Create snapshots of all instances with tag important"},
 		},
 	}.render()
 }
@@ -1041,12 +1518,21 @@ func helpCommandBackupCreate() {
 func helpCommandBackupRestore() {
 	helpInfo{
 		command: COMMAND_BACKUP_RESTORE,
-		desc:    "Restore previously created snapshot of instance data.",
+		desc:    "Restore previously created snapshot of instance data. Snapshot can be taken from other instance or from RDB file (plain or compressed with gzip/zstd). Before restoring, snapshot is verified and checked for compatibility with installed version of Redis.",
 		arguments: []helpInfoArgument{
-			{"id", "Instance unique ID", false},
+			{"id", "Instance unique ID or \"new\" for restoring to a new instance", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_FROM), "Source instance ID or path to RDB file", false},
+			{getNiceOptions(OPT_INDEX), "Snapshot index (restore without interactive selection)", false},
+			{getNiceOptions(OPT_TAGS), "List of tags for a new instance", false},
+			{getNiceOptions(OPT_YES), "Restore without confirmation", false},
 		},
 		examples: []helpInfoExample{
 			{"", "7", "Restore a data snapshot of the instance with the ID 7"},
+			{"", "7 --index 3 --yes", "Restore the third snapshot of the instance with the ID 7 without confirmation"},
+			{"", "7 --from 12 --index 1", "Restore the first snapshot of the instance with the ID 12 to the instance with the ID 7"},
+			{"", "new --from /tmp/dump.rdb.gz", "Create a new instance with data from given RDB file"},
 		},
 	}.render()
 }
@@ -1069,7 +1555,7 @@ func helpCommandBackupClean() {
 func helpCommandBackupList() {
 	helpInfo{
 		command: COMMAND_BACKUP_LIST,
-		desc:    "Show information regarding all backup snapshots.",
+		desc:    "Show information regarding all backup snapshots in all configured backup destinations.",
 		arguments: []helpInfoArgument{
 			{"id", "Instance unique ID", false},
 		},
@@ -1079,6 +1565,77 @@ func helpCommandBackupList() {
 	}.render()
 }
 
+// helpCommandBackupVerify prints info about "backup-verify" command usage
+func helpCommandBackupVerify() {
+	helpInfo{
+		command: COMMAND_BACKUP_VERIFY,
+		desc:    "Verify backup snapshot checksum and RDB file format and show information about snapshot data. If redis-check-rdb is installed, it will be used for additional RDB file validation.",
+		arguments: []helpInfoArgument{
+			{"id", "Instance unique ID", false},
+			{"index", "Snapshot index from backup-list output (latest snapshot by default)", true},
+		},
+		examples: []helpInfoExample{
+			{"", "7", "Verify the latest snapshot of the instance with the ID 7"},
+			{"", "7 3", "Verify the snapshot with index 3 of the instance with the ID 7"},
+		},
+	}.render()
+}
+
+// helpCommandBackupRotateKey prints info about "backup-rotate-key" command usage
+func helpCommandBackupRotateKey() {
+	helpInfo{
+		command: COMMAND_BACKUP_ROTATE_KEY,
+		desc:    "Generate a new backup encryption key and re-encrypt all existing backups of all instances with it. If passphrase is used for encryption, command re-encrypts backups with current passphrase from configuration file (previous passphrase will be requested for backups created before passphrase was changed). Backups of all instances of current node (including destroyed instances) from all configured storages are re-encrypted. Re-encrypted backups are saved with new names (with revision suffix), original backups are removed only after new archives and manifests are written. Old keys are kept in key file while any backup in configured storages (e.g. backup of another node sharing the same directory or bucket) is encrypted with them. Backup archives copied outside of storages must be re-encrypted manually before the old key is removed.",
+		examples: []helpInfoExample{
+			{"", "", "Re-encrypt all backups with a new key"},
+		},
+	}.render()
+}
+
+// helpCommandExport prints info about "export" command usage
+func helpCommandExport() {
+	helpInfo{
+		command: COMMAND_EXPORT,
+		desc:    "Export keys from instance database to file. By default, keys are stored as DUMP payloads with expiration times. Keys are restored with the same absolute expiration time, keys which already expired are skipped on import. With JSON format, strings, lists, sets, sorted sets and hashes are stored as human-readable JSON records (one per line), which can be imported to any version of Redis.",
+		arguments: []helpInfoArgument{
+			{"id:db", "Instance unique ID and database number", false},
+			{"file", "Path to output file", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_MATCH), "Export only keys matching glob-style pattern", false},
+			{getNiceOptions(OPT_FORMAT), "Export format (json)", false},
+			{getNiceOptions(OPT_YES), "Overwrite existing file without confirmation", false},
+		},
+		examples: []helpInfoExample{
+			{"", "1 /tmp/keys.dump", "Export all keys from instance with ID 1"},
+			{"", "1:3 /tmp/users.dump --match 'user:*'", "Export keys with prefix \"user:\" from database 3"},
+			{"", "1 /tmp/keys.json --format json", "Export all keys as JSON records"},
+		},
+	}.render()
+}
+
+// helpCommandImport prints info about "import" command usage
+func helpCommandImport() {
+	helpInfo{
+		command: COMMAND_IMPORT,
+		desc:    "Import keys from file created by export command. Format of file is detected automatically. Existing keys are skipped unless --replace option is used.",
+		arguments: []helpInfoArgument{
+			{"id:db", "Instance unique ID and database number", false},
+			{"file", "Path to file with exported data", false},
+		},
+		options: []helpInfoArgument{
+			{getNiceOptions(OPT_MATCH), "Import only keys matching glob-style pattern", false},
+			{getNiceOptions(OPT_REPLACE), "Replace existing keys", false},
+			{getNiceOptions(OPT_YES), "Replace keys without confirmation", false},
+		},
+		examples: []helpInfoExample{
+			{"", "2 /tmp/keys.dump", "Import all keys to instance with ID 2"},
+			{"", "2:3 /tmp/users.dump --replace", "Import keys to database 3 and replace existing keys"},
+			{"", "2 /tmp/keys.json --match 'session:*'", "Import only keys with prefix \"session:\""},
+		},
+	}.render()
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // getNiceOptions parse option and return formatted string
@@ -1115,6 +1672,7 @@ func (i helpInfo) render() {
 	i.renderDescription()
 	i.renderArguments()
 	i.renderOptions()
+	i.renderFields()
 	i.renderExamples()
 }
 
@@ -1186,6 +1744,23 @@ func (i helpInfo) renderOptions() {
 	fmtc.NewLine()
 }
 
+// renderFields render fields of machine-readable output
+func (i helpInfo) renderFields() {
+	if len(i.fields) == 0 {
+		return
+	}
+
+	fmtc.Println("{*}Output fields{!} {s-}(json/xml/csv){!}\n")
+
+	fmtStr := getArgumentFormatting(i.fields)
+
+	for _, field := range i.fields {
+		fmtc.Printf("  {c}"+fmtStr+"{!} %s\n", field.name, field.desc)
+	}
+
+	fmtc.NewLine()
+}
+
 // renderExamples render examples
 func (i helpInfo) renderExamples() {
 	if len(i.examples) == 0 {
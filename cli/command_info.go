@@ -119,6 +119,8 @@ func InfoCommand(args CommandArgs) int {
 		renderInfoDataAsJSON(info, sections)
 	case FORMAT_XML:
 		renderInfoDataAsXML(info, sections)
+	case FORMAT_CSV:
+		renderInfoDataAsCSV(info, sections)
 	default:
 		renderInfoData(t, info, sections)
 	}
@@ -154,6 +156,8 @@ func renderInfoDataError(format, message string) {
 		renderInfoDataAsJSON(nil, nil)
 	case FORMAT_XML:
 		renderInfoDataAsXML(nil, nil)
+	case FORMAT_CSV:
+		renderInfoDataAsCSV(nil, nil)
 	default:
 		terminal.Error(message)
 	}
@@ -197,6 +201,13 @@ func showInstanceBasicInfo(t *table.Table, id int, info *REDIS.Info, state CORE.
 
 	uri := fmt.Sprintf("redis://%s:%d/%s", host, CORE.GetInstancePort(id), db)
 
+	switch {
+	case CORE.IsSocketOnly():
+		uri = fmt.Sprintf("unix://%s?db=%s", CORE.GetInstanceSocketFilePath(id), db)
+	case CORE.IsTLSOnly():
+		uri = fmt.Sprintf("rediss://%s:%d/%s", host, CORE.GetInstanceTLSPort(id), db)
+	}
+
 	t.Border()
 	fmtc.Println(" ▾ {*}INSTANCE{!}")
 	t.Border()
@@ -208,8 +219,16 @@ func showInstanceBasicInfo(t *table.Table, id int, info *REDIS.Info, state CORE.
 	t.Print("Created", timeutil.Format(created, "%Y/%m/%d %H:%M:%S"))
 	t.Print("Replication type", strutil.Q(string(meta.Preferencies.ReplicationType), "—"))
 	t.Print("URI", uri)
+
+	if CORE.IsTLSEnabled() && !CORE.IsTLSOnly() {
+		t.Print("TLS URI", fmt.Sprintf("rediss://%s:%d/%s", host, CORE.GetInstanceTLSPort(id), db))
+	}
 	t.Print("Compatibility", compatible+" {s-}"+redisVersionInfo+"{!}")
 
+	if state.IsWorks() {
+		showInstanceLimitsInfo(t, id)
+	}
+
 	if !modTime.IsZero() {
 		t.Print("Dump size", fmtutil.PrettySize(size))
 
@@ -235,6 +254,31 @@ func showInstanceBasicInfo(t *table.Table, id int, info *REDIS.Info, state CORE.
 	return true
 }
 
+// showInstanceLimitsInfo prints info about instance resource limits
+func showInstanceLimitsInfo(t *table.Table, id int) {
+	usage, err := CORE.GetInstanceResourceUsage(id)
+
+	if err != nil || usage.Limits.IsEmpty() {
+		return
+	}
+
+	if usage.Limits.MemoryMax != 0 {
+		t.Print("Memory limit", fmt.Sprintf(
+			"%s {s-}(used: %s){!}",
+			fmtutil.PrettySize(usage.Limits.MemoryMax),
+			fmtutil.PrettySize(usage.MemoryCurrent),
+		))
+	}
+
+	if usage.Limits.CPUMax != 0 {
+		t.Print("CPU limit", fmt.Sprintf("%d%%", usage.Limits.CPUMax))
+	}
+
+	if usage.Limits.IOWeight != 0 {
+		t.Print("IO weight", usage.Limits.IOWeight)
+	}
+}
+
 // renderInfoData print instance info
 func renderInfoData(t *table.Table, info *REDIS.Info, sections []string) {
 	if info == nil {
@@ -345,6 +389,31 @@ func renderInfoDataAsXML(info *REDIS.Info, sections []string) {
 	fmt.Println("</info>")
 }
 
+// renderInfoDataAsCSV print info data as csv
+func renderInfoDataAsCSV(info *REDIS.Info, sections []string) {
+	data := newOutputList("info", "field", "section", "field", "value")
+
+	if info == nil {
+		data.Render(FORMAT_CSV)
+		return
+	}
+
+	for _, sectionName := range info.SectionNames {
+		section := info.Sections[sectionName]
+		sectionName = strings.ToLower(section.Header)
+
+		if len(section.Fields) == 0 || !isInfoSectionRequired(sections, sectionName) {
+			continue
+		}
+
+		for _, v := range section.Fields {
+			data.Add(sectionName, v, section.Values[v])
+		}
+	}
+
+	data.Render(FORMAT_CSV)
+}
+
 // renderInfoPropXML render info property as xml node
 func renderInfoPropXML(name, value string) {
 	fmt.Printf("    <%s>%v</%s>\n", name, convertInfoValueType(value), name)
@@ -9,6 +9,7 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,160 +20,393 @@ import (
 	"github.com/essentialkaos/ek/v13/mathutil"
 	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/pager"
-	"github.com/essentialkaos/ek/v13/system/process"
 	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/timeutil"
 
 	CORE "github.com/essentialkaos/rds/core"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// listColumn contains info about list table column
+type listColumn struct {
+	Name   string // Name used in --columns and --sort options
+	Field  string // Field name in machine-readable output
+	Header string
+	Size   int
+	Align  uint8
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// listColumns is a list of all supported columns
+var listColumns = []listColumn{
+	{"id", "id", "ID", 0, table.AR},
+	{"state", "state", "STATE", 8, table.AR},
+	{"mem", "memory", "MEMORY", 10, table.AR},
+	{"ops", "ops", "OPS", 6, table.AR},
+	{"input", "input", "INPUT", 12, table.AR},
+	{"output", "output", "OUTPUT", 12, table.AR},
+	{"clients", "clients", "CLIENTS", 7, table.AR},
+	{"owner", "owner", "OWNER", 18, table.AR},
+	{"desc", "description", "DESCRIPTION", 0, table.AL},
+	{"tags", "tags", "TAGS", 0, table.AL},
+	{"repl", "replication_type", "REPLICATION", 11, table.AR},
+	{"created", "created", "CREATED", 19, table.AR},
+}
+
+// listDefaultColumns is a list of columns shown by default
+var listDefaultColumns = []string{"id", "mem", "owner", "desc"}
+
+// listExtraColumns is a list of columns shown with --extra option
+var listExtraColumns = []string{"id", "mem", "ops", "input", "output", "clients", "owner", "desc"}
+
+// listOutputColumns is a list of columns for machine-readable output
+var listOutputColumns = []string{
+	"id", "state", "mem", "ops", "input", "output", "clients",
+	"owner", "desc", "tags", "repl", "created",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // ListCommand is "list" command handler
 func ListCommand(args CommandArgs) int {
+	format := options.GetS(OPT_FORMAT)
+	columns, err := getListColumns(format != "")
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
 	if !CORE.HasInstances() {
-		terminal.Warn("No instances are created")
+		if format != "" {
+			renderListInstances(nil, columns, format)
+		} else {
+			terminal.Warn("No instances are created")
+		}
 
 		return EC_WARN
 	}
 
-	var t *table.Table
+	query, err := parseInstanceQuery(args)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	sortField, reverse, err := parseListSortOption()
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	var highlights []string
 
-	filter := args
 	idList := CORE.GetInstanceIDList()
-	lastID := strconv.Itoa(idList[len(idList)-1])
-	idColumnSize := mathutil.Between(len(lastID), 2, 4)
+	instances := findListInstances(idList, query)
 
-	if options.GetB(OPT_EXTRA) {
-		t = table.NewTable(
-			"ID", "MEMORY", "OPS", "INPUT", "OUTPUT", "CLIENTS", "OWNER", "DESCRIPTION",
-		).SetSizes(
-			idColumnSize, 10, 6, 12, 12, 7, 18,
-		).SetAlignments(
-			table.AR, table.AR, table.AR, table.AR, table.AR, table.AR, table.AR, table.AL,
-		)
-	} else {
-		t = table.NewTable(
-			"ID", "MEMORY", "OWNER", "DESCRIPTION",
-		).SetSizes(
-			idColumnSize, 10, 18,
-		).SetAlignments(
-			table.AR, table.AR, table.AR, table.AL,
-		)
-	}
-
-	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
+	if len(instances) == 0 && len(args) != 0 && !isInstanceQuery(args) {
+		highlights = args
+		instances = findListInstances(idList, func(inst *queryInstance) bool {
+			return isDescFit(args, inst.Meta.Desc)
+		})
+	}
+
+	if sortField != "" {
+		sortListInstances(instances, sortField, reverse)
+	}
+
+	if format != "" {
+		renderListInstances(instances, columns, format)
+		return EC_OK
+	}
+
+	if useRawOutput {
+		for _, inst := range instances {
+			fmt.Println(inst.ID)
+		}
+
+		return EC_OK
+	}
+
+	if len(instances) == 0 {
+		terminal.Warn("No instances found")
+		return EC_OK
+	}
+
+	if options.GetB(OPT_PAGER) || prefs.AutoPaging {
 		if pager.Setup() == nil {
 			defer pager.Complete()
 		}
 	}
 
-	dataShown := listInstanceSearch(t, idList, filter, false)
+	lastID := strconv.Itoa(idList[len(idList)-1])
+	idColumnSize := mathutil.Between(len(lastID), 2, 4)
+
+	printListInstances(instances, columns, idColumnSize, highlights)
 
-	if !dataShown && len(filter) != 0 {
-		dataShown = listInstanceSearch(t, idList, filter, true)
+	if !fmtc.DisableColors {
+		fmtc.Println("\n Legend: {s-}stopped{!} {s}∙{!} {r}dead{!} {s}∙{!} {y}idle{!} {s}∙{!} {g}active{!} {s}∙{!} {g*}saving{!} {s}∙{!} {g_}syncing{!} {s}∙{!} {c}loading{!} {s}∙{!} {m}hang{!} {s}∙{!} unknown")
 	}
 
-	if !useRawOutput {
-		if dataShown {
-			t.Border()
+	return EC_OK
+}
 
-			if !fmtc.DisableColors {
-				fmtc.Println("\n Legend: {s-}stopped{!} {s}∙{!} {r}dead{!} {s}∙{!} {y}idle{!} {s}∙{!} {g}active{!} {s}∙{!} {g*}saving{!} {s}∙{!} {g_}syncing{!} {s}∙{!} {c}loading{!} {s}∙{!} {m}hang{!} {s}∙{!} unknown")
-			}
-		} else {
-			terminal.Warn("No instances found")
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getListColumns returns list of columns for output
+func getListColumns(isStructured bool) ([]listColumn, error) {
+	var names []string
+
+	switch {
+	case options.Has(OPT_COLUMNS):
+		names = strings.Split(options.GetS(OPT_COLUMNS), ",")
+	case isStructured:
+		names = listOutputColumns
+	case options.GetB(OPT_EXTRA):
+		names = listExtraColumns
+	default:
+		names = listDefaultColumns
+	}
+
+	var result []listColumn
+
+	for _, name := range names {
+		column, ok := findListColumn(strings.TrimSpace(name))
+
+		if !ok {
+			return nil, fmt.Errorf(
+				"Unknown column %q (supported columns: %s)",
+				name, strings.Join(getListColumnNames(), ", "),
+			)
 		}
+
+		result = append(result, column)
 	}
 
-	return EC_OK
+	return result, nil
 }
 
-// ////////////////////////////////////////////////////////////////////////////////// //
+// findListColumn tries to find column with given name or alias
+func findListColumn(name string) (listColumn, bool) {
+	name = strings.ToLower(name)
 
-// listInstanceSearch prints list of instances
-func listInstanceSearch(t *table.Table, idList []int, filter []string, fullTextSearch bool) bool {
-	dataShown := false
-	shown := 0
+	if queryFieldAliases[name] != "" {
+		name = queryFieldAliases[name]
+	}
+
+	for _, column := range listColumns {
+		if column.Name == name || column.Field == name {
+			return column, true
+		}
+	}
+
+	return listColumn{}, false
+}
+
+// getListColumnNames returns names of all supported columns
+func getListColumnNames() []string {
+	var result []string
+
+	for _, column := range listColumns {
+		result = append(result, column.Name)
+	}
+
+	return result
+}
+
+// parseListSortOption parses sorting option and returns column name and reverse flag
+func parseListSortOption() (string, bool, error) {
+	if !options.Has(OPT_SORT) {
+		return "", false, nil
+	}
+
+	name := options.GetS(OPT_SORT)
+	reverse := strings.HasPrefix(name, "^")
+	column, ok := findListColumn(strings.TrimLeft(name, "^"))
+
+	if !ok || column.Name == "tags" {
+		return "", false, fmt.Errorf("Can't sort instances by %q", name)
+	}
+
+	return column.Name, reverse, nil
+}
+
+// findListInstances returns slice with instances which fit given query
+func findListInstances(idList []int, query queryMatcher) []*queryInstance {
+	var result []*queryInstance
 
 	for _, id := range idList {
-		state, err := CORE.GetInstanceState(id, true)
+		inst, err := newQueryInstance(id)
+
+		if err != nil || !query(inst) {
+			continue
+		}
+
+		result = append(result, inst)
+	}
 
-		if err != nil {
-			state = CORE.INSTANCE_STATE_UNKNOWN
+	return result
+}
+
+// sortListInstances sorts instances by given column
+func sortListInstances(instances []*queryInstance, column string, reverse bool) {
+	sort.SliceStable(instances, func(i, j int) bool {
+		i1, i2 := instances[i], instances[j]
+
+		if reverse {
+			i1, i2 = i2, i1
 		}
 
-		meta, err := CORE.GetInstanceMeta(id)
+		switch column {
+		case "state":
+			return getStateName(i1.State) < getStateName(i2.State)
+		case "owner":
+			return i1.Meta.Auth.User < i2.Meta.Auth.User
+		case "desc":
+			return strings.ToLower(i1.Meta.Desc) < strings.ToLower(i2.Meta.Desc)
+		case "repl":
+			return i1.Meta.Preferencies.ReplicationType < i2.Meta.Preferencies.ReplicationType
+		}
 
-		if err != nil {
-			state = CORE.INSTANCE_STATE_UNKNOWN
+		v1, ok1 := i1.Metric(column)
+		v2, ok2 := i2.Metric(column)
+
+		if !ok1 {
+			v1 = -1
 		}
 
-		switch fullTextSearch {
-		case true:
-			if !isDescFit(filter, meta.Desc) {
-				continue
-			}
-		case false:
-			if !isFilterFit(filter, state, meta) {
-				continue
-			}
+		if !ok2 {
+			v2 = -1
 		}
 
-		if useRawOutput {
-			fmt.Println(id)
-			dataShown = true
-			continue
+		return v1 < v2
+	})
+}
+
+// printListInstances prints table with instances
+func printListInstances(instances []*queryInstance, columns []listColumn, idColumnSize int, highlights []string) {
+	var headers []string
+	var sizes []int
+	var aligns []uint8
+
+	for _, column := range columns {
+		headers = append(headers, column.Header)
+		aligns = append(aligns, column.Align)
+
+		if column.Name == "id" {
+			sizes = append(sizes, idColumnSize)
+		} else {
+			sizes = append(sizes, column.Size)
 		}
+	}
+
+	t := table.NewTable(headers...).SetSizes(sizes...).SetAlignments(aligns...)
 
-		if shown > 0 && shown%32 == 0 && shown+8 < len(idList) {
+	for index, inst := range instances {
+		if index > 0 && index%32 == 0 && index+8 < len(instances) {
 			t.Separator()
 		}
 
-		shown++
-		showListInstanceInfo(t, id, meta, state, fullTextSearch, filter)
+		var values []any
 
-		dataShown = true
+		for _, column := range columns {
+			values = append(values, getListColumnValue(inst, column.Name, highlights))
+		}
+
+		t.Print(values...)
 	}
 
-	return dataShown
+	t.Border()
 }
 
-// showListInstanceInfo prints table row with instance info
-func showListInstanceInfo(t *table.Table, id int, meta *CORE.InstanceMeta, state CORE.State, fullTextSearch bool, filter []string) {
-	var instanceDesc string
-	var instanceOPS, instanceInput, instanceOutput, instanceClients string
+// renderListInstances prints instances in given format
+func renderListInstances(instances []*queryInstance, columns []listColumn, format string) {
+	var fields []string
 
-	instanceID := getInstanceIDWithColor(id, state)
-	instanceMem := getInstanceMemoryUsageWithColor(id, state)
-	instanceOwner := getInstanceOwnerWithColor(meta, true)
-
-	if fullTextSearch {
-		instanceDesc = getInstanceDescWithTags(meta, state.IsWorks(), filter)
-	} else {
-		instanceDesc = getInstanceDescWithTags(meta, state.IsWorks(), nil)
+	for _, column := range columns {
+		fields = append(fields, column.Field)
 	}
 
-	if options.GetB(OPT_EXTRA) {
-		instanceOPS, instanceInput, instanceOutput, instanceClients = "{s-}—{!}", "{s-}—{!}", "{s-}—{!}", "{s-}—{!}"
+	data := newOutputList("instances", "instance", fields...)
 
-		if state.IsWorks() {
-			info, err := CORE.GetInstanceInfo(id, time.Second, false)
+	for _, inst := range instances {
+		var values []any
 
-			if err == nil {
-				instanceOPS = fmtutil.PrettyNum(info.GetI("stats", "instantaneous_ops_per_sec"))
-				instanceInput = fmtutil.PrettySize(info.GetF("stats", "instantaneous_input_kbps")*1024.0) + "/s"
-				instanceOutput = fmtutil.PrettySize(info.GetF("stats", "instantaneous_output_kbps")*1024.0) + "/s"
-				instanceClients = fmtutil.PrettyNum(info.GetI("clients", "connected_clients") - 1)
-			}
+		for _, column := range columns {
+			values = append(values, getListColumnRawValue(inst, column.Name))
 		}
 
-		t.Print(
-			instanceID, instanceMem, instanceOPS, instanceInput,
-			instanceOutput, instanceClients, instanceOwner, instanceDesc,
-		)
-	} else {
-		t.Print(instanceID, instanceMem, instanceOwner, instanceDesc)
+		data.Add(values...)
+	}
+
+	data.Render(format)
+}
+
+// getListColumnValue returns formatted column value for table
+func getListColumnValue(inst *queryInstance, column string, highlights []string) string {
+	switch column {
+	case "id":
+		return getInstanceIDWithColor(inst.ID, inst.State)
+	case "state":
+		return getInstanceStateWithColor(inst.State)
+	case "mem":
+		return getInstanceMemoryUsageWithColor(inst)
+	case "owner":
+		return getInstanceOwnerWithColor(inst.Meta, true)
+	case "desc":
+		return getInstanceDescWithTags(inst.Meta, inst.State.IsWorks(), highlights)
+	case "tags":
+		return renderTags(inst.Meta.Tags...)
+	case "repl":
+		return string(inst.Meta.Preferencies.ReplicationType)
+	case "created":
+		return timeutil.Format(time.Unix(inst.Meta.Created, 0), "%Y/%m/%d %H:%M:%S")
+	}
+
+	value, ok := inst.Metric(column)
+
+	if !ok {
+		return "{s-}—{!}"
 	}
+
+	switch column {
+	case "input", "output":
+		return fmtutil.PrettySize(value) + "/s"
+	}
+
+	return fmtutil.PrettyNum(value)
+}
+
+// getListColumnRawValue returns column value for machine-readable output
+func getListColumnRawValue(inst *queryInstance, column string) any {
+	switch column {
+	case "id":
+		return inst.ID
+	case "state":
+		return strings.ToLower(getStateName(inst.State))
+	case "owner":
+		return inst.Meta.Auth.User
+	case "desc":
+		return inst.Meta.Desc
+	case "tags":
+		return inst.Tags()
+	case "repl":
+		return string(inst.Meta.Preferencies.ReplicationType)
+	case "created":
+		return inst.Meta.Created
+	}
+
+	value, ok := inst.Metric(column)
+
+	if !ok {
+		return nil
+	}
+
+	return uint64(value)
 }
 
 // isFilterFit return true if instance fit for filter
@@ -262,24 +496,18 @@ func isDescFit(filter []string, desc string) bool {
 }
 
 // getInstanceMemoryUsageWithColor returns instance memory usage
-func getInstanceMemoryUsageWithColor(id int, state CORE.State) string {
-	if !state.IsWorks() {
+func getInstanceMemoryUsageWithColor(inst *queryInstance) string {
+	if !inst.State.IsWorks() {
 		return "{s-}∙∙∙∙∙∙∙∙{!}"
 	}
 
-	pid := CORE.GetInstancePID(id)
+	memory, ok := inst.Memory()
 
-	if pid == -1 {
-		return "{y}????????{!}"
-	}
-
-	usage, err := process.GetMemInfo(pid)
-
-	if err != nil {
+	if !ok {
 		return "{y}????????{!}"
 	}
 
-	return fmtutil.PrettySize(usage.VmRSS)
+	return fmtutil.PrettySize(memory)
 }
 
 // isMetaContainsTag return true if instance has given tag
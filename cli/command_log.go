@@ -9,7 +9,9 @@ package cli
 
 import (
 	"bufio"
+	"fmt"
 	"os"
+	"os/exec"
 	"strings"
 	"time"
 
@@ -58,6 +60,18 @@ func LogCommand(args CommandArgs) int {
 			return EC_ERROR
 		}
 
+		// In systemd mode instance log is collected by journald
+		if CORE.IsSystemdMode() {
+			err = readJournalLog(CORE.GetInstanceUnitName(id))
+
+			if err != nil {
+				terminal.Error(err.Error())
+				return EC_ERROR
+			}
+
+			return EC_OK
+		}
+
 		logFile = CORE.GetInstanceLogFilePath(id)
 		isRedisLog = true
 	}
@@ -127,6 +141,36 @@ func readLogFile(logFile string, isRedisLog bool) error {
 	}
 }
 
+// readJournalLog reads and formats instance log from journald
+func readJournalLog(unitName string) error {
+	cmd := exec.Command(CORE.BIN_JOURNALCTL, "--follow", "--lines=50", "--output=cat", "--unit="+unitName)
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+
+	if err != nil {
+		return fmt.Errorf("Can't read instance log from journald: %w", err)
+	}
+
+	lastPrint := time.Now()
+	s := bufio.NewScanner(stdout)
+
+	for s.Scan() {
+		if time.Since(lastPrint) > time.Minute {
+			fmtutil.Separator(true)
+		}
+
+		printRedisLogLine(s.Text())
+		lastPrint = time.Now()
+	}
+
+	return cmd.Wait()
+}
+
 // printRDSLogLine formats and prints line from RDS log
 func printRDSLogLine(line string) {
 	var target, colorTag string
@@ -56,6 +56,13 @@ func MemoryCommand(args CommandArgs) int {
 		return EC_ERROR
 	}
 
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" {
+		renderMemoryUsage(metrics, format)
+		return EC_OK
+	}
+
 	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
 		if pager.Setup() == nil {
 			defer pager.Complete()
@@ -108,6 +115,17 @@ func printRawMemoryUsage(metrics []instanceMemoryMetric) {
 	}
 }
 
+// renderMemoryUsage prints memory metrics in given format
+func renderMemoryUsage(metrics []instanceMemoryMetric, format string) {
+	data := newOutputList("memory", "metric", "metric", "value")
+
+	for _, m := range metrics {
+		data.Add(m.Field, m.Value)
+	}
+
+	data.Render(format)
+}
+
 // getInstanceMemoryUsage executes MEMORY STATS command and returns results as
 // a slice with metrics
 func getInstanceMemoryUsage(id int) ([]instanceMemoryMetric, error) {
@@ -0,0 +1,374 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/spinner"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+	"github.com/essentialkaos/ek/v13/version"
+
+	API "github.com/essentialkaos/rds/api"
+	CORE "github.com/essentialkaos/rds/core"
+	SC "github.com/essentialkaos/rds/sync/client"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MIGRATION_PAUSE_TIME is max duration of writes pause while switching roles
+const MIGRATION_PAUSE_TIME = 30 * time.Second
+
+// MIGRATION_MIN_PAUSE_VERSION is minimal Redis version with support of writes pause
+const MIGRATION_MIN_PAUSE_VERSION = "6.2.0"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// migrationTarget contains info about migration target
+type migrationTarget struct {
+	Node string
+	Host string
+	ID   int
+	Port int
+	UUID string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MigrateCommand is "migrate" command handler
+func MigrateCommand(args CommandArgs) int {
+	err := args.Check(true)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	id, _, err := CORE.ParseIDDBPair(args.Get(0))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if !args.Has(1) {
+		terminal.Error("You must define target node")
+		return EC_ERROR
+	}
+
+	if !CORE.IsMigrationEnabled() {
+		terminal.Error("Migration API is not configured (%s is empty)", CORE.MIGRATION_AUTH_TOKEN)
+		return EC_ERROR
+	}
+
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	info, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
+
+	if err != nil {
+		terminal.Error("Can't get instance info: %v", err)
+		return EC_ERROR
+	}
+
+	if info.Get("replication", "role") != "master" {
+		terminal.Warn("Only master instance can be migrated")
+		return EC_WARN
+	}
+
+	state, err := CORE.GetInstanceState(id, true)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	err = showInstanceBasicInfoCard(id, state)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	node := args.Get(1)
+
+	if options.GetB(OPT_DESTROY_SOURCE) {
+		terminal.Warn("Warning! Instance will be destroyed on this node after migration.\n")
+	}
+
+	ok, err := input.ReadAnswer(fmt.Sprintf("Migrate instance %d to node %s?", id, node), "N")
+
+	if err != nil || !ok {
+		return EC_CANCEL
+	}
+
+	fmtc.NewLine()
+
+	spinner.Show("Creating replica on node %s", node)
+
+	resp, err := SC.MigrationCreate(node, meta, CORE.GetInstanceReplicationPort(id))
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	target := &migrationTarget{
+		Node: node,
+		Host: SC.GetMigrationNodeHost(node),
+		ID:   resp.ID,
+		Port: resp.Port,
+		UUID: meta.UUID,
+	}
+
+	if CORE.IsTLSReplication() && resp.TLSPort != 0 {
+		target.Port = resp.TLSPort
+	}
+
+	logger.Info(id, "Started migration to node %s (target instance ID: %d)", node, target.ID)
+
+	err = waitForMigrationSync(id, target)
+
+	if err == nil {
+		err = switchMigrationRoles(id, target)
+	}
+
+	if err != nil {
+		fmtc.NewLine()
+		terminal.Error(err)
+		logger.Error(id, "Migration to node %s failed: %v", node, err)
+		abortMigration(target)
+		return EC_ERROR
+	}
+
+	logger.Info(id, "Instance migrated to node %s (target instance ID: %d)", node, target.ID)
+
+	if options.GetB(OPT_DESTROY_SOURCE) {
+		if !destroyMigratedInstance(id, meta.UUID) {
+			return EC_ERROR
+		}
+	}
+
+	fmtc.NewLine()
+	fmtc.Printf(
+		"{*}Done. Instance with ID %d successfully migrated to %s {s-}(ID: %d | Port: %d){!}\n",
+		id, target.Host, target.ID, resp.Port,
+	)
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// waitForMigrationSync waits until replica on target node finishes initial sync
+func waitForMigrationSync(id int, target *migrationTarget) error {
+	spinner.Show("Syncing data with node %s", target.Node)
+
+	maxWait := time.Duration(CORE.Config.GetI(CORE.REPLICATION_MAX_SYNC_WAIT)) * time.Second
+	deadline := time.Now().Add(maxWait)
+	ticker := time.NewTicker(time.Second)
+
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		if time.Now().After(deadline) {
+			spinner.Done(false)
+			return fmt.Errorf("Replica didn't finish syncing in %v", maxWait)
+		}
+
+		status, err := SC.MigrationStatus(target.Node, target.ID, target.UUID)
+
+		if err != nil {
+			spinner.Done(false)
+			return err
+		}
+
+		switch {
+		case status.SyncInProgress:
+			spinner.Update(
+				"Syncing data with node %s {s}(%s left){!}",
+				target.Node, fmtutil.PrettySize(status.SyncLeftBytes),
+			)
+
+		case status.LinkStatus == "up":
+			lag, err := getMigrationLag(id, status)
+
+			if err != nil {
+				spinner.Done(false)
+				return err
+			}
+
+			spinner.Update(
+				"Replicating data to node %s {s}(lag: %s){!}",
+				target.Node, fmtutil.PrettySize(lag),
+			)
+
+			if lag < 1024*1024 {
+				spinner.Done(true)
+				return nil
+			}
+		}
+	}
+}
+
+// switchMigrationRoles promotes replica on target node to master and makes
+// source instance a replica of it
+func switchMigrationRoles(id int, target *migrationTarget) error {
+	spinner.Show("Switching roles")
+
+	minVer, _ := version.Parse(MIGRATION_MIN_PAUSE_VERSION)
+	canPause := !CORE.GetInstanceVersion(id).Less(minVer)
+
+	if canPause {
+		err := CORE.PauseInstanceWrites(id, MIGRATION_PAUSE_TIME)
+
+		if err != nil {
+			spinner.Done(false)
+			return err
+		}
+
+		defer CORE.UnpauseInstanceWrites(id)
+	}
+
+	spinner.Update("Waiting for replication offsets to converge")
+
+	err := waitForMigrationOffsets(id, target)
+
+	if err != nil {
+		spinner.Done(false)
+		return err
+	}
+
+	spinner.Update("Promoting instance on node %s to master", target.Node)
+
+	err = SC.MigrationPromote(target.Node, target.ID, target.UUID)
+
+	if err != nil {
+		spinner.Done(false)
+		return err
+	}
+
+	err = CORE.ReplicaOf(id, target.Host, target.Port)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		terminal.Warn("Instance on node %s promoted to master, but source instance is still master", target.Node)
+		target.ID = -1 // Promoted instance must not be removed
+		return err
+	}
+
+	if !canPause {
+		terminal.Warn("Redis < %s doesn't support writes pause, writes made while switching roles can be lost", MIGRATION_MIN_PAUSE_VERSION)
+	}
+
+	return nil
+}
+
+// waitForMigrationOffsets waits until replica receives all data from source instance
+func waitForMigrationOffsets(id int, target *migrationTarget) error {
+	deadline := time.Now().Add(MIGRATION_PAUSE_TIME - 5*time.Second)
+	ticker := time.NewTicker(250 * time.Millisecond)
+
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Replication offsets didn't converge")
+		}
+
+		status, err := SC.MigrationStatus(target.Node, target.ID, target.UUID)
+
+		if err != nil {
+			return err
+		}
+
+		lag, err := getMigrationLag(id, status)
+
+		if err != nil {
+			return err
+		}
+
+		if status.LinkStatus == "up" && lag == 0 {
+			return nil
+		}
+	}
+}
+
+// getMigrationLag returns replication lag of replica in bytes
+func getMigrationLag(id int, status *API.MigrationInfo) (int64, error) {
+	info, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
+
+	if err != nil {
+		return 0, fmt.Errorf("Can't get instance info: %v", err)
+	}
+
+	return max(int64(info.GetU("replication", "master_repl_offset"))-status.Offset, 0), nil
+}
+
+// abortMigration removes replica created on target node
+func abortMigration(target *migrationTarget) {
+	if target.ID == -1 {
+		return
+	}
+
+	spinner.Show("Removing replica on node %s", target.Node)
+
+	err := SC.MigrationAbort(target.Node, target.ID, target.UUID)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+}
+
+// destroyMigratedInstance destroys source instance after migration
+func destroyMigratedInstance(id int, uuid string) bool {
+	spinner.Show("Destroying instance {s}(ID: %d){!}", id)
+
+	err := CORE.DestroyInstance(id)
+
+	spinner.Done(err == nil)
+
+	if err != nil {
+		terminal.Error(err)
+		return false
+	}
+
+	logger.Info(id, "Instance destroyed after migration")
+
+	err = SC.PropagateCommand(API.COMMAND_DESTROY, id, uuid)
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	return true
+}
@@ -25,13 +25,21 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// replicationOutputFields is a list of fields for CSV output of replication command
+var replicationOutputFields = []string{
+	"cid", "role", "ip", "hostname", "version", "state",
+	"seen_lag", "sync_lag", "connected",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // ReplicationCommand is "replication" command handler
 func ReplicationCommand(args CommandArgs) int {
 	format := options.GetS(OPT_FORMAT)
 
 	if !CORE.IsSyncDaemonActive() {
 		switch format {
-		case FORMAT_TEXT, FORMAT_JSON, FORMAT_XML:
+		case FORMAT_TEXT, FORMAT_JSON, FORMAT_XML, FORMAT_CSV:
 			fmt.Print(formatReplicationErrorMessage(format))
 		default:
 			terminal.Warn("Can't show replication info: sync daemon is not working")
@@ -44,7 +52,7 @@ func ReplicationCommand(args CommandArgs) int {
 
 	if err != nil {
 		switch format {
-		case FORMAT_TEXT, FORMAT_JSON, FORMAT_XML:
+		case FORMAT_TEXT, FORMAT_JSON, FORMAT_XML, FORMAT_CSV:
 			fmt.Print(formatReplicationErrorMessage(format))
 		default:
 			terminal.Error(err)
@@ -64,6 +72,8 @@ func ReplicationCommand(args CommandArgs) int {
 		renderReplicationInfoJSON(info)
 	case FORMAT_XML:
 		renderReplicationInfoXML(info)
+	case FORMAT_CSV:
+		renderReplicationInfoCSV(info)
 	default:
 		renderReplicationInfo(info)
 	}
@@ -208,6 +218,8 @@ func formatReplicationErrorMessage(format string) string {
 		return fmt.Sprint("{}\n")
 	case FORMAT_XML:
 		return fmt.Sprint("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>\n<replication></replication>\n")
+	case FORMAT_CSV:
+		return fmt.Sprintln(strings.Join(replicationOutputFields, ","))
 	}
 
 	return ""
@@ -230,6 +242,25 @@ func renderReplicationInfoText(info *API.ReplicationInfo) {
 	}
 }
 
+// renderReplicationInfoCSV prints replication info in CSV format
+func renderReplicationInfoCSV(info *API.ReplicationInfo) {
+	data := newOutputList("replication", "client", replicationOutputFields...)
+
+	data.Add(
+		"00000000", "master", info.Master.IP, info.Master.Hostname,
+		info.Master.Version, "online", 0, 0, 0,
+	)
+
+	for _, c := range info.Clients {
+		data.Add(
+			c.CID, c.Role, c.IP, c.Hostname, c.Version, c.State,
+			c.LastSeenLag, c.LastSyncLag, c.ConnectionDate,
+		)
+	}
+
+	data.Render(FORMAT_CSV)
+}
+
 // renderReplicationInfoXML prints replication info in XML format
 func renderReplicationInfoXML(info *API.ReplicationInfo) {
 	fmt.Println(`<?xml version="1.0" encoding="UTF-8" ?>`)
@@ -53,13 +53,15 @@ func SlowlogGetCommand(args CommandArgs) int {
 		}
 	}
 
-	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
+	format := options.GetS(OPT_FORMAT)
+
+	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput && format == "" {
 		if pager.Setup() == nil {
 			defer pager.Complete()
 		}
 	}
 
-	return slowlogGet(id, num)
+	return slowlogGet(id, num, format)
 }
 
 // SlowlogResetCommand is "slowlog-reset" command handler
@@ -84,7 +86,7 @@ func SlowlogResetCommand(args CommandArgs) int {
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // slowlogGet run SLOWLOG GET command
-func slowlogGet(id, num int) int {
+func slowlogGet(id, num int, format string) int {
 	resp, err := CORE.ExecCommand(
 		id, &REDIS.Request{
 			Command: []string{"SLOWLOG", "GET", strconv.Itoa(num)},
@@ -98,6 +100,11 @@ func slowlogGet(id, num int) int {
 
 	entries, err := resp.Array()
 
+	if format != "" {
+		renderSlowlogEntries(entries, format)
+		return EC_OK
+	}
+
 	if len(entries) == 0 || err != nil {
 		terminal.Warn("Slow log is empty")
 		return EC_OK
@@ -136,6 +143,36 @@ func slowlogGet(id, num int) int {
 	return EC_OK
 }
 
+// renderSlowlogEntries prints slow log entries in given format
+func renderSlowlogEntries(entries []*REDIS.Resp, format string) {
+	data := newOutputList(
+		"slowlog", "entry",
+		"id", "timestamp", "duration", "client", "command",
+	)
+
+	for _, entry := range entries {
+		elements, err := entry.Array()
+
+		if len(elements) < 4 || err != nil {
+			continue
+		}
+
+		entryID, _ := elements[0].Int()
+		timestamp, _ := elements[1].Int64()
+		execMs, _ := elements[2].Int64()
+		cmd, _ := elements[3].List()
+		client := ""
+
+		if len(elements) > 5 {
+			client, _ = elements[4].Str()
+		}
+
+		data.Add(entryID, timestamp, execMs, client, strings.Join(cmd, " "))
+	}
+
+	data.Render(format)
+}
+
 // slowlogReset run SLOWLOG RESET command
 func slowlogReset(id int) int {
 	resp, err := CORE.ExecCommand(
@@ -32,6 +32,8 @@ func StatsCommand(args CommandArgs) int {
 			fmt.Println("{}")
 		case FORMAT_XML:
 			fmt.Sprintln(`<?xml version="1.0" encoding="UTF-8" ?>\n<stats></stats>`)
+		case FORMAT_CSV:
+			renderStatsAsCSV(nil)
 		default:
 			terminal.Warn("No instances are created")
 		}
@@ -53,6 +55,8 @@ func StatsCommand(args CommandArgs) int {
 		renderStatsAsJSON(stats)
 	case FORMAT_XML:
 		renderStatsAsXML(stats)
+	case FORMAT_CSV:
+		renderStatsAsCSV(stats)
 	default:
 		renderStats(stats)
 	}
@@ -188,6 +192,53 @@ func renderStatsAsText(stats *CORE.Stats) {
 	fmt.Println("expires_keys", stats.Keys.Expires)
 }
 
+// renderStatsAsCSV print stats data as csv
+func renderStatsAsCSV(stats *CORE.Stats) {
+	data := newOutputList("stats", "metric", "metric", "value")
+
+	if stats == nil {
+		data.Render(FORMAT_CSV)
+		return
+	}
+
+	data.Add("total_instances", stats.Instances.Total)
+	data.Add("active_instances", stats.Instances.Active)
+	data.Add("dead_instances", stats.Instances.Dead)
+	data.Add("bgsave_instances", stats.Instances.BgSave)
+	data.Add("aof_rewrite_instances", stats.Instances.AOFRewrite)
+	data.Add("syncing_instances", stats.Instances.Syncing)
+	data.Add("save_failed_instances", stats.Instances.SaveFailed)
+	data.Add("active_master_instances", stats.Instances.ActiveMaster)
+	data.Add("active_replica_instances", stats.Instances.ActiveReplica)
+	data.Add("outdated_instances", stats.Instances.Outdated)
+	data.Add("connected_clients", stats.Clients.Connected)
+	data.Add("blocked_clients", stats.Clients.Blocked)
+	data.Add("total_system_memory", stats.Memory.TotalSystemMemory)
+	data.Add("system_memory", stats.Memory.SystemMemory)
+	data.Add("total_system_swap", stats.Memory.TotalSystemSwap)
+	data.Add("system_swap", stats.Memory.SystemSwap)
+	data.Add("used_memory", stats.Memory.UsedMemory)
+	data.Add("used_memory_rss", stats.Memory.UsedMemoryRSS)
+	data.Add("used_memory_lua", stats.Memory.UsedMemoryLua)
+	data.Add("used_swap", stats.Memory.UsedSwap)
+	data.Add("total_connections_received", stats.Overall.TotalConnectionsReceived)
+	data.Add("total_commands_processed", stats.Overall.TotalCommandsProcessed)
+	data.Add("instantaneous_ops_per_sec", stats.Overall.InstantaneousOpsPerSec)
+	data.Add("instantaneous_input_kbps", stats.Overall.InstantaneousInputKbps)
+	data.Add("instantaneous_output_kbps", stats.Overall.InstantaneousOutputKbps)
+	data.Add("rejected_connections", stats.Overall.RejectedConnections)
+	data.Add("expired_keys", stats.Overall.ExpiredKeys)
+	data.Add("evicted_keys", stats.Overall.EvictedKeys)
+	data.Add("keyspace_hits", stats.Overall.KeyspaceHits)
+	data.Add("keyspace_misses", stats.Overall.KeyspaceMisses)
+	data.Add("pubsub_channels", stats.Overall.PubsubChannels)
+	data.Add("pubsub_patterns", stats.Overall.PubsubPatterns)
+	data.Add("total_keys", stats.Keys.Total)
+	data.Add("expires_keys", stats.Keys.Expires)
+
+	data.Render(FORMAT_CSV)
+}
+
 // renderStatsAsXML print stats data as xml
 func renderStatsAsXML(stats *CORE.Stats) {
 	fmt.Println(`<?xml version="1.0" encoding="UTF-8" ?>`)
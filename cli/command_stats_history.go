@@ -0,0 +1,221 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/tty"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// sparklineSymbols contains symbols used for rendering sparklines
+var sparklineSymbols = []rune("▁▂▃▄▅▆▇█")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// StatsHistoryCommand is "stats-history" command handler
+func StatsHistoryCommand(args CommandArgs) int {
+	var err error
+
+	name := args.Get(0)
+
+	if name != CORE.HISTORY_NODE {
+		err = args.Check(false)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		id, _, _ := CORE.ParseIDDBPair(name)
+		name = strconv.Itoa(id)
+	}
+
+	if !args.Has(1) {
+		terminal.Warn("You must define metric name")
+		return EC_WARN
+	}
+
+	metric := args.Get(1)
+
+	if !slices.Contains(CORE.GetHistoryMetrics(name), metric) {
+		terminal.Error(
+			"Unknown metric %q (supported metrics: %s)",
+			metric, strings.Join(CORE.GetHistoryMetrics(name), ", "),
+		)
+		return EC_ERROR
+	}
+
+	period := 24 * time.Hour
+
+	if args.Has(2) {
+		period, err = timeutil.ParseDuration(args.Get(2), 'h')
+
+		if err != nil || period <= 0 {
+			terminal.Error("Can't parse period %q", args.Get(2))
+			return EC_ERROR
+		}
+	}
+
+	now := time.Now()
+	points, resolution, err := CORE.GetHistory(name, metric, now.Add(-period))
+
+	if err != nil {
+		terminal.Error(err)
+
+		if !CORE.IsHistoryEnabled() {
+			terminal.Warn("Metrics history collecting is disabled (%s)", CORE.HISTORY_ENABLED)
+		}
+
+		return EC_ERROR
+	}
+
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" || useRawOutput {
+		renderStatsHistory(points, format)
+		return EC_OK
+	}
+
+	if len(points) == 0 {
+		terminal.Warn("There is no data for the last %s", timeutil.PrettyDuration(period))
+		return EC_WARN
+	}
+
+	printStatsHistory(name, metric, points, resolution, now.Add(-period), now)
+
+	return EC_OK
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// printStatsHistory prints metric history as sparkline with summary
+func printStatsHistory(name, metric string, points []CORE.HistoryPoint, resolution time.Duration, start, end time.Time) {
+	var min, max, sum float64
+
+	min = points[0].Value
+
+	for _, point := range points {
+		min = mathutil.Min(min, point.Value)
+		max = mathutil.Max(max, point.Value)
+		sum += point.Value
+	}
+
+	source := "Instance " + name
+
+	if name == CORE.HISTORY_NODE {
+		source = "Node"
+	}
+
+	fmtc.Printf(
+		"{*}%s{!} {s}|{!} {*}%s{!} {s}|{!} %s — %s {s-}(resolution: %s){!}\n\n",
+		source, metric,
+		timeutil.Format(start, "%Y/%m/%d %H:%M"),
+		timeutil.Format(end, "%Y/%m/%d %H:%M"),
+		timeutil.PrettyDuration(resolution),
+	)
+
+	width := mathutil.Between(tty.GetWidth()-4, 20, 240)
+	slots := int(end.Sub(start) / resolution)
+
+	if slots > 0 && slots < width {
+		width = slots
+	}
+
+	fmtc.Printf("  {c}%s{!}\n", renderSparkline(points, start, end, width, min, max))
+	startLabel := timeutil.Format(start, "%m/%d %H:%M")
+	endLabel := timeutil.Format(end, "%m/%d %H:%M")
+
+	fmtc.Printf(
+		"  {s-}%s%s%s{!}\n\n", startLabel,
+		strings.Repeat(" ", mathutil.Max(width-len(startLabel)-len(endLabel), 1)),
+		endLabel,
+	)
+
+	fmtc.Printf(
+		"  {*}Min:{!} %s {s}|{!} {*}Avg:{!} %s {s}|{!} {*}Max:{!} %s {s}|{!} {*}Last:{!} %s {s-}(%s){!}\n",
+		formatHistoryValue(metric, min),
+		formatHistoryValue(metric, sum/float64(len(points))),
+		formatHistoryValue(metric, max),
+		formatHistoryValue(metric, points[len(points)-1].Value),
+		timeutil.Format(points[len(points)-1].Date, "%Y/%m/%d %H:%M:%S"),
+	)
+}
+
+// renderStatsHistory prints metric history in given format
+func renderStatsHistory(points []CORE.HistoryPoint, format string) {
+	data := newOutputList("history", "point", "timestamp", "value")
+
+	for _, point := range points {
+		data.Add(point.Date.Unix(), point.Value)
+	}
+
+	data.Render(format)
+}
+
+// renderSparkline renders sparkline with given width. Every symbol contains
+// average value of all points in given period of time.
+func renderSparkline(points []CORE.HistoryPoint, start, end time.Time, width int, min, max float64) string {
+	sums := make([]float64, width)
+	counts := make([]int, width)
+	step := end.Sub(start) / time.Duration(width)
+
+	for _, point := range points {
+		index := mathutil.Between(int(point.Date.Sub(start)/step), 0, width-1)
+		sums[index] += point.Value
+		counts[index]++
+	}
+
+	var result strings.Builder
+
+	for i := 0; i < width; i++ {
+		if counts[i] == 0 {
+			result.WriteRune(' ')
+			continue
+		}
+
+		level := 0
+
+		if max > min {
+			avg := sums[i] / float64(counts[i])
+			level = int((avg - min) / (max - min) * float64(len(sparklineSymbols)-1))
+		}
+
+		result.WriteRune(sparklineSymbols[level])
+	}
+
+	return result.String()
+}
+
+// formatHistoryValue formats metric value
+func formatHistoryValue(metric string, value float64) string {
+	switch metric {
+	case "mem", "rss", "swap":
+		return fmtutil.PrettySize(value)
+	case "input", "output":
+		return fmtutil.PrettySize(value) + "/s"
+	case "cpu":
+		return fmt.Sprintf("%g%%", fmtutil.Float(value))
+	}
+
+	return fmtutil.PrettyNum(value)
+}
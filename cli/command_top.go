@@ -9,6 +9,8 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -41,7 +43,9 @@ type topItem struct {
 type topItems []topItem
 
 type topDump struct {
-	Data []*topDumpItem `json:"data"`
+	Date     time.Time      `json:"date"`
+	Hostname string         `json:"hostname"`
+	Data     []*topDumpItem `json:"data"`
 }
 
 type topDumpItem struct {
@@ -49,6 +53,52 @@ type topDumpItem struct {
 	Info [][2]string `json:"info"`
 }
 
+// topSnapshot contains field values of all instances at some moment
+type topSnapshot struct {
+	Date     time.Time // Zero for live data and old dumps without date
+	Hostname string
+	Values   map[int]float64
+	IsFloat  bool
+}
+
+// topDiff contains result of comparison of top snapshots
+type topDiff struct {
+	Items     []*topDiffItem
+	Start     time.Time
+	End       time.Time
+	BaseNode  string // Only for comparison of different nodes
+	Node      string // Only for comparison of different nodes
+	Snapshots int
+	HasRate   bool
+	IsFloat   bool
+}
+
+// topDiffItem contains result of comparison for one instance
+type topDiffItem struct {
+	ID     int
+	First  float64
+	Last   float64
+	Change float64
+	Rate   float64 // Change per second
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// topOutputFields is a list of fields for machine-readable output of top command
+var topOutputFields = []string{"rank", "id", "field", "value", "description"}
+
+// topDiffOutputFields is a list of fields for machine-readable output of top-diff
+// command
+var topDiffOutputFields = []string{
+	"rank", "id", "field", "first", "last", "change", "rate", "description",
+}
+
+// topDiffNodesOutputFields is a list of fields for machine-readable output of
+// top-diff command for dumps from different nodes
+var topDiffNodesOutputFields = []string{
+	"rank", "id", "field", "base_node", "base_value", "node", "value", "diff", "description",
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 func (s topItems) Len() int           { return len(s) }
@@ -59,8 +109,15 @@ func (s topItems) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 // TopCommand is "top" command handler
 func TopCommand(args CommandArgs) int {
+	format := options.GetS(OPT_FORMAT)
+
 	if !CORE.HasInstances() {
-		terminal.Warn("No instances are created")
+		if format != "" {
+			newOutputList("top", "item", topOutputFields...).Render(format)
+		} else {
+			terminal.Warn("No instances are created")
+		}
+
 		return EC_WARN
 	}
 
@@ -73,7 +130,12 @@ func TopCommand(args CommandArgs) int {
 	}
 
 	if len(items) == 0 {
-		terminal.Warn("All instances are stopped")
+		if format != "" {
+			newOutputList("top", "item", topOutputFields...).Render(format)
+		} else {
+			terminal.Warn("All instances are stopped")
+		}
+
 		return EC_OK
 	}
 
@@ -89,7 +151,11 @@ func TopCommand(args CommandArgs) int {
 		}
 	}
 
-	printTopInfo(items, resultNum, false)
+	if format != "" {
+		renderTopInfo(items, field, resultNum, format)
+	} else {
+		printTopInfo(items, resultNum, false)
+	}
 
 	return EC_OK
 }
@@ -136,48 +202,73 @@ func TopDumpCommand(args CommandArgs) int {
 
 // TopDiffCommand is "top-diff" command handler
 func TopDiffCommand(args CommandArgs) int {
-	var err error
+	var dumpFiles []string
 
-	if !CORE.HasInstances() {
-		terminal.Warn("No instances are created")
-		return EC_WARN
+	for len(args) != 0 && strings.HasSuffix(args.Get(0), ".gz") {
+		dumpFiles = append(dumpFiles, args.Get(0))
+		args = args[1:]
 	}
 
-	if !args.Has(0) {
+	if len(dumpFiles) == 0 {
 		terminal.Warn("You must define path to dump")
 		return EC_WARN
 	}
 
-	dumpFile := args.Get(0)
-	err = fsutil.ValidatePerms("FRS", dumpFile)
+	field, resultNum, reverse := parseTopCommandArguments(args)
+	snapshots, err := readTopSnapshots(dumpFiles, field)
 
 	if err != nil {
 		terminal.Error(err)
 		return EC_ERROR
 	}
 
-	topDump, err := readTopDump(dumpFile)
+	// Single dump is compared with current data
+	if len(snapshots) == 1 {
+		if !CORE.HasInstances() {
+			terminal.Warn("No instances are created")
+			return EC_WARN
+		}
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
+		snapshot, err := collectTopSnapshot(field)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		snapshots = append(snapshots, snapshot)
 	}
 
-	field, resultNum, reverse := parseTopCommandArguments(args[1:])
-	items, err := collectTopData(field)
+	diff, err := compareTopSnapshots(snapshots)
 
 	if err != nil {
 		terminal.Error(err)
 		return EC_ERROR
 	}
 
+	diff.Sort(reverse)
+
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" {
+		renderTopDiff(diff, field, resultNum, format)
+		return EC_OK
+	}
+
+	if len(diff.Items) == 0 {
+		terminal.Warn("There is no data to compare")
+		return EC_WARN
+	}
+
 	if (options.GetB(OPT_PAGER) || prefs.AutoPaging) && !useRawOutput {
 		if pager.Setup() == nil {
 			defer pager.Complete()
 		}
 	}
 
-	return printTopDiff(items, topDump.Data, field, resultNum, reverse)
+	printTopDiff(diff, resultNum)
+
+	return EC_OK
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -303,7 +394,8 @@ func collectTopCPUInfo(field string) (topItems, error) {
 
 // collectTopDump collect data for dump
 func collectTopDump() *topDump {
-	dump := &topDump{}
+	dump := &topDump{Date: time.Now()}
+	dump.Hostname, _ = os.Hostname()
 
 	for _, id := range CORE.GetInstanceIDList() {
 		state, err := CORE.GetInstanceState(id, false)
@@ -371,64 +463,442 @@ func printTopInfo(items topItems, resultNum int, diff bool) {
 	}
 }
 
-// printTopDiff prints top data diff
-func printTopDiff(curTop topItems, dumpData []*topDumpItem, field string, resultNum int, reverse bool) int {
-	diff, err := diffTopData(field, curTop, dumpData)
+// renderTopInfo prints items from top in given format
+func renderTopInfo(items topItems, field string, resultNum int, format string) {
+	data := newOutputList("top", "item", topOutputFields...)
 
-	if err != nil {
-		terminal.Error(err)
-		return EC_ERROR
+	for i, item := range items {
+		var value any = int64(item.Value)
+		var desc string
+
+		if item.IsFloat {
+			value = item.Value
+		}
+
+		meta, err := CORE.GetInstanceMeta(item.ID)
+
+		if err == nil {
+			desc = meta.Desc
+		}
+
+		data.Add(i+1, item.ID, field, value, desc)
+
+		if i == resultNum-1 {
+			break
+		}
 	}
 
-	if len(diff) == 0 {
-		terminal.Warn("There is no data to compare")
-		return EC_WARN
+	data.Render(format)
+}
+
+// printTopDiff prints result of comparison of top snapshots
+func printTopDiff(diff *topDiff, resultNum int) {
+	if diff.BaseNode == "" && !diff.HasRate {
+		items := make(topItems, 0, len(diff.Items))
+
+		for _, item := range diff.Items {
+			items = append(items, topItem{item.ID, item.Change, diff.IsFloat})
+		}
+
+		printTopInfo(items, resultNum, true)
+		return
 	}
 
-	if reverse {
-		sort.Sort(sort.Reverse(diff))
+	if useRawOutput {
+		for i, item := range diff.Items {
+			fmtc.Printf("%d %s\n", item.ID, formatOutputValue(getTopDiffValue(diff, item.Change)))
+
+			if i == resultNum-1 {
+				break
+			}
+		}
+
+		return
+	}
+
+	var t *table.Table
+
+	if diff.BaseNode != "" {
+		fmtc.Printf("{*}Nodes:{!} %s {s}→{!} %s\n\n", diff.BaseNode, diff.Node)
+		t = table.NewTable("#", strings.ToUpper(diff.BaseNode), strings.ToUpper(diff.Node), "DIFF", "ID", "DESCRIPTION")
+		t.SetAlignments(table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT)
 	} else {
-		sort.Sort(diff)
+		fmtc.Printf(
+			"{*}Period:{!} %s — %s {s-}(%s, %d snapshots){!}\n\n",
+			timeutil.Format(diff.Start, "%Y/%m/%d %H:%M:%S"),
+			timeutil.Format(diff.End, "%Y/%m/%d %H:%M:%S"),
+			timeutil.PrettyDuration(diff.End.Sub(diff.Start)), diff.Snapshots,
+		)
+		t = table.NewTable("#", "CHANGE", "PER SEC", "PER HOUR", "ID", "DESCRIPTION")
+		t.SetAlignments(table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT)
 	}
 
-	printTopInfo(diff, resultNum, true)
+	for i, item := range diff.Items {
+		var desc string
 
-	return EC_OK
+		meta, err := CORE.GetInstanceMeta(item.ID)
+
+		if err == nil {
+			desc = meta.Desc
+		} else {
+			desc = "{s-}--------{!}"
+		}
+
+		if diff.BaseNode != "" {
+			t.Add(
+				fmt.Sprintf("{s}%d{!}", i+1), formatTopDiffValue(item.First, false),
+				formatTopDiffValue(item.Last, false), formatTopDiffValue(item.Change, true),
+				item.ID, desc,
+			)
+		} else {
+			t.Add(
+				fmt.Sprintf("{s}%d{!}", i+1), formatTopDiffValue(item.Change, true),
+				formatTopDiffValue(item.Rate, true), formatTopDiffValue(item.Rate*3600, true),
+				item.ID, desc,
+			)
+		}
+
+		if i == resultNum-1 {
+			break
+		}
+	}
+
+	t.Render()
+}
+
+// renderTopDiff prints result of comparison of top snapshots in given format
+func renderTopDiff(diff *topDiff, field string, resultNum int, format string) {
+	var data *outputData
+
+	if diff.BaseNode != "" {
+		data = newOutputList("top", "item", topDiffNodesOutputFields...)
+	} else {
+		data = newOutputList("top", "item", topDiffOutputFields...)
+	}
+
+	for i, item := range diff.Items {
+		var desc string
+
+		meta, err := CORE.GetInstanceMeta(item.ID)
+
+		if err == nil {
+			desc = meta.Desc
+		}
+
+		switch {
+		case diff.BaseNode != "":
+			data.Add(
+				i+1, item.ID, field, diff.BaseNode, getTopDiffValue(diff, item.First),
+				diff.Node, getTopDiffValue(diff, item.Last),
+				getTopDiffValue(diff, item.Change), desc,
+			)
+		case diff.HasRate:
+			data.Add(
+				i+1, item.ID, field, getTopDiffValue(diff, item.First),
+				getTopDiffValue(diff, item.Last), getTopDiffValue(diff, item.Change),
+				item.Rate, desc,
+			)
+		default:
+			data.Add(
+				i+1, item.ID, field, getTopDiffValue(diff, item.First),
+				getTopDiffValue(diff, item.Last), getTopDiffValue(diff, item.Change),
+				nil, desc,
+			)
+		}
+
+		if i == resultNum-1 {
+			break
+		}
+	}
+
+	data.Render(format)
+}
+
+// readTopSnapshots reads dumps and extracts values of given field
+func readTopSnapshots(files []string, field string) ([]*topSnapshot, error) {
+	var result []*topSnapshot
+
+	for _, file := range files {
+		err := fsutil.ValidatePerms("FRS", file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dump, err := readTopDump(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read dump %s: %w", file, err)
+		}
+
+		snapshot, err := getTopDumpSnapshot(dump, field)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, snapshot)
+	}
+
+	return result, nil
 }
 
-// diffTopData compares top data
-func diffTopData(field string, curTop topItems, dumpData []*topDumpItem) (topItems, error) {
-	result := make(topItems, 0)
+// getTopDumpSnapshot extracts values of given field from dump
+func getTopDumpSnapshot(dump *topDump, field string) (*topSnapshot, error) {
+	snapshot := &topSnapshot{
+		Date:     dump.Date,
+		Hostname: dump.Hostname,
+		Values:   make(map[int]float64),
+	}
 
 	switch field {
 	case "keys", "expires":
 		field += "_total"
 	}
 
-	for _, c := range curTop {
-		v := findDumpInfo(dumpData, c.ID, field)
+	for _, item := range dump.Data {
+		v := findDumpInfo(dump.Data, item.ID, field)
 
 		if v == "" {
 			continue
 		}
 
 		v = strings.Trim(v, "%")
-		vf, err := strconv.ParseFloat(v, 64)
+		value, err := strconv.ParseFloat(v, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Field \"%s\" has an unsupported type", field)
+		}
+
+		snapshot.Values[item.ID] = value
+		snapshot.IsFloat = snapshot.IsFloat || strings.Contains(v, ".")
+	}
+
+	return snapshot, nil
+}
+
+// collectTopSnapshot collects current values of given field
+func collectTopSnapshot(field string) (*topSnapshot, error) {
+	items, err := collectTopData(field)
+
+	if err != nil {
+		return nil, err
+	}
 
-		diff := c.Value - vf
+	snapshot := &topSnapshot{
+		Date:   time.Now(),
+		Values: make(map[int]float64),
+	}
+
+	snapshot.Hostname, _ = os.Hostname()
+
+	for _, item := range items {
+		snapshot.Values[item.ID] = item.Value
+		snapshot.IsFloat = snapshot.IsFloat || item.IsFloat
+	}
+
+	return snapshot, nil
+}
+
+// compareTopSnapshots compares snapshots. Snapshots from different nodes are
+// compared with each other, snapshots from the same node are used for calculating
+// change rate.
+func compareTopSnapshots(snapshots []*topSnapshot) (*topDiff, error) {
+	var nodes []string
+
+	for _, snapshot := range snapshots {
+		if snapshot.Hostname != "" && !slices.Contains(nodes, snapshot.Hostname) {
+			nodes = append(nodes, snapshot.Hostname)
+		}
+	}
+
+	switch len(nodes) {
+	case 0, 1:
+		return compareTopSnapshotsByTime(snapshots), nil
+	case 2:
+		return compareTopSnapshotsByNode(snapshots, nodes[0], nodes[1]), nil
+	}
+
+	return nil, fmt.Errorf("Dumps from more than 2 nodes can't be compared")
+}
+
+// compareTopSnapshotsByTime calculates change and change rate for every instance
+func compareTopSnapshotsByTime(snapshots []*topSnapshot) *topDiff {
+	diff := &topDiff{Snapshots: len(snapshots), HasRate: true}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Date.Before(snapshots[j].Date)
+	})
+
+	for _, snapshot := range snapshots {
+		diff.IsFloat = diff.IsFloat || snapshot.IsFloat
+
+		if snapshot.Date.IsZero() {
+			diff.HasRate = false
+		}
+	}
+
+	diff.Start = snapshots[0].Date
+	diff.End = snapshots[len(snapshots)-1].Date
+
+	if !diff.End.After(diff.Start) {
+		diff.HasRate = false
+	}
+
+	for _, id := range getTopSnapshotsIDList(snapshots) {
+		var dates []time.Time
+		var values []float64
+
+		for _, snapshot := range snapshots {
+			value, ok := snapshot.Values[id]
+
+			if ok {
+				dates = append(dates, snapshot.Date)
+				values = append(values, value)
+			}
+		}
 
-		if diff == 0 {
+		if len(values) < 2 {
 			continue
 		}
 
-		if err != nil {
-			return nil, fmt.Errorf("Field \"%s\" has an unsupported type", field)
+		item := &topDiffItem{
+			ID:     id,
+			First:  values[0],
+			Last:   values[len(values)-1],
+			Change: values[len(values)-1] - values[0],
+		}
+
+		if diff.HasRate {
+			item.Rate = calculateTopChangeRate(dates, values)
 		}
 
-		result = append(result, topItem{c.ID, diff, strings.Contains(v, ".")})
+		if item.Change == 0 && item.Rate == 0 {
+			continue
+		}
+
+		diff.Items = append(diff.Items, item)
 	}
 
-	return result, nil
+	return diff
+}
+
+// compareTopSnapshotsByNode compares the latest snapshots from two nodes
+func compareTopSnapshotsByNode(snapshots []*topSnapshot, baseNode, node string) *topDiff {
+	var base, target *topSnapshot
+
+	diff := &topDiff{Snapshots: len(snapshots), BaseNode: baseNode, Node: node}
+
+	for _, snapshot := range snapshots {
+		switch snapshot.Hostname {
+		case baseNode:
+			if base == nil || !snapshot.Date.Before(base.Date) {
+				base = snapshot
+			}
+		case node:
+			if target == nil || !snapshot.Date.Before(target.Date) {
+				target = snapshot
+			}
+		}
+	}
+
+	diff.IsFloat = base.IsFloat || target.IsFloat
+
+	for _, id := range getTopSnapshotsIDList(snapshots) {
+		baseValue, hasBase := base.Values[id]
+		value, hasValue := target.Values[id]
+
+		if !hasBase || !hasValue || value == baseValue {
+			continue
+		}
+
+		diff.Items = append(diff.Items, &topDiffItem{
+			ID:     id,
+			First:  baseValue,
+			Last:   value,
+			Change: value - baseValue,
+		})
+	}
+
+	return diff
+}
+
+// Sort sorts comparison result by change rate (if available) or by change
+func (d *topDiff) Sort(reverse bool) {
+	sort.SliceStable(d.Items, func(i, j int) bool {
+		v1, v2 := d.Items[i].Change, d.Items[j].Change
+
+		if d.HasRate {
+			v1, v2 = d.Items[i].Rate, d.Items[j].Rate
+		}
+
+		if reverse {
+			return v1 < v2
+		}
+
+		return v1 > v2
+	})
+}
+
+// getTopSnapshotsIDList returns sorted list of instances IDs from all snapshots
+func getTopSnapshotsIDList(snapshots []*topSnapshot) []int {
+	var result []int
+
+	for _, snapshot := range snapshots {
+		for id := range snapshot.Values {
+			if !slices.Contains(result, id) {
+				result = append(result, id)
+			}
+		}
+	}
+
+	sort.Ints(result)
+
+	return result
+}
+
+// calculateTopChangeRate calculates change rate (per second) using least squares
+// method
+func calculateTopChangeRate(dates []time.Time, values []float64) float64 {
+	var sumX, sumY, sumXY, sumXX float64
+
+	n := float64(len(values))
+
+	for i := range values {
+		x := dates[i].Sub(dates[0]).Seconds()
+		sumX += x
+		sumY += values[i]
+		sumXY += x * values[i]
+		sumXX += x * x
+	}
+
+	d := n*sumXX - sumX*sumX
+
+	if d == 0 {
+		return 0
+	}
+
+	return (n*sumXY - sumX*sumY) / d
+}
+
+// getTopDiffValue returns value for machine-readable output
+func getTopDiffValue(diff *topDiff, value float64) any {
+	if diff.IsFloat {
+		return value
+	}
+
+	return int64(value)
+}
+
+// formatTopDiffValue formats value for table
+func formatTopDiffValue(value float64, withSign bool) string {
+	result := strutil.Exclude(fmtutil.PrettyNum(fmtutil.Float(value)), ".00")
+
+	if withSign && value > 0 {
+		result = "+" + result
+	}
+
+	return result
 }
 
 // findDumpInfo tries to find info in dumped data
@@ -8,19 +8,72 @@ package cli
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/fsutil"
 	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/pager"
 	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/timeutil"
 
 	CORE "github.com/essentialkaos/rds/core"
+	REDIS "github.com/essentialkaos/rds/redis"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// TRACK_RECORD_HEADER is first line of file with recorded metrics
+const TRACK_RECORD_HEADER = "# RDS track recording"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// trackSample contains instance metrics sample
+type trackSample struct {
+	Date    time.Time
+	State   string
+	CPU     float64
+	Clients int
+	Ops     int
+	Mem     int
+	MemRSS  int
+	MemLua  int
+	Swap    int
+	Input   int
+	Output  int
+}
+
+// trackRecording contains recorded instance metrics
+type trackRecording struct {
+	ID       int
+	Interval int
+	Hostname string
+	Samples  []*trackSample
+}
+
+// trackRecorder writes metrics samples to file
+type trackRecorder struct {
+	fd *os.File
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// trackRecordFields is a list of fields of metrics sample
+var trackRecordFields = []string{
+	"timestamp", "state", "cpu", "clients", "ops", "mem",
+	"mem_rss", "mem_lua", "swap", "input", "output",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // TrackCommand is "track" command handler
 func TrackCommand(args CommandArgs) int {
 	err := args.Check(false)
@@ -50,13 +103,71 @@ func TrackCommand(args CommandArgs) int {
 		interval = mathutil.Between(interval, 1, 300)
 	}
 
-	return showInteractiveInfo(id, interval)
+	var recorder *trackRecorder
+
+	if options.Has(OPT_RECORD) {
+		output := options.GetS(OPT_RECORD)
+
+		if strings.Contains(output, "%") {
+			output = timeutil.Format(time.Now(), output)
+		}
+
+		recorder, err = createTrackRecorder(output, id, interval)
+
+		if err != nil {
+			terminal.Error(err)
+			return EC_ERROR
+		}
+
+		defer recorder.Close()
+
+		fmtc.Printf("{s-}Metrics will be recorded to %s{!}\n", output)
+	}
+
+	return showInteractiveInfo(id, interval, recorder)
+}
+
+// TrackReplayCommand is "track-replay" command handler
+func TrackReplayCommand(args CommandArgs) int {
+	if !args.Has(0) {
+		terminal.Warn("You must define file with recorded metrics")
+		return EC_WARN
+	}
+
+	recording, err := readTrackRecording(args.Get(0))
+
+	if err != nil {
+		terminal.Error(err)
+		return EC_ERROR
+	}
+
+	if len(recording.Samples) == 0 {
+		terminal.Warn("File %s doesn't contain any samples", args.Get(0))
+		return EC_WARN
+	}
+
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" || useRawOutput {
+		renderTrackRecording(recording, format)
+		return EC_OK
+	}
+
+	if options.GetB(OPT_PAGER) || prefs.AutoPaging {
+		if pager.Setup() == nil {
+			defer pager.Complete()
+		}
+	}
+
+	printTrackRecording(recording)
+
+	return EC_OK
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // showInteractiveInfo show interactive instance info
-func showInteractiveInfo(id, interval int) int {
+func showInteractiveInfo(id, interval int, recorder *trackRecorder) int {
 	fmtc.TPrintf("{s-}Preparation…{!}")
 
 	for {
@@ -71,6 +182,14 @@ func showInteractiveInfo(id, interval int) int {
 
 		if !state.IsWorks() {
 			fmtc.TPrintf("State: " + coloredState)
+
+			err = recorder.Write(&trackSample{Date: time.Now(), State: getStateName(state)})
+
+			if err != nil {
+				fmtc.TPrintf("{r}%v{!}\n", err)
+				return EC_ERROR
+			}
+
 			time.Sleep(time.Second * time.Duration(interval))
 			continue
 		}
@@ -91,27 +210,293 @@ func showInteractiveInfo(id, interval int) int {
 			return EC_ERROR
 		}
 
-		usage := calculateInstanceCPUUsage(
-			extractCPUUsageInfo(i1),
-			extractCPUUsageInfo(i2),
-			interval,
-		)
+		sample := getTrackSample(i1, i2, interval)
+		sample.State = getStateName(state)
 
-		cpu := fmt.Sprintf("%g%%", mathutil.Between(fmtutil.Float(usage[0]+usage[1]), 0.0, 100.0))
-		clients := i2.GetI("clients", "connected_clients")
-		mem := i2.GetI("memory", "used_memory")
-		memRSS := i2.GetI("memory", "used_memory_rss")
-		memLua := i2.GetI("memory", "used_memory_lua")
-		swap := i2.GetI("memory", "used_memory_swap")
-		ops := i2.GetI("stats", "instantaneous_ops_per_sec")
-		input := i2.GetF("stats", "instantaneous_input_kbps") * 1024
-		output := i2.GetF("stats", "instantaneous_output_kbps") * 1024
+		err = recorder.Write(sample)
+
+		if err != nil {
+			fmtc.TPrintf("{r}%v{!}\n", err)
+			return EC_ERROR
+		}
 
 		fmtc.TPrintf(
 			"{*}State:{!} "+coloredState+" {s}|{!} {*}Clients:{!} %s {s}|{!} {*}Commands:{!} %s {s}|{!} {*}CPU:{!} %s {s}|{!} {*}Mem:{!} %s/%s {s}|{!} {*}Lua:{!} %s {s}|{!} {*}Swp:{!} %s {s}|{!} {*}In:{!} %s/s {s}|{!} {*}Out:{!} %s/s",
-			fmtutil.PrettyNum(clients), fmtutil.PrettyNum(ops), cpu,
-			fmtutil.PrettySize(mem), fmtutil.PrettySize(memRSS), fmtutil.PrettySize(memLua),
-			fmtutil.PrettySize(swap), fmtutil.PrettySize(input), fmtutil.PrettySize(output),
+			fmtutil.PrettyNum(sample.Clients), fmtutil.PrettyNum(sample.Ops), fmt.Sprintf("%g%%", sample.CPU),
+			fmtutil.PrettySize(sample.Mem), fmtutil.PrettySize(sample.MemRSS), fmtutil.PrettySize(sample.MemLua),
+			fmtutil.PrettySize(sample.Swap), fmtutil.PrettySize(sample.Input), fmtutil.PrettySize(sample.Output),
+		)
+	}
+}
+
+// getTrackSample creates metrics sample from two info snapshots
+func getTrackSample(i1, i2 *REDIS.Info, interval int) *trackSample {
+	usage := calculateInstanceCPUUsage(
+		extractCPUUsageInfo(i1),
+		extractCPUUsageInfo(i2),
+		interval,
+	)
+
+	return &trackSample{
+		Date:    time.Now(),
+		CPU:     mathutil.Between(fmtutil.Float(usage[0]+usage[1]), 0.0, 100.0),
+		Clients: i2.GetI("clients", "connected_clients"),
+		Ops:     i2.GetI("stats", "instantaneous_ops_per_sec"),
+		Mem:     i2.GetI("memory", "used_memory"),
+		MemRSS:  i2.GetI("memory", "used_memory_rss"),
+		MemLua:  i2.GetI("memory", "used_memory_lua"),
+		Swap:    i2.GetI("memory", "used_memory_swap"),
+		Input:   int(i2.GetF("stats", "instantaneous_input_kbps") * 1024),
+		Output:  int(i2.GetF("stats", "instantaneous_output_kbps") * 1024),
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// printTrackRecording prints recorded metrics as a table
+func printTrackRecording(recording *trackRecording) {
+	first := recording.Samples[0].Date
+	last := recording.Samples[len(recording.Samples)-1].Date
+
+	fmtc.Printf(
+		"{*}Instance:{!} %d {s}|{!} {*}Host:{!} %s {s}|{!} {*}Interval:{!} %ds {s}|{!} {*}Period:{!} %s — %s {s-}(%s){!}\n\n",
+		recording.ID, recording.Hostname, recording.Interval,
+		timeutil.Format(first, "%Y/%m/%d %H:%M:%S"),
+		timeutil.Format(last, "%Y/%m/%d %H:%M:%S"),
+		timeutil.PrettyDuration(last.Sub(first)),
+	)
+
+	t := table.NewTable(
+		"TIME", "STATE", "CPU", "CLIENTS", "OPS", "MEMORY",
+		"RSS", "LUA", "SWAP", "INPUT", "OUTPUT",
+	)
+
+	t.SetAlignments(
+		table.ALIGN_LEFT, table.ALIGN_LEFT, table.ALIGN_RIGHT, table.ALIGN_RIGHT,
+		table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT,
+		table.ALIGN_RIGHT, table.ALIGN_RIGHT, table.ALIGN_RIGHT,
+	)
+
+	peak := &trackSample{}
+
+	for _, sample := range recording.Samples {
+		state := getStateColorTag(parseStateName(sample.State)) + sample.State + "{!}"
+
+		t.Add(
+			timeutil.Format(sample.Date, "%m/%d %H:%M:%S"), state,
+			fmt.Sprintf("%g%%", sample.CPU), fmtutil.PrettyNum(sample.Clients),
+			fmtutil.PrettyNum(sample.Ops), fmtutil.PrettySize(sample.Mem),
+			fmtutil.PrettySize(sample.MemRSS), fmtutil.PrettySize(sample.MemLua),
+			fmtutil.PrettySize(sample.Swap), fmtutil.PrettySize(sample.Input)+"/s",
+			fmtutil.PrettySize(sample.Output)+"/s",
+		)
+
+		peak.CPU = mathutil.Max(peak.CPU, sample.CPU)
+		peak.Clients = mathutil.Max(peak.Clients, sample.Clients)
+		peak.Ops = mathutil.Max(peak.Ops, sample.Ops)
+		peak.Mem = mathutil.Max(peak.Mem, sample.Mem)
+		peak.MemRSS = mathutil.Max(peak.MemRSS, sample.MemRSS)
+		peak.MemLua = mathutil.Max(peak.MemLua, sample.MemLua)
+		peak.Swap = mathutil.Max(peak.Swap, sample.Swap)
+		peak.Input = mathutil.Max(peak.Input, sample.Input)
+		peak.Output = mathutil.Max(peak.Output, sample.Output)
+	}
+
+	t.Separator()
+	t.Add(
+		"{*}Peak{!}", "",
+		fmt.Sprintf("%g%%", peak.CPU), fmtutil.PrettyNum(peak.Clients),
+		fmtutil.PrettyNum(peak.Ops), fmtutil.PrettySize(peak.Mem),
+		fmtutil.PrettySize(peak.MemRSS), fmtutil.PrettySize(peak.MemLua),
+		fmtutil.PrettySize(peak.Swap), fmtutil.PrettySize(peak.Input)+"/s",
+		fmtutil.PrettySize(peak.Output)+"/s",
+	)
+
+	t.Render()
+}
+
+// renderTrackRecording prints recorded metrics in given format
+func renderTrackRecording(recording *trackRecording, format string) {
+	data := newOutputList("samples", "sample", trackRecordFields...)
+
+	for _, sample := range recording.Samples {
+		data.Add(
+			sample.Date.Unix(), sample.State, sample.CPU, sample.Clients,
+			sample.Ops, sample.Mem, sample.MemRSS, sample.MemLua, sample.Swap,
+			sample.Input, sample.Output,
 		)
 	}
+
+	data.Render(format)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// createTrackRecorder creates file for recording metrics and writes header to it
+func createTrackRecorder(file string, id, interval int) (*trackRecorder, error) {
+	if fsutil.IsExist(file) {
+		return nil, fmt.Errorf("File %s already exists", file)
+	}
+
+	fd, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create file for recording: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	_, err = fmt.Fprintf(
+		fd, "%s\n# id: %d\n# interval: %d\n# hostname: %s\n# fields: %s\n",
+		TRACK_RECORD_HEADER, id, interval, hostname,
+		strings.Join(trackRecordFields, " "),
+	)
+
+	if err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("Can't write recording header: %w", err)
+	}
+
+	return &trackRecorder{fd}, nil
+}
+
+// Write writes metrics sample to file
+func (r *trackRecorder) Write(sample *trackSample) error {
+	if r == nil {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(
+		r.fd, "%d %s %s %d %d %d %d %d %d %d %d\n",
+		sample.Date.Unix(), sample.State,
+		strconv.FormatFloat(sample.CPU, 'f', -1, 64),
+		sample.Clients, sample.Ops, sample.Mem, sample.MemRSS,
+		sample.MemLua, sample.Swap, sample.Input, sample.Output,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Can't write metrics sample: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes file with recorded metrics
+func (r *trackRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	return r.fd.Close()
+}
+
+// readTrackRecording reads file with recorded metrics
+func readTrackRecording(file string) (*trackRecording, error) {
+	err := fsutil.ValidatePerms("FRS", file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't open file with recorded metrics: %w", err)
+	}
+
+	defer fd.Close()
+
+	recording := &trackRecording{}
+	scanner := bufio.NewScanner(fd)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == 1 && text != TRACK_RECORD_HEADER:
+			return nil, fmt.Errorf("File %s doesn't contain recorded metrics", file)
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#"):
+			parseTrackRecordingHeader(recording, text)
+			continue
+		}
+
+		sample, err := parseTrackSample(text)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't parse line %d: %w", line, err)
+		}
+
+		recording.Samples = append(recording.Samples, sample)
+	}
+
+	if scanner.Err() != nil {
+		return nil, fmt.Errorf("Can't read file with recorded metrics: %w", scanner.Err())
+	}
+
+	return recording, nil
+}
+
+// parseTrackRecordingHeader parses header line and adds info from it to recording
+func parseTrackRecordingHeader(recording *trackRecording, line string) {
+	name, value, ok := strings.Cut(strings.TrimLeft(line, "# "), ": ")
+
+	if !ok {
+		return
+	}
+
+	switch name {
+	case "id":
+		recording.ID, _ = strconv.Atoi(value)
+	case "interval":
+		recording.Interval, _ = strconv.Atoi(value)
+	case "hostname":
+		recording.Hostname = value
+	}
+}
+
+// parseTrackSample parses line with metrics sample
+func parseTrackSample(line string) (*trackSample, error) {
+	values := strings.Fields(line)
+
+	if len(values) != len(trackRecordFields) {
+		return nil, fmt.Errorf("Sample must contain %d values", len(trackRecordFields))
+	}
+
+	timestamp, err := strconv.ParseInt(values[0], 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timestamp %q", values[0])
+	}
+
+	cpu, err := strconv.ParseFloat(values[2], 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CPU usage value %q", values[2])
+	}
+
+	nums := make([]int, len(values)-3)
+
+	for i, value := range values[3:] {
+		nums[i], err = strconv.Atoi(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s value %q", trackRecordFields[i+3], value)
+		}
+	}
+
+	return &trackSample{
+		Date:    time.Unix(timestamp, 0),
+		State:   values[1],
+		CPU:     cpu,
+		Clients: nums[0],
+		Ops:     nums[1],
+		Mem:     nums[2],
+		MemRSS:  nums[3],
+		MemLua:  nums[4],
+		Swap:    nums[5],
+		Input:   nums[6],
+		Output:  nums[7],
+	}, nil
 }
@@ -9,11 +9,13 @@ package cli
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/essentialkaos/ek/v13/fmtutil/table"
 	"github.com/essentialkaos/ek/v13/fsutil"
 	"github.com/essentialkaos/ek/v13/mathutil"
+	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/timeutil"
 
@@ -22,13 +24,30 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// uptimeOutputFields is a list of fields for machine-readable output of uptime command
+var uptimeOutputFields = []string{"id", "state", "uptime", "last_save", "description"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // UptimeCommand is "uptime" command handler
 func UptimeCommand(args CommandArgs) int {
+	format := options.GetS(OPT_FORMAT)
+
 	if !CORE.HasInstances() {
-		terminal.Warn("No instances are created")
+		if format != "" {
+			newOutputList("uptime", "instance", uptimeOutputFields...).Render(format)
+		} else {
+			terminal.Warn("No instances are created")
+		}
+
 		return EC_WARN
 	}
 
+	if format != "" {
+		renderUptimeInfo(format)
+		return EC_OK
+	}
+
 	idList := CORE.GetInstanceIDList()
 	lastID := strconv.Itoa(idList[len(idList)-1])
 	idColumnSize := mathutil.Between(len(lastID), 2, 4)
@@ -87,3 +106,45 @@ func UptimeCommand(args CommandArgs) int {
 
 	return EC_OK
 }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderUptimeInfo prints info about instances uptime in given format
+func renderUptimeInfo(format string) {
+	data := newOutputList("uptime", "instance", uptimeOutputFields...)
+
+	for _, id := range CORE.GetInstanceIDList() {
+		var uptime, lastSave any
+		var desc string
+
+		state, err := CORE.GetInstanceState(id, true)
+
+		if err != nil {
+			state = CORE.INSTANCE_STATE_UNKNOWN
+		}
+
+		meta, err := CORE.GetInstanceMeta(id)
+
+		if err == nil {
+			desc = meta.Desc
+		}
+
+		if state.IsWorks() {
+			modTime, _ := fsutil.GetMTime(CORE.GetInstancePIDFilePath(id))
+
+			if !modTime.IsZero() {
+				uptime = int64(time.Since(modTime).Seconds())
+			}
+		}
+
+		_, saveDate, _ := getInstanceDataInfo(id)
+
+		if !saveDate.IsZero() {
+			lastSave = saveDate.Unix()
+		}
+
+		data.Add(id, strings.ToLower(getStateName(state)), uptime, lastSave, desc)
+	}
+
+	data.Render(format)
+}
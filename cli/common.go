@@ -287,6 +287,30 @@ func getStateName(state CORE.State) string {
 	}
 }
 
+// parseStateName returns state for given state name (reverse for getStateName)
+func parseStateName(name string) CORE.State {
+	switch name {
+	case "Stopped":
+		return CORE.INSTANCE_STATE_STOPPED
+	case "Dead":
+		return CORE.INSTANCE_STATE_DEAD
+	case "Hang":
+		return CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_HANG
+	case "Loading":
+		return CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_LOADING
+	case "Saving":
+		return CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_SAVING
+	case "Syncing":
+		return CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_SYNCING
+	case "Idle":
+		return CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_IDLE
+	case "Active":
+		return CORE.INSTANCE_STATE_WORKS
+	}
+
+	return CORE.INSTANCE_STATE_UNKNOWN
+}
+
 // getInstanceStateWithColor returns state with color tags
 func getInstanceStateWithColor(state CORE.State) string {
 	return getStateColorTag(state) + getStateName(state) + "{!}"
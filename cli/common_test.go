@@ -0,0 +1,45 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	. "github.com/essentialkaos/check"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type CommonSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&CommonSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *CommonSuite) TestStateNames(c *C) {
+	states := []CORE.State{
+		CORE.INSTANCE_STATE_STOPPED,
+		CORE.INSTANCE_STATE_DEAD,
+		CORE.INSTANCE_STATE_WORKS,
+		CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_IDLE,
+		CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_HANG,
+		CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_LOADING,
+		CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_SAVING,
+		CORE.INSTANCE_STATE_WORKS | CORE.INSTANCE_STATE_SYNCING,
+	}
+
+	for _, state := range states {
+		name := getStateName(state)
+		c.Assert(getStateName(parseStateName(name)), Equals, name)
+		c.Assert(getStateColorTag(parseStateName(name)), Equals, getStateColorTag(state))
+	}
+
+	c.Assert(parseStateName("Unknown"), Equals, CORE.INSTANCE_STATE_UNKNOWN)
+}
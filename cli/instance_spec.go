@@ -0,0 +1,370 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/options"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// instanceSpec contains instance properties for non-interactive create and edit
+type instanceSpec struct {
+	Desc            string   `json:"desc" yaml:"desc"`
+	Owner           string   `json:"owner" yaml:"owner"`
+	Password        string   `json:"password" yaml:"password"`
+	ServicePassword string   `json:"service_password" yaml:"service_password"`
+	ReplicationType string   `json:"replication_type" yaml:"replication_type"`
+	TemplateProfile string   `json:"template_profile" yaml:"template_profile"`
+	Tags            []string `json:"tags" yaml:"tags"`
+	DisableSaves    *bool    `json:"disable_saves" yaml:"disable_saves"`
+	Secure          bool     `json:"secure" yaml:"secure"`
+	MemoryMax       string   `json:"memory_max" yaml:"memory_max"`
+	CPUMax          *int     `json:"cpu_max" yaml:"cpu_max"`
+	IOWeight        *int     `json:"io_weight" yaml:"io_weight"`
+}
+
+// instanceSpecResult contains info about created or modified instance
+type instanceSpecResult struct {
+	ID              int      `json:"id"`
+	UUID            string   `json:"uuid"`
+	Port            int      `json:"port"`
+	TLSPort         int      `json:"tls_port,omitempty"`
+	Owner           string   `json:"owner"`
+	Desc            string   `json:"desc"`
+	ReplicationType string   `json:"replication_type"`
+	TemplateProfile string   `json:"template_profile,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	IsSaveDisabled  bool     `json:"is_save_disabled"`
+	Password        string   `json:"password,omitempty"`
+	ServicePassword string   `json:"service_password,omitempty"`
+	Changes         []string `json:"changes,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isNonInteractiveInput returns true if instance properties are defined using
+// options or stdin
+func isNonInteractiveInput() bool {
+	return options.GetB(OPT_STDIN) || options.Has(OPT_DESC) ||
+		options.Has(OPT_OWNER) || options.Has(OPT_PASSWORD) ||
+		options.Has(OPT_SERVICE_PASS) || options.Has(OPT_REPLICATION) ||
+		options.Has(OPT_PROFILE)
+}
+
+// readInstanceSpec reads instance spec from stdin (JSON) and options. Values
+// from options override values from stdin.
+func readInstanceSpec() (*instanceSpec, error) {
+	spec := &instanceSpec{}
+
+	if options.GetB(OPT_STDIN) {
+		err := json.NewDecoder(os.Stdin).Decode(spec)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't decode instance spec from stdin: %v", err)
+		}
+	}
+
+	if options.Has(OPT_DESC) {
+		spec.Desc = options.GetS(OPT_DESC)
+	}
+
+	if options.Has(OPT_OWNER) {
+		spec.Owner = options.GetS(OPT_OWNER)
+	}
+
+	if options.Has(OPT_PASSWORD) {
+		spec.Password = options.GetS(OPT_PASSWORD)
+	}
+
+	if options.Has(OPT_SERVICE_PASS) {
+		spec.ServicePassword = options.GetS(OPT_SERVICE_PASS)
+	}
+
+	if options.Has(OPT_REPLICATION) {
+		spec.ReplicationType = options.GetS(OPT_REPLICATION)
+	}
+
+	if options.Has(OPT_PROFILE) {
+		spec.TemplateProfile = options.GetS(OPT_PROFILE)
+	}
+
+	if options.Has(OPT_TAGS) {
+		spec.Tags = strings.Split(options.GetS(OPT_TAGS), ",")
+	}
+
+	if options.GetB(OPT_DISABLE_SAVES) {
+		spec.DisableSaves = new(bool)
+		*spec.DisableSaves = true
+	}
+
+	if options.GetB(OPT_SECURE) {
+		spec.Secure = true
+	}
+
+	spec.readLimitsOptions()
+
+	return spec, nil
+}
+
+// hasLimitsOptions returns true if resource limits are defined using options
+func hasLimitsOptions() bool {
+	return options.Has(OPT_MEMORY_MAX) || options.Has(OPT_CPU_MAX) || options.Has(OPT_IO_WEIGHT)
+}
+
+// readLimitsOptions reads resource limits from options
+func (s *instanceSpec) readLimitsOptions() {
+	if options.Has(OPT_MEMORY_MAX) {
+		s.MemoryMax = options.GetS(OPT_MEMORY_MAX)
+	}
+
+	if options.Has(OPT_CPU_MAX) {
+		s.CPUMax = new(int)
+		*s.CPUMax = options.GetI(OPT_CPU_MAX)
+	}
+
+	if options.Has(OPT_IO_WEIGHT) {
+		s.IOWeight = new(int)
+		*s.IOWeight = options.GetI(OPT_IO_WEIGHT)
+	}
+}
+
+// Validate validates instance spec
+func (s *instanceSpec) Validate(isCreate bool) error {
+	var err error
+
+	if isCreate && s.Desc == "" {
+		return fmt.Errorf("Instance description is required")
+	}
+
+	s.Desc, err = inputValidatorDesc{}.Validate(s.Desc)
+
+	if err != nil {
+		return err
+	}
+
+	s.Owner, err = inputValidatorOwner{}.Validate(s.Owner)
+
+	if err != nil {
+		return err
+	}
+
+	s.Password, err = inputValidatorPassword{}.Validate(s.Password)
+
+	if err != nil {
+		return fmt.Errorf("Invalid instance password: %v", err)
+	}
+
+	s.ServicePassword, err = inputValidatorPassword{}.Validate(s.ServicePassword)
+
+	if err != nil {
+		return fmt.Errorf("Invalid service password: %v", err)
+	}
+
+	switch strings.ToLower(s.ReplicationType) {
+	case "":
+		if isCreate {
+			s.ReplicationType = CORE.Config.GetS(CORE.REPLICATION_DEFAULT_ROLE)
+		}
+	case string(CORE.REPL_TYPE_REPLICA), "r":
+		s.ReplicationType = string(CORE.REPL_TYPE_REPLICA)
+	case string(CORE.REPL_TYPE_STANDBY), "s":
+		s.ReplicationType = string(CORE.REPL_TYPE_STANDBY)
+	default:
+		return fmt.Errorf("Unsupported replication type %q (\"replica\" or \"standby\")", s.ReplicationType)
+	}
+
+	if s.TemplateProfile != "" && !CORE.IsTemplateProfileExist(s.TemplateProfile) {
+		return fmt.Errorf("Template profile %q doesn't exist", s.TemplateProfile)
+	}
+
+	if len(s.Tags) > CORE.MAX_TAGS {
+		return fmt.Errorf("Max number of tags (%d) reached", CORE.MAX_TAGS)
+	}
+
+	for _, tag := range s.Tags {
+		if !CORE.IsValidTag(tag) {
+			return fmt.Errorf("Tag %s has the wrong format", tag)
+		}
+	}
+
+	return s.validateLimits()
+}
+
+// validateLimits validates resource limits. Zero value of any limit means that
+// global limit from configuration file will be used.
+func (s *instanceSpec) validateLimits() error {
+	if s.MemoryMax != "" {
+		memoryMax := fmtutil.ParseSize(s.MemoryMax)
+
+		switch {
+		case memoryMax == 0 && strings.Trim(s.MemoryMax, "0") != "":
+			return fmt.Errorf("Invalid memory limit %q", s.MemoryMax)
+		case memoryMax != 0 && memoryMax < CORE.MIN_MEMORY_MAX:
+			return fmt.Errorf("Memory limit can't be less than %s", fmtutil.PrettySize(CORE.MIN_MEMORY_MAX))
+		}
+	}
+
+	if s.CPUMax != nil && *s.CPUMax != 0 && (*s.CPUMax < CORE.MIN_CPU_MAX || *s.CPUMax > CORE.MAX_CPU_MAX) {
+		return fmt.Errorf("CPU limit must be in range %d-%d", CORE.MIN_CPU_MAX, CORE.MAX_CPU_MAX)
+	}
+
+	if s.IOWeight != nil && *s.IOWeight != 0 && (*s.IOWeight < CORE.MIN_IO_WEIGHT || *s.IOWeight > CORE.MAX_IO_WEIGHT) {
+		return fmt.Errorf("IO weight must be in range %d-%d", CORE.MIN_IO_WEIGHT, CORE.MAX_IO_WEIGHT)
+	}
+
+	return nil
+}
+
+// setLimits sets resource limits from spec and returns list of changes
+func (s *instanceSpec) setLimits(prefs *CORE.InstancePreferencies) []string {
+	var changes []string
+
+	if s.MemoryMax != "" {
+		memoryMax := fmtutil.ParseSize(s.MemoryMax)
+
+		if memoryMax != prefs.MemoryMax {
+			changes = append(changes, fmt.Sprintf(
+				"memory limit %s → %s", formatLimit(prefs.MemoryMax, true), formatLimit(memoryMax, true),
+			))
+			prefs.MemoryMax = memoryMax
+		}
+	}
+
+	if s.CPUMax != nil && *s.CPUMax != prefs.CPUMax {
+		changes = append(changes, fmt.Sprintf(
+			"CPU limit %s → %s", formatLimit(uint64(prefs.CPUMax), false), formatLimit(uint64(*s.CPUMax), false),
+		))
+		prefs.CPUMax = *s.CPUMax
+	}
+
+	if s.IOWeight != nil && *s.IOWeight != prefs.IOWeight {
+		changes = append(changes, fmt.Sprintf(
+			"IO weight %s → %s", formatLimit(uint64(prefs.IOWeight), false), formatLimit(uint64(*s.IOWeight), false),
+		))
+		prefs.IOWeight = *s.IOWeight
+	}
+
+	return changes
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// formatLimit formats resource limit value
+func formatLimit(value uint64, isSize bool) string {
+	switch {
+	case value == 0:
+		return "default"
+	case isSize:
+		return fmtutil.PrettySize(value)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// newInstanceSpecResult creates result struct for given instance meta
+func newInstanceSpecResult(meta *CORE.InstanceMeta) *instanceSpecResult {
+	result := &instanceSpecResult{
+		ID:              meta.ID,
+		UUID:            meta.UUID,
+		Port:            CORE.GetInstancePort(meta.ID),
+		Owner:           meta.Auth.User,
+		Desc:            meta.Desc,
+		ReplicationType: string(meta.Preferencies.ReplicationType),
+		TemplateProfile: meta.Preferencies.TemplateProfile,
+		Tags:            meta.Tags,
+		IsSaveDisabled:  meta.Preferencies.IsSaveDisabled,
+	}
+
+	if CORE.IsTLSEnabled() {
+		result.TLSPort = CORE.GetInstanceTLSPort(meta.ID)
+	}
+
+	return result
+}
+
+// renderInstanceSpecResult prints result in given format
+func renderInstanceSpecResult(result *instanceSpecResult, format string) {
+	switch format {
+	case FORMAT_JSON:
+		jsonData, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(jsonData))
+
+	case FORMAT_CSV:
+		fields := getInstanceSpecResultFields(result)
+		data := newOutputRecord("instance")
+		values := make([]any, len(fields))
+
+		for i, field := range fields {
+			data.fields = append(data.fields, field[0])
+			values[i] = field[1]
+		}
+
+		data.Add(values...).Render(FORMAT_CSV)
+
+	case FORMAT_XML:
+		fmt.Println("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>")
+		fmt.Println("<instance>")
+
+		for _, field := range getInstanceSpecResultFields(result) {
+			fmt.Printf("  <%s>%s</%s>\n", field[0], html.EscapeString(field[1]), field[0])
+		}
+
+		fmt.Println("</instance>")
+
+	default:
+		for _, field := range getInstanceSpecResultFields(result) {
+			fmt.Printf("%s %s\n", field[0], field[1])
+		}
+	}
+}
+
+// getInstanceSpecResultFields returns slice with result fields names and values
+func getInstanceSpecResultFields(result *instanceSpecResult) [][2]string {
+	fields := [][2]string{
+		{"id", fmt.Sprint(result.ID)},
+		{"uuid", result.UUID},
+		{"port", fmt.Sprint(result.Port)},
+	}
+
+	if result.TLSPort != 0 {
+		fields = append(fields, [2]string{"tls_port", fmt.Sprint(result.TLSPort)})
+	}
+
+	fields = append(fields,
+		[2]string{"owner", result.Owner},
+		[2]string{"desc", result.Desc},
+		[2]string{"replication_type", result.ReplicationType},
+		[2]string{"template_profile", result.TemplateProfile},
+		[2]string{"tags", strings.Join(result.Tags, ",")},
+		[2]string{"is_save_disabled", fmt.Sprint(result.IsSaveDisabled)},
+	)
+
+	if result.Password != "" {
+		fields = append(fields, [2]string{"password", result.Password})
+	}
+
+	if result.ServicePassword != "" {
+		fields = append(fields, [2]string{"service_password", result.ServicePassword})
+	}
+
+	for _, change := range result.Changes {
+		fields = append(fields, [2]string{"change", change})
+	}
+
+	return fields
+}
@@ -0,0 +1,229 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// outputData contains structured data for machine-readable output
+type outputData struct {
+	name   string // name of root node
+	item   string // name of item node (empty for single record)
+	fields []string
+	rows   [][]any
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newOutputList creates new structured data with list of records
+func newOutputList(name, item string, fields ...string) *outputData {
+	return &outputData{name: name, item: item, fields: fields}
+}
+
+// newOutputRecord creates new structured data with single record
+func newOutputRecord(name string, fields ...string) *outputData {
+	return &outputData{name: name, fields: fields}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Add adds new record
+func (d *outputData) Add(values ...any) *outputData {
+	d.rows = append(d.rows, values)
+
+	return d
+}
+
+// HasData returns true if output contains at least one record
+func (d *outputData) HasData() bool {
+	return len(d.rows) != 0
+}
+
+// Render prints data in given format
+func (d *outputData) Render(format string) {
+	switch format {
+	case FORMAT_JSON:
+		d.renderJSON()
+	case FORMAT_XML:
+		d.renderXML()
+	case FORMAT_CSV:
+		d.renderCSV()
+	default:
+		d.renderText()
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isRecord returns true if data is a single record
+func (d *outputData) isRecord() bool {
+	return d.item == ""
+}
+
+// renderText prints data in text format ("key value" for record and
+// tab-separated values for list)
+func (d *outputData) renderText() {
+	if d.isRecord() {
+		if !d.HasData() {
+			return
+		}
+
+		for i, field := range d.fields {
+			fmt.Printf("%s %s\n", field, formatOutputValue(d.rows[0][i]))
+		}
+
+		return
+	}
+
+	for _, row := range d.rows {
+		values := make([]string, len(row))
+
+		for i, value := range row {
+			values[i] = formatOutputValue(value)
+		}
+
+		fmt.Println(strings.Join(values, "\t"))
+	}
+}
+
+// renderJSON prints data in JSON format
+func (d *outputData) renderJSON() {
+	var buf, result bytes.Buffer
+
+	if d.isRecord() {
+		if d.HasData() {
+			d.writeJSONObject(&buf, d.rows[0])
+		} else {
+			buf.WriteString("{}")
+		}
+	} else {
+		buf.WriteString("[")
+
+		for i, row := range d.rows {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			d.writeJSONObject(&buf, row)
+		}
+
+		buf.WriteString("]")
+	}
+
+	err := json.Indent(&result, buf.Bytes(), "", "  ")
+
+	if err != nil {
+		fmt.Println(buf.String())
+		return
+	}
+
+	fmt.Println(result.String())
+}
+
+// writeJSONObject writes record as JSON object with fields in defined order
+func (d *outputData) writeJSONObject(buf *bytes.Buffer, row []any) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteString("{")
+
+	for i, field := range d.fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		buf.WriteString(strconv.Quote(field) + ":")
+
+		if enc.Encode(row[i]) != nil {
+			buf.WriteString("null")
+		}
+	}
+
+	buf.WriteString("}")
+}
+
+// renderXML prints data in XML format
+func (d *outputData) renderXML() {
+	fmt.Println("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>")
+
+	if !d.HasData() {
+		fmt.Printf("<%s></%s>\n", d.name, d.name)
+		return
+	}
+
+	fmt.Printf("<%s>\n", d.name)
+
+	if d.isRecord() {
+		d.writeXMLFields(d.rows[0], "  ")
+	} else {
+		for _, row := range d.rows {
+			fmt.Printf("  <%s>\n", d.item)
+			d.writeXMLFields(row, "    ")
+			fmt.Printf("  </%s>\n", d.item)
+		}
+	}
+
+	fmt.Printf("</%s>\n", d.name)
+}
+
+// writeXMLFields prints record fields as XML nodes
+func (d *outputData) writeXMLFields(row []any, indent string) {
+	for i, field := range d.fields {
+		fmt.Printf(
+			"%s<%s>%s</%s>\n", indent, field,
+			html.EscapeString(formatOutputValue(row[i])), field,
+		)
+	}
+}
+
+// renderCSV prints data in CSV format
+func (d *outputData) renderCSV() {
+	w := csv.NewWriter(os.Stdout)
+
+	w.Write(d.fields)
+
+	for _, row := range d.rows {
+		values := make([]string, len(row))
+
+		for i, value := range row {
+			values[i] = formatOutputValue(value)
+		}
+
+		w.Write(values)
+	}
+
+	w.Flush()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// formatOutputValue formats value for text, XML and CSV output
+func formatOutputValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
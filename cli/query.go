@@ -0,0 +1,621 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/system/process"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	CORE "github.com/essentialkaos/rds/core"
+	REDIS "github.com/essentialkaos/rds/redis"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Query operators
+const (
+	QUERY_OP_EQ       = "="
+	QUERY_OP_NE       = "!="
+	QUERY_OP_GT       = ">"
+	QUERY_OP_GE       = ">="
+	QUERY_OP_LT       = "<"
+	QUERY_OP_LE       = "<="
+	QUERY_OP_CONTAINS = "~"
+)
+
+// Query keywords
+const (
+	QUERY_AND = "and"
+	QUERY_OR  = "or"
+	QUERY_NOT = "not"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// queryMatcher is function which checks if instance fits query
+type queryMatcher func(inst *queryInstance) bool
+
+// queryInstance contains instance data used by queries, sorting and columns
+type queryInstance struct {
+	ID    int
+	State CORE.State
+	Meta  *CORE.InstanceMeta
+
+	info       *REDIS.Info
+	memory     uint64
+	isInfoRead bool
+	isMemRead  bool
+}
+
+// queryParser is instances query parser
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// queryOperators is a list of supported operators (longest first)
+var queryOperators = []string{
+	QUERY_OP_GE, QUERY_OP_LE, QUERY_OP_NE,
+	QUERY_OP_EQ, QUERY_OP_GT, QUERY_OP_LT, QUERY_OP_CONTAINS,
+}
+
+// queryFields is a list of fields supported by queries
+var queryFields = []string{
+	"id", "owner", "tag", "desc", "state", "repl",
+	"mem", "ops", "input", "output", "clients", "created",
+}
+
+// queryFieldAliases is map [alias → field]
+var queryFieldAliases = map[string]string{
+	"memory":           "mem",
+	"description":      "desc",
+	"tags":             "tag",
+	"replication":      "repl",
+	"replication_type": "repl",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newQueryInstance creates new query instance with given ID
+func newQueryInstance(id int) (*queryInstance, error) {
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := CORE.GetInstanceState(id, true)
+
+	if err != nil {
+		state = CORE.INSTANCE_STATE_UNKNOWN
+	}
+
+	return &queryInstance{ID: id, State: state, Meta: meta}, nil
+}
+
+// Info returns instance info or nil if instance doesn't work
+func (i *queryInstance) Info() *REDIS.Info {
+	if i.isInfoRead {
+		return i.info
+	}
+
+	i.isInfoRead = true
+
+	if !i.State.IsWorks() {
+		return nil
+	}
+
+	i.info, _ = CORE.GetInstanceInfo(i.ID, time.Second, false)
+
+	return i.info
+}
+
+// Memory returns instance memory usage (RSS)
+func (i *queryInstance) Memory() (uint64, bool) {
+	if i.isMemRead {
+		return i.memory, i.memory != 0
+	}
+
+	i.isMemRead = true
+
+	if !i.State.IsWorks() {
+		return 0, false
+	}
+
+	pid := CORE.GetInstancePID(i.ID)
+
+	if pid == -1 {
+		return 0, false
+	}
+
+	usage, err := process.GetMemInfo(pid)
+
+	if err != nil {
+		return 0, false
+	}
+
+	i.memory = usage.VmRSS
+
+	return i.memory, true
+}
+
+// Metric returns numeric instance metric (mem, ops, input, output, clients,
+// created, id)
+func (i *queryInstance) Metric(field string) (float64, bool) {
+	switch field {
+	case "id":
+		return float64(i.ID), true
+
+	case "created":
+		return float64(i.Meta.Created), true
+
+	case "mem":
+		mem, ok := i.Memory()
+		return float64(mem), ok
+	}
+
+	info := i.Info()
+
+	if info == nil {
+		return 0, false
+	}
+
+	switch field {
+	case "ops":
+		return float64(info.GetI("stats", "instantaneous_ops_per_sec")), true
+	case "input":
+		return info.GetF("stats", "instantaneous_input_kbps") * 1024.0, true
+	case "output":
+		return info.GetF("stats", "instantaneous_output_kbps") * 1024.0, true
+	case "clients":
+		return float64(info.GetI("clients", "connected_clients") - 1), true
+	}
+
+	return 0, false
+}
+
+// Tags returns instance tags without colors
+func (i *queryInstance) Tags() []string {
+	result := []string{}
+
+	for _, tag := range i.Meta.Tags {
+		rawTag, _ := CORE.ParseTag(tag)
+		result = append(result, rawTag)
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isInstanceQuery returns true if given arguments contain query with predicates
+// or boolean operators and not just a list of filters
+func isInstanceQuery(args []string) bool {
+	for _, token := range tokenizeInstanceQuery(args) {
+		switch {
+		case token == "(", token == ")",
+			isQueryKeyword(token, QUERY_AND),
+			isQueryKeyword(token, QUERY_OR),
+			isQueryKeyword(token, QUERY_NOT),
+			strings.ContainsAny(token, "=<>~"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseInstanceQuery parses instances query. Query without terms matches
+// all instances.
+func parseInstanceQuery(args []string) (queryMatcher, error) {
+	p := &queryParser{tokens: tokenizeInstanceQuery(args)}
+
+	if len(p.tokens) == 0 {
+		return func(inst *queryInstance) bool { return true }, nil
+	}
+
+	matcher, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("Unexpected %q in query", p.tokens[p.pos])
+	}
+
+	return matcher, nil
+}
+
+// tokenizeInstanceQuery splits query into tokens
+func tokenizeInstanceQuery(args []string) []string {
+	var result []string
+	var token strings.Builder
+	var isQuoted bool
+
+	flush := func() {
+		if token.Len() != 0 {
+			result = append(result, token.String())
+			token.Reset()
+		}
+	}
+
+	for _, r := range strings.Join(args, " ") {
+		switch {
+		case r == '"':
+			isQuoted = !isQuoted
+		case isQuoted:
+			token.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			result = append(result, string(r))
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// peek returns current token
+func (p *queryParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.pos]
+}
+
+// parseOr parses OR expression
+func (p *queryParser) parseOr() (queryMatcher, error) {
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for isQueryKeyword(p.peek(), QUERY_OR) {
+		p.pos++
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(inst *queryInstance) bool { return l(inst) || right(inst) }
+	}
+
+	return left, nil
+}
+
+// parseAnd parses AND expression (terms without operator between them are
+// joined by AND)
+func (p *queryParser) parseAnd() (queryMatcher, error) {
+	left, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+
+		if token == "" || token == ")" || isQueryKeyword(token, QUERY_OR) {
+			break
+		}
+
+		if isQueryKeyword(token, QUERY_AND) {
+			p.pos++
+		}
+
+		right, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(inst *queryInstance) bool { return l(inst) && right(inst) }
+	}
+
+	return left, nil
+}
+
+// parseNot parses NOT expression
+func (p *queryParser) parseNot() (queryMatcher, error) {
+	if !isQueryKeyword(p.peek(), QUERY_NOT) {
+		return p.parsePrimary()
+	}
+
+	p.pos++
+
+	matcher, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(inst *queryInstance) bool { return !matcher(inst) }, nil
+}
+
+// parsePrimary parses term or expression in parentheses
+func (p *queryParser) parsePrimary() (queryMatcher, error) {
+	token := p.peek()
+
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("Unexpected end of query")
+
+	case token == ")",
+		isQueryKeyword(token, QUERY_AND),
+		isQueryKeyword(token, QUERY_OR):
+		return nil, fmt.Errorf("Unexpected %q in query", token)
+	}
+
+	p.pos++
+
+	if token != "(" {
+		return parseQueryTerm(token)
+	}
+
+	matcher, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() != ")" {
+		return nil, fmt.Errorf("Query has unbalanced parentheses")
+	}
+
+	p.pos++
+
+	return matcher, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseQueryTerm parses single query term (predicate or filter)
+func parseQueryTerm(token string) (queryMatcher, error) {
+	index := strings.IndexAny(token, "=!<>~")
+
+	if index == -1 {
+		return func(inst *queryInstance) bool {
+			return isFilterFit([]string{token}, inst.State, inst.Meta)
+		}, nil
+	}
+
+	if index == 0 {
+		return nil, fmt.Errorf("Query term %q has no field name", token)
+	}
+
+	field := strings.ToLower(token[:index])
+	rest := token[index:]
+
+	if queryFieldAliases[field] != "" {
+		field = queryFieldAliases[field]
+	}
+
+	if !slices.Contains(queryFields, field) {
+		return nil, fmt.Errorf(
+			"Unknown field %q in query (supported fields: %s)",
+			field, strings.Join(queryFields, ", "),
+		)
+	}
+
+	var op string
+
+	for _, queryOp := range queryOperators {
+		if strings.HasPrefix(rest, queryOp) {
+			op = queryOp
+			break
+		}
+	}
+
+	value := strings.TrimPrefix(rest, op)
+
+	if op == "" || value == "" {
+		return nil, fmt.Errorf("Query term %q is malformed", token)
+	}
+
+	switch field {
+	case "owner", "desc":
+		return parseQueryStringTerm(token, field, op, value)
+	case "tag":
+		return parseQueryTagTerm(token, op, value)
+	case "state", "repl":
+		return parseQueryEnumTerm(token, field, op, value)
+	}
+
+	return parseQueryNumTerm(token, field, op, value)
+}
+
+// parseQueryStringTerm parses predicate for string field
+func parseQueryStringTerm(token, field, op, value string) (queryMatcher, error) {
+	getValue := func(inst *queryInstance) string {
+		if field == "owner" {
+			return inst.Meta.Auth.User
+		}
+
+		return inst.Meta.Desc
+	}
+
+	switch op {
+	case QUERY_OP_EQ:
+		return func(inst *queryInstance) bool { return getValue(inst) == value }, nil
+	case QUERY_OP_NE:
+		return func(inst *queryInstance) bool { return getValue(inst) != value }, nil
+	case QUERY_OP_CONTAINS:
+		value = strings.ToLower(value)
+		return func(inst *queryInstance) bool {
+			return strings.Contains(strings.ToLower(getValue(inst)), value)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Operator %q is not supported by field %q (term %q)", op, field, token)
+}
+
+// parseQueryTagTerm parses predicate for tag field
+func parseQueryTagTerm(token, op, value string) (queryMatcher, error) {
+	switch op {
+	case QUERY_OP_EQ:
+		return func(inst *queryInstance) bool { return isMetaContainsTag(inst.Meta, value) }, nil
+	case QUERY_OP_NE:
+		return func(inst *queryInstance) bool { return !isMetaContainsTag(inst.Meta, value) }, nil
+	}
+
+	return nil, fmt.Errorf("Operator %q is not supported by field \"tag\" (term %q)", op, token)
+}
+
+// parseQueryEnumTerm parses predicate for state or replication type field
+func parseQueryEnumTerm(token, field, op, value string) (queryMatcher, error) {
+	if op != QUERY_OP_EQ && op != QUERY_OP_NE {
+		return nil, fmt.Errorf("Operator %q is not supported by field %q (term %q)", op, field, token)
+	}
+
+	var matcher queryMatcher
+
+	switch field {
+	case "state":
+		if !slices.Contains(selectorStates, value) {
+			return nil, fmt.Errorf(
+				"Unknown state %q in query (supported states: %s)",
+				value, strings.Join(selectorStates, ", "),
+			)
+		}
+
+		matcher = func(inst *queryInstance) bool {
+			return isFilterFit([]string{value}, inst.State, inst.Meta)
+		}
+
+	case "repl":
+		switch CORE.ReplicationType(value) {
+		case CORE.REPL_TYPE_REPLICA, CORE.REPL_TYPE_STANDBY:
+			// ok
+		default:
+			return nil, fmt.Errorf("Unknown replication type %q in query", value)
+		}
+
+		matcher = func(inst *queryInstance) bool {
+			return string(inst.Meta.Preferencies.ReplicationType) == value
+		}
+	}
+
+	if op == QUERY_OP_NE {
+		return func(inst *queryInstance) bool { return !matcher(inst) }, nil
+	}
+
+	return matcher, nil
+}
+
+// parseQueryNumTerm parses predicate for numeric field
+func parseQueryNumTerm(token, field, op, value string) (queryMatcher, error) {
+	if op == QUERY_OP_CONTAINS {
+		return nil, fmt.Errorf("Operator %q is not supported by field %q (term %q)", op, field, token)
+	}
+
+	var num float64
+
+	switch field {
+	case "mem", "input", "output":
+		size := fmtutil.ParseSize(value)
+
+		if size == 0 && strings.Trim(value, "0") != "" {
+			return nil, fmt.Errorf("Can't parse size %q in query term %q", value, token)
+		}
+
+		num = float64(size)
+
+	case "created":
+		age, err := timeutil.ParseDuration(value, 'd')
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't parse duration %q in query term %q: %v", value, token, err)
+		}
+
+		// created<30d means "created less than 30 days ago", so we compare
+		// creation dates in reverse order
+		num = float64(time.Now().Add(-age).Unix())
+		op = reverseQueryOperator(op)
+
+	default:
+		v, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't parse number %q in query term %q", value, token)
+		}
+
+		num = v
+	}
+
+	return func(inst *queryInstance) bool {
+		v, ok := inst.Metric(field)
+		return ok && compareQueryNum(op, v, num)
+	}, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// compareQueryNum compares two numbers using given operator
+func compareQueryNum(op string, v1, v2 float64) bool {
+	switch op {
+	case QUERY_OP_EQ:
+		return v1 == v2
+	case QUERY_OP_NE:
+		return v1 != v2
+	case QUERY_OP_GT:
+		return v1 > v2
+	case QUERY_OP_GE:
+		return v1 >= v2
+	case QUERY_OP_LT:
+		return v1 < v2
+	case QUERY_OP_LE:
+		return v1 <= v2
+	}
+
+	return false
+}
+
+// reverseQueryOperator returns operator for reversed comparison
+func reverseQueryOperator(op string) string {
+	switch op {
+	case QUERY_OP_GT:
+		return QUERY_OP_LT
+	case QUERY_OP_GE:
+		return QUERY_OP_LE
+	case QUERY_OP_LT:
+		return QUERY_OP_GT
+	case QUERY_OP_LE:
+		return QUERY_OP_GE
+	}
+
+	return op
+}
+
+// isQueryKeyword returns true if token is given keyword
+func isQueryKeyword(token, keyword string) bool {
+	return strings.ToLower(token) == keyword
+}
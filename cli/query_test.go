@@ -0,0 +1,160 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strings"
+
+	. "github.com/essentialkaos/check"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type QuerySuite struct {
+	instances []*queryInstance
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&QuerySuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *QuerySuite) SetUpSuite(c *C) {
+	s.instances = []*queryInstance{
+		newTestQueryInstance(1, CORE.INSTANCE_STATE_WORKS, "john", "Session cache", "cache", "prod"),
+		newTestQueryInstance(2, CORE.INSTANCE_STATE_STOPPED, "bob", "Queue", "prod"),
+		newTestQueryInstance(3, CORE.INSTANCE_STATE_WORKS, "bob", "Test cache", "cache"),
+		newTestQueryInstance(4, CORE.INSTANCE_STATE_STOPPED, "john", "Old data"),
+	}
+}
+
+func (s *QuerySuite) TestTokenizer(c *C) {
+	c.Assert(
+		tokenizeInstanceQuery([]string{`(owner=john`, `OR tag=cache)and`, `desc~"session cache"`}),
+		DeepEquals,
+		[]string{"(", "owner=john", "OR", "tag=cache", ")", "and", "desc~session cache"},
+	)
+
+	c.Assert(tokenizeInstanceQuery([]string{"  "}), HasLen, 0)
+
+	c.Assert(isInstanceQuery([]string{"works", "my"}), Equals, false)
+	c.Assert(isInstanceQuery([]string{"owner=john"}), Equals, true)
+	c.Assert(isInstanceQuery([]string{"not", "works"}), Equals, true)
+}
+
+func (s *QuerySuite) TestSimpleTerms(c *C) {
+	c.Assert(s.match(c, ""), DeepEquals, []int{1, 2, 3, 4})
+	c.Assert(s.match(c, "works"), DeepEquals, []int{1, 3})
+	c.Assert(s.match(c, "owner=bob"), DeepEquals, []int{2, 3})
+	c.Assert(s.match(c, "OWNER!=bob"), DeepEquals, []int{1, 4})
+	c.Assert(s.match(c, "tags=cache"), DeepEquals, []int{1, 3})
+	c.Assert(s.match(c, "tag!=prod"), DeepEquals, []int{3, 4})
+	c.Assert(s.match(c, "desc~CACHE"), DeepEquals, []int{1, 3})
+	c.Assert(s.match(c, "state=stopped"), DeepEquals, []int{2, 4})
+	c.Assert(s.match(c, "id>=3"), DeepEquals, []int{3, 4})
+	c.Assert(s.match(c, "id<2"), DeepEquals, []int{1})
+	c.Assert(s.match(c, "id!=2"), DeepEquals, []int{1, 3, 4})
+}
+
+func (s *QuerySuite) TestPrecedence(c *C) {
+	// AND binds tighter than OR
+	c.Assert(s.match(c, "owner=john or owner=bob and tag=prod"), DeepEquals, []int{1, 2, 4})
+	c.Assert(s.match(c, "owner=bob and tag=prod or id=4"), DeepEquals, []int{2, 4})
+
+	// Terms without operator are joined by AND
+	c.Assert(s.match(c, "owner=bob works"), DeepEquals, []int{3})
+	c.Assert(s.match(c, "owner=bob works or id=1"), DeepEquals, []int{1, 3})
+
+	// NOT binds tighter than AND
+	c.Assert(s.match(c, "not works and owner=john"), DeepEquals, []int{4})
+	c.Assert(s.match(c, "not not works"), DeepEquals, []int{1, 3})
+	c.Assert(s.match(c, "NOT tag=cache OR id=1"), DeepEquals, []int{1, 2, 4})
+}
+
+func (s *QuerySuite) TestParentheses(c *C) {
+	c.Assert(s.match(c, "(owner=john or owner=bob) and tag=prod"), DeepEquals, []int{1, 2})
+	c.Assert(s.match(c, "owner=bob and (tag=prod or id=4)"), DeepEquals, []int{2})
+	c.Assert(s.match(c, "not (works or tag=prod)"), DeepEquals, []int{4})
+	c.Assert(s.match(c, "((id=1))"), DeepEquals, []int{1})
+	c.Assert(s.match(c, "(id=1)(owner=john)"), DeepEquals, []int{1})
+}
+
+func (s *QuerySuite) TestQuotedValues(c *C) {
+	c.Assert(s.match(c, `desc="Session cache"`), DeepEquals, []int{1})
+	c.Assert(s.match(c, `"desc=Test cache"`), DeepEquals, []int{3})
+	c.Assert(s.match(c, `desc!="Session cache" and tag=cache`), DeepEquals, []int{3})
+
+	// Parentheses and keywords in quotes are part of value
+	c.Assert(s.match(c, `desc~"cache (old) or queue"`), HasLen, 0)
+}
+
+func (s *QuerySuite) TestErrors(c *C) {
+	errs := map[string]string{
+		"(owner=john":       `Query has unbalanced parentheses`,
+		"owner=john)":       `Unexpected "\)" in query`,
+		"()":                `Unexpected "\)" in query`,
+		"owner=john and":    `Unexpected end of query`,
+		"or owner=john":     `Unexpected "or" in query`,
+		"owner=john and or": `Unexpected "or" in query`,
+		"not":               `Unexpected end of query`,
+		"=john":             `Query term "=john" has no field name`,
+		"owner=":            `Query term "owner=" is malformed`,
+		"size>1":            `Unknown field "size" in query .*`,
+		"owner>john":        `Operator ">" is not supported by field "owner" .*`,
+		"tag~cache":         `Operator "~" is not supported by field "tag" .*`,
+		"state=running":     `Unknown state "running" in query .*`,
+		"repl=master":       `Unknown replication type "master" in query`,
+		"id~1":              `Operator "~" is not supported by field "id" .*`,
+		"id>one":            `Can't parse number "one" in query term "id>one"`,
+		"mem>lots":          `Can't parse size "lots" in query term "mem>lots"`,
+		"created<soon":      `Can't parse duration "soon" in query term "created<soon".*`,
+	}
+
+	for query, errPattern := range errs {
+		_, err := parseInstanceQuery(strings.Fields(query))
+		c.Assert(err, ErrorMatches, errPattern, Commentf("Query: %s", query))
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// match returns IDs of instances matched by given query
+func (s *QuerySuite) match(c *C, query string) []int {
+	matcher, err := parseInstanceQuery(strings.Fields(query))
+	c.Assert(err, IsNil, Commentf("Query: %s", query))
+
+	result := []int{}
+
+	for _, inst := range s.instances {
+		if matcher(inst) {
+			result = append(result, inst.ID)
+		}
+	}
+
+	return result
+}
+
+// newTestQueryInstance creates query instance which doesn't require running
+// Redis server
+func newTestQueryInstance(id int, state CORE.State, owner, desc string, tags ...string) *queryInstance {
+	return &queryInstance{
+		ID:    id,
+		State: state,
+		Meta: &CORE.InstanceMeta{
+			ID:   id,
+			Desc: desc,
+			Tags: tags,
+			Auth: &CORE.InstanceAuth{User: owner},
+		},
+		isInfoRead: true,
+		isMemRead:  true,
+	}
+}
@@ -0,0 +1,333 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// selectorCommands is a list of commands which support instance selectors
+var selectorCommands = []string{
+	COMMAND_BACKUP_CREATE, COMMAND_KILL, COMMAND_REGEN, COMMAND_RELOAD,
+	COMMAND_RESTART, COMMAND_RESTART_PROP, COMMAND_START, COMMAND_START_PROP,
+	COMMAND_STOP, COMMAND_STOP_PROP,
+}
+
+// selectorStates is a list of supported values for state selector
+var selectorStates = []string{
+	"works", "stopped", "dead", "hang", "idle", "active", "syncing", "saving",
+	"loading", "abandoned", "master-up", "master-down", "no-replica",
+	"with-replica", "with-errors",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isInstanceSelector returns true if given command argument is instance selector
+// and not a single instance ID
+func isInstanceSelector(arg string) bool {
+	if arg == "" || arg == "all" || arg == "*" {
+		return false
+	}
+
+	_, _, err := CORE.ParseIDDBPair(arg)
+
+	return err != nil
+}
+
+// selectInstances returns sorted list of instances IDs which fit given selector.
+// Selector is a comma-separated list of terms (ID, ID range, tag:{tag},
+// owner:{user}, state:{state} or outdated) or an instances query (the same as
+// used by list command). Instance is selected if it fits at least one of terms.
+func selectInstances(selector string) ([]int, error) {
+	var result []int
+
+	if isInstanceQuery([]string{selector}) {
+		return selectInstancesByQuery(selector)
+	}
+
+	terms := strings.Split(selector, ",")
+
+	for _, term := range terms {
+		err := validateSelectorTerm(term)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range CORE.GetInstanceIDList() {
+		meta, err := CORE.GetInstanceMeta(id)
+
+		if err != nil {
+			continue
+		}
+
+		state, err := CORE.GetInstanceState(id, true)
+
+		if err != nil {
+			state = CORE.INSTANCE_STATE_UNKNOWN
+		}
+
+		for _, term := range terms {
+			if isSelectorTermFit(term, id, meta, state) {
+				result = append(result, id)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// selectInstancesByQuery returns list of instances IDs which fit given query
+func selectInstancesByQuery(selector string) ([]int, error) {
+	var result []int
+
+	query, err := parseInstanceQuery([]string{selector})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range CORE.GetInstanceIDList() {
+		inst, err := newQueryInstance(id)
+
+		if err == nil && query(inst) {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
+
+// validateSelectorTerm validates selector term
+func validateSelectorTerm(term string) error {
+	name, value, hasValue := strings.Cut(term, ":")
+
+	switch {
+	case term == "":
+		return fmt.Errorf("Selector contains empty term")
+
+	case term == "outdated":
+		return nil
+
+	case hasValue && (name == "tag" || name == "owner"):
+		if value == "" {
+			return fmt.Errorf("Selector term %q has no value", term)
+		}
+
+		return nil
+
+	case hasValue && name == "state":
+		if !slices.Contains(selectorStates, value) {
+			return fmt.Errorf(
+				"Unknown state %q in selector (supported states: %s)",
+				value, strings.Join(selectorStates, ", "),
+			)
+		}
+
+		return nil
+	}
+
+	_, _, err := parseSelectorRange(term)
+
+	if err != nil {
+		return fmt.Errorf("Unknown selector term %q", term)
+	}
+
+	return nil
+}
+
+// isSelectorTermFit returns true if instance fits given selector term
+func isSelectorTermFit(term string, id int, meta *CORE.InstanceMeta, state CORE.State) bool {
+	name, value, _ := strings.Cut(term, ":")
+
+	switch {
+	case term == "outdated":
+		return isFilterFit([]string{"outdated"}, state, meta)
+	case name == "tag":
+		return isMetaContainsTag(meta, value)
+	case name == "owner":
+		return meta.Auth.User == value
+	case name == "state":
+		return isFilterFit([]string{value}, state, meta)
+	}
+
+	start, end, err := parseSelectorRange(term)
+
+	return err == nil && id >= start && id <= end
+}
+
+// parseSelectorRange parses single ID or ID range (10-20)
+func parseSelectorRange(term string) (int, int, error) {
+	startStr, endStr, isRange := strings.Cut(term, "-")
+
+	start, err := strconv.Atoi(startStr)
+
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if !isRange {
+		return start, start, nil
+	}
+
+	end, err := strconv.Atoi(endStr)
+
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if end < start {
+		return -1, -1, fmt.Errorf("Invalid range %q", term)
+	}
+
+	return start, end, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// executeCommandRoutineForSelector executes command for every instance
+// which fit given selector
+func executeCommandRoutineForSelector(cmd string, cr *CommandRoutine, args []string) {
+	fmtc.NewLine()
+
+	idList, err := selectInstances(args[0])
+
+	if err != nil {
+		terminal.Error(err)
+		fmtc.NewLine()
+		CORE.Shutdown(EC_ERROR)
+	}
+
+	if len(idList) == 0 {
+		terminal.Warn("There are no instances which fit selector %q", args[0])
+		fmtc.NewLine()
+		CORE.Shutdown(EC_WARN)
+	}
+
+	if !cr.Auth.Has(AUTH_NO) {
+		ok, err := authenticate(AUTH_SUPERUSER, true, "")
+
+		if err != nil || !ok {
+			if err != nil {
+				terminal.Error(err)
+			} else {
+				terminal.Error("Can't authenticate you with given password")
+			}
+
+			fmtc.NewLine()
+			CORE.Shutdown(EC_ERROR)
+		}
+	}
+
+	showSelectedInstances(idList)
+
+	ok, err := input.ReadAnswer(
+		fmt.Sprintf("Do you want to execute \"%s\" for these %d instances?", cmd, len(idList)), "N",
+	)
+
+	if err != nil || !ok {
+		fmtc.NewLine()
+		CORE.Shutdown(EC_CANCEL)
+	}
+
+	// All confirmations for every instance are implied
+	input.AlwaysYes = true
+
+	results := make(map[int]int)
+
+	for _, id := range idList {
+		fmtc.NewLine()
+		fmtc.Printf("{s-}━━━{!} {*}%d{!} {s-}━━━{!}\n\n", id)
+
+		results[id] = cr.Handler(CommandArgs(append([]string{strconv.Itoa(id)}, args[1:]...)))
+	}
+
+	fmtc.NewLine()
+
+	ec := showSelectorResults(idList, results)
+
+	fmtc.NewLine()
+
+	CORE.Shutdown(ec)
+}
+
+// showSelectedInstances shows table with instances selected by selector
+func showSelectedInstances(idList []int) {
+	t := table.NewTable("ID", "STATE", "OWNER", "DESCRIPTION").SetSizes(4, 10, 18)
+
+	for _, id := range idList {
+		meta, _ := CORE.GetInstanceMeta(id)
+		state, err := CORE.GetInstanceState(id, true)
+
+		if err != nil {
+			state = CORE.INSTANCE_STATE_UNKNOWN
+		}
+
+		t.Add(
+			getInstanceIDWithColor(id, state),
+			getInstanceStateWithColor(state),
+			getInstanceOwnerWithColor(meta, false),
+			getInstanceDescWithTags(meta, state.IsWorks(), nil),
+		)
+	}
+
+	t.Render()
+	fmtc.NewLine()
+}
+
+// showSelectorResults shows table with command execution results for every
+// instance and returns summary exit code
+func showSelectorResults(idList []int, results map[int]int) int {
+	var hasErrors, hasWarnings bool
+
+	t := table.NewTable("ID", "RESULT", "DESCRIPTION").SetSizes(4, 10)
+
+	for _, id := range idList {
+		var result string
+
+		switch results[id] {
+		case EC_OK:
+			result = "{g}OK{!}"
+		case EC_WARN:
+			result, hasWarnings = "{y}WARN{!}", true
+		case EC_CANCEL:
+			result, hasWarnings = "{s}CANCEL{!}", true
+		default:
+			result, hasErrors = "{r}ERROR{!}", true
+		}
+
+		meta, _ := CORE.GetInstanceMeta(id)
+
+		t.Add(id, result, getInstanceDescWithTags(meta, true, nil))
+	}
+
+	t.Render()
+
+	switch {
+	case hasErrors:
+		return EC_ERROR
+	case hasWarnings:
+		return EC_WARN
+	}
+
+	return EC_OK
+}
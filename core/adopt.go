@@ -0,0 +1,782 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/pid"
+	"github.com/essentialkaos/ek/v13/system"
+	"github.com/essentialkaos/ek/v13/version"
+
+	REDIS "github.com/essentialkaos/rds/redis"
+	RDB "github.com/essentialkaos/rds/redis/rdb"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// META_CUSTOM_DIRECTIVES is name of meta storage key with custom configuration
+// directives which are added to generated instance configuration
+const META_CUSTOM_DIRECTIVES = "custom-directives"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AdoptionPlan contains info about adoption of unmanaged Redis server
+type AdoptionPlan struct {
+	Meta       *InstanceMeta
+	ConfigFile string           // Path to configuration file of unmanaged server
+	PID        int              // PID of unmanaged server (-1 if server is not running)
+	Port       int              // Port of unmanaged server
+	Process    *AdoptProcess    // Info about unmanaged server process (nil if server is not running)
+	Directives []string         // Custom directives preserved in managed configuration
+	Dropped    []string         // Directives replaced by RDS managed configuration
+	DataFiles  []*AdoptDataFile // Data files to move
+}
+
+// AdoptDataFile contains info about data file of unmanaged server
+type AdoptDataFile struct {
+	Source string
+	Target string
+	Size   int64
+	UID    int // Original owner UID
+	GID    int // Original owner GID
+}
+
+// AdoptProcess contains info required for starting unmanaged server after
+// failed adoption
+type AdoptProcess struct {
+	Binary  string // Path to server binary
+	WorkDir string // Server working directory
+	Unit    string // Name of systemd unit if server managed by systemd
+	UID     int
+	GID     int
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// adoptManagedProps is list of configuration properties controlled by RDS
+var adoptManagedProps = []string{
+	"aclfile", "appenddirname", "appendfilename", "bind", "daemonize",
+	"dbfilename", "dir", "include", "logfile", "masterauth", "masteruser",
+	"pidfile", "port", "proc-title-template", "rename-command", "replicaof",
+	"requirepass", "save", "slaveof", "supervised", "tls-auth-clients",
+	"tls-ca-cert-file", "tls-cert-file", "tls-key-file", "tls-port",
+	"tls-replication", "unixsocket", "unixsocketperm", "user",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsManagedConfigProp returns true if given configuration property is controlled
+// by RDS and can't be overridden by custom directives (Redis reads property names
+// case-insensitively, so we do the same)
+func IsManagedConfigProp(prop string) bool {
+	return slices.Contains(adoptManagedProps, strings.ToLower(prop))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PlanAdoption creates plan for adoption of unmanaged Redis server with
+// given configuration file
+func PlanAdoption(configFile, instancePassword string) (*AdoptionPlan, error) {
+	err := fsutil.ValidatePerms("FRS", configFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := REDIS.ReadConfig(configFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read configuration file: %w", err)
+	}
+
+	if config.Has("replicaof") || config.Has("slaveof") {
+		return nil, fmt.Errorf("Server is configured as a replica, only masters can be adopted")
+	}
+
+	meta, err := NewInstanceMeta(instancePassword, config.Get("requirepass"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Preferencies.IsSaveDisabled = config.Get("save") == `""`
+
+	plan := &AdoptionPlan{
+		Meta:       meta,
+		ConfigFile: configFile,
+		PID:        -1,
+	}
+
+	plan.Port, _ = strconv.Atoi(config.Get("port"))
+
+	if !config.Has("port") {
+		plan.Port = 6379
+	}
+
+	plan.PID, err = getUnmanagedServerPID(config, plan.Port)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.PID > 0 {
+		plan.Process, err = getUnmanagedProcessInfo(plan.PID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	managedConfig, err := renderAdoptedConfig(meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Directives, plan.Dropped = getCustomDirectives(config, managedConfig)
+
+	plan.DataFiles, err = getUnmanagedDataFiles(configFile, config, managedConfig, meta.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan.Directives) != 0 {
+		meta.Storage.Set(META_CUSTOM_DIRECTIVES, strings.Join(plan.Directives, "\n"))
+	}
+
+	return plan, nil
+}
+
+// AdoptInstance stops unmanaged server, creates instance and moves data files
+// to instance data directory. If adoption failed, unmanaged server will be
+// started again.
+func AdoptInstance(plan *AdoptionPlan) error {
+	if plan == nil || plan.Meta == nil {
+		return fmt.Errorf("Adoption plan is empty")
+	}
+
+	err := checkAdoptedDataCompatibility(plan)
+
+	if err != nil {
+		return err
+	}
+
+	err = stopUnmanagedServer(plan)
+
+	if err != nil {
+		return err
+	}
+
+	err = CreateInstance(plan.Meta)
+
+	if err == nil {
+		err = moveAdoptedDataFiles(plan)
+	}
+
+	if err != nil {
+		rollbackErr := RollbackAdoption(plan)
+
+		if rollbackErr != nil {
+			return fmt.Errorf("%w (rollback error: %v)", err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RollbackAdoption destroys instance created by adoption, returns data files
+// back and starts unmanaged server with original configuration
+func RollbackAdoption(plan *AdoptionPlan) error {
+	if plan == nil || plan.Meta == nil {
+		return fmt.Errorf("Adoption plan is empty")
+	}
+
+	id := plan.Meta.ID
+
+	if IsInstanceExist(id) {
+		state, err := GetInstanceState(id, false)
+
+		if err == nil && state.IsWorks() {
+			err = StopInstance(id, true)
+
+			if err != nil {
+				return fmt.Errorf("Can't stop instance: %w", err)
+			}
+		}
+
+		err = restoreAdoptedDataFiles(plan.DataFiles)
+
+		if err != nil {
+			return err
+		}
+
+		err = DestroyInstance(id)
+
+		if err != nil {
+			return fmt.Errorf("Can't destroy instance: %w", err)
+		}
+	}
+
+	return startUnmanagedServer(plan)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getUnmanagedServerPID returns PID of unmanaged server
+func getUnmanagedServerPID(config *REDIS.Config, port int) (int, error) {
+	serverPID := -1
+
+	if config.Get("pidfile") != "" {
+		serverPID = pid.Read(config.Get("pidfile"))
+	}
+
+	if serverPID > 0 && pid.IsProcessWorks(serverPID) {
+		return serverPID, nil
+	}
+
+	_, err := REDIS.ExecCommand(&REDIS.Request{
+		Command: []string{"PING"},
+		Port:    port,
+		Timeout: time.Second,
+	})
+
+	// Server without auth or with auth both reply to PING or return
+	// NOAUTH error, so any response means that server is works
+	if err == nil || strings.HasPrefix(err.Error(), "NOAUTH") {
+		return -1, fmt.Errorf("Server on port %d is works, but its PID is unknown (pidfile is not defined or doesn't exist). Stop server manually before adoption.", port)
+	}
+
+	return -1, nil
+}
+
+// getUnmanagedProcessInfo returns info about unmanaged server process
+func getUnmanagedProcessInfo(serverPID int) (*AdoptProcess, error) {
+	procDir := "/proc/" + strconv.Itoa(serverPID)
+	binary, err := os.Readlink(procDir + "/exe")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't get server binary path: %w", err)
+	}
+
+	process := &AdoptProcess{
+		// Binary can be replaced by package update while server works
+		Binary: strings.TrimSuffix(binary, " (deleted)"),
+	}
+
+	process.WorkDir, _ = os.Readlink(procDir + "/cwd")
+	process.UID, process.GID = getFileOwner(procDir)
+
+	cgroup, _ := getProcessCGroup(serverPID)
+
+	if strings.HasSuffix(cgroup, ".service") {
+		process.Unit = path.Base(cgroup)
+	}
+
+	return process, nil
+}
+
+// renderAdoptedConfig renders and parses managed configuration for given meta
+func renderAdoptedConfig(meta *InstanceMeta) (*REDIS.Config, error) {
+	confData, err := generateConfigFromTemplate(
+		TEMPLATE_SOURCE_REDIS, meta.Preferencies.TemplateProfile,
+		createConfigFromMeta(meta),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := os.CreateTemp("", "rds-adopt-*.conf")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create temporary file: %w", err)
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(confData)
+	tmpFile.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't write temporary file: %w", err)
+	}
+
+	return REDIS.ReadConfig(tmpFile.Name())
+}
+
+// getCustomDirectives returns slice with directives from unmanaged config which
+// differ from managed config and slice with directives which will be dropped
+func getCustomDirectives(config, managedConfig *REDIS.Config) ([]string, []string) {
+	var names []string
+	var directives, dropped []string
+
+	// Redis reads property names case-insensitively, so values of the same
+	// property written in different case are merged
+	values := make(map[string][]string)
+
+	for _, prop := range config.Props {
+		name := strings.ToLower(prop)
+
+		if values[name] == nil {
+			names = append(names, name)
+		}
+
+		values[name] = append(values[name], config.Data[prop]...)
+	}
+
+	for _, name := range names {
+		// Multi-valued properties (e.g. save or rename-command) are equal only
+		// if all values are equal
+		if slices.Equal(values[name], managedConfig.Data[name]) {
+			continue
+		}
+
+		for _, value := range values[name] {
+			switch {
+			case name == "requirepass", name == "masterauth", name == "user":
+				dropped = append(dropped, name+" [hidden]")
+			case IsManagedConfigProp(name):
+				dropped = append(dropped, name+" "+value)
+			default:
+				directives = append(directives, name+" "+value)
+			}
+		}
+	}
+
+	return directives, dropped
+}
+
+// getUnmanagedDataFiles returns list of data files which must be moved to
+// instance data directory
+func getUnmanagedDataFiles(configFile string, config, managedConfig *REDIS.Config, id int) ([]*AdoptDataFile, error) {
+	var result []*AdoptDataFile
+
+	dataDir := config.Get("dir")
+
+	switch dataDir {
+	case "":
+		dataDir = path.Dir(configFile)
+	default:
+		if !path.IsAbs(dataDir) {
+			dataDir = path.Join(path.Dir(configFile), dataDir)
+		}
+	}
+
+	targetDir := GetInstanceDataDirPath(id)
+
+	for _, prop := range []string{"dbfilename", "appendfilename", "appenddirname"} {
+		source := config.Get(prop)
+		target := managedConfig.Get(prop)
+
+		if target == "" {
+			continue
+		}
+
+		if source == "" {
+			switch prop {
+			case "dbfilename":
+				source = "dump.rdb"
+			case "appendfilename":
+				source = "appendonly.aof"
+			case "appenddirname":
+				source = "appendonlydir"
+			}
+		}
+
+		source = path.Join(dataDir, strings.Trim(source, `"`))
+
+		if !fsutil.IsExist(source) {
+			continue
+		}
+
+		size, err := getAdoptedDataSize(source)
+
+		if err != nil {
+			return nil, err
+		}
+
+		uid, gid := getFileOwner(source)
+
+		result = append(result, &AdoptDataFile{
+			Source: source,
+			Target: path.Join(targetDir, strings.Trim(target, `"`)),
+			Size:   size,
+			UID:    uid,
+			GID:    gid,
+		})
+	}
+
+	return result, nil
+}
+
+// getAdoptedDataSize returns size of data file or directory
+func getAdoptedDataSize(source string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(source, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("Can't check data file %s: %w", source, err)
+	}
+
+	return size, nil
+}
+
+// checkAdoptedDataCompatibility checks if data of unmanaged server can be loaded
+// by installed version of Redis. Check must be done before server shutdown,
+// because data saved by newer version of Redis can't be loaded by older one.
+func checkAdoptedDataCompatibility(plan *AdoptionPlan) error {
+	redisVer, err := GetRedisVersion()
+
+	if err != nil {
+		return fmt.Errorf("Can't get installed Redis version: %w", err)
+	}
+
+	if plan.PID > 0 && pid.IsProcessWorks(plan.PID) {
+		serverVer, err := getUnmanagedServerVersion(plan)
+
+		if err != nil {
+			return err
+		}
+
+		rdbInfo := &RDB.Info{Version: RDB.VersionForRedis(serverVer.Major(), serverVer.Minor())}
+		err = CheckRDBCompatibility(rdbInfo, redisVer)
+
+		if err != nil {
+			return fmt.Errorf("Data of Redis %s can't be loaded by installed Redis: %w", serverVer.String(), err)
+		}
+
+		return nil
+	}
+
+	for _, dataFile := range plan.DataFiles {
+		for _, file := range getAdoptedDataFileList(dataFile.Source) {
+			rdbVersion, err := RDB.ReadVersion(file)
+
+			switch {
+			case err == RDB.ErrWrongMagic:
+				continue // AOF file without RDB preamble
+			case err != nil:
+				return fmt.Errorf("Can't check data file %s: %w", file, err)
+			}
+
+			err = CheckRDBCompatibility(&RDB.Info{Version: rdbVersion}, redisVer)
+
+			if err != nil {
+				return fmt.Errorf("Data file %s can't be loaded by installed Redis: %w", file, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getUnmanagedServerVersion returns version of running unmanaged server
+func getUnmanagedServerVersion(plan *AdoptionPlan) (version.Version, error) {
+	req := &REDIS.Request{
+		Command: []string{"INFO", "server"},
+		Port:    plan.Port,
+		Timeout: 3 * time.Second,
+	}
+
+	if plan.Meta.Preferencies.ServicePassword != "" {
+		req.Auth = REDIS.Auth{User: "default", Password: plan.Meta.Preferencies.ServicePassword}
+	}
+
+	info, err := REDIS.GetInfo(req)
+
+	if err != nil {
+		return version.Version{}, fmt.Errorf("Can't get Redis server info: %w", err)
+	}
+
+	serverVer, err := version.Parse(info.Get("server", "redis_version"))
+
+	if err != nil || serverVer.IsZero() {
+		return version.Version{}, fmt.Errorf("Can't parse Redis server version %q", info.Get("server", "redis_version"))
+	}
+
+	return serverVer, nil
+}
+
+// getAdoptedDataFileList returns list of data files (AOF directory may contain
+// a few files)
+func getAdoptedDataFileList(source string) []string {
+	if !fsutil.IsDir(source) {
+		return []string{source}
+	}
+
+	var result []string
+
+	for _, file := range fsutil.List(source, true, fsutil.ListingFilter{Perms: "F"}) {
+		result = append(result, path.Join(source, file))
+	}
+
+	return result
+}
+
+// stopUnmanagedServer stops unmanaged server with data saving
+func stopUnmanagedServer(plan *AdoptionPlan) error {
+	if plan.PID <= 0 || !pid.IsProcessWorks(plan.PID) {
+		return nil
+	}
+
+	req := &REDIS.Request{
+		Command: []string{"SHUTDOWN", "SAVE"},
+		Port:    plan.Port,
+		Timeout: time.Minute,
+	}
+
+	if plan.Meta.Preferencies.ServicePassword != "" {
+		req.Auth = REDIS.Auth{User: "default", Password: plan.Meta.Preferencies.ServicePassword}
+	}
+
+	// Server closes connection on shutdown, so error is expected here
+	REDIS.ExecCommand(req)
+
+	if isProcStopped(plan.PID, Config.GetI(DELAY_STOP)) {
+		return nil
+	}
+
+	syscall.Kill(plan.PID, syscall.SIGTERM)
+
+	if isProcStopped(plan.PID, Config.GetI(DELAY_STOP)) {
+		return nil
+	}
+
+	return fmt.Errorf("Can't stop Redis server (PID: %d)", plan.PID)
+}
+
+// moveAdoptedDataFiles moves data files of unmanaged server to instance
+// data directory
+func moveAdoptedDataFiles(plan *AdoptionPlan) error {
+	redisUser, err := system.LookupUser(Config.GetS(REDIS_USER))
+
+	if err != nil {
+		return err
+	}
+
+	var moved []*AdoptDataFile
+
+	for _, file := range plan.DataFiles {
+		err = moveAdoptedData(file.Source, file.Target)
+
+		if err == nil {
+			err = chownAdoptedData(file.Target, redisUser.UID, redisUser.GID)
+		}
+
+		if err != nil {
+			// Return already moved files back
+			restoreAdoptedDataFiles(moved)
+
+			return fmt.Errorf("Can't move data file %s: %w", file.Source, err)
+		}
+
+		moved = append(moved, file)
+	}
+
+	return nil
+}
+
+// restoreAdoptedDataFiles moves data files back to unmanaged server data
+// directory and restores original owner
+func restoreAdoptedDataFiles(files []*AdoptDataFile) error {
+	for _, file := range files {
+		if !fsutil.IsExist(file.Target) {
+			continue
+		}
+
+		err := moveAdoptedData(file.Target, file.Source)
+
+		if err == nil {
+			err = chownAdoptedData(file.Source, file.UID, file.GID)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Can't move data file %s back: %w", file.Target, err)
+		}
+	}
+
+	return nil
+}
+
+// startUnmanagedServer starts unmanaged server with original configuration
+func startUnmanagedServer(plan *AdoptionPlan) error {
+	process := plan.Process
+
+	// Server wasn't working before adoption
+	if process == nil {
+		return nil
+	}
+
+	if process.Unit != "" {
+		return execSystemctl("start", process.Unit)
+	}
+
+	cmd := exec.Command(process.Binary, plan.ConfigFile)
+	cmd.Dir = process.WorkDir
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+		Credential: &syscall.Credential{
+			Uid: uint32(process.UID),
+			Gid: uint32(process.GID),
+		},
+	}
+
+	err := cmd.Start()
+
+	if err != nil {
+		return fmt.Errorf("Can't start Redis server: %w", err)
+	}
+
+	// Server can work in foreground, so we don't wait for it
+	cmd.Process.Release()
+
+	for range Config.GetI(DELAY_START) {
+		_, err = REDIS.ExecCommand(&REDIS.Request{
+			Command: []string{"PING"},
+			Port:    plan.Port,
+			Timeout: time.Second,
+		})
+
+		if err == nil || strings.HasPrefix(err.Error(), "NOAUTH") {
+			return nil
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("Redis server with configuration %s didn't start", plan.ConfigFile)
+}
+
+// getFileOwner returns UID and GID of file owner
+func getFileOwner(file string) (int, int) {
+	info, err := os.Stat(file)
+
+	if err != nil {
+		return 0, 0
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return 0, 0
+	}
+
+	return int(stat.Uid), int(stat.Gid)
+}
+
+// moveAdoptedData moves data file or directory. If source and target are
+// placed on different devices, data will be copied.
+func moveAdoptedData(source, target string) error {
+	if fsutil.IsExist(target) {
+		err := os.RemoveAll(target)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err := os.Rename(source, target)
+
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if fsutil.IsDir(source) {
+		return fsutil.CopyDir(source, target)
+	}
+
+	return fsutil.CopyFile(source, target)
+}
+
+// chownAdoptedData changes owner of data file or directory
+func chownAdoptedData(target string, uid, gid int) error {
+	return filepath.Walk(target, func(file string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		return os.Chown(file, uid, gid)
+	})
+}
+
+// appendCustomDirectives merges custom directives with generated configuration
+func appendCustomDirectives(confData []byte, directives string) []byte {
+	if directives == "" {
+		return confData
+	}
+
+	var props []string
+
+	customData := make(map[string][]string)
+
+	for _, line := range strings.Split(directives, "\n") {
+		prop, _, _ := strings.Cut(line, " ")
+		prop = strings.ToLower(prop)
+
+		if customData[prop] == nil {
+			props = append(props, prop)
+		}
+
+		customData[prop] = append(customData[prop], line)
+	}
+
+	var result []string
+
+	for _, line := range strings.Split(strings.TrimRight(string(confData), "\n"), "\n") {
+		prop, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		prop = strings.ToLower(prop)
+
+		if strings.HasPrefix(prop, "#") || customData[prop] == nil {
+			result = append(result, line)
+			continue
+		}
+
+		// Replace first occurrence of property with custom directives and
+		// remove all other occurrences
+		if slices.Contains(props, prop) {
+			result = append(result, customData[prop]...)
+			props = slices.DeleteFunc(props, func(p string) bool { return p == prop })
+		}
+	}
+
+	if len(props) != 0 {
+		result = append(result, "", "# Custom directives", "")
+
+		for _, prop := range props {
+			result = append(result, customData[prop]...)
+		}
+	}
+
+	return []byte(strings.Join(result, "\n") + "\n")
+}
@@ -0,0 +1,96 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	. "github.com/essentialkaos/check"
+
+	REDIS "github.com/essentialkaos/rds/redis"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type AdoptSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&AdoptSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *AdoptSuite) TestManagedProps(c *C) {
+	c.Assert(IsManagedConfigProp("port"), Equals, true)
+	c.Assert(IsManagedConfigProp("PORT"), Equals, true)
+	c.Assert(IsManagedConfigProp("Dir"), Equals, true)
+	c.Assert(IsManagedConfigProp("LoadModule"), Equals, false)
+	c.Assert(IsManagedConfigProp("maxmemory-policy"), Equals, false)
+}
+
+func (s *AdoptSuite) TestMixedCaseDirectives(c *C) {
+	config := &REDIS.Config{
+		Props: []string{"PORT", "MaxMemory-Policy", "Dir"},
+		Data: map[string][]string{
+			"PORT":             {"6380"},
+			"MaxMemory-Policy": {"allkeys-lru"},
+			"Dir":              {"/var/lib/redis"},
+		},
+	}
+
+	managedConfig := &REDIS.Config{
+		Props: []string{"port", "maxmemory-policy", "dir"},
+		Data: map[string][]string{
+			"port":             {"63001"},
+			"maxmemory-policy": {"noeviction"},
+			"dir":              {"/opt/redis/data/1"},
+		},
+	}
+
+	directives, dropped := getCustomDirectives(config, managedConfig)
+
+	c.Assert(directives, DeepEquals, []string{"maxmemory-policy allkeys-lru"})
+	c.Assert(dropped, DeepEquals, []string{"port 6380", "dir /var/lib/redis"})
+
+	confData := []byte("port 63001\nMAXMEMORY-POLICY noeviction\n# maxmemory-policy volatile-lru\ndir /opt/redis/data/1\n")
+
+	c.Assert(
+		string(appendCustomDirectives(confData, "MaxMemory-Policy allkeys-lru\nLoadModule /usr/lib/redis/mod.so")),
+		Equals,
+		"port 63001\nMaxMemory-Policy allkeys-lru\n# maxmemory-policy volatile-lru\ndir /opt/redis/data/1\n"+
+			"\n# Custom directives\n\nLoadModule /usr/lib/redis/mod.so\n",
+	)
+}
+
+func (s *AdoptSuite) TestMultiValuedDirectives(c *C) {
+	config := &REDIS.Config{
+		Props: []string{"client-output-buffer-limit", "CLIENT-OUTPUT-BUFFER-LIMIT", "save", "maxmemory"},
+		Data: map[string][]string{
+			"client-output-buffer-limit": {"normal 0 0 0"},
+			"CLIENT-OUTPUT-BUFFER-LIMIT": {"replica 512mb 128mb 60"},
+			"save":                       {"900 1", "300 100"},
+			"maxmemory":                  {"1gb"},
+		},
+	}
+
+	managedConfig := &REDIS.Config{
+		Props: []string{"client-output-buffer-limit", "save", "maxmemory"},
+		Data: map[string][]string{
+			"client-output-buffer-limit": {"normal 0 0 0", "replica 256mb 64mb 60"},
+			"save":                       {"900 1", "300 10"},
+			"maxmemory":                  {"1gb"},
+		},
+	}
+
+	directives, dropped := getCustomDirectives(config, managedConfig)
+
+	c.Assert(directives, DeepEquals, []string{
+		"client-output-buffer-limit normal 0 0 0",
+		"client-output-buffer-limit replica 512mb 128mb 60",
+	})
+
+	c.Assert(dropped, DeepEquals, []string{"save 900 1", "save 300 100"})
+}
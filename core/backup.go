@@ -0,0 +1,1242 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/cron"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/strutil"
+	"github.com/essentialkaos/ek/v13/timeutil"
+	"github.com/essentialkaos/ek/v13/version"
+
+	REDIS "github.com/essentialkaos/rds/redis"
+	RDB "github.com/essentialkaos/rds/redis/rdb"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// BACKUP_PERMS is default permissions for backups
+const BACKUP_PERMS = 0600
+
+// DEFAULT_BACKUP_KEEP_LAST is default number of backups to keep
+const DEFAULT_BACKUP_KEEP_LAST = 10
+
+// DEFAULT_BACKUP_DUMP_TIMEOUT is default maximum duration of saving instance
+// data before archiving
+const DEFAULT_BACKUP_DUMP_TIMEOUT = 2 * time.Hour
+
+// BACKUP_SCHEDULE_OFF is schedule value for disabling scheduled backups
+const BACKUP_SCHEDULE_OFF = "off"
+
+// BACKUP_SCHEDULES is name of configuration section with instance and tag
+// backup schedules
+const BACKUP_SCHEDULES = "backup-schedules"
+
+const (
+	BACKUP_TYPE_RDB = "rdb" // RDB snapshot
+	BACKUP_TYPE_AOF = "aof" // Append only file (or set of files for Redis 7+)
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type BackupStage uint8
+
+const (
+	BACKUP_STAGE_DUMP    BackupStage = 1
+	BACKUP_STAGE_COPY    BackupStage = 2
+	BACKUP_STAGE_STORE   BackupStage = 3
+	BACKUP_STAGE_REWRITE BackupStage = 4
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// BackupInfo contains info about instance backup
+type BackupInfo struct {
+	Storage       string    `json:"-"`                        // Storage name
+	Storages      []string  `json:"-"`                        // Names of all storages with backup
+	File          string    `json:"file"`                     // Backup archive file name
+	Type          string    `json:"type,omitempty"`           // Backup type (rdb/aof)
+	Size          int64     `json:"size"`                     // Backup archive size
+	RDBSize       int64     `json:"rdb_size,omitempty"`       // Size of uncompressed RDB file
+	AOFSize       int64     `json:"aof_size,omitempty"`       // Size of uncompressed AOF files
+	Date          time.Time `json:"date"`                     // Backup creation date
+	Compression   string    `json:"compression,omitempty"`    // Compression method
+	Encryption    string    `json:"encryption,omitempty"`     // Encryption method
+	EncryptionKey string    `json:"encryption_key,omitempty"` // Encryption key ID
+	Checksum      string    `json:"sha256,omitempty"`         // SHA-256 checksum of archive
+	InstanceID    int       `json:"instance_id"`              // Instance ID
+	InstanceUUID  string    `json:"instance_uuid,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	RedisVersion  string    `json:"redis_version,omitempty"`
+}
+
+// BackupRetention contains backups retention policy
+type BackupRetention struct {
+	KeepLast   int    // Number of latest backups to keep
+	KeepHourly int    // Number of hourly backups to keep
+	KeepDaily  int    // Number of daily backups to keep
+	KeepWeekly int    // Number of weekly backups to keep
+	MaxSize    uint64 // Maximum total size of all backups
+}
+
+// BackupVerifyInfo contains backup verification result
+type BackupVerifyInfo struct {
+	RDB         *RDB.Info // Info from RDB file (or RDB preamble of AOF)
+	AOFFiles    []string  // List of AOF files in archive
+	CheckBinary string    // Path to redis-check-rdb or redis-check-aof used for verification
+}
+
+// BackupProgressHandler is handler for backup progress updates
+type BackupProgressHandler func(stage BackupStage, size int64)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	ErrBackupNoRDB            = errors.New("There is no RDB snapshot of instance data")
+	ErrBackupChecksumMismatch = errors.New("Backup archive checksum mismatch")
+	ErrBackupNotFile          = errors.New("Backup source is not a regular file")
+	ErrBackupNoAOF            = errors.New("There is no append only file of instance data")
+	ErrBackupAOFDisabled      = errors.New("Instance has append only file disabled")
+	ErrBackupMultiPartAOF     = errors.New("Multi-part AOF requires Redis 7.0 or greater")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GetInstanceBackups returns info about backups of instance with given ID from
+// all configured storages sorted from oldest to newest. Backup stored in several
+// storages is listed once with the first storage used for reading.
+func GetInstanceBackups(id int) ([]*BackupInfo, error) {
+	var result []*BackupInfo
+
+	storages, err := GetBackupStorages()
+
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := GetInstanceBackupOwner(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*BackupInfo)
+
+	for _, storage := range storages {
+		backups, err := getStorageBackups(storage, owner)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't get list of backups from %s storage: %w", storage.Name(), err)
+		}
+
+		for _, backup := range backups {
+			if index[backup.File] != nil {
+				index[backup.File].Storages = append(index[backup.File].Storages, storage.Name())
+				continue
+			}
+
+			index[backup.File] = backup
+			result = append(result, backup)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result, nil
+}
+
+// GetFileBackup returns info about backup archive or RDB file placed outside
+// of backup storages. If file has manifest, it will be used for checksum
+// validation.
+func GetFileBackup(file string) (*BackupInfo, error) {
+	file, err := filepath.Abs(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !fsutil.IsRegular(file) {
+		return nil, ErrBackupNotFile
+	}
+
+	err = fsutil.ValidatePerms("FR", file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &BackupInfo{
+		Storage:     BACKUP_STORAGE_FILE,
+		File:        file,
+		Type:        BACKUP_TYPE_RDB,
+		Size:        fsutil.GetSize(file),
+		Compression: getCompressionByExt(strings.TrimSuffix(file, BACKUP_ENCRYPTED_EXT)),
+	}
+
+	if strings.HasSuffix(file, BACKUP_ENCRYPTED_EXT) {
+		backup.Encryption = BACKUP_ENCRYPTION_AES_GCM
+	}
+
+	if strings.Contains(path.Base(file), ".aof.tar") {
+		backup.Type = BACKUP_TYPE_AOF
+	}
+
+	backup.Date, _ = fsutil.GetMTime(file)
+	manifestFile := path.Join(path.Dir(file), getBackupManifestName(path.Base(file)))
+
+	if fsutil.IsExist(manifestFile) {
+		manifest := &BackupInfo{}
+		err = jsonutil.Read(manifestFile, manifest)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read backup manifest: %w", err)
+		}
+
+		backup.Checksum = manifest.Checksum
+		backup.Compression = manifest.Compression
+		backup.Encryption = manifest.Encryption
+		backup.EncryptionKey = manifest.EncryptionKey
+		backup.Type = strutil.Q(manifest.Type, BACKUP_TYPE_RDB)
+		backup.Date = manifest.Date
+	}
+
+	return backup, nil
+}
+
+// CheckRDBCompatibility checks if RDB file can be loaded by given Redis version
+func CheckRDBCompatibility(rdbInfo *RDB.Info, redisVer version.Version) error {
+	if redisVer.IsZero() {
+		return fmt.Errorf("Can't check compatibility: unknown Redis version")
+	}
+
+	if rdbInfo.Version > RDB.VersionForRedis(redisVer.Major(), redisVer.Minor()) {
+		return fmt.Errorf(
+			"RDB version %d requires Redis %s or greater (current version is %s)",
+			rdbInfo.Version, RDB.MinRedisVersion(rdbInfo.Version), redisVer.String(),
+		)
+	}
+
+	return nil
+}
+
+// CreateInstanceBackup creates backup of instance data and stores it in all
+// configured storages. Backup type (RDB or AOF) depends on instance
+// configuration. If instance works, data will be saved using BGSAVE or
+// BGREWRITEAOF command before archiving.
+func CreateInstanceBackup(id int, progressHandler BackupProgressHandler) (*BackupInfo, error) {
+	storages, err := GetBackupStorages()
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := GetInstanceState(id, false)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't check instance state: %w", err)
+	}
+
+	backupType := BACKUP_TYPE_RDB
+
+	if IsInstanceAOFEnabled(id) {
+		backupType = BACKUP_TYPE_AOF
+	}
+
+	switch {
+	case state.IsWorks() && backupType == BACKUP_TYPE_AOF:
+		err = rewriteAOF(id, progressHandler)
+	case state.IsWorks():
+		err = saveRDB(id, progressHandler)
+	case backupType == BACKUP_TYPE_AOF && !hasAOF(id):
+		err = ErrBackupNoAOF
+	case backupType == BACKUP_TYPE_RDB && !fsutil.IsExist(GetInstanceRDBPath(id)):
+		err = ErrBackupNoRDB
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := getBackupEncryptionKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hostname, _ := os.Hostname()
+	compression := getBackupCompression()
+
+	// Name contains nanoseconds, so backups created within the same second
+	// (e.g. manual and scheduled) don't overwrite each other
+	backup := &BackupInfo{
+		File: fmt.Sprintf(
+			"backup-%d-%09d%s", now.Unix(), now.Nanosecond(),
+			getBackupArchiveExt(backupType, compression),
+		),
+		Type:         backupType,
+		Date:         now,
+		Compression:  compression,
+		InstanceID:   id,
+		InstanceUUID: meta.UUID,
+		Hostname:     hostname,
+		RedisVersion: GetInstanceVersion(id).String(),
+	}
+
+	if key != nil {
+		backup.File += BACKUP_ENCRYPTED_EXT
+		backup.Encryption = BACKUP_ENCRYPTION_AES_GCM
+		backup.EncryptionKey = key.ID
+	}
+
+	for _, storage := range storages {
+		err = checkBackupNotExist(storage, backup)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	archiveFd, err := os.CreateTemp(GetInstanceDataDirPath(id), ".backup-*.tmp")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create temporary file: %w", err)
+	}
+
+	archiveFile := archiveFd.Name()
+	archiveFd.Close()
+
+	defer os.Remove(archiveFile)
+
+	if backupType == BACKUP_TYPE_AOF {
+		if progressHandler != nil {
+			progressHandler(BACKUP_STAGE_COPY, 0)
+		}
+
+		backup.Checksum, backup.AOFSize, err = createAOFBackupArchive(id, archiveFile, compression, key)
+	} else {
+		rdbFile := GetInstanceRDBPath(id)
+		backup.RDBSize = fsutil.GetSize(rdbFile)
+
+		if progressHandler != nil {
+			progressHandler(BACKUP_STAGE_COPY, backup.RDBSize)
+		}
+
+		backup.Checksum, err = createBackupArchive(rdbFile, archiveFile, compression, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	backup.Size = fsutil.GetSize(archiveFile)
+
+	for _, storage := range storages {
+		if progressHandler != nil {
+			progressHandler(BACKUP_STAGE_STORE, backup.Size)
+		}
+
+		err = storeBackup(storage, backup, archiveFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't save backup to %s storage: %w", storage.Name(), err)
+		}
+	}
+
+	_, err = ApplyBackupRetention(id)
+
+	if err != nil {
+		return backup, err
+	}
+
+	return backup, nil
+}
+
+// RestoreInstanceBackup replaces instance data with data from given backup.
+// If instance uses append only file, RDB snapshot will be used as a base of
+// a new append only file.
+func RestoreInstanceBackup(id int, backup *BackupInfo) error {
+	if backup.IsAOF() {
+		return restoreAOFBackup(id, backup)
+	}
+
+	dataDir := GetInstanceDataDirPath(id)
+	rdbFile := GetInstanceRDBPath(id)
+	tmpFile := rdbFile + ".restore"
+
+	uid, gid, err := fsutil.GetOwner(dataDir)
+
+	if err != nil {
+		return fmt.Errorf("Can't get data directory owner: %w", err)
+	}
+
+	defer os.Remove(tmpFile)
+
+	err = extractBackup(backup, tmpFile)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Chown(tmpFile, uid, gid)
+
+	if err != nil {
+		return fmt.Errorf("Can't change RDB file owner: %w", err)
+	}
+
+	if IsInstanceAOFEnabled(id) {
+		// Redis ignores RDB file if append only file is enabled
+		err = replaceAOFWithRDB(id, tmpFile)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmpFile, rdbFile)
+}
+
+// VerifyBackup checks backup archive checksum and validates RDB file format
+// using redis-check-rdb (if present) and built-in RDB parser
+func VerifyBackup(backup *BackupInfo) (*BackupVerifyInfo, error) {
+	if backup.IsAOF() {
+		return verifyAOFBackup(backup)
+	}
+
+	tmpFd, err := os.CreateTemp(Config.GetS(PATH_DATA_DIR), ".verify-*.rdb")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create temporary file: %w", err)
+	}
+
+	tmpFile := tmpFd.Name()
+	tmpFd.Close()
+
+	defer os.Remove(tmpFile)
+
+	err = extractBackup(backup, tmpFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupVerifyInfo{}
+	result.RDB, err = RDB.Read(tmpFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("RDB file is invalid: %w", err)
+	}
+
+	checkBinary := getCheckBinary("redis-check-rdb")
+	redisVer, _ := GetRedisVersion()
+
+	// redis-check-rdb can't read files created by newer versions of Redis
+	if checkBinary != "" && CheckRDBCompatibility(result.RDB, redisVer) == nil {
+		err = execDataCheck(checkBinary, tmpFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.CheckBinary = checkBinary
+	}
+
+	return result, nil
+}
+
+// RemoveInstanceBackups removes all backups of instance with given ID from
+// all configured storages
+func RemoveInstanceBackups(id int) error {
+	storages, err := GetBackupStorages()
+
+	if err != nil {
+		return err
+	}
+
+	owner, err := GetInstanceBackupOwner(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, storage := range storages {
+		backups, err := getStorageBackups(storage, owner)
+
+		if err != nil {
+			return err
+		}
+
+		for _, backup := range backups {
+			err = RemoveBackup(backup)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveBackup removes backup archive and manifest from storage
+func RemoveBackup(backup *BackupInfo) error {
+	storage, err := GetBackupStorage(backup.Storage)
+
+	if err != nil {
+		return err
+	}
+
+	err = storage.Remove(backup.Owner(), backup.File)
+
+	if err != nil {
+		return fmt.Errorf("Can't remove backup file: %w", err)
+	}
+
+	if backup.Checksum == "" {
+		return nil
+	}
+
+	err = storage.Remove(backup.Owner(), getBackupManifestName(backup.File))
+
+	if err != nil {
+		return fmt.Errorf("Can't remove backup manifest: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyBackupRetention removes backups which don't match retention policy from
+// all configured storages and returns info about removed backups
+func ApplyBackupRetention(id int) ([]*BackupInfo, error) {
+	var removed []*BackupInfo
+
+	storages, err := GetBackupStorages()
+
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := GetInstanceBackupOwner(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	retention := GetBackupRetention()
+
+	for _, storage := range storages {
+		backups, err := getStorageBackups(storage, owner)
+
+		if err != nil {
+			return removed, err
+		}
+
+		for _, backup := range getExpiredBackups(backups, retention) {
+			err = RemoveBackup(backup)
+
+			if err != nil {
+				return removed, err
+			}
+
+			removed = append(removed, backup)
+		}
+	}
+
+	return removed, nil
+}
+
+// GetBackupRetention returns backups retention policy from configuration
+func GetBackupRetention() *BackupRetention {
+	return &BackupRetention{
+		KeepLast:   Config.GetI(BACKUP_KEEP_LAST, DEFAULT_BACKUP_KEEP_LAST),
+		KeepHourly: Config.GetI(BACKUP_KEEP_HOURLY),
+		KeepDaily:  Config.GetI(BACKUP_KEEP_DAILY),
+		KeepWeekly: Config.GetI(BACKUP_KEEP_WEEKLY),
+		MaxSize:    Config.GetSZ(BACKUP_MAX_SIZE),
+	}
+}
+
+// GetInstanceBackupSchedule returns backup schedule (cron expression) for
+// instance with given ID. Instance schedule takes precedence over tag schedule,
+// and tag schedule takes precedence over global schedule.
+func GetInstanceBackupSchedule(id int) string {
+	schedule := Config.GetS(BACKUP_SCHEDULES + ":instance-" + strconv.Itoa(id))
+
+	if schedule == "" {
+		meta, err := GetInstanceMeta(id)
+
+		if err == nil {
+			for _, tag := range meta.Tags {
+				tagName, _ := ParseTag(tag)
+				schedule = Config.GetS(BACKUP_SCHEDULES + ":tag-" + tagName)
+
+				if schedule != "" {
+					break
+				}
+			}
+		}
+	}
+
+	if schedule == "" {
+		schedule = Config.GetS(BACKUP_SCHEDULE)
+	}
+
+	if schedule == BACKUP_SCHEDULE_OFF {
+		return ""
+	}
+
+	return schedule
+}
+
+// GetBackupDumpTimeout returns maximum duration of saving instance data
+func GetBackupDumpTimeout() time.Duration {
+	return Config.GetTD(BACKUP_DUMP_TIMEOUT, DEFAULT_BACKUP_DUMP_TIMEOUT)
+}
+
+// HasBackupSchedules returns true if at least one backup schedule is defined
+func HasBackupSchedules() bool {
+	if Config.GetS(BACKUP_SCHEDULE) != "" && Config.GetS(BACKUP_SCHEDULE) != BACKUP_SCHEDULE_OFF {
+		return true
+	}
+
+	for _, prop := range Config.Props(BACKUP_SCHEDULES) {
+		if Config.GetS(BACKUP_SCHEDULES+":"+prop) != BACKUP_SCHEDULE_OFF {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WaitForDump waits until RDB file is updated. Handler will be called with
+// the size of temporary RDB file while instance saves data.
+func WaitForDump(id int, progressHandler func(size int64)) error {
+	var tempRDB string
+
+	start := time.Now()
+	timeout := GetBackupDumpTimeout()
+	rdbFile := GetInstanceRDBPath(id)
+	modTime, _ := fsutil.GetMTime(rdbFile)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		curModTime, _ := fsutil.GetMTime(rdbFile)
+
+		if !curModTime.IsZero() && !curModTime.Equal(modTime) {
+			return nil
+		}
+
+		state, err := GetInstanceState(id, false)
+
+		if err == nil && !state.IsWorks() {
+			return fmt.Errorf("Instance stopped before data was saved")
+		}
+
+		info, err := GetInstanceInfo(id, 3*time.Second, false)
+
+		if err == nil && info.Get("persistence", "rdb_bgsave_in_progress") == "0" {
+			if info.Get("persistence", "rdb_last_bgsave_status") != "ok" {
+				return fmt.Errorf("Background saving failed (see instance log for details)")
+			}
+
+			if info.GetI("persistence", "rdb_last_save_time") >= int(start.Unix()) {
+				return nil
+			}
+		}
+
+		if time.Since(start) > timeout {
+			return fmt.Errorf(
+				"Instance didn't save data in %s (%s)",
+				timeutil.PrettyDuration(timeout), BACKUP_DUMP_TIMEOUT,
+			)
+		}
+
+		if tempRDB == "" {
+			tempRDB = getTemporaryRDBPath(id)
+		}
+
+		if tempRDB != "" && progressHandler != nil {
+			tempRDBSize := fsutil.GetSize(tempRDB)
+
+			if tempRDBSize > 0 {
+				progressHandler(tempRDBSize)
+			}
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsAOF returns true if backup contains append only file
+func (b *BackupInfo) IsAOF() bool {
+	return b != nil && b.Type == BACKUP_TYPE_AOF
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// saveRDB saves instance data using BGSAVE command
+func saveRDB(id int, progressHandler BackupProgressHandler) error {
+	_, err := ExecCommand(id, &REDIS.Request{
+		Command: []string{"BGSAVE"},
+	})
+
+	if err != nil {
+		return fmt.Errorf("Can't exec BGSAVE command: %w", err)
+	}
+
+	return WaitForDump(id, func(size int64) {
+		if progressHandler != nil {
+			progressHandler(BACKUP_STAGE_DUMP, size)
+		}
+	})
+}
+
+// getStorageBackups returns info about backups of given owner from given storage
+// sorted from oldest to newest
+func getStorageBackups(storage BackupStorage, owner *BackupOwner) ([]*BackupInfo, error) {
+	var result []*BackupInfo
+
+	objects, err := storage.List(owner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[string]bool)
+
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Name, ".json") {
+			manifests[obj.Name] = true
+		}
+	}
+
+	for _, obj := range objects {
+		if !strings.Contains(obj.Name, ".rdb") && !strings.Contains(obj.Name, ".aof") {
+			continue
+		}
+
+		manifest := getBackupManifestName(obj.Name)
+
+		if !manifests[manifest] {
+			// Backups created by previous versions of RDS have no manifest
+			if strings.HasSuffix(obj.Name, ".rdb") {
+				result = append(result, &BackupInfo{
+					Storage:      storage.Name(),
+					Storages:     []string{storage.Name()},
+					File:         obj.Name,
+					Type:         BACKUP_TYPE_RDB,
+					Size:         obj.Size,
+					Date:         extractBackupDate(obj.Name),
+					InstanceID:   owner.ID,
+					InstanceUUID: owner.UUID,
+					Hostname:     owner.Hostname,
+				})
+			}
+
+			continue
+		}
+
+		backup, err := readBackupManifest(storage, owner, manifest)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip backups of other instances and nodes placed to owner directory
+		// by mistake
+		if backup.InstanceUUID != "" && owner.UUID != "" && backup.InstanceUUID != owner.UUID {
+			continue
+		}
+
+		if backup.Hostname != "" && owner.Hostname != "" && backup.Hostname != owner.Hostname {
+			continue
+		}
+
+		backup.Storage = storage.Name()
+		backup.Storages = []string{storage.Name()}
+		backup.Type = strutil.Q(backup.Type, BACKUP_TYPE_RDB)
+		backup.InstanceID = owner.ID
+		backup.InstanceUUID = strutil.Q(backup.InstanceUUID, owner.UUID)
+		backup.Hostname = strutil.Q(backup.Hostname, owner.Hostname)
+
+		result = append(result, backup)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result, nil
+}
+
+// readBackupManifest reads and decodes backup manifest
+func readBackupManifest(storage BackupStorage, owner *BackupOwner, manifest string) (*BackupInfo, error) {
+	r, err := storage.Read(owner, manifest)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read backup manifest %s: %w", manifest, err)
+	}
+
+	defer r.Close()
+
+	backup := &BackupInfo{}
+	err = json.NewDecoder(r).Decode(backup)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode backup manifest %s: %w", manifest, err)
+	}
+
+	return backup, nil
+}
+
+// storeBackup writes backup archive and manifest to given storage
+func storeBackup(storage BackupStorage, backup *BackupInfo, archiveFile string) error {
+	fd, err := os.Open(archiveFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	err = storage.Write(backup.Owner(), backup.File, fd, backup.Size)
+
+	if err != nil {
+		return err
+	}
+
+	manifestData, err := json.MarshalIndent(backup, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return storage.Write(
+		backup.Owner(), getBackupManifestName(backup.File),
+		bytes.NewReader(manifestData), int64(len(manifestData)),
+	)
+}
+
+// checkBackupNotExist returns error if storage already contains backup with
+// the same name
+func checkBackupNotExist(storage BackupStorage, backup *BackupInfo) error {
+	objects, err := storage.List(backup.Owner())
+
+	if err != nil {
+		return fmt.Errorf("Can't list backups in %s storage: %w", storage.Name(), err)
+	}
+
+	for _, obj := range objects {
+		if obj.Name == backup.File {
+			return fmt.Errorf("Backup %s already exists in %s storage", backup.File, storage.Name())
+		}
+	}
+
+	return nil
+}
+
+// createBackupArchive creates backup archive from RDB file and returns
+// SHA-256 checksum of archive
+func createBackupArchive(rdbFile, archiveFile, compression string, key *BackupKey) (string, error) {
+	rdbFd, err := os.Open(rdbFile)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't open RDB file: %w", err)
+	}
+
+	defer rdbFd.Close()
+
+	fd, err := os.OpenFile(archiveFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, BACKUP_PERMS)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	defer fd.Close()
+
+	hasher := sha256.New()
+	aw, err := newArchiveWriter(io.MultiWriter(fd, hasher), compression, key)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	_, err = io.Copy(aw, rdbFd)
+
+	if err == nil {
+		err = aw.Close()
+	} else {
+		aw.Close()
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// newArchiveWriter creates writer which compresses and encrypts (if key is
+// set) archive data
+func newArchiveWriter(w io.Writer, compression string, key *BackupKey) (io.WriteCloser, error) {
+	if key == nil {
+		return getCompressor(w, compression)
+	}
+
+	ew, err := newEncryptWriter(w, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cw, err := getCompressor(ew, compression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &archiveWriter{cw, ew}, nil
+}
+
+// getBackupManifestName returns name of manifest for given backup archive
+func getBackupManifestName(file string) string {
+	for _, ext := range []string{".rdb", ".aof"} {
+		base, _, ok := strings.Cut(file, ext)
+
+		if ok {
+			return base + ".json"
+		}
+	}
+
+	return file + ".json"
+}
+
+// extractBackup reads backup from storage, decompresses it to given file
+// and checks archive checksum
+func extractBackup(backup *BackupInfo, file string) error {
+	return readBackup(backup, func(r io.Reader) error {
+		fd, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+
+		if err != nil {
+			return fmt.Errorf("Can't create RDB file: %w", err)
+		}
+
+		_, err = io.Copy(fd, r)
+
+		if err == nil {
+			err = fd.Close()
+		} else {
+			fd.Close()
+		}
+
+		return err
+	})
+}
+
+// readBackup reads backup from storage, passes decompressed data to given
+// handler and checks archive checksum
+func readBackup(backup *BackupInfo, handler func(r io.Reader) error) error {
+	return readBackupStream(backup, nil, true, handler)
+}
+
+// readBackupStream reads backup from storage, decrypts it using given keys (or
+// keys from configuration), decompresses it if required, passes data to given
+// handler and checks archive checksum
+func readBackupStream(backup *BackupInfo, keys []*BackupKey, decompress bool, handler func(r io.Reader) error) error {
+	var err error
+
+	if backup.Encryption != "" && keys == nil {
+		keys, err = GetBackupKeys()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := openBackup(backup)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	hasher := sha256.New()
+	var sr io.Reader = io.TeeReader(r, hasher)
+
+	if backup.Encryption != "" {
+		sr, err = newDecryptReader(sr, keys)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	dr := io.NopCloser(sr)
+
+	if decompress {
+		dr, err = getDecompressor(sr, backup.Compression)
+
+		if err != nil {
+			return fmt.Errorf("Can't decompress backup: %w", err)
+		}
+	}
+
+	err = handler(dr)
+
+	if err == nil {
+		// Read the rest of archive for checksum calculation
+		_, err = io.Copy(io.Discard, dr)
+	}
+
+	if err == nil {
+		err = dr.Close()
+	} else {
+		dr.Close()
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't extract backup: %w", err)
+	}
+
+	if backup.Checksum != "" && backup.Checksum != hex.EncodeToString(hasher.Sum(nil)) {
+		return ErrBackupChecksumMismatch
+	}
+
+	return nil
+}
+
+// openBackup opens backup archive for reading
+func openBackup(backup *BackupInfo) (io.ReadCloser, error) {
+	if backup.Storage == BACKUP_STORAGE_FILE {
+		return os.Open(backup.File)
+	}
+
+	storage, err := GetBackupStorage(backup.Storage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := storage.Read(backup.Owner(), backup.File)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read backup from %s storage: %w", storage.Name(), err)
+	}
+
+	return r, nil
+}
+
+// getCheckBinary returns path to Redis check utility (redis-check-rdb or
+// redis-check-aof) placed near Redis binary
+func getCheckBinary(name string) string {
+	binary := path.Join(path.Dir(Config.GetS(REDIS_BINARY)), name)
+
+	if !fsutil.IsExist(binary) || !fsutil.IsExecutable(binary) {
+		return ""
+	}
+
+	return binary
+}
+
+// execDataCheck checks RDB or AOF file using redis-check-rdb or redis-check-aof
+func execDataCheck(binary, file string) error {
+	output, err := exec.Command(binary, file).CombinedOutput()
+
+	if err == nil {
+		return nil
+	}
+
+	outputLines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lastLine := outputLines[len(outputLines)-1]
+
+	if lastLine != "" {
+		return fmt.Errorf("%s returned error: %s", path.Base(binary), lastLine)
+	}
+
+	return fmt.Errorf("%s returned error: %w", path.Base(binary), err)
+}
+
+// getExpiredBackups returns slice with backups which don't match retention policy
+func getExpiredBackups(backups []*BackupInfo, retention *BackupRetention) []*BackupInfo {
+	var result []*BackupInfo
+
+	if len(backups) == 0 {
+		return nil
+	}
+
+	keep := make(map[string]bool)
+
+	if retention.KeepLast+retention.KeepHourly+retention.KeepDaily+retention.KeepWeekly == 0 {
+		for _, backup := range backups {
+			keep[backup.File] = true
+		}
+	} else {
+		markBackupsByPeriod(backups, keep, retention.KeepLast, func(t time.Time) string {
+			return strconv.FormatInt(t.UnixNano(), 10)
+		})
+
+		markBackupsByPeriod(backups, keep, retention.KeepHourly, func(t time.Time) string {
+			return t.Format("2006010215")
+		})
+
+		markBackupsByPeriod(backups, keep, retention.KeepDaily, func(t time.Time) string {
+			return t.Format("20060102")
+		})
+
+		markBackupsByPeriod(backups, keep, retention.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d%02d", year, week)
+		})
+	}
+
+	if retention.MaxSize > 0 {
+		var totalSize uint64
+
+		for _, backup := range backups {
+			if keep[backup.File] {
+				totalSize += uint64(backup.Size)
+			}
+		}
+
+		// Remove the oldest backups until total size fits the limit, but
+		// always keep the latest one
+		for _, backup := range backups[:len(backups)-1] {
+			if totalSize <= retention.MaxSize {
+				break
+			}
+
+			if keep[backup.File] {
+				keep[backup.File] = false
+				totalSize -= uint64(backup.Size)
+			}
+		}
+	}
+
+	for _, backup := range backups {
+		if !keep[backup.File] {
+			result = append(result, backup)
+		}
+	}
+
+	return result
+}
+
+// markBackupsByPeriod marks the latest backup in each of the given number of
+// latest periods
+func markBackupsByPeriod(backups []*BackupInfo, keep map[string]bool, num int, periodFunc func(t time.Time) string) {
+	if num <= 0 {
+		return
+	}
+
+	periods := make(map[string]bool)
+
+	for i := len(backups) - 1; i >= 0; i-- {
+		period := periodFunc(backups[i].Date)
+
+		if periods[period] {
+			continue
+		}
+
+		periods[period] = true
+		keep[backups[i].File] = true
+
+		if len(periods) == num {
+			break
+		}
+	}
+}
+
+// extractBackupDate extracts backup creation date from filename
+func extractBackupDate(file string) time.Time {
+	ts := strutil.Exclude(file, "backup-")
+	ts, _, _ = strings.Cut(ts, ".")
+	ts, nsec, _ := strings.Cut(ts, "-")
+	nsec, _, _ = strings.Cut(nsec, "-") // Skip revision of re-encrypted backup
+	tsi, _ := strconv.ParseInt(ts, 10, 64)
+	nseci, _ := strconv.ParseInt(nsec, 10, 64)
+
+	if tsi == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(tsi, nseci)
+}
+
+// getTemporaryRDBPath returns path to temporary RDB file
+func getTemporaryRDBPath(id int) string {
+	files := fsutil.List(
+		GetInstanceDataDirPath(id), false,
+		fsutil.ListingFilter{
+			MatchPatterns: []string{"temp-*.rdb"},
+			MTimeYounger:  time.Now().Unix() - 60,
+		},
+	)
+
+	if len(files) == 0 {
+		return ""
+	}
+
+	return path.Join(GetInstanceDataDirPath(id), files[0])
+}
+
+// validateBackupDumpTimeout validates maximum duration of saving data
+func validateBackupDumpTimeout(config knf.IConfig, prop string, value any) error {
+	if config.GetS(prop) != "" && config.GetTD(prop) < time.Minute {
+		return fmt.Errorf("Property %s can't be less than 1 minute", prop)
+	}
+
+	return nil
+}
+
+// validateBackupSchedule validates backup schedule cron expression
+func validateBackupSchedule(config knf.IConfig, prop string, value any) error {
+	schedule := strings.TrimSpace(config.GetS(prop))
+
+	if schedule == "" || schedule == BACKUP_SCHEDULE_OFF {
+		return nil
+	}
+
+	_, err := cron.Parse(schedule)
+
+	if err != nil {
+		return fmt.Errorf("Property %s contains invalid cron expression: %v", prop, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,736 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	REDIS "github.com/essentialkaos/rds/redis"
+	RDB "github.com/essentialkaos/rds/redis/rdb"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AOF_MANIFEST_EXT is extension of multi-part AOF manifest
+const AOF_MANIFEST_EXT = ".manifest"
+
+// AOF_SNAPSHOT_ATTEMPTS is max number of attempts to get consistent set of
+// AOF files
+const AOF_SNAPSHOT_ATTEMPTS = 5
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// aofFile is AOF file opened for archiving
+type aofFile struct {
+	Name string    // Name of file in archive
+	Size int64     // Size of data
+	fd   *os.File  // File descriptor
+	r    io.Reader // Data reader
+}
+
+// aofManifestEntry is entry from multi-part AOF manifest
+type aofManifestEntry struct {
+	File string // File name
+	Type string // File type (b - base, i - incremental, h - history)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrBackupAOFChanged is returned if AOF files are changed too often for
+// creating consistent backup
+var ErrBackupAOFChanged = errors.New("Can't get consistent set of AOF files: manifest changed during backup")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hasAOF returns true if instance has append only file
+func hasAOF(id int) bool {
+	return fsutil.IsExist(getAOFManifestPath(id)) || fsutil.IsExist(GetInstanceAOFPath(id))
+}
+
+// getAOFManifestPath returns path to multi-part AOF manifest
+func getAOFManifestPath(id int) string {
+	return path.Join(
+		GetInstanceAOFDirPath(id),
+		path.Base(GetInstanceAOFPath(id))+AOF_MANIFEST_EXT,
+	)
+}
+
+// isMultiPartAOFSupported returns true if installed Redis supports multi-part AOF
+func isMultiPartAOFSupported() bool {
+	redisVer, err := GetRedisVersion()
+	return err == nil && redisVer.Major() >= 7
+}
+
+// rewriteAOF rewrites append only file using BGREWRITEAOF command and waits
+// until rewrite is finished
+func rewriteAOF(id int, progressHandler BackupProgressHandler) error {
+	info, err := GetInstanceInfo(id, 3*time.Second, false)
+
+	if err != nil {
+		return fmt.Errorf("Can't get instance info: %w", err)
+	}
+
+	if info.Get("persistence", "aof_enabled") != "1" {
+		return ErrBackupAOFDisabled
+	}
+
+	if progressHandler != nil {
+		progressHandler(BACKUP_STAGE_REWRITE, 0)
+	}
+
+	// Don't start rewrite if it's already in progress
+	if info.Get("persistence", "aof_rewrite_in_progress") != "1" {
+		_, err = ExecCommand(id, &REDIS.Request{
+			Command: []string{"BGREWRITEAOF"},
+		})
+
+		if err != nil {
+			return fmt.Errorf("Can't exec BGREWRITEAOF command: %w", err)
+		}
+	}
+
+	start := time.Now()
+	timeout := GetBackupDumpTimeout()
+	ticker := time.NewTicker(time.Second)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+		state, err := GetInstanceState(id, false)
+
+		if err == nil && !state.IsWorks() {
+			return fmt.Errorf("Instance stopped before append only file was rewritten")
+		}
+
+		if time.Since(start) > timeout {
+			return fmt.Errorf(
+				"Instance didn't rewrite append only file in %s (%s)",
+				timeutil.PrettyDuration(timeout), BACKUP_DUMP_TIMEOUT,
+			)
+		}
+
+		info, err = GetInstanceInfo(id, 3*time.Second, false)
+
+		if err != nil {
+			continue
+		}
+
+		if info.Get("persistence", "aof_rewrite_in_progress") == "1" ||
+			info.Get("persistence", "aof_rewrite_scheduled") == "1" {
+			continue
+		}
+
+		if info.Get("persistence", "aof_last_bgrewrite_status") != "ok" {
+			return fmt.Errorf("Append only file rewrite failed")
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// createAOFBackupArchive creates tar archive with instance AOF files and
+// returns SHA-256 checksum of archive and size of AOF files
+func createAOFBackupArchive(id int, archiveFile, compression string, key *BackupKey) (string, int64, error) {
+	files, err := openAOFFiles(id)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer closeAOFFiles(files)
+
+	fd, err := os.OpenFile(archiveFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, BACKUP_PERMS)
+
+	if err != nil {
+		return "", 0, fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	defer fd.Close()
+
+	hasher := sha256.New()
+	cw, err := newArchiveWriter(io.MultiWriter(fd, hasher), compression, key)
+
+	if err != nil {
+		return "", 0, fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	var size int64
+
+	tw := tar.NewWriter(cw)
+	now := time.Now()
+
+	for _, file := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.Name,
+			Size:     file.Size,
+			Mode:     0640,
+			ModTime:  now,
+		})
+
+		if err == nil {
+			_, err = io.Copy(tw, file.r)
+		}
+
+		if err != nil {
+			cw.Close()
+			return "", 0, fmt.Errorf("Can't add %s to backup archive: %w", file.Name, err)
+		}
+
+		size += file.Size
+	}
+
+	err = tw.Close()
+
+	if err == nil {
+		err = cw.Close()
+	} else {
+		cw.Close()
+	}
+
+	if err != nil {
+		return "", 0, fmt.Errorf("Can't create backup archive: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+// openAOFFiles opens all AOF files of instance. For multi-part AOF all files
+// from manifest are opened and manifest is checked again to make sure that
+// files were not replaced by rewrite while opening. Data appended to AOF after
+// opening is ignored.
+func openAOFFiles(id int) ([]*aofFile, error) {
+	manifestFile := getAOFManifestPath(id)
+
+	if !fsutil.IsExist(manifestFile) {
+		aofPath := GetInstanceAOFPath(id)
+
+		if !fsutil.IsExist(aofPath) {
+			return nil, ErrBackupNoAOF
+		}
+
+		file, err := openAOFFile(aofPath, path.Base(aofPath))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []*aofFile{file}, nil
+	}
+
+	aofDir := path.Dir(manifestFile)
+	dirName := path.Base(aofDir)
+
+	for range AOF_SNAPSHOT_ATTEMPTS {
+		manifestData, err := os.ReadFile(manifestFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read AOF manifest: %w", err)
+		}
+
+		entries, manifestData := parseAOFManifest(manifestData)
+
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("AOF manifest %s is empty", manifestFile)
+		}
+
+		files, err := openAOFManifestFiles(aofDir, dirName, entries)
+
+		if err != nil {
+			// Files can be removed by rewrite, so we just try again
+			continue
+		}
+
+		curManifestData, err := os.ReadFile(manifestFile)
+
+		if err != nil || !bytes.Equal(manifestData, filterAOFManifest(curManifestData)) {
+			closeAOFFiles(files)
+			continue
+		}
+
+		files = append(files, &aofFile{
+			Name: dirName + "/" + path.Base(manifestFile),
+			Size: int64(len(manifestData)),
+			r:    bytes.NewReader(manifestData),
+		})
+
+		return files, nil
+	}
+
+	return nil, ErrBackupAOFChanged
+}
+
+// openAOFManifestFiles opens all files from AOF manifest
+func openAOFManifestFiles(aofDir, dirName string, entries []aofManifestEntry) ([]*aofFile, error) {
+	var result []*aofFile
+
+	for _, entry := range entries {
+		file, err := openAOFFile(path.Join(aofDir, entry.File), dirName+"/"+entry.File)
+
+		if err != nil {
+			closeAOFFiles(result)
+			return nil, err
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// openAOFFile opens AOF file for archiving
+func openAOFFile(file, name string) (*aofFile, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't open AOF file: %w", err)
+	}
+
+	stat, err := fd.Stat()
+
+	if err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("Can't get AOF file info: %w", err)
+	}
+
+	return &aofFile{
+		Name: name,
+		Size: stat.Size(),
+		fd:   fd,
+		r:    io.LimitReader(fd, stat.Size()),
+	}, nil
+}
+
+// closeAOFFiles closes all opened AOF files
+func closeAOFFiles(files []*aofFile) {
+	for _, file := range files {
+		if file.fd != nil {
+			file.fd.Close()
+		}
+	}
+}
+
+// parseAOFManifest parses multi-part AOF manifest and returns base and
+// incremental files and manifest data without history files
+func parseAOFManifest(data []byte) ([]aofManifestEntry, []byte) {
+	var result []aofManifestEntry
+
+	data = filterAOFManifest(data)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		entry := parseAOFManifestLine(line)
+
+		if entry.File != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result, data
+}
+
+// filterAOFManifest removes history files from AOF manifest
+func filterAOFManifest(data []byte) []byte {
+	var buf bytes.Buffer
+
+	for _, line := range strings.Split(string(data), "\n") {
+		entry := parseAOFManifestLine(line)
+
+		if entry.File == "" || entry.Type == "h" {
+			continue
+		}
+
+		buf.WriteString(line + "\n")
+	}
+
+	return buf.Bytes()
+}
+
+// parseAOFManifestLine parses line from AOF manifest
+func parseAOFManifestLine(line string) aofManifestEntry {
+	var entry aofManifestEntry
+
+	fields := strings.Fields(line)
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch fields[i] {
+		case "file":
+			entry.File = strings.Trim(fields[i+1], `"`)
+		case "type":
+			entry.Type = fields[i+1]
+		}
+	}
+
+	// File name with path separator is not allowed in manifest
+	if strings.Contains(entry.File, "/") {
+		return aofManifestEntry{}
+	}
+
+	return entry
+}
+
+// extractAOFArchive extracts files from AOF backup archive to given directory
+// and returns list with names of files in archive
+func extractAOFArchive(backup *BackupInfo, dir string) ([]string, error) {
+	var result []string
+
+	err := readBackup(backup, func(r io.Reader) error {
+		tr := tar.NewReader(r)
+
+		for {
+			hdr, err := tr.Next()
+
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			name := path.Clean(hdr.Name)
+
+			if path.IsAbs(name) || strings.HasPrefix(name, "..") || strings.Count(name, "/") > 1 {
+				return fmt.Errorf("Archive contains file with invalid name %q", hdr.Name)
+			}
+
+			fd, err := os.OpenFile(
+				path.Join(dir, path.Base(name)),
+				os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640,
+			)
+
+			if err != nil {
+				return fmt.Errorf("Can't create AOF file: %w", err)
+			}
+
+			_, err = io.Copy(fd, tr)
+
+			if err == nil {
+				err = fd.Close()
+			} else {
+				fd.Close()
+			}
+
+			if err != nil {
+				return err
+			}
+
+			result = append(result, name)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("Backup archive doesn't contain append only files")
+	}
+
+	return result, nil
+}
+
+// restoreAOFBackup replaces instance AOF with files from given backup
+func restoreAOFBackup(id int, backup *BackupInfo) error {
+	if !IsInstanceAOFEnabled(id) {
+		return ErrBackupAOFDisabled
+	}
+
+	dataDir := GetInstanceDataDirPath(id)
+	aofDir := GetInstanceAOFDirPath(id)
+	aofPath := GetInstanceAOFPath(id)
+	tmpDir := aofDir + ".restore"
+
+	uid, gid, err := fsutil.GetOwner(dataDir)
+
+	if err != nil {
+		return fmt.Errorf("Can't get data directory owner: %w", err)
+	}
+
+	os.RemoveAll(tmpDir)
+
+	err = os.Mkdir(tmpDir, 0750)
+
+	if err != nil {
+		return fmt.Errorf("Can't create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	files, err := extractAOFArchive(backup, tmpDir)
+
+	if err != nil {
+		return err
+	}
+
+	manifest := getAOFArchiveManifest(files)
+
+	if manifest == "" {
+		if len(files) != 1 {
+			return fmt.Errorf("Backup archive contains more than one AOF file without manifest")
+		}
+
+		tmpFile := path.Join(tmpDir, path.Base(files[0]))
+		err = os.Chown(tmpFile, uid, gid)
+
+		if err != nil {
+			return fmt.Errorf("Can't change AOF file owner: %w", err)
+		}
+
+		// Redis 7+ prefers multi-part AOF if it exists
+		err = os.RemoveAll(aofDir)
+
+		if err != nil {
+			return fmt.Errorf("Can't remove current AOF directory: %w", err)
+		}
+
+		return os.Rename(tmpFile, aofPath)
+	}
+
+	if !isMultiPartAOFSupported() {
+		return ErrBackupMultiPartAOF
+	}
+
+	// Manifest name depends on appendfilename property
+	err = os.Rename(
+		path.Join(tmpDir, path.Base(manifest)),
+		path.Join(tmpDir, path.Base(aofPath)+AOF_MANIFEST_EXT),
+	)
+
+	if err != nil {
+		return fmt.Errorf("Can't rename AOF manifest: %w", err)
+	}
+
+	return replaceAOFDir(tmpDir, aofDir, aofPath, uid, gid)
+}
+
+// replaceAOFWithRDB replaces instance AOF with AOF which uses given RDB file
+// as a base
+func replaceAOFWithRDB(id int, rdbFile string) error {
+	dataDir := GetInstanceDataDirPath(id)
+	aofDir := GetInstanceAOFDirPath(id)
+	aofPath := GetInstanceAOFPath(id)
+
+	uid, gid, err := fsutil.GetOwner(dataDir)
+
+	if err != nil {
+		return fmt.Errorf("Can't get data directory owner: %w", err)
+	}
+
+	if !isMultiPartAOFSupported() {
+		// Redis < 7 can load AOF with RDB preamble
+		err = fsutil.CopyFile(rdbFile, aofPath, 0640)
+
+		if err == nil {
+			err = os.Chown(aofPath, uid, gid)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Can't create AOF file: %w", err)
+		}
+
+		return nil
+	}
+
+	tmpDir := aofDir + ".restore"
+	baseName := path.Base(aofPath) + ".1.base.rdb"
+
+	os.RemoveAll(tmpDir)
+
+	err = os.Mkdir(tmpDir, 0750)
+
+	if err != nil {
+		return fmt.Errorf("Can't create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	err = fsutil.CopyFile(rdbFile, path.Join(tmpDir, baseName), 0640)
+
+	if err != nil {
+		return fmt.Errorf("Can't create AOF base file: %w", err)
+	}
+
+	err = os.WriteFile(
+		path.Join(tmpDir, path.Base(aofPath)+AOF_MANIFEST_EXT),
+		[]byte("file "+baseName+" seq 1 type b\n"), 0640,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Can't create AOF manifest: %w", err)
+	}
+
+	return replaceAOFDir(tmpDir, aofDir, aofPath, uid, gid)
+}
+
+// replaceAOFDir replaces instance AOF directory with given directory
+func replaceAOFDir(tmpDir, aofDir, aofPath string, uid, gid int) error {
+	err := os.Chown(tmpDir, uid, gid)
+
+	if err != nil {
+		return fmt.Errorf("Can't change AOF directory owner: %w", err)
+	}
+
+	for _, file := range fsutil.List(tmpDir, true) {
+		err = os.Chown(path.Join(tmpDir, file), uid, gid)
+
+		if err != nil {
+			return fmt.Errorf("Can't change AOF file owner: %w", err)
+		}
+	}
+
+	err = os.RemoveAll(aofDir)
+
+	if err == nil {
+		err = os.Remove(aofPath)
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Can't remove current AOF: %w", err)
+	}
+
+	return os.Rename(tmpDir, aofDir)
+}
+
+// verifyAOFBackup checks backup archive checksum and validates AOF files
+func verifyAOFBackup(backup *BackupInfo) (*BackupVerifyInfo, error) {
+	tmpDir, err := os.MkdirTemp(Config.GetS(PATH_DATA_DIR), ".verify-*")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	files, err := extractAOFArchive(backup, tmpDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupVerifyInfo{AOFFiles: files}
+	checkTarget := path.Join(tmpDir, path.Base(files[0]))
+	baseFile := checkTarget
+
+	if manifest := getAOFArchiveManifest(files); manifest != "" {
+		checkTarget = path.Join(tmpDir, path.Base(manifest))
+		baseFile, err = checkAOFManifestFiles(checkTarget)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Base file or single AOF can contain RDB data (RDB preamble)
+	if hasRDBPreamble(baseFile) {
+		result.RDB, err = RDB.Read(baseFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("RDB data in AOF is invalid: %w", err)
+		}
+	}
+
+	checkBinary := getCheckBinary("redis-check-aof")
+	redisVer, _ := GetRedisVersion()
+
+	if checkBinary == "" || (result.RDB != nil && CheckRDBCompatibility(result.RDB, redisVer) != nil) {
+		return result, nil
+	}
+
+	err = execDataCheck(checkBinary, checkTarget)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.CheckBinary = checkBinary
+
+	return result, nil
+}
+
+// checkAOFManifestFiles checks that all files from manifest are present and
+// returns path to base file
+func checkAOFManifestFiles(manifestFile string) (string, error) {
+	var baseFile string
+
+	data, err := os.ReadFile(manifestFile)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't read AOF manifest: %w", err)
+	}
+
+	entries, _ := parseAOFManifest(data)
+
+	if len(entries) == 0 {
+		return "", fmt.Errorf("AOF manifest is empty")
+	}
+
+	for _, entry := range entries {
+		file := path.Join(path.Dir(manifestFile), entry.File)
+
+		if !fsutil.IsExist(file) {
+			return "", fmt.Errorf("AOF file %s from manifest is missing in archive", entry.File)
+		}
+
+		if entry.Type == "b" {
+			baseFile = file
+		}
+	}
+
+	return baseFile, nil
+}
+
+// getAOFArchiveManifest returns name of multi-part AOF manifest from list of
+// archive files
+func getAOFArchiveManifest(files []string) string {
+	for _, file := range files {
+		if strings.HasSuffix(file, AOF_MANIFEST_EXT) {
+			return file
+		}
+	}
+
+	return ""
+}
+
+// hasRDBPreamble returns true if file starts with RDB header
+func hasRDBPreamble(file string) bool {
+	if file == "" {
+		return false
+	}
+
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return false
+	}
+
+	defer fd.Close()
+
+	header := make([]byte, 5)
+	_, err = io.ReadFull(fd, header)
+
+	return err == nil && string(header) == RDB.MAGIC
+}
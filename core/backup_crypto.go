@@ -0,0 +1,740 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/strutil"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// BACKUP_ENCRYPTION_AES_GCM is name of encryption used for backups
+const BACKUP_ENCRYPTION_AES_GCM = "aes-256-gcm"
+
+// BACKUP_ENCRYPTED_EXT is extension of encrypted backup archives
+const BACKUP_ENCRYPTED_EXT = ".enc"
+
+// BACKUP_KEY_PERMS is permissions for file with encryption keys
+const BACKUP_KEY_PERMS = 0600
+
+const (
+	// ENC_MAGIC is magic string of encrypted archive
+	ENC_MAGIC = "RDSENC"
+
+	// ENC_VERSION is encrypted archive format version
+	ENC_VERSION = 1
+
+	// ENC_HEADER_SIZE is size of encrypted archive header
+	// (magic + version + key type + key ID + salt)
+	ENC_HEADER_SIZE = 6 + 1 + 1 + 8 + 16
+
+	// ENC_CHUNK_SIZE is size of plaintext chunk
+	ENC_CHUNK_SIZE = 64 * 1024
+)
+
+const (
+	ENC_KEY_TYPE_FILE       byte = 'k'
+	ENC_KEY_TYPE_PASSPHRASE byte = 'p'
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// BackupKey is backup encryption key
+type BackupKey struct {
+	ID         string // Key ID (empty for passphrase)
+	key        []byte // Master key
+	passphrase string // Passphrase
+}
+
+// encWriter is writer which encrypts data using AES-256-GCM with chunks
+type encWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint64
+}
+
+// decReader is reader which decrypts data encrypted by encWriter
+type decReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	data    []byte
+	counter uint64
+	last    bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	ErrBackupNoKey           = errors.New("There is no suitable key for backup decryption")
+	ErrBackupWrongEncryption = errors.New("Backup archive is not encrypted or has unsupported format")
+	ErrBackupTruncated       = errors.New("Encrypted backup archive is truncated")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsBackupEncryptionEnabled returns true if backups encryption is enabled
+func IsBackupEncryptionEnabled() bool {
+	return Config.GetB(BACKUP_ENCRYPTION)
+}
+
+// NewPassphraseBackupKey creates new encryption key from passphrase
+func NewPassphraseBackupKey(passphrase string) *BackupKey {
+	return &BackupKey{passphrase: passphrase}
+}
+
+// GetBackupKeys returns all backup encryption keys. The first key is used for
+// encryption, others are used only for decryption.
+func GetBackupKeys() ([]*BackupKey, error) {
+	if Config.GetS(BACKUP_ENCRYPTION_KEY_FILE) == "" {
+		if Config.GetS(BACKUP_ENCRYPTION_PASSPHRASE) == "" {
+			return nil, nil
+		}
+
+		return []*BackupKey{NewPassphraseBackupKey(Config.GetS(BACKUP_ENCRYPTION_PASSPHRASE))}, nil
+	}
+
+	return readBackupKeyFile(Config.GetS(BACKUP_ENCRYPTION_KEY_FILE))
+}
+
+// RotateBackupKey generates new encryption key (if key file is used) and
+// re-encrypts backups of all instances of current node (including destroyed
+// ones) from all configured storages with current key. Old passphrase is used
+// for decryption of backups encrypted before passphrase was changed. Old keys
+// are removed from key file only if there are no backups encrypted with them
+// in any configured storage (e.g. backups of other nodes sharing the same
+// storage). Handler will be called for every re-encrypted backup.
+func RotateBackupKey(oldPassphrase string, progressHandler func(backup *BackupInfo)) (int, error) {
+	var keys []*BackupKey
+
+	if !IsBackupEncryptionEnabled() {
+		return 0, fmt.Errorf("Backup encryption is disabled")
+	}
+
+	storages, err := GetBackupStorages()
+
+	if err != nil {
+		return 0, err
+	}
+
+	keyFile := Config.GetS(BACKUP_ENCRYPTION_KEY_FILE)
+
+	if keyFile != "" {
+		newKey, err := genBackupKey()
+
+		if err != nil {
+			return 0, err
+		}
+
+		keys = append(keys, newKey)
+
+		if fsutil.IsExist(keyFile) {
+			oldKeys, err := readBackupKeyFile(keyFile)
+
+			if err != nil {
+				return 0, err
+			}
+
+			keys = append(keys, oldKeys...)
+		}
+
+		// Old keys are kept until all backups are re-encrypted
+		err = writeBackupKeyFile(keyFile, keys)
+
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		keys = append(keys, NewPassphraseBackupKey(Config.GetS(BACKUP_ENCRYPTION_PASSPHRASE)))
+	}
+
+	if oldPassphrase != "" {
+		keys = append(keys, NewPassphraseBackupKey(oldPassphrase))
+	}
+
+	var count int
+
+	usedKeys := make(map[string]bool)
+
+	for _, storage := range storages {
+		owners, err := storage.Owners()
+
+		if err != nil {
+			return count, fmt.Errorf("Can't get list of backups owners from %s storage: %w", storage.Name(), err)
+		}
+
+		for _, owner := range owners {
+			backups, err := getStorageBackups(storage, owner)
+
+			if err != nil {
+				return count, err
+			}
+
+			for _, backup := range backups {
+				// Backups of other nodes can be encrypted by keys we don't have
+				if !owner.IsLocal() || (!keys[0].IsPassphrase() && backup.EncryptionKey == keys[0].ID) {
+					usedKeys[backup.EncryptionKey] = true
+					continue
+				}
+
+				if progressHandler != nil {
+					progressHandler(backup)
+				}
+
+				err = reencryptBackup(backup, keys)
+
+				if err != nil {
+					return count, fmt.Errorf("Can't re-encrypt backup %s: %w", backup.File, err)
+				}
+
+				count++
+			}
+		}
+	}
+
+	if keyFile == "" {
+		return count, nil
+	}
+
+	activeKeys := keys[:1]
+
+	for _, key := range keys[1:] {
+		if !key.IsPassphrase() && usedKeys[key.ID] {
+			activeKeys = append(activeKeys, key)
+		}
+	}
+
+	return count, writeBackupKeyFile(keyFile, activeKeys)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsPassphrase returns true if key is derived from passphrase
+func (k *BackupKey) IsPassphrase() bool {
+	return k != nil && k.passphrase != ""
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Write encrypts and writes data
+func (w *encWriter) Write(p []byte) (int, error) {
+	var n int
+
+	for len(p) > 0 {
+		if len(w.buf) == ENC_CHUNK_SIZE {
+			err := w.flush(false)
+
+			if err != nil {
+				return n, err
+			}
+		}
+
+		size := min(ENC_CHUNK_SIZE-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:size]...)
+		p = p[size:]
+		n += size
+	}
+
+	return n, nil
+}
+
+// Close writes the last chunk of data
+func (w *encWriter) Close() error {
+	return w.flush(true)
+}
+
+// flush encrypts and writes buffered chunk
+func (w *encWriter) flush(last bool) error {
+	nonce := getEncNonce(w.counter, last)
+	_, err := w.w.Write(w.aead.Seal(nil, nonce, w.buf, w.header))
+
+	w.buf = w.buf[:0]
+	w.counter++
+
+	return err
+}
+
+// Read reads and decrypts data
+func (r *decReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.last {
+			return 0, io.EOF
+		}
+
+		err := r.readChunk()
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+// readChunk reads and decrypts next chunk
+func (r *decReader) readChunk() error {
+	n, err := io.ReadFull(r.r, r.buf)
+
+	switch {
+	case err == io.ErrUnexpectedEOF, err == io.EOF:
+		r.last = true
+	case err != nil:
+		return err
+	default:
+		_, err = r.r.Peek(1)
+		r.last = err == io.EOF
+	}
+
+	if n < r.aead.Overhead() {
+		return ErrBackupTruncated
+	}
+
+	r.data, err = r.aead.Open(r.buf[:0], getEncNonce(r.counter, r.last), r.buf[:n], r.header)
+
+	if err != nil {
+		return fmt.Errorf("Can't decrypt backup archive: wrong key or corrupted data")
+	}
+
+	r.counter++
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getBackupEncryptionKey returns key for encrypting new backups or nil if
+// encryption is disabled
+func getBackupEncryptionKey() (*BackupKey, error) {
+	if !IsBackupEncryptionEnabled() {
+		return nil, nil
+	}
+
+	keyFile := Config.GetS(BACKUP_ENCRYPTION_KEY_FILE)
+
+	// Key file is created on first use
+	if keyFile != "" && !fsutil.IsExist(keyFile) {
+		key, err := genBackupKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = createBackupKeyFile(keyFile, key)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := GetBackupKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("There is no key for backup encryption")
+	}
+
+	return keys[0], nil
+}
+
+// reencryptBackup decrypts backup using given keys and encrypts it with the
+// first key. Re-encrypted backup is saved with a new name, so original backup
+// is removed only after new archive and manifest are successfully written.
+func reencryptBackup(backup *BackupInfo, keys []*BackupKey) error {
+	storage, err := GetBackupStorage(backup.Storage)
+
+	if err != nil {
+		return err
+	}
+
+	tmpDir := getBackupTempDir(storage, backup.Owner())
+	err = os.MkdirAll(tmpDir, 0700)
+
+	if err != nil {
+		return fmt.Errorf("Can't create directory for temporary file: %w", err)
+	}
+
+	tmpFd, err := os.CreateTemp(tmpDir, ".rekey-*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("Can't create temporary file: %w", err)
+	}
+
+	tmpFile := tmpFd.Name()
+
+	defer os.Remove(tmpFile)
+
+	hasher := sha256.New()
+	ew, err := newEncryptWriter(io.MultiWriter(tmpFd, hasher), keys[0])
+
+	if err != nil {
+		tmpFd.Close()
+		return err
+	}
+
+	err = readBackupStream(backup, keys, false, func(r io.Reader) error {
+		_, err := io.Copy(ew, r)
+		return err
+	})
+
+	if err == nil {
+		err = ew.Close()
+	}
+
+	tmpFd.Close()
+
+	if err != nil {
+		return err
+	}
+
+	newBackup := *backup
+	newBackup.File = getReencryptedBackupName(backup.File)
+	newBackup.Encryption = BACKUP_ENCRYPTION_AES_GCM
+	newBackup.EncryptionKey = keys[0].ID
+	newBackup.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	newBackup.Size = fsutil.GetSize(tmpFile)
+
+	err = checkBackupNotExist(storage, &newBackup)
+
+	if err != nil {
+		return err
+	}
+
+	err = storeBackup(storage, &newBackup, tmpFile)
+
+	if err != nil {
+		return err
+	}
+
+	return RemoveBackup(backup)
+}
+
+// getReencryptedBackupName returns name for re-encrypted backup with increased
+// revision (backup-<ts>-<nsec>-r<revision>.rdb.enc)
+func getReencryptedBackupName(file string) string {
+	name, ext, _ := strings.Cut(file, ".")
+	revision := 1
+	base, revStr, ok := strings.Cut(strutil.Exclude(name, "backup-"), "-r")
+
+	if ok {
+		rev, err := strconv.Atoi(revStr)
+
+		if err == nil && rev > 0 {
+			name, revision = "backup-"+base, rev+1
+		}
+	}
+
+	if !strings.HasSuffix(ext, BACKUP_ENCRYPTED_EXT) {
+		ext += BACKUP_ENCRYPTED_EXT
+	}
+
+	return name + "-r" + strconv.Itoa(revision) + "." + ext
+}
+
+// newEncryptWriter creates writer which encrypts data with given key
+func newEncryptWriter(w io.Writer, key *BackupKey) (io.WriteCloser, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't generate salt: %w", err)
+	}
+
+	fileKey, err := key.derive(salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, ENC_HEADER_SIZE)
+	header = append(header, ENC_MAGIC...)
+	header = append(header, ENC_VERSION)
+
+	if key.IsPassphrase() {
+		header = append(header, ENC_KEY_TYPE_PASSPHRASE)
+		header = append(header, getPassphraseCheck(fileKey)...)
+	} else {
+		header = append(header, ENC_KEY_TYPE_FILE)
+		keyID, _ := hex.DecodeString(key.ID)
+		header = append(header, keyID...)
+	}
+
+	header = append(header, salt...)
+
+	aead, err := newAEAD(fileKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &encWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, ENC_CHUNK_SIZE),
+	}, nil
+}
+
+// newDecryptReader creates reader which decrypts data using one of given keys
+func newDecryptReader(r io.Reader, keys []*BackupKey) (io.Reader, error) {
+	header := make([]byte, ENC_HEADER_SIZE)
+	_, err := io.ReadFull(r, header)
+
+	if err != nil {
+		return nil, ErrBackupWrongEncryption
+	}
+
+	if string(header[:6]) != ENC_MAGIC || header[6] != ENC_VERSION {
+		return nil, ErrBackupWrongEncryption
+	}
+
+	keyType, keyID, salt := header[7], header[8:16], header[16:]
+	fileKey, err := findFileKey(keys, keyType, keyID, salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(fileKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &decReader{
+		r:      bufio.NewReaderSize(r, ENC_CHUNK_SIZE),
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, ENC_CHUNK_SIZE+aead.Overhead()),
+	}, nil
+}
+
+// findFileKey finds key for given archive header and derives file key
+func findFileKey(keys []*BackupKey, keyType byte, keyID, salt []byte) ([]byte, error) {
+	for _, key := range keys {
+		switch {
+		case keyType == ENC_KEY_TYPE_FILE && !key.IsPassphrase():
+			if key.ID != hex.EncodeToString(keyID) {
+				continue
+			}
+
+			return key.derive(salt)
+
+		case keyType == ENC_KEY_TYPE_PASSPHRASE && key.IsPassphrase():
+			fileKey, err := key.derive(salt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if hmac.Equal(getPassphraseCheck(fileKey), keyID) {
+				return fileKey, nil
+			}
+		}
+	}
+
+	return nil, ErrBackupNoKey
+}
+
+// derive derives archive encryption key using given salt
+func (k *BackupKey) derive(salt []byte) ([]byte, error) {
+	if k.IsPassphrase() {
+		key, err := scrypt.Key([]byte(k.passphrase), salt, 1<<15, 8, 1, 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't derive key from passphrase: %w", err)
+		}
+
+		return key, nil
+	}
+
+	mac := hmac.New(sha256.New, k.key)
+	mac.Write(salt)
+
+	return mac.Sum(nil), nil
+}
+
+// newAEAD creates AES-256-GCM cipher
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// getEncNonce returns nonce for chunk with given index
+func getEncNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+// getPassphraseCheck returns value for checking key derived from passphrase
+func getPassphraseCheck(fileKey []byte) []byte {
+	mac := hmac.New(sha256.New, fileKey)
+	mac.Write([]byte(ENC_MAGIC))
+	return mac.Sum(nil)[:8]
+}
+
+// genBackupKey generates new random encryption key
+func genBackupKey() (*BackupKey, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't generate encryption key: %w", err)
+	}
+
+	return newBackupKey(key), nil
+}
+
+// newBackupKey creates key struct for given master key
+func newBackupKey(key []byte) *BackupKey {
+	keyHash := sha256.Sum256(key)
+	return &BackupKey{ID: hex.EncodeToString(keyHash[:8]), key: key}
+}
+
+// readBackupKeyFile reads keys from key file (one hex-encoded key per line)
+func readBackupKeyFile(file string) ([]*BackupKey, error) {
+	var result []*BackupKey
+
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read encryption key file: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, err := hex.DecodeString(line)
+
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("Encryption key file %s contains invalid key", file)
+		}
+
+		result = append(result, newBackupKey(key))
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("Encryption key file %s is empty", file)
+	}
+
+	return result, nil
+}
+
+// writeBackupKeyFile writes keys to key file
+func writeBackupKeyFile(file string, keys []*BackupKey) error {
+	var buf bytes.Buffer
+
+	for _, key := range keys {
+		buf.WriteString(hex.EncodeToString(key.key) + "\n")
+	}
+
+	tmpFile := file + ".tmp"
+	err := os.WriteFile(tmpFile, buf.Bytes(), BACKUP_KEY_PERMS)
+
+	if err != nil {
+		return fmt.Errorf("Can't write encryption key file: %w", err)
+	}
+
+	return os.Rename(tmpFile, file)
+}
+
+// createBackupKeyFile creates key file with given key. If file was created
+// by another process, file will be kept as is.
+func createBackupKeyFile(file string, key *BackupKey) error {
+	err := os.MkdirAll(path.Dir(file), 0700)
+
+	if err != nil {
+		return fmt.Errorf("Can't create directory for encryption key file: %w", err)
+	}
+
+	fd, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, BACKUP_KEY_PERMS)
+
+	switch {
+	case os.IsExist(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("Can't create encryption key file: %w", err)
+	}
+
+	_, err = fd.WriteString(hex.EncodeToString(key.key) + "\n")
+
+	if err == nil {
+		err = fd.Close()
+	} else {
+		fd.Close()
+	}
+
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("Can't write encryption key file: %w", err)
+	}
+
+	return nil
+}
+
+// validateBackupEncryption checks that encryption key file or passphrase is set
+func validateBackupEncryption(config knf.IConfig, prop string, value any) error {
+	if config.GetS(BACKUP_ENCRYPTION_KEY_FILE) == "" && config.GetS(BACKUP_ENCRYPTION_PASSPHRASE) == "" {
+		return fmt.Errorf(
+			"Property %s requires %s or %s to be set", prop,
+			BACKUP_ENCRYPTION_KEY_FILE, BACKUP_ENCRYPTION_PASSPHRASE,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type BackupCryptoSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&BackupCryptoSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *BackupCryptoSuite) TestRoundTrip(c *C) {
+	key, err := genBackupKey()
+	c.Assert(err, IsNil)
+
+	for _, size := range []int{0, 1, ENC_CHUNK_SIZE - 1, ENC_CHUNK_SIZE, ENC_CHUNK_SIZE + 1, ENC_CHUNK_SIZE*3 + 17} {
+		data := genTestData(size)
+		encData := encryptTestData(c, data, key)
+
+		c.Assert(len(encData), Not(Equals), size)
+
+		decData, err := decryptTestData(encData, []*BackupKey{key})
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(decData, data), Equals, true, Commentf("size: %d", size))
+	}
+}
+
+func (s *BackupCryptoSuite) TestPassphrase(c *C) {
+	data := genTestData(ENC_CHUNK_SIZE + 100)
+	encData := encryptTestData(c, data, NewPassphraseBackupKey("test1234"))
+
+	decData, err := decryptTestData(encData, []*BackupKey{
+		NewPassphraseBackupKey("wrong"),
+		NewPassphraseBackupKey("test1234"),
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(decData, data), Equals, true)
+
+	_, err = decryptTestData(encData, []*BackupKey{NewPassphraseBackupKey("wrong")})
+	c.Assert(err, Equals, ErrBackupNoKey)
+}
+
+func (s *BackupCryptoSuite) TestWrongKey(c *C) {
+	key1, _ := genBackupKey()
+	key2, _ := genBackupKey()
+
+	encData := encryptTestData(c, genTestData(100), key1)
+
+	_, err := decryptTestData(encData, []*BackupKey{key2})
+	c.Assert(err, Equals, ErrBackupNoKey)
+
+	_, err = decryptTestData(encData, []*BackupKey{key2, key1})
+	c.Assert(err, IsNil)
+
+	_, err = decryptTestData([]byte("RDB0011"), []*BackupKey{key1})
+	c.Assert(err, Equals, ErrBackupWrongEncryption)
+}
+
+func (s *BackupCryptoSuite) TestTamper(c *C) {
+	key, _ := genBackupKey()
+	keys := []*BackupKey{key}
+	data := genTestData(ENC_CHUNK_SIZE*2 + 10)
+	encData := encryptTestData(c, data, key)
+	chunkSize := ENC_CHUNK_SIZE + 16
+
+	// Modified data
+	modData := bytes.Clone(encData)
+	modData[ENC_HEADER_SIZE+100] ^= 0x01
+	_, err := decryptTestData(modData, keys)
+	c.Assert(err, NotNil)
+
+	// Modified header (salt)
+	modData = bytes.Clone(encData)
+	modData[ENC_HEADER_SIZE-1] ^= 0x01
+	_, err = decryptTestData(modData, keys)
+	c.Assert(err, NotNil)
+
+	// Removed last chunk
+	_, err = decryptTestData(encData[:ENC_HEADER_SIZE+chunkSize*2], keys)
+	c.Assert(err, NotNil)
+
+	// Truncated last chunk
+	_, err = decryptTestData(encData[:len(encData)-5], keys)
+	c.Assert(err, NotNil)
+
+	// Swapped chunks
+	modData = bytes.Clone(encData)
+	copy(modData[ENC_HEADER_SIZE:], encData[ENC_HEADER_SIZE+chunkSize:ENC_HEADER_SIZE+chunkSize*2])
+	copy(modData[ENC_HEADER_SIZE+chunkSize:], encData[ENC_HEADER_SIZE:ENC_HEADER_SIZE+chunkSize])
+	_, err = decryptTestData(modData, keys)
+	c.Assert(err, NotNil)
+
+	// Appended data
+	_, err = decryptTestData(append(bytes.Clone(encData), encData[ENC_HEADER_SIZE:ENC_HEADER_SIZE+chunkSize]...), keys)
+	c.Assert(err, NotNil)
+}
+
+func (s *BackupCryptoSuite) TestKeyFile(c *C) {
+	keyFile := path.Join(c.MkDir(), "keys", "backup.key")
+
+	key1, _ := genBackupKey()
+	key2, _ := genBackupKey()
+
+	c.Assert(createBackupKeyFile(keyFile, key1), IsNil)
+	c.Assert(createBackupKeyFile(keyFile, key2), IsNil)
+
+	keys, err := readBackupKeyFile(keyFile)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 1)
+	c.Assert(keys[0].ID, Equals, key1.ID)
+
+	c.Assert(writeBackupKeyFile(keyFile, []*BackupKey{key2, key1}), IsNil)
+
+	keys, err = readBackupKeyFile(keyFile)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 2)
+	c.Assert(keys[0].ID, Equals, key2.ID)
+	c.Assert(keys[1].ID, Equals, key1.ID)
+
+	os.WriteFile(keyFile, []byte("abcd\n"), 0600)
+	_, err = readBackupKeyFile(keyFile)
+	c.Assert(err, ErrorMatches, "Encryption key file .* contains invalid key")
+}
+
+func (s *BackupCryptoSuite) TestRotation(c *C) {
+	var err error
+
+	tmpDir := c.MkDir()
+	keyFile := path.Join(tmpDir, "keys", "backup.key")
+
+	Config, err = knf.Parse([]byte(
+		"[path]\n  data-dir: " + tmpDir + "\n\n" +
+			"[backup]\n  destinations: dir\n  dir: " + path.Join(tmpDir, "backups") + "\n" +
+			"  encryption: true\n  encryption-key-file: " + keyFile + "\n",
+	))
+
+	c.Assert(err, IsNil)
+
+	// Key file must be created on the first use
+	key1, err := getBackupEncryptionKey()
+	c.Assert(err, IsNil)
+	c.Assert(key1, NotNil)
+
+	hostname, _ := os.Hostname()
+	storage, _ := GetBackupStorage(BACKUP_STORAGE_DIR)
+
+	local := &BackupOwner{ID: 1, UUID: "aaaa-1111", Hostname: hostname}
+	destroyed := &BackupOwner{ID: 2, UUID: "bbbb-2222", Hostname: hostname}
+	foreign := &BackupOwner{ID: 1, UUID: "cccc-3333", Hostname: hostname + "-other"}
+
+	data := genTestData(ENC_CHUNK_SIZE + 1000)
+
+	for _, owner := range []*BackupOwner{local, destroyed, foreign} {
+		storeTestEncryptedBackup(c, storage, owner, data, key1)
+	}
+
+	num, err := RotateBackupKey("", nil)
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 2)
+
+	keys, err := GetBackupKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 2)
+	c.Assert(keys[1].ID, Equals, key1.ID)
+
+	for _, owner := range []*BackupOwner{local, destroyed} {
+		backups, err := getStorageBackups(storage, owner)
+		c.Assert(err, IsNil)
+		c.Assert(backups, HasLen, 1)
+		c.Assert(backups[0].EncryptionKey, Equals, keys[0].ID)
+		c.Assert(backups[0].File, Equals, "backup-1700000000-r1.rdb.enc")
+		c.Assert(backups[0].Date.Unix(), Equals, int64(1700000000))
+		c.Assert(readTestBackup(backups[0]), DeepEquals, data)
+
+		// Original archive and manifest must be removed
+		objects, err := storage.List(owner)
+		c.Assert(err, IsNil)
+		c.Assert(objects, HasLen, 2)
+	}
+
+	// Key must be removed when there are no backups encrypted with it
+	c.Assert(os.RemoveAll(path.Join(tmpDir, "backups", foreign.Hostname)), IsNil)
+
+	num, err = RotateBackupKey("", nil)
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, 2)
+
+	newKeys, err := GetBackupKeys()
+	c.Assert(err, IsNil)
+	c.Assert(newKeys, HasLen, 1)
+	c.Assert(newKeys[0].ID, Not(Equals), keys[0].ID)
+
+	backups, err := getStorageBackups(storage, local)
+	c.Assert(err, IsNil)
+	c.Assert(backups, HasLen, 1)
+	c.Assert(backups[0].File, Equals, "backup-1700000000-r2.rdb.enc")
+	c.Assert(readTestBackup(backups[0]), DeepEquals, data)
+
+	// Temporary files must be created in storage directory and removed
+	c.Assert(fsutil.List(path.Join(tmpDir, "backups", local.Path()), false), HasLen, 2)
+}
+
+func (s *BackupCryptoSuite) TestReencryptedBackupName(c *C) {
+	c.Assert(getReencryptedBackupName("backup-1700000000-000000001.rdb"), Equals, "backup-1700000000-000000001-r1.rdb.enc")
+	c.Assert(getReencryptedBackupName("backup-1700000000-000000001.rdb.zst.enc"), Equals, "backup-1700000000-000000001-r1.rdb.zst.enc")
+	c.Assert(getReencryptedBackupName("backup-1700000000-000000001-r1.aof.tar.enc"), Equals, "backup-1700000000-000000001-r2.aof.tar.enc")
+	c.Assert(getReencryptedBackupName("backup-1700000000.rdb.enc"), Equals, "backup-1700000000-r1.rdb.enc")
+	c.Assert(getReencryptedBackupName("backup-1700000000-r9.rdb.enc"), Equals, "backup-1700000000-r10.rdb.enc")
+
+	c.Assert(getBackupManifestName("backup-1700000000-000000001-r1.rdb.enc"), Equals, "backup-1700000000-000000001-r1.json")
+	c.Assert(extractBackupDate("backup-1700000000-000000001-r1.rdb.enc"), Equals, time.Unix(1700000000, 1))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// genTestData generates random data with given size
+func genTestData(size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	return data
+}
+
+// encryptTestData encrypts data with given key
+func encryptTestData(c *C, data []byte, key *BackupKey) []byte {
+	var buf bytes.Buffer
+
+	w, err := newEncryptWriter(&buf, key)
+	c.Assert(err, IsNil)
+
+	// Write data by small parts for checking chunks buffering
+	for len(data) > 0 {
+		n := min(len(data), 1000)
+		_, err = w.Write(data[:n])
+		c.Assert(err, IsNil)
+		data = data[n:]
+	}
+
+	c.Assert(w.Close(), IsNil)
+
+	return buf.Bytes()
+}
+
+// decryptTestData decrypts data using given keys
+func decryptTestData(data []byte, keys []*BackupKey) ([]byte, error) {
+	r, err := newDecryptReader(bytes.NewReader(data), keys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// storeTestEncryptedBackup saves encrypted backup to storage
+func storeTestEncryptedBackup(c *C, storage BackupStorage, owner *BackupOwner, data []byte, key *BackupKey) {
+	tmpDir := c.MkDir()
+	rdbFile := path.Join(tmpDir, "dump.rdb")
+	archiveFile := path.Join(tmpDir, "archive")
+
+	c.Assert(os.WriteFile(rdbFile, data, 0600), IsNil)
+
+	checksum, err := createBackupArchive(rdbFile, archiveFile, BACKUP_COMPRESSION_NONE, key)
+	c.Assert(err, IsNil)
+
+	backup := &BackupInfo{
+		File:          "backup-1700000000.rdb" + BACKUP_ENCRYPTED_EXT,
+		Type:          BACKUP_TYPE_RDB,
+		Date:          time.Unix(1700000000, 0),
+		Compression:   BACKUP_COMPRESSION_NONE,
+		Encryption:    BACKUP_ENCRYPTION_AES_GCM,
+		EncryptionKey: key.ID,
+		Checksum:      checksum,
+		Size:          fsutil.GetSize(archiveFile),
+		InstanceID:    owner.ID,
+		InstanceUUID:  owner.UUID,
+		Hostname:      owner.Hostname,
+	}
+
+	c.Assert(storeBackup(storage, backup, archiveFile), IsNil)
+}
+
+// readTestBackup reads and decrypts backup data
+func readTestBackup(backup *BackupInfo) []byte {
+	var data []byte
+
+	readBackup(backup, func(r io.Reader) error {
+		var err error
+		data, err = io.ReadAll(r)
+		return err
+	})
+
+	return data
+}
@@ -0,0 +1,549 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// S3_UNSIGNED_PAYLOAD is payload hash value for requests with unsigned body
+const S3_UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD"
+
+// S3_EMPTY_PAYLOAD is SHA-256 hash of empty payload
+const S3_EMPTY_PAYLOAD = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3_PART_SIZE is default size of part for multipart upload, objects larger
+// than part size are uploaded using multipart upload
+const S3_PART_SIZE = 64 * 1024 * 1024
+
+// S3_MAX_PARTS is max number of parts in multipart upload
+const S3_MAX_PARTS = 10000
+
+// S3_REQUEST_TIMEOUT is max duration of one request to S3 (object downloads
+// are limited only by response header timeout)
+const S3_REQUEST_TIMEOUT = 15 * time.Minute
+
+// S3_RESPONSE_TIMEOUT is max time of waiting for response headers
+const S3_RESPONSE_TIMEOUT = 2 * time.Minute
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// s3Storage is S3-compatible backup storage
+type s3Storage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	partSize  int64
+	client    *http.Client
+}
+
+// s3MultipartUpload is result of CreateMultipartUpload request
+type s3MultipartUpload struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// s3CompletedUpload is body of CompleteMultipartUpload request
+type s3CompletedUpload struct {
+	XMLName xml.Name           `xml:"CompleteMultipartUpload"`
+	Parts   []*s3CompletedPart `xml:"Part"`
+}
+
+// s3CompletedPart contains info about uploaded part
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3ListResult is result of ListObjectsV2 request
+type s3ListResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// s3ResponseBody is response body which releases request context on close
+type s3ResponseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// s3Error is S3 error response
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newS3Storage creates new S3-compatible storage using configuration
+func newS3Storage() (*s3Storage, error) {
+	endpoint, err := url.Parse(Config.GetS(BACKUP_S3_ENDPOINT))
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse S3 endpoint URL: %w", err)
+	}
+
+	return &s3Storage{
+		endpoint:  endpoint,
+		region:    Config.GetS(BACKUP_S3_REGION, "us-east-1"),
+		bucket:    Config.GetS(BACKUP_S3_BUCKET),
+		prefix:    strings.Trim(Config.GetS(BACKUP_S3_PREFIX), "/"),
+		accessKey: Config.GetS(BACKUP_S3_ACCESS_KEY),
+		secretKey: Config.GetS(BACKUP_S3_SECRET_KEY),
+		partSize:  S3_PART_SIZE,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   30 * time.Second,
+				ResponseHeaderTimeout: S3_RESPONSE_TIMEOUT,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+	}, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Name returns storage name
+func (s *s3Storage) Name() string {
+	return BACKUP_STORAGE_S3
+}
+
+// Owners returns list of all backups owners in storage
+func (s *s3Storage) Owners() ([]*BackupOwner, error) {
+	var result []*BackupOwner
+
+	owners := make(map[string]bool)
+	objects, err := s.list(s.getPrefix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objects {
+		parts := strings.Split(obj.Name, "/")
+
+		if len(parts) != 3 || owners[parts[0]+"/"+parts[1]] {
+			continue
+		}
+
+		owner := parseBackupOwnerPath(parts[0], parts[1])
+
+		if owner != nil {
+			owners[parts[0]+"/"+parts[1]] = true
+			result = append(result, owner)
+		}
+	}
+
+	return result, nil
+}
+
+// List returns list of objects for given owner
+func (s *s3Storage) List(owner *BackupOwner) ([]*BackupObject, error) {
+	var result []*BackupObject
+
+	objects, err := s.list(s.getOwnerPrefix(owner))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objects {
+		if !strings.Contains(obj.Name, "/") {
+			result = append(result, obj)
+		}
+	}
+
+	return result, nil
+}
+
+// Read opens object for reading
+func (s *s3Storage) Read(owner *BackupOwner, name string) (io.ReadCloser, error) {
+	// Download of big object can take a lot of time, so we don't limit
+	// request duration
+	resp, err := s.doWithTimeout(0, http.MethodGet, s.getOwnerPrefix(owner)+name, nil, nil, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Write writes object with given size
+func (s *s3Storage) Write(owner *BackupOwner, name string, r io.Reader, size int64) error {
+	if size > s.partSize {
+		return s.writeMultipart(s.getOwnerPrefix(owner)+name, r, size)
+	}
+
+	resp, err := s.do(http.MethodPut, s.getOwnerPrefix(owner)+name, nil, r, size)
+
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// Remove removes object
+func (s *s3Storage) Remove(owner *BackupOwner, name string) error {
+	resp, err := s.do(http.MethodDelete, s.getOwnerPrefix(owner)+name, nil, nil, 0)
+
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// list returns list of all objects with given key prefix. Object names are
+// relative to prefix.
+func (s *s3Storage) list(prefix string) ([]*BackupObject, error) {
+	var result []*BackupObject
+	var token string
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(http.MethodGet, "", query, nil, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		listResult := &s3ListResult{}
+		err = xml.NewDecoder(resp.Body).Decode(listResult)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't decode S3 response: %w", err)
+		}
+
+		for _, obj := range listResult.Contents {
+			name := strings.TrimPrefix(obj.Key, prefix)
+
+			if name != "" {
+				result = append(result, &BackupObject{Name: name, Size: obj.Size})
+			}
+		}
+
+		if !listResult.IsTruncated || listResult.NextContinuationToken == "" {
+			break
+		}
+
+		token = listResult.NextContinuationToken
+	}
+
+	return result, nil
+}
+
+// writeMultipart writes object using multipart upload
+func (s *s3Storage) writeMultipart(key string, r io.Reader, size int64) error {
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0)
+
+	if err != nil {
+		return err
+	}
+
+	upload := &s3MultipartUpload{}
+	err = xml.NewDecoder(resp.Body).Decode(upload)
+	resp.Body.Close()
+
+	if err != nil || upload.UploadID == "" {
+		return fmt.Errorf("Can't decode S3 response: %v", err)
+	}
+
+	err = s.uploadParts(key, upload.UploadID, r, size)
+
+	if err != nil {
+		resp, abortErr := s.do(http.MethodDelete, key, url.Values{"uploadId": {upload.UploadID}}, nil, 0)
+
+		if abortErr == nil {
+			resp.Body.Close()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// uploadParts uploads object parts and completes multipart upload
+func (s *s3Storage) uploadParts(key, uploadID string, r io.Reader, size int64) error {
+	completed := &s3CompletedUpload{}
+	partSize := max(s.partSize, (size+S3_MAX_PARTS-1)/S3_MAX_PARTS)
+
+	for partNum, offset := 1, int64(0); offset < size; partNum++ {
+		partLen := min(partSize, size-offset)
+		query := url.Values{
+			"partNumber": {strconv.Itoa(partNum)},
+			"uploadId":   {uploadID},
+		}
+
+		resp, err := s.do(http.MethodPut, key, query, io.LimitReader(r, partLen), partLen)
+
+		if err != nil {
+			return fmt.Errorf("Can't upload part %d: %w", partNum, err)
+		}
+
+		resp.Body.Close()
+
+		completed.Parts = append(completed.Parts, &s3CompletedPart{partNum, resp.Header.Get("ETag")})
+		offset += partLen
+	}
+
+	data, err := xml.Marshal(completed)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(
+		http.MethodPost, key, url.Values{"uploadId": {uploadID}},
+		bytes.NewReader(data), int64(len(data)),
+	)
+
+	if err != nil {
+		return fmt.Errorf("Can't complete multipart upload: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// S3 can return error in response with 200 status code
+	s3Err := &s3Error{}
+	xml.NewDecoder(resp.Body).Decode(s3Err)
+
+	if s3Err.Code != "" {
+		return fmt.Errorf("Can't complete multipart upload: S3 returned error %s: %s", s3Err.Code, s3Err.Message)
+	}
+
+	return nil
+}
+
+// getPrefix returns key prefix for all backup objects
+func (s *s3Storage) getPrefix() string {
+	if s.prefix == "" {
+		return ""
+	}
+
+	return s.prefix + "/"
+}
+
+// getOwnerPrefix returns objects key prefix for given backups owner
+func (s *s3Storage) getOwnerPrefix(owner *BackupOwner) string {
+	return s.getPrefix() + owner.Path() + "/"
+}
+
+// do sends signed request to S3 (path-style) with default timeout and checks
+// response status
+func (s *s3Storage) do(method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	return s.doWithTimeout(S3_REQUEST_TIMEOUT, method, key, query, body, size)
+}
+
+// doWithTimeout sends signed request to S3 (path-style) and checks response status
+func (s *s3Storage) doWithTimeout(timeout time.Duration, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	resp, err := s.send(ctx, method, key, query, body, size)
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Request context must be canceled only after response body is read
+	resp.Body = &s3ResponseBody{resp.Body, cancel}
+
+	return resp, nil
+}
+
+// send sends signed request to S3 and checks response status
+func (s *s3Storage) send(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	uri := "/" + s.bucket
+
+	if key != "" {
+		uri += "/" + key
+	}
+
+	reqURL := *s.endpoint
+	reqURL.Path = strings.TrimRight(reqURL.Path, "/") + uri
+	reqURL.RawQuery = encodeS3Query(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create S3 request: %w", err)
+	}
+
+	payloadHash := S3_EMPTY_PAYLOAD
+
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = S3_UNSIGNED_PAYLOAD
+	}
+
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't send request to S3: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		s3Err := &s3Error{}
+		xml.NewDecoder(resp.Body).Decode(s3Err)
+
+		if s3Err.Code != "" {
+			return nil, fmt.Errorf("S3 returned error %s: %s", s3Err.Code, s3Err.Message)
+		}
+
+		return nil, fmt.Errorf("S3 returned status code %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// sign signs request using AWS Signature Version 4
+func (s *s3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodeS3Path(req.URL.Path),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" +
+		hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders,
+		hex.EncodeToString(hmacSHA256(key, stringToSign)),
+	))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Close closes response body and releases request context
+func (b *s3ResponseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hmacSHA256 calculates HMAC-SHA256 for given data
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// encodeS3Path encodes request path for canonical request
+func encodeS3Path(p string) string {
+	segments := strings.Split(p, "/")
+
+	for i, segment := range segments {
+		segments[i] = encodeS3Value(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// encodeS3Query encodes query with sorted keys for canonical request
+func encodeS3Query(query url.Values) string {
+	var keys, result []string
+
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		result = append(result, encodeS3Value(key)+"="+encodeS3Value(query.Get(key)))
+	}
+
+	return strings.Join(result, "&")
+}
+
+// encodeS3Value encodes value using AWS URI encoding rules
+func encodeS3Value(v string) string {
+	var buf strings.Builder
+
+	for _, c := range []byte(v) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+
+	return buf.String()
+}
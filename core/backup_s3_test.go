@@ -0,0 +1,370 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/knf"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// fakeS3 is minimal in-memory S3-compatible server (MinIO stand-in)
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	parts   map[string]map[int][]byte
+	uploads int
+}
+
+type BackupS3Suite struct {
+	s3     *fakeS3
+	server *httptest.Server
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+var _ = Suite(&BackupS3Suite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *BackupS3Suite) SetUpTest(c *C) {
+	s.s3 = &fakeS3{objects: make(map[string][]byte), parts: make(map[string]map[int][]byte)}
+	s.server = httptest.NewServer(s.s3)
+
+	var err error
+
+	Config, err = knf.Parse([]byte(
+		"[backup]\n  keep-last: 2\n\n" +
+			"[backup-s3]\n  endpoint: " + s.server.URL + "\n  bucket: test\n  prefix: rds\n" +
+			"  access-key: test\n  secret-key: test\n",
+	))
+
+	c.Assert(err, IsNil)
+}
+
+func (s *BackupS3Suite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *BackupS3Suite) TestBasicOperations(c *C) {
+	storage, err := newS3Storage()
+	c.Assert(err, IsNil)
+
+	owner := &BackupOwner{ID: 1, UUID: "d2a0a5f2", Hostname: "node1"}
+
+	c.Assert(storage.Write(owner, "backup-1.rdb", strings.NewReader("DATA"), 4), IsNil)
+	c.Assert(s.s3.has("test/rds/node1/1-d2a0a5f2/backup-1.rdb"), Equals, true)
+
+	objects, err := storage.List(owner)
+	c.Assert(err, IsNil)
+	c.Assert(objects, HasLen, 1)
+	c.Assert(objects[0].Name, Equals, "backup-1.rdb")
+	c.Assert(objects[0].Size, Equals, int64(4))
+
+	r, err := storage.Read(owner, "backup-1.rdb")
+	c.Assert(err, IsNil)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	c.Assert(string(data), Equals, "DATA")
+
+	c.Assert(storage.Remove(owner, "backup-1.rdb"), IsNil)
+
+	objects, err = storage.List(owner)
+	c.Assert(err, IsNil)
+	c.Assert(objects, HasLen, 0)
+
+	_, err = storage.Read(owner, "backup-1.rdb")
+	c.Assert(err, ErrorMatches, "S3 returned error NoSuchKey: .*")
+}
+
+func (s *BackupS3Suite) TestOwnersIsolation(c *C) {
+	storage, err := newS3Storage()
+	c.Assert(err, IsNil)
+
+	master := &BackupOwner{ID: 1, UUID: "aaaa-1111", Hostname: "master"}
+	minion := &BackupOwner{ID: 1, UUID: "aaaa-1111", Hostname: "minion"}
+	reused := &BackupOwner{ID: 1, UUID: "bbbb-2222", Hostname: "master"}
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		for _, owner := range []*BackupOwner{master, minion} {
+			c.Assert(writeTestBackup(storage, owner, date.Add(time.Duration(i)*time.Hour)), IsNil)
+		}
+	}
+
+	c.Assert(writeTestBackup(storage, reused, date.Add(time.Hour)), IsNil)
+
+	owners, err := storage.Owners()
+	c.Assert(err, IsNil)
+	c.Assert(owners, HasLen, 3)
+
+	backups, err := getStorageBackups(storage, reused)
+	c.Assert(err, IsNil)
+	c.Assert(backups, HasLen, 1)
+	c.Assert(backups[0].InstanceUUID, Equals, "bbbb-2222")
+
+	masterBackups, err := getStorageBackups(storage, master)
+	c.Assert(err, IsNil)
+	c.Assert(masterBackups, HasLen, 3)
+
+	// Retention on master must not touch minion backups
+	for _, backup := range getExpiredBackups(masterBackups, GetBackupRetention()) {
+		c.Assert(RemoveBackup(backup), IsNil)
+	}
+
+	masterBackups, err = getStorageBackups(storage, master)
+	c.Assert(err, IsNil)
+	c.Assert(masterBackups, HasLen, 2)
+
+	minionBackups, err := getStorageBackups(storage, minion)
+	c.Assert(err, IsNil)
+	c.Assert(minionBackups, HasLen, 3)
+}
+
+func (s *BackupS3Suite) TestForeignManifest(c *C) {
+	storage, err := newS3Storage()
+	c.Assert(err, IsNil)
+
+	owner := &BackupOwner{ID: 1, UUID: "aaaa-1111", Hostname: "node1"}
+	foreign := &BackupOwner{ID: 1, UUID: "bbbb-2222", Hostname: "node2"}
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c.Assert(writeTestBackup(storage, foreign, date), IsNil)
+
+	// Copy backup of another instance to owner directory
+	s.s3.mu.Lock()
+	for key, data := range s.s3.objects {
+		s.s3.objects[strings.Replace(key, foreign.Path(), owner.Path(), 1)] = data
+	}
+	s.s3.mu.Unlock()
+
+	backups, err := getStorageBackups(storage, owner)
+	c.Assert(err, IsNil)
+	c.Assert(backups, HasLen, 0)
+}
+
+func (s *BackupS3Suite) TestMultipartUpload(c *C) {
+	storage, err := newS3Storage()
+	c.Assert(err, IsNil)
+
+	storage.partSize = 4
+
+	owner := &BackupOwner{ID: 1, UUID: "d2a0a5f2", Hostname: "node1"}
+	data := "ABCDEFGHIJ"
+
+	c.Assert(storage.Write(owner, "backup-1.rdb", strings.NewReader(data), int64(len(data))), IsNil)
+	c.Assert(s.s3.uploads, Equals, 1)
+	c.Assert(s.s3.parts, HasLen, 0)
+
+	r, err := storage.Read(owner, "backup-1.rdb")
+	c.Assert(err, IsNil)
+	readData, _ := io.ReadAll(r)
+	r.Close()
+	c.Assert(string(readData), Equals, data)
+
+	// Failed upload must be aborted
+	err = storage.Write(owner, "backup-2.rdb", strings.NewReader("ABCDEF"), 10)
+	c.Assert(err, NotNil)
+	c.Assert(s.s3.has("test/rds/node1/1-d2a0a5f2/backup-2.rdb"), Equals, false)
+	c.Assert(s.s3.parts, HasLen, 0)
+}
+
+func (s *BackupS3Suite) TestBackupNames(c *C) {
+	storage, err := newS3Storage()
+	c.Assert(err, IsNil)
+
+	owner := &BackupOwner{ID: 1, UUID: "d2a0a5f2", Hostname: "node1"}
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c.Assert(extractBackupDate("backup-1704067200.rdb.gz").Equal(date), Equals, true)
+	c.Assert(extractBackupDate("backup-1704067200-000001500.rdb.gz").Equal(date.Add(1500)), Equals, true)
+	c.Assert(extractBackupDate("unknown.rdb").IsZero(), Equals, true)
+
+	backup := &BackupInfo{
+		File: "backup-1704067200-000001500.rdb", InstanceID: owner.ID,
+		InstanceUUID: owner.UUID, Hostname: owner.Hostname,
+	}
+
+	c.Assert(checkBackupNotExist(storage, backup), IsNil)
+	c.Assert(storage.Write(owner, backup.File, strings.NewReader("DATA"), 4), IsNil)
+	c.Assert(checkBackupNotExist(storage, backup), ErrorMatches, "Backup .* already exists in s3 storage")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeTestBackup writes fake backup with manifest to storage
+func writeTestBackup(storage BackupStorage, owner *BackupOwner, date time.Time) error {
+	backup := &BackupInfo{
+		File:         "backup-" + date.Format("20060102150405") + ".rdb",
+		Type:         BACKUP_TYPE_RDB,
+		Size:         4,
+		Date:         date,
+		Checksum:     "0000",
+		InstanceID:   owner.ID,
+		InstanceUUID: owner.UUID,
+		Hostname:     owner.Hostname,
+	}
+
+	err := storage.Write(owner, backup.File, strings.NewReader("DATA"), 4)
+
+	if err != nil {
+		return err
+	}
+
+	manifest := `{"file":"` + backup.File + `","type":"rdb","size":4,"date":"` +
+		date.Format(time.RFC3339) + `","sha256":"0000","instance_id":1,` +
+		`"instance_uuid":"` + owner.UUID + `","hostname":"` + owner.Hostname + `"}`
+
+	return storage.Write(
+		owner, getBackupManifestName(backup.File),
+		strings.NewReader(manifest), int64(len(manifest)),
+	)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ServeHTTP handles S3 requests
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test/") {
+		writeFakeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		s.list(w, key+"/"+r.URL.Query().Get("prefix"), r.URL.Query().Get("continuation-token"))
+
+	case r.Method == http.MethodPost && r.URL.Query().Has("uploads"):
+		s.uploads++
+		uploadID := "upload-" + strconv.Itoa(s.uploads)
+		s.parts[uploadID] = make(map[int][]byte)
+		xml.NewEncoder(w).Encode(&s3MultipartUpload{UploadID: uploadID})
+
+	case r.Method == http.MethodPut && r.URL.Query().Has("partNumber"):
+		parts, ok := s.parts[r.URL.Query().Get("uploadId")]
+		data, _ := io.ReadAll(r.Body)
+
+		if !ok || int64(len(data)) != r.ContentLength {
+			writeFakeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+
+		partNum, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		parts[partNum] = data
+		w.Header().Set("ETag", `"etag-`+strconv.Itoa(partNum)+`"`)
+
+	case r.Method == http.MethodPost && r.URL.Query().Has("uploadId"):
+		uploadID := r.URL.Query().Get("uploadId")
+		completed := &s3CompletedUpload{}
+		xml.NewDecoder(r.Body).Decode(completed)
+
+		var data []byte
+
+		for _, part := range completed.Parts {
+			data = append(data, s.parts[uploadID][part.PartNumber]...)
+		}
+
+		s.objects[key] = data
+		delete(s.parts, uploadID)
+
+	case r.Method == http.MethodDelete && r.URL.Query().Has("uploadId"):
+		delete(s.parts, r.URL.Query().Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+
+		if !ok {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		w.Write(data)
+
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[key] = data
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// has returns true if object with given key exists
+func (s *fakeS3) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.objects[key]
+
+	return ok
+}
+
+// list writes ListObjectsV2 response with objects with given prefix. Response
+// is paginated by 2 objects for checking continuation tokens handling.
+func (s *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+
+	bucket, _, _ := strings.Cut(prefix, "/")
+
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > token {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	result := &s3ListResult{}
+
+	if len(keys) > 2 {
+		keys = keys[:2]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[1]
+	}
+
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct {
+			Key  string `xml:"Key"`
+			Size int64  `xml:"Size"`
+		}{strings.TrimPrefix(key, bucket+"/"), int64(len(s.objects[key]))})
+	}
+
+	xml.NewEncoder(w).Encode(result)
+}
+
+// writeFakeS3Error writes S3 error response
+func writeFakeS3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(&s3Error{Code: code, Message: http.StatusText(status)})
+}
@@ -0,0 +1,566 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	BACKUP_STORAGE_DATA = "data" // Backups directory in RDS data directory
+	BACKUP_STORAGE_DIR  = "dir"  // Local or network (NFS) directory
+	BACKUP_STORAGE_S3   = "s3"   // S3-compatible object storage
+	BACKUP_STORAGE_FILE = "file" // Backup archive or RDB file outside of storages
+)
+
+// BACKUP_DATA_DIR is name of directory with backups in RDS data directory
+const BACKUP_DATA_DIR = ".backups"
+
+const (
+	BACKUP_COMPRESSION_NONE = "none"
+	BACKUP_COMPRESSION_GZIP = "gzip"
+	BACKUP_COMPRESSION_ZSTD = "zstd"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// BackupStorage is generic backup storage
+type BackupStorage interface {
+	// Name returns storage name
+	Name() string
+
+	// Owners returns list of all backups owners in storage
+	Owners() ([]*BackupOwner, error)
+
+	// List returns list of objects for given owner
+	List(owner *BackupOwner) ([]*BackupObject, error)
+
+	// Read opens object for reading
+	Read(owner *BackupOwner, name string) (io.ReadCloser, error)
+
+	// Write writes object with given size
+	Write(owner *BackupOwner, name string, r io.Reader, size int64) error
+
+	// Remove removes object
+	Remove(owner *BackupOwner, name string) error
+}
+
+// BackupObject contains info about object in backup storage
+type BackupObject struct {
+	Name string
+	Size int64
+}
+
+// BackupOwner contains info about instance which owns backups. Shared storages
+// (directory and S3) keep backups of every node and instance separately, so
+// retention on one node never touches backups of another node, and instance
+// with reused ID never gets backups of destroyed instance.
+type BackupOwner struct {
+	ID       int
+	UUID     string
+	Hostname string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dataStorage is backup storage in RDS data directory. Backups are stored
+// outside of instances data directories, so they are kept after instance
+// is destroyed.
+type dataStorage struct {
+	dir string
+}
+
+// dirStorage is backup storage in local or network directory
+type dirStorage struct {
+	dir string
+}
+
+// zstdReader is reader for data decompressed by zstd
+type zstdReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+// zstdWriter is writer for data compressed by zstd
+type zstdWriter struct {
+	io.WriteCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+// archiveWriter is writer for compressed and encrypted archive
+type archiveWriter struct {
+	cw io.WriteCloser // Compressor
+	ew io.WriteCloser // Encryptor
+}
+
+// nopWriteCloser is writer with no-op Close method
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GetBackupStorages returns all configured backup storages
+func GetBackupStorages() ([]BackupStorage, error) {
+	var result []BackupStorage
+
+	for _, name := range Config.GetL(BACKUP_DESTINATIONS, []string{BACKUP_STORAGE_DATA}) {
+		storage, err := GetBackupStorage(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, storage)
+	}
+
+	return result, nil
+}
+
+// GetBackupStorage returns backup storage with given name
+func GetBackupStorage(name string) (BackupStorage, error) {
+	switch name {
+	case BACKUP_STORAGE_DATA:
+		return &dataStorage{path.Join(Config.GetS(PATH_DATA_DIR), BACKUP_DATA_DIR)}, nil
+
+	case BACKUP_STORAGE_DIR:
+		return &dirStorage{Config.GetS(BACKUP_DIR)}, nil
+
+	case BACKUP_STORAGE_S3:
+		return newS3Storage()
+	}
+
+	return nil, fmt.Errorf("Unknown backup storage %q", name)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GetInstanceBackupOwner returns backups owner for instance with given ID
+func GetInstanceBackupOwner(id int) (*BackupOwner, error) {
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &BackupOwner{ID: id, UUID: meta.UUID, Hostname: hostname}, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Owner returns backup owner
+func (b *BackupInfo) Owner() *BackupOwner {
+	return &BackupOwner{ID: b.InstanceID, UUID: b.InstanceUUID, Hostname: b.Hostname}
+}
+
+// Path returns relative path to owner backups in shared storage
+func (o *BackupOwner) Path() string {
+	return o.Hostname + "/" + strconv.Itoa(o.ID) + "-" + o.UUID
+}
+
+// IsLocal returns true if backups owner is instance on current node
+func (o *BackupOwner) IsLocal() bool {
+	hostname, _ := os.Hostname()
+	return o.Hostname == hostname
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Name returns storage name
+func (s *dataStorage) Name() string {
+	return BACKUP_STORAGE_DATA
+}
+
+// Owners returns list of all backups owners in storage
+func (s *dataStorage) Owners() ([]*BackupOwner, error) {
+	var result []*BackupOwner
+
+	if !fsutil.IsExist(s.dir) {
+		return nil, nil
+	}
+
+	hostname, _ := os.Hostname()
+
+	for _, ownerDir := range fsutil.List(s.dir, true, fsutil.ListingFilter{Perms: "D"}) {
+		owner := parseBackupOwnerPath(hostname, ownerDir)
+
+		if owner != nil {
+			result = append(result, owner)
+		}
+	}
+
+	return result, nil
+}
+
+// List returns list of objects for given owner
+func (s *dataStorage) List(owner *BackupOwner) ([]*BackupObject, error) {
+	return listBackupObjects(s.ownerDir(owner))
+}
+
+// Read opens object for reading
+func (s *dataStorage) Read(owner *BackupOwner, name string) (io.ReadCloser, error) {
+	return os.Open(path.Join(s.ownerDir(owner), name))
+}
+
+// Write writes object with given size
+func (s *dataStorage) Write(owner *BackupOwner, name string, r io.Reader, size int64) error {
+	return writeBackupObject(s.ownerDir(owner), name, r)
+}
+
+// Remove removes object
+func (s *dataStorage) Remove(owner *BackupOwner, name string) error {
+	return removeBackupObject(s.ownerDir(owner), name)
+}
+
+// ownerDir returns path to directory with owner backups (<id>-<uuid>)
+func (s *dataStorage) ownerDir(owner *BackupOwner) string {
+	return path.Join(s.dir, strconv.Itoa(owner.ID)+"-"+owner.UUID)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Name returns storage name
+func (s *dirStorage) Name() string {
+	return BACKUP_STORAGE_DIR
+}
+
+// Owners returns list of all backups owners in storage
+func (s *dirStorage) Owners() ([]*BackupOwner, error) {
+	var result []*BackupOwner
+
+	if !fsutil.IsExist(s.dir) {
+		return nil, nil
+	}
+
+	for _, hostname := range fsutil.List(s.dir, true, fsutil.ListingFilter{Perms: "D"}) {
+		hostDir := path.Join(s.dir, hostname)
+
+		for _, ownerDir := range fsutil.List(hostDir, true, fsutil.ListingFilter{Perms: "D"}) {
+			owner := parseBackupOwnerPath(hostname, ownerDir)
+
+			if owner != nil {
+				result = append(result, owner)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// List returns list of objects for given owner
+func (s *dirStorage) List(owner *BackupOwner) ([]*BackupObject, error) {
+	return listBackupObjects(path.Join(s.dir, owner.Path()))
+}
+
+// Read opens object for reading
+func (s *dirStorage) Read(owner *BackupOwner, name string) (io.ReadCloser, error) {
+	return os.Open(path.Join(s.dir, owner.Path(), name))
+}
+
+// Write writes object with given size
+func (s *dirStorage) Write(owner *BackupOwner, name string, r io.Reader, size int64) error {
+	return writeBackupObject(path.Join(s.dir, owner.Path()), name, r)
+}
+
+// Remove removes object
+func (s *dirStorage) Remove(owner *BackupOwner, name string) error {
+	return removeBackupObject(path.Join(s.dir, owner.Path()), name)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Close waits until zstd process is finished
+func (r *zstdReader) Close() error {
+	r.ReadCloser.Close()
+	return getZstdError(r.cmd.Wait(), r.stderr)
+}
+
+// Close closes zstd input and waits until all data is compressed
+func (w *zstdWriter) Close() error {
+	err := w.WriteCloser.Close()
+	waitErr := getZstdError(w.cmd.Wait(), w.stderr)
+
+	if waitErr != nil {
+		return waitErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't close zstd input: %w", err)
+	}
+
+	return nil
+}
+
+// Close does nothing
+func (w nopWriteCloser) Close() error {
+	return nil
+}
+
+// Write writes data to compressor
+func (w *archiveWriter) Write(p []byte) (int, error) {
+	return w.cw.Write(p)
+}
+
+// Close flushes compressed data and writes the last encrypted chunk
+func (w *archiveWriter) Close() error {
+	err := w.cw.Close()
+
+	if err != nil {
+		w.ew.Close()
+		return err
+	}
+
+	return w.ew.Close()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// listBackupObjects returns list of backup objects in given directory
+func listBackupObjects(dir string) ([]*BackupObject, error) {
+	var result []*BackupObject
+
+	if !fsutil.IsExist(dir) {
+		return nil, nil
+	}
+
+	files := fsutil.List(
+		dir, false,
+		fsutil.ListingFilter{MatchPatterns: []string{"backup-*"}},
+	)
+
+	for _, file := range files {
+		result = append(result, &BackupObject{
+			Name: file,
+			Size: fsutil.GetSize(path.Join(dir, file)),
+		})
+	}
+
+	return result, nil
+}
+
+// writeBackupObject atomically writes backup object to given directory
+func writeBackupObject(dir, name string, r io.Reader) error {
+	err := os.MkdirAll(dir, 0700)
+
+	if err != nil {
+		return fmt.Errorf("Can't create backup directory: %w", err)
+	}
+
+	file := path.Join(dir, name)
+	tmpFile := path.Join(dir, "."+name+".tmp")
+	fd, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, BACKUP_PERMS)
+
+	if err != nil {
+		return fmt.Errorf("Can't create backup file: %w", err)
+	}
+
+	_, err = io.Copy(fd, r)
+
+	if err == nil {
+		err = fd.Close()
+	} else {
+		fd.Close()
+	}
+
+	if err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("Can't write backup file: %w", err)
+	}
+
+	return os.Rename(tmpFile, file)
+}
+
+// removeBackupObject removes backup object from given directory
+func removeBackupObject(dir, name string) error {
+	err := os.Remove(path.Join(dir, name))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// parseBackupOwnerPath parses owner directory name (<id>-<uuid>)
+func parseBackupOwnerPath(hostname, dir string) *BackupOwner {
+	idStr, uuid, ok := strings.Cut(dir, "-")
+
+	if !ok || hostname == "" || uuid == "" {
+		return nil
+	}
+
+	id, err := strconv.Atoi(idStr)
+
+	if err != nil || id <= 0 {
+		return nil
+	}
+
+	return &BackupOwner{ID: id, UUID: uuid, Hostname: hostname}
+}
+
+// getBackupTempDir returns path to directory for temporary files placed next
+// to storage objects, so big archives are not copied between filesystems
+func getBackupTempDir(storage BackupStorage, owner *BackupOwner) string {
+	switch s := storage.(type) {
+	case *dataStorage:
+		return s.ownerDir(owner)
+	case *dirStorage:
+		return path.Join(s.dir, owner.Path())
+	}
+
+	return Config.GetS(PATH_DATA_DIR)
+}
+
+// getCompressionByExt returns compression method by file extension
+func getCompressionByExt(file string) string {
+	switch path.Ext(file) {
+	case ".gz":
+		return BACKUP_COMPRESSION_GZIP
+	case ".zst":
+		return BACKUP_COMPRESSION_ZSTD
+	}
+
+	return BACKUP_COMPRESSION_NONE
+}
+
+// getBackupCompression returns compression used for new backups
+func getBackupCompression() string {
+	return Config.GetS(BACKUP_COMPRESSION, BACKUP_COMPRESSION_NONE)
+}
+
+// getBackupArchiveExt returns backup archive file extension for given backup
+// type and compression
+func getBackupArchiveExt(backupType, compression string) string {
+	ext := ".rdb"
+
+	if backupType == BACKUP_TYPE_AOF {
+		ext = ".aof.tar"
+	}
+
+	switch compression {
+	case BACKUP_COMPRESSION_GZIP:
+		return ext + ".gz"
+	case BACKUP_COMPRESSION_ZSTD:
+		return ext + ".zst"
+	}
+
+	return ext
+}
+
+// getCompressor returns writer which compresses data using given compression
+func getCompressor(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case BACKUP_COMPRESSION_GZIP:
+		return gzip.NewWriter(w), nil
+
+	case BACKUP_COMPRESSION_ZSTD:
+		stderr := &bytes.Buffer{}
+		cmd := exec.Command(BIN_ZSTD, "-q", "-c", "-T0")
+		cmd.Stdout = w
+		cmd.Stderr = stderr
+		stdin, err := cmd.StdinPipe()
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = cmd.Start()
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't start zstd: %w", err)
+		}
+
+		return &zstdWriter{stdin, cmd, stderr}, nil
+	}
+
+	return nopWriteCloser{w}, nil
+}
+
+// getDecompressor returns reader which decompresses data using given compression
+func getDecompressor(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case BACKUP_COMPRESSION_GZIP:
+		return gzip.NewReader(r)
+
+	case BACKUP_COMPRESSION_ZSTD:
+		stderr := &bytes.Buffer{}
+		cmd := exec.Command(BIN_ZSTD, "-q", "-d", "-c")
+		cmd.Stdin = r
+		cmd.Stderr = stderr
+		stdout, err := cmd.StdoutPipe()
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = cmd.Start()
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't start zstd: %w", err)
+		}
+
+		return &zstdReader{stdout, cmd, stderr}, nil
+	}
+
+	return io.NopCloser(r), nil
+}
+
+// getZstdError returns error with zstd output if zstd process failed
+func getZstdError(err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+
+	output := strings.TrimSpace(stderr.String())
+
+	if output == "" {
+		return fmt.Errorf("zstd failed: %w", err)
+	}
+
+	return fmt.Errorf("zstd failed: %w (%s)", err, output)
+}
+
+// validateBackupDestinations validates list of backup destinations
+func validateBackupDestinations(config knf.IConfig, prop string, value any) error {
+	for _, name := range config.GetL(prop) {
+		switch name {
+		case BACKUP_STORAGE_DATA, BACKUP_STORAGE_DIR, BACKUP_STORAGE_S3:
+			continue
+		}
+
+		return fmt.Errorf("Property %s contains unknown backup destination %q", prop, name)
+	}
+
+	return nil
+}
+
+// validateZstdBinary checks if zstd binary is installed
+func validateZstdBinary(config knf.IConfig, prop string, value any) error {
+	if !fsutil.IsExist(BIN_ZSTD) {
+		return fmt.Errorf("Property %s requires zstd (%s is not installed)", prop, BIN_ZSTD)
+	}
+
+	return nil
+}
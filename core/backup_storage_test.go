@@ -0,0 +1,82 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type BackupStorageSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&BackupStorageSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *BackupStorageSuite) TestDataStorage(c *C) {
+	var err error
+
+	dataDir := c.MkDir()
+	Config, err = knf.Parse([]byte("[path]\n  data-dir: " + dataDir + "\n"))
+	c.Assert(err, IsNil)
+
+	storage, err := GetBackupStorage(BACKUP_STORAGE_DATA)
+	c.Assert(err, IsNil)
+
+	owner := &BackupOwner{ID: 1, UUID: "b5a9a6b3", Hostname: "test"}
+	err = storage.Write(owner, "backup-1700000000-000000000.rdb", strings.NewReader("TEST"), 4)
+	c.Assert(err, IsNil)
+
+	// Backups must not be stored in instance data directory
+	c.Assert(strings.HasPrefix(storage.(*dataStorage).ownerDir(owner), GetInstanceDataDirPath(1)), Equals, false)
+	c.Assert(storage.(*dataStorage).ownerDir(owner), Equals, path.Join(dataDir, BACKUP_DATA_DIR, "1-b5a9a6b3"))
+
+	objects, err := storage.List(owner)
+	c.Assert(err, IsNil)
+	c.Assert(objects, HasLen, 1)
+	c.Assert(objects[0].Size, Equals, int64(4))
+
+	owners, err := storage.Owners()
+	c.Assert(err, IsNil)
+	c.Assert(owners, HasLen, 1)
+	c.Assert(owners[0].ID, Equals, 1)
+	c.Assert(owners[0].UUID, Equals, "b5a9a6b3")
+
+	r, err := storage.Read(owner, "backup-1700000000-000000000.rdb")
+	c.Assert(err, IsNil)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	c.Assert(string(data), Equals, "TEST")
+
+	c.Assert(storage.Remove(owner, "backup-1700000000-000000000.rdb"), IsNil)
+
+	objects, err = storage.List(owner)
+	c.Assert(err, IsNil)
+	c.Assert(objects, HasLen, 0)
+}
+
+func (s *BackupStorageSuite) TestZstdError(c *C) {
+	c.Assert(getZstdError(nil, &bytes.Buffer{}), IsNil)
+
+	err := getZstdError(errors.New("exit status 1"), bytes.NewBufferString("zstd: /*stdin*\\: unknown header \n"))
+	c.Assert(err, ErrorMatches, `zstd failed: exit status 1 \(zstd: .* unknown header\)`)
+
+	err = getZstdError(errors.New("exit status 1"), &bytes.Buffer{})
+	c.Assert(err, ErrorMatches, `zstd failed: exit status 1`)
+}
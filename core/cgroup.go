@@ -0,0 +1,391 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// CGROUP_ROOT is path to cgroup v2 unified hierarchy
+const CGROUP_ROOT = "/sys/fs/cgroup"
+
+// CGROUP_RDS_DIR is name of parent cgroup (slice) for all instances
+const CGROUP_RDS_DIR = "rds.slice"
+
+// CPU_MAX_PERIOD is period (in microseconds) used for cpu.max
+const CPU_MAX_PERIOD = 100000
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ResourceLimits contains instance resource limits
+type ResourceLimits struct {
+	MemoryMax uint64 `json:"memory_max"` // Memory limit in bytes
+	CPUMax    int    `json:"cpu_max"`    // CPU limit in percents of one CPU core
+	IOWeight  int    `json:"io_weight"`  // IO weight (1-10000)
+}
+
+// ResourceUsage contains info about instance resource usage from cgroup
+type ResourceUsage struct {
+	Limits        *ResourceLimits `json:"limits"`
+	CGroup        string          `json:"cgroup"`         // Cgroup path
+	MemoryCurrent uint64          `json:"memory_current"` // Current memory usage in bytes
+	CPUUsage      uint64          `json:"cpu_usage"`      // Total CPU time in microseconds
+	CPUThrottled  uint64          `json:"cpu_throttled"`  // Total throttled time in microseconds
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsEmpty returns true if there are no limits
+func (l *ResourceLimits) IsEmpty() bool {
+	return l == nil || (l.MemoryMax == 0 && l.CPUMax == 0 && l.IOWeight == 0)
+}
+
+// getControllers returns list of cgroup controllers required for limits
+func (l *ResourceLimits) getControllers() []string {
+	var result []string
+
+	if l.MemoryMax != 0 {
+		result = append(result, "memory")
+	}
+
+	if l.CPUMax != 0 {
+		result = append(result, "cpu")
+	}
+
+	if l.IOWeight != 0 {
+		result = append(result, "io")
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsCGroupV2Supported returns true if system uses cgroup v2 unified hierarchy
+func IsCGroupV2Supported() bool {
+	return fsutil.IsExist(path.Join(CGROUP_ROOT, "cgroup.controllers"))
+}
+
+// GetInstanceLimits returns resource limits for instance with given ID. Limits
+// defined in instance meta take precedence over global limits.
+func GetInstanceLimits(id int) (*ResourceLimits, error) {
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &ResourceLimits{
+		MemoryMax: Config.GetSZ(LIMITS_MEMORY_MAX),
+		CPUMax:    Config.GetI(LIMITS_CPU_MAX),
+		IOWeight:  Config.GetI(LIMITS_IO_WEIGHT),
+	}
+
+	if meta.Preferencies.MemoryMax != 0 {
+		limits.MemoryMax = meta.Preferencies.MemoryMax
+	}
+
+	if meta.Preferencies.CPUMax != 0 {
+		limits.CPUMax = meta.Preferencies.CPUMax
+	}
+
+	if meta.Preferencies.IOWeight != 0 {
+		limits.IOWeight = meta.Preferencies.IOWeight
+	}
+
+	return limits, nil
+}
+
+// GetInstanceResourceUsage returns info about limits and resource usage
+// from instance cgroup
+func GetInstanceResourceUsage(id int) (*ResourceUsage, error) {
+	instancePID := GetInstancePID(id)
+
+	if instancePID == -1 {
+		return nil, ErrCantReadPID
+	}
+
+	cgroup, err := getProcessCGroup(instancePID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cgroupDir := path.Join(CGROUP_ROOT, cgroup)
+	usage := &ResourceUsage{CGroup: cgroup, Limits: &ResourceLimits{}}
+
+	memMax := readCGroupValue(cgroupDir, "memory.max")
+
+	if memMax != "max" {
+		usage.Limits.MemoryMax, _ = strconv.ParseUint(memMax, 10, 64)
+	}
+
+	cpuMax := readCGroupValue(cgroupDir, "cpu.max")
+
+	if cpuMax != "" && !strings.HasPrefix(cpuMax, "max") {
+		quota, _ := strconv.Atoi(strings.Fields(cpuMax)[0])
+		period := CPU_MAX_PERIOD
+
+		if len(strings.Fields(cpuMax)) > 1 {
+			period, _ = strconv.Atoi(strings.Fields(cpuMax)[1])
+		}
+
+		if period > 0 {
+			usage.Limits.CPUMax = quota * 100 / period
+		}
+	}
+
+	ioWeight := strings.Fields(readCGroupValue(cgroupDir, "io.weight"))
+
+	if len(ioWeight) > 1 && ioWeight[1] != "100" {
+		usage.Limits.IOWeight, _ = strconv.Atoi(ioWeight[1])
+	}
+
+	usage.MemoryCurrent, _ = strconv.ParseUint(readCGroupValue(cgroupDir, "memory.current"), 10, 64)
+
+	cpuStat := readCGroupStat(cgroupDir, "cpu.stat")
+	usage.CPUUsage = cpuStat["usage_usec"]
+	usage.CPUThrottled = cpuStat["throttled_usec"]
+
+	return usage, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// UpdateInstanceLimits applies changed resource limits to working instance
+func UpdateInstanceLimits(id int) error {
+	if !IsInstanceExist(id) {
+		return fmt.Errorf("Instance with ID %d doesn't exist", id)
+	}
+
+	state, err := GetInstanceState(id, false)
+
+	// Limits will be applied on start
+	if err != nil || !state.IsWorks() {
+		return nil
+	}
+
+	if IsSystemdMode() {
+		return updateInstanceUnitLimits(id)
+	}
+
+	return applyInstanceLimits(id)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applyInstanceLimits creates cgroup for instance, configures limits and
+// moves all instance processes to it
+func applyInstanceLimits(id int) error {
+	limits, err := GetInstanceLimits(id)
+
+	if err != nil {
+		return err
+	}
+
+	cgroupDir := getInstanceCGroupPath(id)
+
+	// Instance has no limits and was never moved to cgroup
+	if limits.IsEmpty() && !fsutil.IsExist(cgroupDir) {
+		return nil
+	}
+
+	if !IsCGroupV2Supported() {
+		return fmt.Errorf("Can't apply resource limits for instance with ID %d: cgroup v2 is not supported", id)
+	}
+
+	instancePID := GetInstancePID(id)
+
+	if instancePID == -1 {
+		return fmt.Errorf("Can't get instance PID for instance with ID %d", id)
+	}
+
+	rdsDir := path.Join(CGROUP_ROOT, CGROUP_RDS_DIR)
+
+	// Controllers must be enabled on each level before creating child cgroup
+	err = enableCGroupControllers(CGROUP_ROOT, limits.getControllers())
+
+	if err == nil {
+		err = os.MkdirAll(rdsDir, 0755)
+	}
+
+	if err == nil {
+		err = enableCGroupControllers(rdsDir, limits.getControllers())
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't configure cgroup for instance with ID %d: %w", id, err)
+	}
+
+	err = os.MkdirAll(cgroupDir, 0755)
+
+	if err != nil {
+		return fmt.Errorf("Can't create cgroup for instance with ID %d: %w", id, err)
+	}
+
+	memMax, cpuMax, ioWeight := "max", "max", "default 100"
+
+	if limits.MemoryMax != 0 {
+		memMax = strconv.FormatUint(limits.MemoryMax, 10)
+	}
+
+	if limits.CPUMax != 0 {
+		cpuMax = fmt.Sprintf("%d %d", limits.CPUMax*CPU_MAX_PERIOD/100, CPU_MAX_PERIOD)
+	}
+
+	if limits.IOWeight != 0 {
+		ioWeight = "default " + strconv.Itoa(limits.IOWeight)
+	}
+
+	for _, file := range []struct {
+		name, value string
+		isSet       bool
+	}{
+		{"memory.max", memMax, limits.MemoryMax != 0},
+		{"cpu.max", cpuMax, limits.CPUMax != 0},
+		{"io.weight", ioWeight, limits.IOWeight != 0},
+	} {
+		// File doesn't exist if controller is not available, it's ok
+		// if limit is not set
+		if !file.isSet && !fsutil.IsExist(path.Join(cgroupDir, file.name)) {
+			continue
+		}
+
+		err = writeCGroupValue(cgroupDir, file.name, file.value)
+
+		if err != nil {
+			return fmt.Errorf("Can't set %s for instance with ID %d: %w", file.name, id, err)
+		}
+	}
+
+	pids, err := getRedisTreePIDs(id, instancePID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, ppid := range pids {
+		err = writeCGroupValue(cgroupDir, "cgroup.procs", strconv.Itoa(ppid))
+
+		if err != nil {
+			return fmt.Errorf("Can't move instance process to cgroup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// enableCGroupControllers enables given controllers for children of cgroup. Only
+// controllers which are not enabled yet will be enabled, so cgroups managed by
+// systemd won't be modified if all required controllers are already enabled.
+func enableCGroupControllers(dir string, controllers []string) error {
+	available := strings.Fields(readCGroupValue(dir, "cgroup.controllers"))
+	enabled := strings.Fields(readCGroupValue(dir, "cgroup.subtree_control"))
+
+	for _, controller := range controllers {
+		switch {
+		case slices.Contains(enabled, controller):
+			continue
+		case !slices.Contains(available, controller):
+			return fmt.Errorf("Controller %q is not available in cgroup %s", controller, dir)
+		}
+
+		// Controllers must be enabled one by one, because write fails
+		// completely if any of controllers can't be enabled
+		err := writeCGroupValue(dir, "cgroup.subtree_control", "+"+controller)
+
+		if err != nil {
+			return fmt.Errorf("Can't enable controller %q in cgroup %s: %w", controller, dir, err)
+		}
+	}
+
+	return nil
+}
+
+// removeInstanceCGroup removes instance cgroup
+func removeInstanceCGroup(id int) error {
+	cgroupDir := getInstanceCGroupPath(id)
+
+	if !fsutil.IsExist(cgroupDir) {
+		return nil
+	}
+
+	// Cgroup can be removed only with rmdir
+	return os.Remove(cgroupDir)
+}
+
+// getInstanceCGroupPath returns path to cgroup for instance with given ID
+func getInstanceCGroupPath(id int) string {
+	return path.Join(CGROUP_ROOT, CGROUP_RDS_DIR, strconv.Itoa(id))
+}
+
+// getProcessCGroup returns cgroup v2 path for process with given PID
+func getProcessCGroup(pid int) (string, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cgroup")
+
+	if err != nil {
+		return "", fmt.Errorf("Can't read process cgroup info: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(line, "0::"), nil
+		}
+	}
+
+	return "", fmt.Errorf("Process with PID %d is not in cgroup v2 hierarchy", pid)
+}
+
+// readCGroupValue reads value from cgroup file
+func readCGroupValue(dir, file string) string {
+	data, err := os.ReadFile(path.Join(dir, file))
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// readCGroupStat reads cgroup flat-keyed stat file
+func readCGroupStat(dir, file string) map[string]uint64 {
+	result := make(map[string]uint64)
+	data, err := os.ReadFile(path.Join(dir, file))
+
+	if err != nil {
+		return result
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), " ")
+
+		if ok {
+			result[key], _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	return result
+}
+
+// writeCGroupValue writes value to cgroup file
+func writeCGroupValue(dir, file, value string) error {
+	return os.WriteFile(path.Join(dir, file), []byte(value), 0644)
+}
@@ -0,0 +1,60 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+
+	"github.com/essentialkaos/ek/v13/path"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type CGroupSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&CGroupSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *CGroupSuite) TestControllers(c *C) {
+	limits := &ResourceLimits{}
+
+	c.Assert(limits.IsEmpty(), Equals, true)
+	c.Assert(limits.getControllers(), HasLen, 0)
+
+	limits = &ResourceLimits{MemoryMax: 1024, IOWeight: 100}
+
+	c.Assert(limits.IsEmpty(), Equals, false)
+	c.Assert(limits.getControllers(), DeepEquals, []string{"memory", "io"})
+}
+
+func (s *CGroupSuite) TestEnableControllers(c *C) {
+	dir := c.MkDir()
+
+	os.WriteFile(path.Join(dir, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0644)
+	os.WriteFile(path.Join(dir, "cgroup.subtree_control"), []byte("memory pids\n"), 0644)
+
+	// All controllers are already enabled, nothing must be written
+	c.Assert(enableCGroupControllers(dir, []string{"memory"}), IsNil)
+	c.Assert(readCGroupValue(dir, "cgroup.subtree_control"), Equals, "memory pids")
+
+	c.Assert(enableCGroupControllers(dir, []string{"memory", "io"}), IsNil)
+	c.Assert(readCGroupValue(dir, "cgroup.subtree_control"), Equals, "+io")
+
+	os.WriteFile(path.Join(dir, "cgroup.controllers"), []byte("cpu memory\n"), 0644)
+	os.WriteFile(path.Join(dir, "cgroup.subtree_control"), []byte("\n"), 0644)
+
+	c.Assert(enableCGroupControllers(dir, []string{"io"}), ErrorMatches, `Controller "io" is not available in cgroup .*`)
+	c.Assert(readCGroupValue(dir, "cgroup.subtree_control"), Equals, "")
+
+	c.Assert(enableCGroupControllers(c.MkDir()+"/unknown", []string{"cpu"}), NotNil)
+}
@@ -13,11 +13,13 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"maps"
 	"math/rand"
 	"net"
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -60,6 +62,10 @@ const VERSION = "A2"
 // META_VERSION is current meta version
 const META_VERSION = 1
 
+// META_NAME is name of meta storage key with stable instance name used for
+// declarative configuration
+const META_NAME = "name"
+
 // Limits
 const (
 	MIN_INSTANCES        = 16
@@ -94,12 +100,22 @@ const (
 	MAX_IONICE_CLASS     = 3
 	MIN_IONICE_CLASSDATA = 0
 	MAX_IONICE_CLASSDATA = 7
+	MIN_CPU_MAX          = 1
+	MAX_CPU_MAX          = 100 * 1024
+	MIN_IO_WEIGHT        = 1
+	MAX_IO_WEIGHT        = 10000
+	MIN_MEMORY_MAX       = 16 * 1024 * 1024
+	MIN_HOOK_TIMEOUT     = 1       // 1 Sec
+	MAX_HOOK_TIMEOUT     = 10 * 60 // 10 Min
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 const (
-	BIN_RUNUSER = "/sbin/runuser"
+	BIN_RUNUSER    = "/sbin/runuser"
+	BIN_SYSTEMCTL  = "/usr/bin/systemctl"
+	BIN_JOURNALCTL = "/usr/bin/journalctl"
+	BIN_ZSTD       = "/usr/bin/zstd"
 )
 
 const (
@@ -146,6 +162,10 @@ const (
 	REDIS_NICE             = "redis:nice"
 	REDIS_IONICE_CLASS     = "redis:ionice-class"
 	REDIS_IONICE_CLASSDATA = "redis:ionice-classdata"
+	REDIS_SYSTEMD          = "redis:systemd"
+	REDIS_UNIX_SOCKET      = "redis:unix-socket"
+	REDIS_SOCKET_ONLY      = "redis:socket-only"
+	REDIS_MAX_OPEN_FILES   = "redis:max-open-files"
 
 	SENTINEL_BINARY           = "sentinel:binary"
 	SENTINEL_PORT             = "sentinel:port"
@@ -159,6 +179,49 @@ const (
 	TEMPLATES_REDIS    = "templates:redis"
 	TEMPLATES_SENTINEL = "templates:sentinel"
 
+	TLS_CA_CERT      = "tls:ca-cert"
+	TLS_CERT         = "tls:cert"
+	TLS_KEY          = "tls:key"
+	TLS_START_PORT   = "tls:start-port"
+	TLS_AUTH_CLIENTS = "tls:auth-clients"
+	TLS_REPLICATION  = "tls:replication"
+	TLS_ONLY         = "tls:only"
+	TLS_SERVER_NAME  = "tls:server-name"
+
+	LIMITS_MEMORY_MAX = "limits:memory-max"
+	LIMITS_CPU_MAX    = "limits:cpu-max"
+	LIMITS_IO_WEIGHT  = "limits:io-weight"
+
+	HOOKS_DIR            = "hooks:dir"
+	HOOKS_TIMEOUT        = "hooks:timeout"
+	HOOKS_FAILURE_POLICY = "hooks:failure-policy"
+
+	BACKUP_SCHEDULE     = "backup:schedule"
+	BACKUP_KEEP_LAST    = "backup:keep-last"
+	BACKUP_KEEP_HOURLY  = "backup:keep-hourly"
+	BACKUP_KEEP_DAILY   = "backup:keep-daily"
+	BACKUP_KEEP_WEEKLY  = "backup:keep-weekly"
+	BACKUP_MAX_SIZE     = "backup:max-size"
+	BACKUP_DESTINATIONS = "backup:destinations"
+	BACKUP_DIR          = "backup:dir"
+	BACKUP_COMPRESSION  = "backup:compression"
+	BACKUP_DUMP_TIMEOUT = "backup:dump-timeout"
+
+	BACKUP_ENCRYPTION            = "backup:encryption"
+	BACKUP_ENCRYPTION_KEY_FILE   = "backup:encryption-key-file"
+	BACKUP_ENCRYPTION_PASSPHRASE = "backup:encryption-passphrase"
+
+	BACKUP_S3_ENDPOINT   = "backup-s3:endpoint"
+	BACKUP_S3_REGION     = "backup-s3:region"
+	BACKUP_S3_BUCKET     = "backup-s3:bucket"
+	BACKUP_S3_PREFIX     = "backup-s3:prefix"
+	BACKUP_S3_ACCESS_KEY = "backup-s3:access-key"
+	BACKUP_S3_SECRET_KEY = "backup-s3:secret-key"
+
+	HISTORY_ENABLED    = "history:enabled"
+	HISTORY_RESOLUTION = "history:resolution"
+	HISTORY_RETENTION  = "history:retention"
+
 	PATH_META_DIR   = "path:meta-dir"
 	PATH_CONFIG_DIR = "path:config-dir"
 	PATH_DATA_DIR   = "path:data-dir"
@@ -178,6 +241,10 @@ const (
 	REPLICATION_MAX_SYNC_WAIT       = "replication:max-sync-wait"
 	REPLICATION_INIT_SYNC_DELAY     = "replication:init-sync-delay"
 
+	MIGRATION_IP         = "migration:ip"
+	MIGRATION_PORT       = "migration:port"
+	MIGRATION_AUTH_TOKEN = "migration:auth-token"
+
 	DELAY_START = "delay:start"
 	DELAY_STOP  = "delay:stop"
 )
@@ -290,6 +357,10 @@ type InstancePreferencies struct {
 	SentinelPassword string          `json:"sentinel_password"`          // Sentinel user password
 	ReplicationType  ReplicationType `json:"replication_type"`           // Replication type
 	IsSaveDisabled   bool            `json:"is_save_disabled"`           // Disabled saves flag
+	TemplateProfile  string          `json:"template_profile,omitempty"` // Configuration template profile
+	MemoryMax        uint64          `json:"memory_max,omitempty"`       // Memory limit (cgroup memory.max)
+	CPUMax           int             `json:"cpu_max,omitempty"`          // CPU limit in percents (cgroup cpu.max)
+	IOWeight         int             `json:"io_weight,omitempty"`        // IO weight (cgroup io.weight)
 }
 
 type InstanceInfo struct {
@@ -388,6 +459,9 @@ type instanceConfigData struct {
 	IsSecure         bool
 	IsSaveDisabled   bool
 	IsReplica        bool
+	IsSocketEnabled  bool
+	IsSocketOnly     bool
+	TLS              *instanceConfigTLSData
 
 	tags    []string
 	storage Storage
@@ -408,6 +482,7 @@ type sentinelConfigData struct {
 	Port    string
 	PidFile string
 	LogFile string
+	TLS     *instanceConfigTLSData
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -694,7 +769,7 @@ func ValidateTemplates() []error {
 		errs.Add(fmt.Errorf("Can't generate instance meta for validation: %w", err))
 	} else {
 		_, err = generateConfigFromTemplate(
-			TEMPLATE_SOURCE_REDIS,
+			TEMPLATE_SOURCE_REDIS, "",
 			createConfigFromMeta(meta),
 		)
 
@@ -702,8 +777,8 @@ func ValidateTemplates() []error {
 	}
 
 	_, err = generateConfigFromTemplate(
-		TEMPLATE_SOURCE_SENTINEL,
-		&sentinelConfigData{},
+		TEMPLATE_SOURCE_SENTINEL, "",
+		&sentinelConfigData{TLS: getTLSConfigData()},
 	)
 
 	errs.Add(err)
@@ -711,6 +786,23 @@ func ValidateTemplates() []error {
 	return errs.All()
 }
 
+// GetTemplateProfiles returns list of available configuration template profiles
+func GetTemplateProfiles() []string {
+	return fsutil.List(
+		Config.GetS(TEMPLATES_REDIS), true,
+		fsutil.ListingFilter{Perms: "DRX"},
+	)
+}
+
+// IsTemplateProfileExist returns true if configuration template profile exists
+func IsTemplateProfileExist(profile string) bool {
+	if profile == "" || strings.ContainsAny(profile, "/.") {
+		return false
+	}
+
+	return fsutil.CheckPerms("DRX", path.Join(Config.GetS(TEMPLATES_REDIS), profile))
+}
+
 // HasInstances returns true if that at least one instance exists
 func HasInstances() bool {
 	return !fsutil.IsEmptyDir(Config.GetS(PATH_META_DIR))
@@ -746,6 +838,11 @@ func GetInstancePIDFilePath(id int) string {
 	return path.Join(Config.GetS(PATH_PID_DIR), strconv.Itoa(id)+".pid")
 }
 
+// GetInstanceSocketFilePath returns path to unix socket file for instance with given ID
+func GetInstanceSocketFilePath(id int) string {
+	return path.Join(Config.GetS(PATH_PID_DIR), strconv.Itoa(id)+".sock")
+}
+
 // GetStatesFilePath returns path to global states file
 func GetStatesFilePath() string {
 	return path.Join(Config.GetS(MAIN_DIR), STATES_DATA_FILE)
@@ -1028,6 +1125,12 @@ func CreateInstance(meta *InstanceMeta) error {
 
 	meta.Created = time.Now().Unix()
 
+	err = RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_CREATE, meta)
+
+	if err != nil {
+		return err
+	}
+
 	if !IsSentinel() {
 		err = createInstanceData(meta)
 
@@ -1052,7 +1155,7 @@ func CreateInstance(meta *InstanceMeta) error {
 		}
 	}
 
-	return nil
+	return RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_CREATE, meta)
 }
 
 // RegenerateInstanceConfig regenerate redis config file for given instance
@@ -1160,6 +1263,26 @@ func UpdateInstance(newMeta *InstanceMeta) error {
 		hasChanges = true
 	}
 
+	if newMeta.Preferencies.IsSaveDisabled != oldMeta.Preferencies.IsSaveDisabled {
+		oldMeta.Preferencies.IsSaveDisabled = newMeta.Preferencies.IsSaveDisabled
+		hasChanges = true
+	}
+
+	if newMeta.Preferencies.TemplateProfile != oldMeta.Preferencies.TemplateProfile {
+		if newMeta.Preferencies.TemplateProfile != "" &&
+			!IsTemplateProfileExist(newMeta.Preferencies.TemplateProfile) {
+			return fmt.Errorf("Template profile %q doesn't exist", newMeta.Preferencies.TemplateProfile)
+		}
+
+		oldMeta.Preferencies.TemplateProfile = newMeta.Preferencies.TemplateProfile
+		hasChanges = true
+	}
+
+	if newMeta.Storage != nil && !maps.Equal(newMeta.Storage, oldMeta.Storage) {
+		oldMeta.Storage = maps.Clone(newMeta.Storage)
+		hasChanges = true
+	}
+
 	if !hasChanges {
 		return nil
 	}
@@ -1247,14 +1370,19 @@ func GetInstanceConfig(id int, timeout time.Duration) (*REDIS.Config, error) {
 		return nil, err
 	}
 
-	return REDIS.GetConfig(
-		&REDIS.Request{
-			Command: []string{"CONFIG", "GET", "*"},
-			Port:    GetInstancePort(id),
-			Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
-			Timeout: timeout,
-		},
-	)
+	req := &REDIS.Request{
+		Command: []string{"CONFIG", "GET", "*"},
+		Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
+		Timeout: timeout,
+	}
+
+	err = ConfigureInstanceRequest(id, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return REDIS.GetConfig(req)
 }
 
 // GetInstanceConfigChanges returns difference between file config
@@ -1338,7 +1466,7 @@ func GetInstanceAOFPath(id int) string {
 		return path.Join(dataDir, "appendonly.aof")
 	}
 
-	aof := config.Get("dbfilename")
+	aof := config.Get("appendfilename")
 
 	if aof == "" {
 		return path.Join(dataDir, "appendonly.aof")
@@ -1347,6 +1475,37 @@ func GetInstanceAOFPath(id int) string {
 	return path.Join(dataDir, aof)
 }
 
+// GetInstanceAOFDirPath returns path to the directory with multi-part append
+// only files (Redis 7+)
+func GetInstanceAOFDirPath(id int) string {
+	dataDir := GetInstanceDataDirPath(id)
+	config, err := ReadInstanceConfig(id)
+
+	// Return default path
+	if err != nil {
+		return path.Join(dataDir, "appendonlydir")
+	}
+
+	aofDir := config.Get("appenddirname")
+
+	if aofDir == "" {
+		return path.Join(dataDir, "appendonlydir")
+	}
+
+	return path.Join(dataDir, aofDir)
+}
+
+// IsInstanceAOFEnabled returns true if instance uses append only file
+func IsInstanceAOFEnabled(id int) bool {
+	config, err := ReadInstanceConfig(id)
+
+	if err != nil {
+		return false
+	}
+
+	return strings.ToLower(config.Get("appendonly")) == "yes"
+}
+
 // GetInstanceInfo returns info from instance
 func GetInstanceInfo(id int, timeout time.Duration, all bool) (*REDIS.Info, error) {
 	var info *REDIS.Info
@@ -1367,14 +1526,19 @@ func GetInstanceInfo(id int, timeout time.Duration, all bool) (*REDIS.Info, erro
 		command = append(command, "all")
 	}
 
-	return REDIS.GetInfo(
-		&REDIS.Request{
-			Command: command,
-			Port:    GetInstancePort(id),
-			Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
-			Timeout: timeout,
-		},
-	)
+	req := &REDIS.Request{
+		Command: command,
+		Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
+		Timeout: timeout,
+	}
+
+	err = ConfigureInstanceRequest(id, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return REDIS.GetInfo(req)
 }
 
 // ExecCommand executes Redis command on given instance
@@ -1384,7 +1548,11 @@ func ExecCommand(id int, req *REDIS.Request) (*REDIS.Resp, error) {
 	}
 
 	if req.Port == 0 {
-		req.Port = GetInstancePort(id)
+		err := ConfigureInstanceRequest(id, req)
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if req.Auth.User == "" {
@@ -1406,6 +1574,77 @@ func ExecCommand(id int, req *REDIS.Request) (*REDIS.Resp, error) {
 	return resp, nil
 }
 
+// ConnectToInstance creates new connection to DB on instance with given ID
+func ConnectToInstance(id, db int, timeout time.Duration) (*REDIS.Client, error) {
+	if !IsInstanceExist(id) {
+		return nil, fmt.Errorf("Instance with ID %d doesn't exist", id)
+	}
+
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read instance meta: %v", err)
+	}
+
+	req := &REDIS.Request{
+		DB:      db,
+		Auth:    REDIS.Auth{"admin", meta.Preferencies.AdminPassword},
+		Timeout: timeout,
+	}
+
+	err = ConfigureInstanceRequest(id, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := REDIS.Connect(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't connect to instance: %v", err)
+	}
+
+	return rc, nil
+}
+
+// ConfigureInstanceRequest sets connection info (unix socket, port and TLS
+// configuration) for request to instance with given ID
+func ConfigureInstanceRequest(id int, req *REDIS.Request) error {
+	// Unix socket is preferred for local management traffic and it's
+	// the only way to connect to instance in socket-only mode
+	if IsSocketOnly() || IsInstanceSocketAvailable(id) {
+		req.Port = GetInstancePort(id)
+		req.Socket = GetInstanceSocketFilePath(id)
+		return nil
+	}
+
+	if !IsTLSOnly() {
+		req.Port = GetInstancePort(id)
+		return nil
+	}
+
+	cfg, err := GetTLSConfig()
+
+	if err != nil {
+		return err
+	}
+
+	req.Port = GetInstanceTLSPort(id)
+	req.TLS = cfg
+
+	return nil
+}
+
+// IsSocketOnly returns true if instances listen only unix socket
+func IsSocketOnly() bool {
+	return Config.GetB(REDIS_UNIX_SOCKET) && Config.GetB(REDIS_SOCKET_ONLY)
+}
+
+// IsInstanceSocketAvailable returns true if instance listens unix socket
+func IsInstanceSocketAvailable(id int) bool {
+	return Config.GetB(REDIS_UNIX_SOCKET) && fsutil.IsSocket(GetInstanceSocketFilePath(id))
+}
+
 // ParseIDDBPair parse ID/DB pair (id:db id/db)
 func ParseIDDBPair(pair string) (int, int, error) {
 	if pair == "" {
@@ -1447,28 +1686,57 @@ func StartInstance(id int, controlLoading bool) error {
 		return fmt.Errorf("Instance with ID %d doesn't exist", id)
 	}
 
-	err := runAsUser(
-		Config.GetS(REDIS_USER),
-		GetInstanceLogFilePath(id),
-		Config.GetS(REDIS_BINARY),
-		GetInstanceConfigFilePath(id),
-		"--daemonize", "yes", // Always daemonize server
-	)
+	meta, err := GetInstanceMeta(id)
 
 	if err != nil {
 		return err
 	}
 
-	if !isProcStarted(strconv.Itoa(id), Config.GetI(DELAY_START)) {
-		return fmt.Errorf("Instance PID file %s was not created", GetInstancePIDFilePath(id))
+	err = RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_START, meta)
+
+	if err != nil {
+		return err
 	}
 
-	err = configureSchedulers(id)
+	if IsSystemdMode() {
+		// Schedulers are configured by systemd unit
+		err = startInstanceUnit(id)
+	} else {
+		err = removeStaleInstanceUnit(id)
+
+		if err == nil {
+			err = runAsUser(
+				Config.GetS(REDIS_USER),
+				GetInstanceLogFilePath(id),
+				Config.GetS(REDIS_BINARY),
+				GetInstanceConfigFilePath(id),
+				"--daemonize", "yes", // Always daemonize server
+			)
+		}
+	}
 
 	if err != nil {
 		return err
 	}
 
+	if !isProcStarted(strconv.Itoa(id), Config.GetI(DELAY_START)) {
+		return fmt.Errorf("Instance PID file %s was not created", GetInstancePIDFilePath(id))
+	}
+
+	if !IsSystemdMode() {
+		err = configureSchedulers(id)
+
+		if err != nil {
+			return err
+		}
+
+		err = applyInstanceLimits(id)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	if controlLoading {
 		isInstanceFullyStarted(id)
 	}
@@ -1485,24 +1753,16 @@ func StartInstance(id int, controlLoading bool) error {
 		return err
 	}
 
-	if IsSentinelActive() {
-		meta, err := GetInstanceMeta(id)
+	// Sentinel monitoring works only with replicas
+	if IsSentinelActive() && meta.Preferencies.ReplicationType.IsReplica() {
+		err = SentinelStartMonitoring(id)
 
 		if err != nil {
-			return err
-		}
-
-		// Sentinel monitoring works only with replicas
-		if meta.Preferencies.ReplicationType.IsReplica() {
-			err = SentinelStartMonitoring(id)
-
-			if err != nil {
-				return fmt.Errorf("Can't start Sentinel monitoring: %w", err)
-			}
+			return fmt.Errorf("Can't start Sentinel monitoring: %w", err)
 		}
 	}
 
-	return nil
+	return RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_START, meta)
 }
 
 // StopInstance stopping instance
@@ -1519,6 +1779,18 @@ func StopInstance(id int, force bool) error {
 		return ErrCantReadPID
 	}
 
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_STOP, meta)
+
+	if err != nil {
+		return err
+	}
+
 	if IsSentinelActive() && IsSentinelMonitors(id) {
 		err = SentinelStopMonitoring(id)
 
@@ -1533,33 +1805,25 @@ func StopInstance(id int, force bool) error {
 		return err
 	}
 
-	cmdStart := time.Now()
-	stopDelay := time.Second * time.Duration(Config.GetI(DELAY_STOP))
-
-	for range time.NewTicker(time.Second).C {
-		if pid.IsProcessWorks(instancePID) {
-			if isInstanceSavingData(id) {
-				time.Sleep(3 * time.Second)
-				cmdStart = time.Now()
-				continue
-			}
-
-			if time.Since(cmdStart) > stopDelay {
-				if force {
-					syscall.Kill(instancePID, syscall.SIGKILL)
-					return nil
-				}
+	err = waitForInstanceStop(id, instancePID, force)
 
-				return ErrInstanceStillWorks
-			}
+	if err != nil {
+		return err
+	}
 
-			continue
-		}
+	// Instance is already stopped by SHUTDOWN command, so we only
+	// have to update unit state or remove unit if systemd mode is disabled
+	if IsSystemdMode() {
+		err = stopInstanceUnit(id)
+	} else {
+		err = removeStaleInstanceUnit(id)
+	}
 
-		return nil
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_STOP, meta)
 }
 
 // KillInstance send KILL signal to Redis
@@ -1574,7 +1838,21 @@ func KillInstance(id int) error {
 		return ErrCantReadPID
 	}
 
-	syscall.Kill(pid, syscall.SIGKILL)
+	// Instance can be started by systemd even if systemd mode is disabled
+	// (if it was disabled while instance was working)
+	if IsSystemdMode() || isInstanceUnitExist(id) {
+		err := killInstanceUnit(id)
+
+		if err == nil {
+			err = removeStaleInstanceUnit(id)
+		}
+
+		if err != nil {
+			return err
+		}
+	} else {
+		syscall.Kill(pid, syscall.SIGKILL)
+	}
 
 	pidFile := GetInstancePIDFilePath(id)
 
@@ -1591,12 +1869,22 @@ func KillInstance(id int) error {
 
 // DestroyInstance destroy (delete) instance
 func DestroyInstance(id int) error {
-	var err error
-
 	if !IsInstanceExist(id) {
 		return fmt.Errorf("Instance with ID %d doesn't exist", id)
 	}
 
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_DESTROY, meta)
+
+	if err != nil {
+		return err
+	}
+
 	if IsSentinelMonitors(id) {
 		err = SentinelStopMonitoring(id)
 
@@ -1620,6 +1908,18 @@ func DestroyInstance(id int) error {
 			}
 		}
 
+		err = removeInstanceUnit(id)
+
+		if err != nil {
+			return err
+		}
+
+		err = removeInstanceCGroup(id)
+
+		if err != nil {
+			return fmt.Errorf("Can't remove instance cgroup: %v", err)
+		}
+
 		err = os.RemoveAll(GetInstanceConfigFilePath(id))
 
 		if err != nil {
@@ -1655,7 +1955,13 @@ func DestroyInstance(id int) error {
 		return fmt.Errorf("Can't remove meta file: %v", err)
 	}
 
-	return nil
+	err = RemoveInstanceHistory(id)
+
+	if err != nil {
+		return fmt.Errorf("Can't remove metrics history: %v", err)
+	}
+
+	return RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_DESTROY, meta)
 }
 
 // SentinelStart start (run) Sentinel daemon
@@ -1757,9 +2063,7 @@ func SentinelCheck(id int) (string, bool) {
 		return "Sentinel is stopped", false
 	}
 
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.CheckQuorum(sCfg, id)
 }
@@ -1774,9 +2078,7 @@ func SentinelReset() error {
 		return ErrSentinelIsStopped
 	}
 
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.Reset(sCfg)
 }
@@ -1801,14 +2103,12 @@ func SentinelStartMonitoring(id int) error {
 		return err
 	}
 
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	iCfg := &SENTINEL.InstanceConfig{
 		ID:   id,
 		IP:   Config.GetS(REPLICATION_MASTER_IP, netutil.GetIP()),
-		Port: GetInstancePort(id),
+		Port: GetInstanceReplicationPort(id),
 
 		Auth: SENTINEL.Auth{REDIS_USER_SENTINEL, meta.Preferencies.SentinelPassword},
 
@@ -1835,9 +2135,7 @@ func SentinelStopMonitoring(id int) error {
 		return nil
 	}
 
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.Remove(sCfg, id)
 }
@@ -1888,23 +2186,37 @@ func SentinelMasterSwitch(id int) error {
 		priority = "100" // Default priority
 	}
 
-	return runSentinelFailoverSwitch(id, priority)
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_ROLE_CHANGE, meta, "RDS_INSTANCE_ROLE=master")
+
+	if err != nil {
+		return err
+	}
+
+	err = runSentinelFailoverSwitch(id, priority)
+
+	if err != nil {
+		return err
+	}
+
+	return RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_ROLE_CHANGE, meta, "RDS_INSTANCE_ROLE=master")
 }
 
 // SentinelMasterIP returns IP of master instance
 func SentinelMasterIP(id int) (string, error) {
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.GetMasterIP(sCfg, id)
 }
 
 // SentinelInfo returns info from Sentinel about master, replicas and sentinels
 func SentinelInfo(id int) (*SENTINEL.Info, error) {
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.GetInfo(sCfg, id)
 }
@@ -1912,9 +2224,7 @@ func SentinelInfo(id int) (*SENTINEL.Info, error) {
 // IsSentinelMonitors returns true if Sentinel monitoring instance
 // with given ID
 func IsSentinelMonitors(id int) bool {
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	return SENTINEL.IsSentinelMonitors(sCfg, id)
 }
@@ -2094,6 +2404,11 @@ func IsSyncDaemonInstalled() bool {
 	return env.Which("rds-sync") != ""
 }
 
+// IsMigrationEnabled returns true if migration API is configured
+func IsMigrationEnabled() bool {
+	return Config.GetS(MIGRATION_AUTH_TOKEN) != ""
+}
+
 // IsSentinelActive returns true if Sentinel is works
 func IsSentinelActive() bool {
 	return pid.IsWorks(PID_SENTINEL)
@@ -2414,8 +2729,13 @@ func (p *instanceConfigData) LogDir() string {
 	return GetInstanceLogDirPath(p.ID)
 }
 
-// LogFile returns path to log file for instance with given ID
+// LogFile returns path to log file for instance with given ID. In systemd mode
+// instance writes log to stdout, so it will be collected by journald.
 func (p *instanceConfigData) LogFile() string {
+	if IsSystemdMode() {
+		return `""`
+	}
+
 	return GetInstanceLogFilePath(p.ID)
 }
 
@@ -2424,6 +2744,11 @@ func (p *instanceConfigData) PidFile() string {
 	return GetInstancePIDFilePath(p.ID)
 }
 
+// SocketFile returns path to unix socket file for instance with given ID
+func (p *instanceConfigData) SocketFile() string {
+	return GetInstanceSocketFilePath(p.ID)
+}
+
 // MasterHost returns redis master host (IP)
 func (p *instanceConfigData) MasterHost() string {
 	return Config.GetS(REPLICATION_MASTER_IP)
@@ -2431,7 +2756,12 @@ func (p *instanceConfigData) MasterHost() string {
 
 // MasterPort returns redis master port
 func (p *instanceConfigData) MasterPort() int {
-	return GetInstancePort(p.ID)
+	return GetInstanceReplicationPort(p.ID)
+}
+
+// TLSPort returns instance TLS port
+func (p *instanceConfigData) TLSPort() int {
+	return GetInstanceTLSPort(p.ID)
 }
 
 // HasTag return true if configuration has given tag
@@ -2602,6 +2932,7 @@ func validateConfig(c *knf.Config) []error {
 		{REDIS_START_PORT, knfn.Port, nil},
 		{REDIS_START_PORT, knfv.Greater, MIN_PORT},
 		{REDIS_START_PORT, knfv.Less, MAX_PORT},
+		{REDIS_START_PORT, validatePortRanges, nil},
 		{REDIS_NICE, knfv.Greater, MIN_NICE},
 		{REDIS_NICE, knfv.Less, MAX_NICE},
 		{REDIS_IONICE_CLASS, knfv.Greater, MIN_IONICE_CLASS},
@@ -2652,6 +2983,147 @@ func validateConfig(c *knf.Config) []error {
 		}},
 	}
 
+	// SYSTEMD //
+
+	validators.AddIf(
+		c.GetB(REDIS_SYSTEMD) && c.GetS(REDIS_MAX_OPEN_FILES) != "",
+		knf.Validators{
+			{REDIS_MAX_OPEN_FILES, knfv.Greater, MIN_PROCS},
+		},
+	)
+
+	// SOCKET //
+
+	validators.AddIf(
+		c.GetB(REDIS_SOCKET_ONLY),
+		knf.Validators{
+			{REDIS_SOCKET_ONLY, validateSocketOnly, nil},
+		},
+	)
+
+	// TLS //
+
+	validators.AddIf(
+		c.GetS(TLS_CERT) != "" || c.GetS(TLS_KEY) != "",
+		knf.Validators{
+			{TLS_CA_CERT, knfv.Set, nil},
+			{TLS_CERT, knfv.Set, nil},
+			{TLS_KEY, knfv.Set, nil},
+			{TLS_CA_CERT, knff.Perms, "FR"},
+			{TLS_CERT, knff.Perms, "FR"},
+			{TLS_KEY, knff.Perms, "FR"},
+			{TLS_START_PORT, knfv.Set, nil},
+			{TLS_START_PORT, knfn.Port, nil},
+			{TLS_START_PORT, knfv.Greater, MIN_PORT},
+			{TLS_START_PORT, knfv.Less, MAX_PORT},
+			{TLS_AUTH_CLIENTS, knfv.SetToAny, []string{
+				"", TLS_AUTH_CLIENTS_YES, TLS_AUTH_CLIENTS_NO, TLS_AUTH_CLIENTS_OPTIONAL,
+			}},
+		},
+	)
+
+	// LIMITS //
+
+	validators.AddIf(
+		c.GetS(LIMITS_MEMORY_MAX) != "",
+		knf.Validators{
+			{LIMITS_MEMORY_MAX, knfv.TypeSize, nil},
+			{LIMITS_MEMORY_MAX, knfv.SizeGreater, MIN_MEMORY_MAX},
+		},
+	)
+
+	validators.AddIf(
+		c.GetS(LIMITS_CPU_MAX) != "",
+		knf.Validators{
+			{LIMITS_CPU_MAX, knfv.Greater, MIN_CPU_MAX},
+			{LIMITS_CPU_MAX, knfv.Less, MAX_CPU_MAX},
+		},
+	)
+
+	validators.AddIf(
+		c.GetS(LIMITS_IO_WEIGHT) != "",
+		knf.Validators{
+			{LIMITS_IO_WEIGHT, knfv.Greater, MIN_IO_WEIGHT},
+			{LIMITS_IO_WEIGHT, knfv.Less, MAX_IO_WEIGHT},
+		},
+	)
+
+	// HOOKS //
+
+	validators.AddIf(
+		c.GetS(HOOKS_DIR) != "",
+		knf.Validators{
+			{HOOKS_DIR, knff.Perms, "DRX"},
+			{HOOKS_TIMEOUT, knfv.Greater, MIN_HOOK_TIMEOUT},
+			{HOOKS_TIMEOUT, knfv.Less, MAX_HOOK_TIMEOUT},
+			{HOOKS_FAILURE_POLICY, knfv.SetToAny, []string{
+				"", HOOK_POLICY_ABORT, HOOK_POLICY_WARN,
+			}},
+		},
+	)
+
+	// BACKUP //
+
+	validators = append(validators, knf.Validators{
+		{BACKUP_SCHEDULE, validateBackupSchedule, nil},
+		{BACKUP_KEEP_LAST, knfv.Greater, 0},
+		{BACKUP_KEEP_HOURLY, knfv.Greater, 0},
+		{BACKUP_KEEP_DAILY, knfv.Greater, 0},
+		{BACKUP_KEEP_WEEKLY, knfv.Greater, 0},
+		{BACKUP_COMPRESSION, knfv.SetToAny, []string{
+			"", BACKUP_COMPRESSION_NONE, BACKUP_COMPRESSION_GZIP, BACKUP_COMPRESSION_ZSTD,
+		}},
+		{BACKUP_DESTINATIONS, validateBackupDestinations, nil},
+		{BACKUP_DUMP_TIMEOUT, validateBackupDumpTimeout, nil},
+	}...)
+
+	validators.AddIf(
+		slices.Contains(c.GetL(BACKUP_DESTINATIONS), BACKUP_STORAGE_DIR),
+		knf.Validators{
+			{BACKUP_DIR, knfv.Set, nil},
+			{BACKUP_DIR, knff.Perms, "DRWX"},
+		},
+	)
+
+	validators.AddIf(
+		slices.Contains(c.GetL(BACKUP_DESTINATIONS), BACKUP_STORAGE_S3),
+		knf.Validators{
+			{BACKUP_S3_ENDPOINT, knfv.Set, nil},
+			{BACKUP_S3_BUCKET, knfv.Set, nil},
+			{BACKUP_S3_ACCESS_KEY, knfv.Set, nil},
+			{BACKUP_S3_SECRET_KEY, knfv.Set, nil},
+		},
+	)
+
+	validators.AddIf(
+		c.GetB(BACKUP_ENCRYPTION),
+		knf.Validators{
+			{BACKUP_ENCRYPTION, validateBackupEncryption, nil},
+		},
+	)
+
+	validators.AddIf(
+		c.GetS(BACKUP_COMPRESSION) == BACKUP_COMPRESSION_ZSTD,
+		knf.Validators{
+			{BACKUP_COMPRESSION, validateZstdBinary, nil},
+		},
+	)
+
+	for _, prop := range c.Props(BACKUP_SCHEDULES) {
+		validators = append(validators, &knf.Validator{
+			BACKUP_SCHEDULES + ":" + prop, validateBackupSchedule, nil,
+		})
+	}
+
+	// HISTORY //
+
+	validators.AddIf(
+		c.GetB(HISTORY_ENABLED),
+		knf.Validators{
+			{HISTORY_RETENTION, validateHistoryRetention, nil},
+		},
+	)
+
 	// REPLICATION //
 
 	validators.AddIf(
@@ -2676,9 +3148,91 @@ func validateConfig(c *knf.Config) []error {
 		},
 	)
 
+	// MIGRATION //
+
+	validators.AddIf(
+		c.GetS(MIGRATION_AUTH_TOKEN) != "",
+		knf.Validators{
+			{MIGRATION_IP, knfn.IP, nil},
+			{MIGRATION_PORT, knfv.Set, nil},
+			{MIGRATION_PORT, knfv.Greater, MIN_PORT},
+			{MIGRATION_PORT, knfv.Less, MAX_PORT},
+			{MIGRATION_AUTH_TOKEN, knfv.LenEquals, TOKEN_LENGTH},
+		},
+	)
+
 	return c.Validate(validators)
 }
 
+// validatePortRanges checks that instance, TLS and Sentinel ports don't overlap
+func validatePortRanges(config knf.IConfig, prop string, value any) error {
+	type portRange struct {
+		prop       string
+		start, end int
+	}
+
+	maxInstances := config.GetI(MAIN_MAX_INSTANCES)
+	sentinelPort := config.GetI(SENTINEL_PORT, 63999)
+
+	ranges := []portRange{
+		{REDIS_START_PORT, config.GetI(REDIS_START_PORT) + 1, config.GetI(REDIS_START_PORT) + maxInstances},
+		{SENTINEL_PORT, sentinelPort, sentinelPort},
+	}
+
+	if config.GetS(TLS_CERT) != "" && config.GetS(TLS_KEY) != "" {
+		ranges = append(ranges, portRange{
+			TLS_START_PORT, config.GetI(TLS_START_PORT) + 1, config.GetI(TLS_START_PORT) + maxInstances,
+		})
+	}
+
+	// Sync daemon on master node listens on master port
+	if config.GetS(REPLICATION_ROLE) != "" {
+		masterPort := config.GetI(REPLICATION_MASTER_PORT)
+		ranges = append(ranges, portRange{REPLICATION_MASTER_PORT, masterPort, masterPort})
+	}
+
+	if config.GetS(MIGRATION_AUTH_TOKEN) != "" {
+		migrationPort := config.GetI(MIGRATION_PORT)
+		ranges = append(ranges, portRange{MIGRATION_PORT, migrationPort, migrationPort})
+	}
+
+	for i, r1 := range ranges {
+		if r1.end > MAX_PORT {
+			return fmt.Errorf(
+				"Port range %d-%d defined by %s and %s exceeds max port number (%d)",
+				r1.start, r1.end, r1.prop, MAIN_MAX_INSTANCES, MAX_PORT,
+			)
+		}
+
+		for _, r2 := range ranges[i+1:] {
+			if r1.start <= r2.end && r2.start <= r1.end {
+				return fmt.Errorf(
+					"Ports defined by %s (%d-%d) overlap with ports defined by %s (%d-%d)",
+					r1.prop, r1.start, r1.end, r2.prop, r2.start, r2.end,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSocketOnly validates socket-only mode configuration
+func validateSocketOnly(config knf.IConfig, prop string, value any) error {
+	switch {
+	case !config.GetB(REDIS_UNIX_SOCKET):
+		return fmt.Errorf("Property %s requires %s to be enabled", REDIS_SOCKET_ONLY, REDIS_UNIX_SOCKET)
+	case config.GetS(TLS_CERT) != "" || config.GetS(TLS_KEY) != "":
+		return fmt.Errorf("Property %s can't be used with TLS", REDIS_SOCKET_ONLY)
+	case config.GetS(REPLICATION_ROLE) != "":
+		return fmt.Errorf("Property %s can't be used with replication", REDIS_SOCKET_ONLY)
+	case config.GetS(MIGRATION_AUTH_TOKEN) != "":
+		return fmt.Errorf("Property %s can't be used with migration", REDIS_SOCKET_ONLY)
+	}
+
+	return nil
+}
+
 // createInstanceData create all required files and directories for instance
 func createInstanceData(meta *InstanceMeta) error {
 	var err error
@@ -2738,12 +3292,16 @@ func saveInstanceMeta(meta *InstanceMeta) error {
 // createInstanceConfig create redis config from template
 func createInstanceConfig(meta *InstanceMeta) error {
 	cfg := createConfigFromMeta(meta)
-	confData, err := generateConfigFromTemplate(TEMPLATE_SOURCE_REDIS, cfg)
+	confData, err := generateConfigFromTemplate(
+		TEMPLATE_SOURCE_REDIS, meta.Preferencies.TemplateProfile, cfg,
+	)
 
 	if err != nil {
 		return err
 	}
 
+	confData = appendCustomDirectives(confData, meta.Storage.Get(META_CUSTOM_DIRECTIVES))
+
 	err = os.WriteFile(GetInstanceConfigFilePath(meta.ID), confData, 0640)
 
 	if err != nil {
@@ -3058,11 +3616,12 @@ func generateSentinelConfig() error {
 	}
 
 	confData, err := generateConfigFromTemplate(
-		TEMPLATE_SOURCE_SENTINEL,
+		TEMPLATE_SOURCE_SENTINEL, "",
 		&sentinelConfigData{
 			Port:    sentinelPort,
 			PidFile: sentinelPidFile,
 			LogFile: sentinelLogFile,
+			TLS:     getTLSConfigData(),
 		},
 	)
 
@@ -3092,7 +3651,7 @@ func generateSentinelConfig() error {
 
 // getConfigTemplateData reads configuration data from template
 // for currently installed Redis/Sentinel version
-func getConfigTemplateData(source TemplateSource) (string, string, error) {
+func getConfigTemplateData(source TemplateSource, profile string) (string, string, error) {
 	var err error
 	var templateFile, templateFilePath string
 
@@ -3107,6 +3666,15 @@ func getConfigTemplateData(source TemplateSource) (string, string, error) {
 	switch source {
 	case TEMPLATE_SOURCE_REDIS:
 		templateFile = "redis-" + majorRedisVer + ".conf"
+
+		if profile != "" {
+			if !IsTemplateProfileExist(profile) {
+				return "", "", fmt.Errorf("Template profile %q doesn't exist", profile)
+			}
+
+			templateFile = path.Join(profile, templateFile)
+		}
+
 		templateFilePath, err = path.JoinSecure(Config.GetS(TEMPLATES_REDIS), templateFile)
 	case TEMPLATE_SOURCE_SENTINEL:
 		templateFile = "sentinel-" + majorRedisVer + ".conf"
@@ -3129,8 +3697,8 @@ func getConfigTemplateData(source TemplateSource) (string, string, error) {
 }
 
 // generateConfigFromTemplate generates configuration from template
-func generateConfigFromTemplate(source TemplateSource, data any) ([]byte, error) {
-	templateFile, templateData, err := getConfigTemplateData(source)
+func generateConfigFromTemplate(source TemplateSource, profile string, data any) ([]byte, error) {
+	templateFile, templateData, err := getConfigTemplateData(source, profile)
 
 	if err != nil {
 		return nil, err
@@ -3249,6 +3817,41 @@ func isInstanceFullyStarted(id int) bool {
 	return false
 }
 
+// waitForInstanceStop waits until instance process is stopped
+func waitForInstanceStop(id, instancePID int, force bool) error {
+	cmdStart := time.Now()
+	stopDelay := time.Second * time.Duration(Config.GetI(DELAY_STOP))
+
+	for range time.NewTicker(time.Second).C {
+		if pid.IsProcessWorks(instancePID) {
+			if isInstanceSavingData(id) {
+				time.Sleep(3 * time.Second)
+				cmdStart = time.Now()
+				continue
+			}
+
+			if time.Since(cmdStart) > stopDelay {
+				if force {
+					if IsSystemdMode() || isInstanceUnitExist(id) {
+						return killInstanceUnit(id)
+					}
+
+					syscall.Kill(instancePID, syscall.SIGKILL)
+					return nil
+				}
+
+				return ErrInstanceStillWorks
+			}
+
+			continue
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
 // runAsUser run binary as defined user
 func runAsUser(user, logFile string, args ...string) error {
 	if !fsutil.IsRegular(BIN_RUNUSER) {
@@ -3387,11 +3990,16 @@ func runSentinelFailoverSwitch(id int, priority string) error {
 	}
 
 	req := &REDIS.Request{
-		Port:    GetInstancePort(id),
 		Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
 		Timeout: time.Second,
 	}
 
+	err = ConfigureInstanceRequest(id, req)
+
+	if err != nil {
+		return err
+	}
+
 	// Set instance priority to 1 (will be preferred by Sentinel)
 	req.Command = []string{"CONFIG", "SET", "slave-priority", "1"}
 
@@ -3401,9 +4009,7 @@ func runSentinelFailoverSwitch(id int, priority string) error {
 		return fmt.Errorf("Can't set slave priority: %v", err)
 	}
 
-	sCfg := &SENTINEL.SentinelConfig{
-		Port: Config.GetI(SENTINEL_PORT),
-	}
+	sCfg := getSentinelConfig()
 
 	// Force Sentinel failover
 	sentinelErr := SENTINEL.Failover(sCfg, id)
@@ -3477,6 +4083,9 @@ func createConfigFromMeta(meta *InstanceMeta) *instanceConfigData {
 		ServicePassword:  meta.Preferencies.ServicePassword,
 		IsSecure:         meta.Preferencies.ServicePassword != "",
 		IsSaveDisabled:   meta.Preferencies.IsSaveDisabled,
+		IsSocketEnabled:  Config.GetB(REDIS_UNIX_SOCKET),
+		IsSocketOnly:     IsSocketOnly(),
+		TLS:              getTLSConfigData(),
 
 		RDS: &instanceConfigRDSData{
 			HasReplication:     Config.GetS(REPLICATION_ROLE) != "",
@@ -3516,11 +4125,16 @@ func applyChangedConfigProps(id int, diff []REDIS.ConfigPropDiff) []error {
 	var errs []error
 
 	req := &REDIS.Request{
-		Port:    GetInstancePort(id),
 		Auth:    REDIS.Auth{REDIS_USER_ADMIN, meta.Preferencies.AdminPassword},
 		Timeout: time.Second,
 	}
 
+	err = ConfigureInstanceRequest(id, req)
+
+	if err != nil {
+		return []error{err}
+	}
+
 	for _, info := range diff {
 		newValue := info.FileValue
 
@@ -3577,6 +4191,20 @@ func execShutdownCommand(id int) error {
 	return nil
 }
 
+// getSentinelConfig returns configuration for connection to Sentinel
+func getSentinelConfig() *SENTINEL.SentinelConfig {
+	sCfg := &SENTINEL.SentinelConfig{
+		Port: Config.GetI(SENTINEL_PORT),
+	}
+
+	// Sentinel doesn't listen plain TCP port in TLS-only mode
+	if IsTLSOnly() {
+		sCfg.TLS, _ = GetTLSConfig()
+	}
+
+	return sCfg
+}
+
 // getSHA256Hash returns SHA-256 hash for given data
 func getSHA256Hash(data string) string {
 	return fmt.Sprintf("%064x", sha256.Sum256([]byte(data)))
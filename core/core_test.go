@@ -0,0 +1,79 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/knf"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type CoreSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&CoreSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *CoreSuite) TestPortRanges(c *C) {
+	config := parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n[sentinel]\n  port: 63999\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), IsNil)
+
+	config = parseConfig(c, "[main]\n  max-instances: 1024\n[redis]\n  start-port: 63000\n[sentinel]\n  port: 63999\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by redis:start-port \(63001-64024\) overlap with ports defined by sentinel:port \(63999-63999\)`)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 65500\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Port range 65501-65628 defined by redis:start-port and main:max-instances exceeds max port number \(65535\)`)
+
+	tlsConfig := "[tls]\n  cert: /etc/rds/tls.crt\n  key: /etc/rds/tls.key\n"
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+tlsConfig+"  start-port: 62000\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), IsNil)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+tlsConfig+"  start-port: 63100\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by redis:start-port \(63001-63128\) overlap with ports defined by tls:start-port \(63101-63228\)`)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 62000\n"+tlsConfig+"  start-port: 63900\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by sentinel:port \(63999-63999\) overlap with ports defined by tls:start-port \(63901-64028\)`)
+
+	replConfig := "[replication]\n  role: master\n  master-port: "
+	migrationConfig := "[migration]\n  auth-token: " + strings.Repeat("A", TOKEN_LENGTH) + "\n  port: "
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+replConfig+"64000\n"+migrationConfig+"64001\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), IsNil)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+replConfig+"63050\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by redis:start-port \(63001-63128\) overlap with ports defined by replication:master-port \(63050-63050\)`)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+migrationConfig+"63999\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by sentinel:port \(63999-63999\) overlap with ports defined by migration:port \(63999-63999\)`)
+
+	config = parseConfig(c, "[main]\n  max-instances: 128\n[redis]\n  start-port: 63000\n"+replConfig+"64000\n"+migrationConfig+"64000\n")
+	c.Assert(validatePortRanges(config, REDIS_START_PORT, nil), ErrorMatches,
+		`Ports defined by replication:master-port \(64000-64000\) overlap with ports defined by migration:port \(64000-64000\)`)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseConfig parses configuration data
+func parseConfig(c *C, data string) *knf.Config {
+	config, err := knf.Parse([]byte(data))
+	c.Assert(err, IsNil)
+	return config
+}
@@ -0,0 +1,423 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HISTORY_NODE is name of history with node metrics
+const HISTORY_NODE = "node"
+
+// HISTORY_PERMS is default permissions for history files
+const HISTORY_PERMS = 0644
+
+// Default history settings
+const (
+	DEFAULT_HISTORY_RESOLUTION = 5 * time.Minute
+	DEFAULT_HISTORY_RETENTION  = 7 * 24 * time.Hour
+)
+
+// History limits
+const (
+	MIN_HISTORY_RESOLUTION = 10 * time.Second
+	MAX_HISTORY_POINTS     = 100000
+)
+
+const (
+	historyMagic      = "RDSH"
+	historyVersion    = 1
+	historyHeaderSize = 32
+	historyNameSize   = 16
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HistoryPoint is single value from metrics history
+type HistoryPoint struct {
+	Date  time.Time
+	Value float64
+}
+
+// HistorySample contains values of metrics collected at the same time
+type HistorySample map[string]float64
+
+// historyHeader contains round-robin file header
+type historyHeader struct {
+	Resolution time.Duration
+	Slots      int
+	Metrics    []string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HistoryInstanceMetrics is a list of metrics stored for every instance
+var HistoryInstanceMetrics = []string{
+	"mem", "rss", "ops", "clients", "keys", "cpu", "input", "output",
+}
+
+// HistoryNodeMetrics is a list of metrics stored for node
+var HistoryNodeMetrics = []string{
+	"mem", "swap", "ops", "clients", "keys", "input", "output", "instances",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsHistoryEnabled returns true if metrics history collecting is enabled
+func IsHistoryEnabled() bool {
+	return Config.GetB(HISTORY_ENABLED)
+}
+
+// GetHistoryResolution returns interval between metrics samples
+func GetHistoryResolution() time.Duration {
+	return Config.GetTD(HISTORY_RESOLUTION, DEFAULT_HISTORY_RESOLUTION)
+}
+
+// GetHistoryRetention returns period of time for which metrics are stored
+func GetHistoryRetention() time.Duration {
+	return Config.GetTD(HISTORY_RETENTION, DEFAULT_HISTORY_RETENTION)
+}
+
+// GetHistoryMetrics returns list of metrics stored in history with given name
+func GetHistoryMetrics(name string) []string {
+	if name == HISTORY_NODE {
+		return HistoryNodeMetrics
+	}
+
+	return HistoryInstanceMetrics
+}
+
+// AddHistorySample adds metrics sample to history with given name (instance
+// ID or "node"). If history file was created with different resolution or
+// retention, it will be recreated.
+func AddHistorySample(name string, date time.Time, sample HistorySample) error {
+	err := checkHistoryDir()
+
+	if err != nil {
+		return err
+	}
+
+	resolution := GetHistoryResolution()
+	header := &historyHeader{
+		Resolution: resolution,
+		Slots:      int(GetHistoryRetention() / resolution),
+		Metrics:    GetHistoryMetrics(name),
+	}
+
+	fd, err := openHistoryFile(getHistoryFilePath(name), header)
+
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	slot := date.Truncate(resolution)
+	buf := &bytes.Buffer{}
+
+	binary.Write(buf, binary.LittleEndian, slot.Unix())
+
+	for _, metric := range header.Metrics {
+		value, ok := sample[metric]
+
+		if !ok {
+			value = math.NaN()
+		}
+
+		binary.Write(buf, binary.LittleEndian, value)
+	}
+
+	_, err = fd.WriteAt(buf.Bytes(), header.slotOffset(slot))
+
+	if err != nil {
+		return fmt.Errorf("Can't write metrics sample: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistory returns values of given metric from history with given name
+// (instance ID or "node") collected after given date
+func GetHistory(name, metric string, since time.Time) ([]HistoryPoint, time.Duration, error) {
+	historyFile := getHistoryFilePath(name)
+
+	if !fsutil.IsExist(historyFile) {
+		return nil, 0, fmt.Errorf("There is no metrics history for %s", name)
+	}
+
+	fd, err := os.Open(historyFile)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("Can't open metrics history file: %w", err)
+	}
+
+	defer fd.Close()
+
+	header, err := readHistoryHeader(fd)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	index := -1
+
+	for i, m := range header.Metrics {
+		if m == metric {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		return nil, 0, fmt.Errorf(
+			"Unknown metric %q (supported metrics: %s)",
+			metric, strings.Join(header.Metrics, ", "),
+		)
+	}
+
+	data := make([]byte, header.slotSize()*header.Slots)
+	_, err = fd.ReadAt(data, header.dataOffset())
+
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("Can't read metrics history: %w", err)
+	}
+
+	var result []HistoryPoint
+
+	for i := 0; i < header.Slots; i++ {
+		slot := data[i*header.slotSize():]
+		ts := int64(binary.LittleEndian.Uint64(slot))
+
+		if ts <= 0 || ts < since.Unix() {
+			continue
+		}
+
+		value := math.Float64frombits(binary.LittleEndian.Uint64(slot[8+index*8:]))
+
+		if math.IsNaN(value) {
+			continue
+		}
+
+		result = append(result, HistoryPoint{time.Unix(ts, 0), value})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result, header.Resolution, nil
+}
+
+// RemoveInstanceHistory removes metrics history of instance with given ID
+func RemoveInstanceHistory(id int) error {
+	historyFile := getHistoryFilePath(strconv.Itoa(id))
+
+	if !fsutil.IsExist(historyFile) {
+		return nil
+	}
+
+	return os.Remove(historyFile)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// slotSize returns size of one slot in bytes
+func (h *historyHeader) slotSize() int {
+	return 8 + len(h.Metrics)*8
+}
+
+// dataOffset returns offset of the first slot
+func (h *historyHeader) dataOffset() int64 {
+	return int64(historyHeaderSize + len(h.Metrics)*historyNameSize)
+}
+
+// slotOffset returns offset of slot for given date
+func (h *historyHeader) slotOffset(date time.Time) int64 {
+	index := (date.Unix() / int64(h.Resolution/time.Second)) % int64(h.Slots)
+	return h.dataOffset() + index*int64(h.slotSize())
+}
+
+// fileSize returns expected size of history file
+func (h *historyHeader) fileSize() int64 {
+	return h.dataOffset() + int64(h.Slots*h.slotSize())
+}
+
+// isEqual returns true if headers are equal
+func (h *historyHeader) isEqual(hh *historyHeader) bool {
+	return h.Resolution == hh.Resolution && h.Slots == hh.Slots &&
+		strings.Join(h.Metrics, " ") == strings.Join(hh.Metrics, " ")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// checkHistoryDir checks history directory and creates it if required
+func checkHistoryDir() error {
+	historyDir := getHistoryDirPath()
+
+	if fsutil.IsExist(historyDir) {
+		return nil
+	}
+
+	err := os.Mkdir(historyDir, 0755)
+
+	if err != nil {
+		return fmt.Errorf("Can't create directory for metrics history: %w", err)
+	}
+
+	return nil
+}
+
+// openHistoryFile opens history file for writing and creates it if file doesn't
+// exist or has different header
+func openHistoryFile(file string, header *historyHeader) (*os.File, error) {
+	fd, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, HISTORY_PERMS)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't open metrics history file: %w", err)
+	}
+
+	fileHeader, err := readHistoryHeader(fd)
+
+	if err == nil && fileHeader.isEqual(header) {
+		return fd, nil
+	}
+
+	err = writeHistoryHeader(fd, header)
+
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	return fd, nil
+}
+
+// readHistoryHeader reads and parses history file header
+func readHistoryHeader(fd *os.File) (*historyHeader, error) {
+	data := make([]byte, historyHeaderSize)
+	_, err := fd.ReadAt(data, 0)
+
+	if err != nil || string(data[:4]) != historyMagic {
+		return nil, fmt.Errorf("File %s is not a metrics history file", fd.Name())
+	}
+
+	if binary.LittleEndian.Uint16(data[4:]) != historyVersion {
+		return nil, fmt.Errorf("Metrics history file %s has unsupported version", fd.Name())
+	}
+
+	metricsNum := int(binary.LittleEndian.Uint16(data[6:]))
+	header := &historyHeader{
+		Resolution: time.Duration(binary.LittleEndian.Uint32(data[8:])) * time.Second,
+		Slots:      int(binary.LittleEndian.Uint32(data[12:])),
+	}
+
+	if header.Resolution == 0 || header.Slots == 0 || metricsNum == 0 {
+		return nil, fmt.Errorf("Metrics history file %s has invalid header", fd.Name())
+	}
+
+	names := make([]byte, metricsNum*historyNameSize)
+	_, err = fd.ReadAt(names, historyHeaderSize)
+
+	if err != nil {
+		return nil, fmt.Errorf("Metrics history file %s has invalid header", fd.Name())
+	}
+
+	for i := 0; i < metricsNum; i++ {
+		name := names[i*historyNameSize : (i+1)*historyNameSize]
+		header.Metrics = append(header.Metrics, string(bytes.TrimRight(name, "\x00")))
+	}
+
+	return header, nil
+}
+
+// writeHistoryHeader writes header to history file and removes all collected data
+func writeHistoryHeader(fd *os.File, header *historyHeader) error {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString(historyMagic)
+	binary.Write(buf, binary.LittleEndian, uint16(historyVersion))
+	binary.Write(buf, binary.LittleEndian, uint16(len(header.Metrics)))
+	binary.Write(buf, binary.LittleEndian, uint32(header.Resolution/time.Second))
+	binary.Write(buf, binary.LittleEndian, uint32(header.Slots))
+	buf.Write(make([]byte, historyHeaderSize-buf.Len()))
+
+	for _, metric := range header.Metrics {
+		name := make([]byte, historyNameSize)
+		copy(name, metric)
+		buf.Write(name)
+	}
+
+	err := fd.Truncate(0)
+
+	if err == nil {
+		_, err = fd.WriteAt(buf.Bytes(), 0)
+	}
+
+	if err == nil {
+		err = fd.Truncate(header.fileSize())
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't create metrics history file: %w", err)
+	}
+
+	return nil
+}
+
+// getHistoryDirPath returns path to directory with metrics history
+func getHistoryDirPath() string {
+	return path.Join(Config.GetS(MAIN_DIR), "history")
+}
+
+// getHistoryFilePath returns path to history file with given name
+func getHistoryFilePath(name string) string {
+	return path.Join(getHistoryDirPath(), name+".rrd")
+}
+
+// validateHistoryRetention validates history resolution and retention
+func validateHistoryRetention(config knf.IConfig, prop string, value any) error {
+	resolution := config.GetTD(HISTORY_RESOLUTION, DEFAULT_HISTORY_RESOLUTION)
+	retention := config.GetTD(HISTORY_RETENTION, DEFAULT_HISTORY_RETENTION)
+
+	switch {
+	case resolution < MIN_HISTORY_RESOLUTION:
+		return fmt.Errorf(
+			"Property %s can't be less than %v",
+			HISTORY_RESOLUTION, MIN_HISTORY_RESOLUTION,
+		)
+
+	case retention < resolution:
+		return fmt.Errorf(
+			"Property %s can't be less than %s",
+			HISTORY_RETENTION, HISTORY_RESOLUTION,
+		)
+
+	case retention/resolution > MAX_HISTORY_POINTS:
+		return fmt.Errorf(
+			"History can't contain more than %d points (%s / %s)",
+			MAX_HISTORY_POINTS, HISTORY_RETENTION, HISTORY_RESOLUTION,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"math"
+	"os"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/knf"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type HistorySuite struct {
+	dir string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&HistorySuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *HistorySuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.configure(c, "10s", "30s")
+}
+
+func (s *HistorySuite) TestBasic(c *C) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	c.Assert(AddHistorySample("1", date, HistorySample{"mem": 100, "ops": 5}), IsNil)
+
+	points, resolution, err := GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(resolution, Equals, 10*time.Second)
+	c.Assert(points, HasLen, 1)
+	c.Assert(points[0].Date.Equal(date), Equals, true)
+	c.Assert(points[0].Value, Equals, 100.0)
+
+	// Metrics without values must be skipped
+	points, _, err = GetHistory("1", "keys", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 0)
+
+	_, _, err = GetHistory("1", "unknown", time.Time{})
+	c.Assert(err, ErrorMatches, `Unknown metric "unknown" .*`)
+
+	_, _, err = GetHistory("2", "mem", time.Time{})
+	c.Assert(err, ErrorMatches, "There is no metrics history for 2")
+
+	c.Assert(RemoveInstanceHistory(1), IsNil)
+	c.Assert(RemoveInstanceHistory(1), IsNil)
+
+	_, _, err = GetHistory("1", "mem", time.Time{})
+	c.Assert(err, NotNil)
+}
+
+func (s *HistorySuite) TestSlotWrapAround(c *C) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 3 slots, so the first sample must be overwritten by the fourth one
+	for i := 0; i < 4; i++ {
+		sampleDate := date.Add(time.Duration(i)*10*time.Second + 3*time.Second)
+		c.Assert(AddHistorySample(HISTORY_NODE, sampleDate, HistorySample{"mem": float64(i)}), IsNil)
+	}
+
+	points, _, err := GetHistory(HISTORY_NODE, "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 3)
+
+	for i, point := range points {
+		c.Assert(point.Date.Equal(date.Add(time.Duration(i+1)*10*time.Second)), Equals, true)
+		c.Assert(point.Value, Equals, float64(i+1))
+	}
+
+	// Filtering by date
+	points, _, err = GetHistory(HISTORY_NODE, "mem", date.Add(25*time.Second))
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 1)
+	c.Assert(points[0].Value, Equals, 3.0)
+
+	info, err := os.Stat(getHistoryFilePath(HISTORY_NODE))
+	c.Assert(err, IsNil)
+
+	header := &historyHeader{Resolution: 10 * time.Second, Slots: 3, Metrics: HistoryNodeMetrics}
+	c.Assert(info.Size(), Equals, header.fileSize())
+}
+
+func (s *HistorySuite) TestHeaderChange(c *C) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	c.Assert(AddHistorySample("1", date, HistorySample{"mem": 1}), IsNil)
+	c.Assert(AddHistorySample("1", date.Add(10*time.Second), HistorySample{"mem": 2}), IsNil)
+
+	// Same settings, data must be kept
+	s.configure(c, "10s", "30s")
+	c.Assert(AddHistorySample("1", date.Add(20*time.Second), HistorySample{"mem": 3}), IsNil)
+
+	points, _, err := GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 3)
+
+	// Retention changed, collected data must be removed
+	s.configure(c, "10s", "1m")
+	c.Assert(AddHistorySample("1", date.Add(30*time.Second), HistorySample{"mem": 4}), IsNil)
+
+	points, resolution, err := GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(resolution, Equals, 10*time.Second)
+	c.Assert(points, HasLen, 1)
+	c.Assert(points[0].Value, Equals, 4.0)
+
+	// Resolution changed
+	s.configure(c, "20s", "1m")
+	c.Assert(AddHistorySample("1", date.Add(40*time.Second), HistorySample{"mem": 5}), IsNil)
+
+	points, resolution, err = GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(resolution, Equals, 20*time.Second)
+	c.Assert(points, HasLen, 1)
+	c.Assert(points[0].Value, Equals, 5.0)
+
+	// File with different list of metrics must be recreated
+	historyFile := getHistoryFilePath("1")
+	fd, err := openHistoryFile(historyFile, &historyHeader{
+		Resolution: 20 * time.Second, Slots: 3, Metrics: []string{"mem", "rss"},
+	})
+
+	c.Assert(err, IsNil)
+	fd.Close()
+
+	_, _, err = GetHistory("1", "ops", time.Time{})
+	c.Assert(err, ErrorMatches, `Unknown metric "ops" .*`)
+
+	points, _, err = GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 0)
+
+	// Corrupted file must be recreated
+	c.Assert(os.WriteFile(historyFile, []byte("ABCD"), HISTORY_PERMS), IsNil)
+
+	_, _, err = GetHistory("1", "mem", time.Time{})
+	c.Assert(err, ErrorMatches, "File .* is not a metrics history file")
+
+	c.Assert(AddHistorySample("1", date, HistorySample{"mem": math.Pi}), IsNil)
+
+	points, _, err = GetHistory("1", "mem", time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 1)
+	c.Assert(points[0].Value, Equals, math.Pi)
+}
+
+func (s *HistorySuite) TestValidator(c *C) {
+	s.configure(c, "5s", "1m")
+	c.Assert(validateHistoryRetention(Config, HISTORY_RETENTION, nil), NotNil)
+
+	s.configure(c, "1m", "30s")
+	c.Assert(validateHistoryRetention(Config, HISTORY_RETENTION, nil), NotNil)
+
+	s.configure(c, "10s", "30d")
+	c.Assert(validateHistoryRetention(Config, HISTORY_RETENTION, nil), NotNil)
+
+	s.configure(c, "5m", "7d")
+	c.Assert(validateHistoryRetention(Config, HISTORY_RETENTION, nil), IsNil)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// configure sets history configuration
+func (s *HistorySuite) configure(c *C, resolution, retention string) {
+	var err error
+
+	Config, err = knf.Parse([]byte(
+		"[main]\n  dir: " + s.dir + "\n\n" +
+			"[history]\n  enabled: true\n  resolution: " + resolution +
+			"\n  retention: " + retention + "\n",
+	))
+
+	c.Assert(err, IsNil)
+}
@@ -0,0 +1,170 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type HookEvent string
+
+const (
+	HOOK_EVENT_CREATE      HookEvent = "create"
+	HOOK_EVENT_START       HookEvent = "start"
+	HOOK_EVENT_STOP        HookEvent = "stop"
+	HOOK_EVENT_DESTROY     HookEvent = "destroy"
+	HOOK_EVENT_ROLE_CHANGE HookEvent = "role-change"
+)
+
+type HookStage string
+
+const (
+	HOOK_STAGE_PRE  HookStage = "pre"
+	HOOK_STAGE_POST HookStage = "post"
+)
+
+const (
+	HOOK_POLICY_ABORT = "abort"
+	HOOK_POLICY_WARN  = "warn"
+)
+
+// DEFAULT_HOOK_TIMEOUT is default hook execution timeout in seconds
+const DEFAULT_HOOK_TIMEOUT = 30
+
+// HOOK_WAIT_DELAY is delay for closing hook output after it was killed
+const HOOK_WAIT_DELAY = 3 * time.Second
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RunInstanceHooks runs global and template profile hooks for given instance event.
+// Additional environment variables can be passed using vars in "NAME=value" format.
+// Only pre-hooks can abort the action, post-hook failures are logged as warnings.
+func RunInstanceHooks(stage HookStage, event HookEvent, meta *InstanceMeta, vars ...string) error {
+	if Config.GetS(HOOKS_DIR) == "" || meta == nil {
+		return nil
+	}
+
+	for _, hook := range getHookScripts(stage, event, meta) {
+		err := runHookScript(hook, getHookEnv(stage, event, meta, vars))
+
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("Hook %s failed: %w", hook, err)
+
+		if stage == HOOK_STAGE_PRE && Config.GetS(HOOKS_FAILURE_POLICY, HOOK_POLICY_WARN) == HOOK_POLICY_ABORT {
+			return err
+		}
+
+		log.Warn("(%3d) %v", meta.ID, err)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getHookScripts returns list of executable hook scripts for given event
+func getHookScripts(stage HookStage, event HookEvent, meta *InstanceMeta) []string {
+	var result []string
+
+	hooksDir := Config.GetS(HOOKS_DIR)
+	hookName := string(stage) + "-" + string(event)
+	dirs := []string{hooksDir}
+
+	if meta.Preferencies != nil && meta.Preferencies.TemplateProfile != "" {
+		profileDir, err := path.JoinSecure(hooksDir, meta.Preferencies.TemplateProfile)
+
+		if err == nil {
+			dirs = append(dirs, profileDir)
+		}
+	}
+
+	for _, dir := range dirs {
+		hook := path.Join(dir, hookName)
+
+		if fsutil.IsRegular(hook) && fsutil.IsExecutable(hook) {
+			result = append(result, hook)
+		}
+	}
+
+	return result
+}
+
+// getHookEnv returns environment variables for hook script
+func getHookEnv(stage HookStage, event HookEvent, meta *InstanceMeta, vars []string) []string {
+	var owner, profile string
+
+	if meta.Auth != nil {
+		owner = meta.Auth.User
+	}
+
+	if meta.Preferencies != nil {
+		profile = meta.Preferencies.TemplateProfile
+	}
+
+	env := append(os.Environ(),
+		"RDS_HOOK_STAGE="+string(stage),
+		"RDS_HOOK_EVENT="+string(event),
+		"RDS_NODE_ROLE="+Config.GetS(REPLICATION_ROLE),
+		"RDS_INSTANCE_ID="+strconv.Itoa(meta.ID),
+		"RDS_INSTANCE_PORT="+strconv.Itoa(GetInstancePort(meta.ID)),
+		"RDS_INSTANCE_UUID="+meta.UUID,
+		"RDS_INSTANCE_OWNER="+owner,
+		"RDS_INSTANCE_TAGS="+strings.Join(meta.Tags, ","),
+		"RDS_INSTANCE_PROFILE="+profile,
+	)
+
+	return append(env, vars...)
+}
+
+// runHookScript runs hook script with configured timeout
+func runHookScript(hook string, env []string) error {
+	timeout := time.Duration(Config.GetI(HOOKS_TIMEOUT, DEFAULT_HOOK_TIMEOUT)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Env = env
+	// Child processes of the hook can keep output pipes open after the
+	// hook was killed, so we don't wait for them forever
+	cmd.WaitDelay = HOOK_WAIT_DELAY
+
+	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Timeout (%v) exceeded", timeout)
+	}
+
+	if err != nil {
+		outputLines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		lastLine := outputLines[len(outputLines)-1]
+
+		if lastLine != "" {
+			return fmt.Errorf("%v (%s)", err, lastLine)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type HooksSuite struct {
+	dir string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&HooksSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *HooksSuite) SetUpTest(c *C) {
+	var err error
+
+	s.dir = c.MkDir()
+
+	Config, err = knf.Parse([]byte(
+		"[hooks]\n  dir: " + s.dir + "\n  timeout: 1\n  failure-policy: abort\n",
+	))
+
+	c.Assert(err, IsNil)
+}
+
+func (s *HooksSuite) TestFailurePolicy(c *C) {
+	meta := &InstanceMeta{ID: 1}
+
+	s.addHook(c, "pre-start", "echo 'Something went wrong'\nexit 1")
+	s.addHook(c, "post-start", "exit 1")
+
+	err := RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_START, meta)
+	c.Assert(err, ErrorMatches, `Hook .*/pre-start failed: exit status 1 \(Something went wrong\)`)
+
+	// Post-hook failures must never abort the action
+	c.Assert(RunInstanceHooks(HOOK_STAGE_POST, HOOK_EVENT_START, meta), IsNil)
+
+	c.Assert(RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_STOP, meta), IsNil)
+}
+
+func (s *HooksSuite) TestTimeout(c *C) {
+	meta := &InstanceMeta{ID: 1}
+
+	// Child process keeps output pipe open after hook was killed
+	s.addHook(c, "pre-stop", "sleep 30 &\nsleep 30")
+
+	start := time.Now()
+	err := RunInstanceHooks(HOOK_STAGE_PRE, HOOK_EVENT_STOP, meta)
+
+	c.Assert(err, ErrorMatches, `Hook .*/pre-stop failed: Timeout \(1s\) exceeded`)
+	c.Assert(time.Since(start) < 10*time.Second, Equals, true)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// addHook creates executable hook script
+func (s *HooksSuite) addHook(c *C, name, script string) {
+	err := os.WriteFile(path.Join(s.dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755)
+	c.Assert(err, IsNil)
+}
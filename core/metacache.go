@@ -150,6 +150,10 @@ func (c *MetaCache) getClone(original *InstanceMeta) *InstanceMeta {
 			SentinelPassword: original.Preferencies.SentinelPassword,
 			ReplicationType:  original.Preferencies.ReplicationType,
 			IsSaveDisabled:   original.Preferencies.IsSaveDisabled,
+			TemplateProfile:  original.Preferencies.TemplateProfile,
+			MemoryMax:        original.Preferencies.MemoryMax,
+			CPUMax:           original.Preferencies.CPUMax,
+			IOWeight:         original.Preferencies.IOWeight,
 		},
 		Auth: &InstanceAuth{
 			Pepper: original.Auth.Pepper,
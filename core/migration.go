@@ -0,0 +1,225 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	REDIS "github.com/essentialkaos/rds/redis"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReplicationSource contains info about external (non-RDS) replication source
+type ReplicationSource struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReplicaOf makes instance a replica of given master or a master if host is empty
+func ReplicaOf(id int, host string, port int) error {
+	command := []string{"REPLICAOF", "NO", "ONE"}
+
+	if host != "" {
+		command = []string{"REPLICAOF", host, strconv.Itoa(port)}
+	}
+
+	_, err := ExecCommand(id, &REDIS.Request{Command: command, Timeout: 5 * time.Second})
+
+	if err != nil {
+		return fmt.Errorf("Can't change replication role: %w", err)
+	}
+
+	return nil
+}
+
+// PauseInstanceWrites pauses all write commands on instance for given duration
+func PauseInstanceWrites(id int, duration time.Duration) error {
+	_, err := ExecCommand(id, &REDIS.Request{
+		Command: []string{
+			"CLIENT", "PAUSE", strconv.FormatInt(duration.Milliseconds(), 10), "WRITE",
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("Can't pause writes: %w", err)
+	}
+
+	return nil
+}
+
+// UnpauseInstanceWrites resumes processing of write commands on instance
+func UnpauseInstanceWrites(id int) error {
+	_, err := ExecCommand(id, &REDIS.Request{Command: []string{"CLIENT", "UNPAUSE"}})
+
+	if err != nil {
+		return fmt.Errorf("Can't unpause writes: %w", err)
+	}
+
+	return nil
+}
+
+// GetInstanceByUUID returns ID of instance with given UUID or -1 if there is
+// no such instance
+func GetInstanceByUUID(uuid string) int {
+	for _, id := range GetInstanceIDList() {
+		meta, err := GetInstanceMeta(id)
+
+		if err == nil && meta.UUID == uuid {
+			return id
+		}
+	}
+
+	return -1
+}
+
+// GetInstanceByName returns ID of instance with given name or -1 if there is
+// no such instance
+func GetInstanceByName(name string) int {
+	for _, id := range GetInstanceIDList() {
+		meta, err := GetInstanceMeta(id)
+
+		if err == nil && meta.Storage.Get(META_NAME) == name {
+			return id
+		}
+	}
+
+	return -1
+}
+
+// GetMigrationInstanceID returns ID for instance migrated from other node. Source
+// instance ID is used if it's available, so instance keeps the same port.
+func GetMigrationInstanceID(id int) int {
+	if Config.GetB(MAIN_ALLOW_ID_REUSE) && id > 0 && id <= Config.GetI(MAIN_MAX_INSTANCES) && !IsInstanceExist(id) {
+		return id
+	}
+
+	return GetAvailableInstanceID()
+}
+
+// ParseReplicationSource parses external replication source address (host:port)
+// and auth data (password or user:password)
+func ParseReplicationSource(addr, auth string) (*ReplicationSource, error) {
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid replication source address %q: %w", addr, err)
+	}
+
+	source := &ReplicationSource{Host: host}
+	source.Port, err = strconv.Atoi(port)
+
+	if err != nil || source.Port < 1 || source.Port > 65535 {
+		return nil, fmt.Errorf("Invalid replication source port %q", port)
+	}
+
+	if strings.Contains(auth, ":") {
+		source.User, source.Password, _ = strings.Cut(auth, ":")
+	} else {
+		source.Password = auth
+	}
+
+	return source, nil
+}
+
+// CheckReplicationSource checks connection to external replication source
+func CheckReplicationSource(source *ReplicationSource) error {
+	rc := &REDIS.Client{
+		Network:      "tcp",
+		Addr:         net.JoinHostPort(source.Host, strconv.Itoa(source.Port)),
+		DialTimeout:  3 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	}
+
+	err := rc.Connect()
+
+	if err != nil {
+		return fmt.Errorf("Can't connect to replication source: %w", err)
+	}
+
+	defer rc.Close()
+
+	if source.Password != "" {
+		args := []any{source.Password}
+
+		if source.User != "" {
+			args = []any{source.User, source.Password}
+		}
+
+		resp := rc.Cmd("AUTH", args...)
+
+		if resp.Err != nil {
+			return fmt.Errorf("Can't authenticate on replication source: %w", resp.Err)
+		}
+	}
+
+	resp := rc.Cmd("PING")
+
+	if resp.Err != nil {
+		return fmt.Errorf("Replication source returned an error: %w", resp.Err)
+	}
+
+	return nil
+}
+
+// StartExternalReplication makes instance a replica of external replication source
+func StartExternalReplication(id int, source *ReplicationSource) error {
+	for _, prop := range [][]string{{"masteruser", source.User}, {"masterauth", source.Password}} {
+		_, err := ExecCommand(id, &REDIS.Request{
+			Command: []string{"CONFIG", "SET", prop[0], prop[1]},
+		})
+
+		if err != nil {
+			return fmt.Errorf("Can't set %s: %w", prop[0], err)
+		}
+	}
+
+	return ReplicaOf(id, source.Host, source.Port)
+}
+
+// StopExternalReplication detaches instance from external replication source
+// and restores replication auth settings
+func StopExternalReplication(id int) error {
+	meta, err := GetInstanceMeta(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = ReplicaOf(id, "", 0)
+
+	if err != nil {
+		return err
+	}
+
+	props := [][]string{
+		{"masteruser", "sync"},
+		{"masterauth", meta.Preferencies.SyncPassword},
+	}
+
+	for _, prop := range props {
+		_, err = ExecCommand(id, &REDIS.Request{
+			Command: []string{"CONFIG", "SET", prop[0], prop[1]},
+		})
+
+		if err != nil {
+			return fmt.Errorf("Can't restore %s: %w", prop[0], err)
+		}
+	}
+
+	return nil
+}
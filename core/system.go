@@ -76,6 +76,11 @@ func isSystemHasKernelIssues() (bool, error) {
 
 // isSystemHasLimitsIssues returns true if system has problems with limits for Redis user
 func isSystemHasLimitsIssues() (bool, error) {
+	// Limits are defined in instance unit
+	if IsSystemdMode() {
+		return false, nil
+	}
+
 	if !fsutil.CheckPerms("FRX", BIN_RUNUSER) {
 		return false, fmt.Errorf("%s can't be executed", BIN_RUNUSER)
 	}
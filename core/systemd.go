@@ -0,0 +1,307 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SYSTEMD_UNITS_DIR is path to directory with systemd units
+const SYSTEMD_UNITS_DIR = "/etc/systemd/system"
+
+// DEFAULT_MAX_OPEN_FILES is default limit of open files for instance unit
+const DEFAULT_MAX_OPEN_FILES = MIN_PROCS
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type unitConfigData struct {
+	ID           int
+	User         string
+	Binary       string
+	ConfigFile   string
+	StartTimeout int
+	MaxOpenFiles int
+	Nice         int
+	IOClass      string
+	IOPriority   int
+	Limits       *ResourceLimits
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// unitTemplate is template for instance systemd unit
+const unitTemplate = `# This unit is generated by RDS, DO NOT EDIT
+
+[Unit]
+Description=Redis instance {{.ID}} (RDS)
+Documentation=https://kaos.sh/rds
+Requires=network.target remote-fs.target
+After=network.target remote-fs.target
+
+[Service]
+Type=notify
+NotifyAccess=main
+User={{.User}}
+UMask=0027
+ExecStart={{.Binary}} {{.ConfigFile}} --daemonize no --supervised systemd --logfile ""
+Restart=on-failure
+RestartSec=5
+TimeoutStartSec={{.StartTimeout}}
+TimeoutStopSec=infinity
+LimitNOFILE={{.MaxOpenFiles}}
+{{- if .Nice }}
+Nice={{.Nice}}
+{{- end }}
+{{- if .IOClass }}
+IOSchedulingClass={{.IOClass}}
+IOSchedulingPriority={{.IOPriority}}
+{{- end }}
+{{- if .Limits.MemoryMax }}
+MemoryMax={{.Limits.MemoryMax}}
+{{- end }}
+{{- if .Limits.CPUMax }}
+CPUQuota={{.Limits.CPUMax}}%
+{{- end }}
+{{- if .Limits.IOWeight }}
+IOWeight={{.Limits.IOWeight}}
+{{- end }}
+SyslogIdentifier=rds-{{.ID}}
+`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsSystemdMode returns true if instances are managed by systemd
+func IsSystemdMode() bool {
+	return Config.GetB(REDIS_SYSTEMD)
+}
+
+// GetInstanceUnitName returns name of systemd unit for instance with given ID
+func GetInstanceUnitName(id int) string {
+	return "rds-" + strconv.Itoa(id) + ".service"
+}
+
+// GetInstanceUnitFilePath returns path to systemd unit file for instance with given ID
+func GetInstanceUnitFilePath(id int) string {
+	return path.Join(SYSTEMD_UNITS_DIR, GetInstanceUnitName(id))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// startInstanceUnit generates unit for instance and starts it using systemctl
+func startInstanceUnit(id int) error {
+	err := createInstanceUnit(id)
+
+	if err != nil {
+		return fmt.Errorf("Can't create systemd unit: %w", err)
+	}
+
+	return execSystemctl("start", GetInstanceUnitName(id))
+}
+
+// stopInstanceUnit stops instance unit using systemctl
+func stopInstanceUnit(id int) error {
+	if !fsutil.IsExist(GetInstanceUnitFilePath(id)) {
+		return nil
+	}
+
+	return execSystemctl("stop", GetInstanceUnitName(id))
+}
+
+// killInstanceUnit sends KILL signal to all unit processes and stops unit
+func killInstanceUnit(id int) error {
+	unitName := GetInstanceUnitName(id)
+
+	// Stop job must be queued before sending signal, otherwise systemd can
+	// restart unit (Restart=on-failure) before it will be stopped
+	err := execSystemctl("stop", "--no-block", unitName)
+
+	if err != nil {
+		return err
+	}
+
+	err = execSystemctl("kill", "--signal=SIGKILL", unitName)
+
+	if err != nil {
+		return err
+	}
+
+	// Wait until unit is stopped
+	return execSystemctl("stop", unitName)
+}
+
+// createInstanceUnit generates systemd unit file for instance
+func createInstanceUnit(id int) error {
+	unitData, err := generateInstanceUnit(id)
+
+	if err != nil {
+		return err
+	}
+
+	unitFile := GetInstanceUnitFilePath(id)
+	curData, _ := os.ReadFile(unitFile)
+
+	if bytes.Equal(curData, unitData) {
+		return nil
+	}
+
+	err = os.WriteFile(unitFile, unitData, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	return execSystemctl("daemon-reload")
+}
+
+// updateInstanceUnitLimits updates unit file and applies resource limits to
+// working unit
+func updateInstanceUnitLimits(id int) error {
+	err := createInstanceUnit(id)
+
+	if err != nil {
+		return fmt.Errorf("Can't update systemd unit: %w", err)
+	}
+
+	limits, err := GetInstanceLimits(id)
+
+	if err != nil {
+		return err
+	}
+
+	// Empty value resets property to default
+	memMax, cpuQuota, ioWeight := "infinity", "", ""
+
+	if limits.MemoryMax != 0 {
+		memMax = strconv.FormatUint(limits.MemoryMax, 10)
+	}
+
+	if limits.CPUMax != 0 {
+		cpuQuota = strconv.Itoa(limits.CPUMax) + "%"
+	}
+
+	if limits.IOWeight != 0 {
+		ioWeight = strconv.Itoa(limits.IOWeight)
+	}
+
+	return execSystemctl(
+		"set-property", "--runtime", GetInstanceUnitName(id),
+		"MemoryMax="+memMax, "CPUQuota="+cpuQuota, "IOWeight="+ioWeight,
+	)
+}
+
+// removeStaleInstanceUnit stops and removes unit created in systemd mode
+// if this mode is disabled
+func removeStaleInstanceUnit(id int) error {
+	if IsSystemdMode() || !isInstanceUnitExist(id) {
+		return nil
+	}
+
+	err := stopInstanceUnit(id)
+
+	if err != nil {
+		return err
+	}
+
+	return removeInstanceUnit(id)
+}
+
+// isInstanceUnitExist returns true if instance has systemd unit file
+func isInstanceUnitExist(id int) bool {
+	return fsutil.IsExist(GetInstanceUnitFilePath(id))
+}
+
+// removeInstanceUnit removes systemd unit file for instance
+func removeInstanceUnit(id int) error {
+	unitFile := GetInstanceUnitFilePath(id)
+
+	if !fsutil.IsExist(unitFile) {
+		return nil
+	}
+
+	err := os.Remove(unitFile)
+
+	if err != nil {
+		return fmt.Errorf("Can't remove systemd unit file: %w", err)
+	}
+
+	return execSystemctl("daemon-reload")
+}
+
+// generateInstanceUnit generates systemd unit data for instance
+func generateInstanceUnit(id int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	limits, err := GetInstanceLimits(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := &unitConfigData{
+		ID:           id,
+		User:         Config.GetS(REDIS_USER),
+		Binary:       Config.GetS(REDIS_BINARY),
+		ConfigFile:   GetInstanceConfigFilePath(id),
+		StartTimeout: Config.GetI(DELAY_START),
+		MaxOpenFiles: Config.GetI(REDIS_MAX_OPEN_FILES, DEFAULT_MAX_OPEN_FILES),
+		Nice:         Config.GetI(REDIS_NICE),
+		IOPriority:   Config.GetI(REDIS_IONICE_CLASSDATA),
+		Limits:       limits,
+	}
+
+	switch Config.GetI(REDIS_IONICE_CLASS) {
+	case 1:
+		data.IOClass = "realtime"
+	case 2:
+		data.IOClass = "best-effort"
+	case 3:
+		data.IOClass = "idle"
+	}
+
+	t, err := template.New("unit").Parse(unitTemplate)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse unit template: %w", err)
+	}
+
+	err = t.Execute(&buf, data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't render unit template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// execSystemctl executes systemctl with given arguments
+func execSystemctl(args ...string) error {
+	output, err := exec.Command(BIN_SYSTEMCTL, args...).CombinedOutput()
+
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+
+		if msg == "" {
+			return fmt.Errorf("systemctl %s failed: %w", args[0], err)
+		}
+
+		return fmt.Errorf("systemctl %s failed: %s", args[0], msg)
+	}
+
+	return nil
+}
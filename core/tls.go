@@ -0,0 +1,137 @@
+package core
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	TLS_AUTH_CLIENTS_YES      = "yes"
+	TLS_AUTH_CLIENTS_NO       = "no"
+	TLS_AUTH_CLIENTS_OPTIONAL = "optional"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type instanceConfigTLSData struct {
+	IsEnabled     bool
+	IsOnly        bool
+	IsReplication bool
+	CACertFile    string
+	CertFile      string
+	KeyFile       string
+	AuthClients   string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// tlsConfig is cached TLS configuration for connections to instances
+var tlsConfig *tls.Config
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsTLSEnabled returns true if TLS is configured on node
+func IsTLSEnabled() bool {
+	return Config.GetS(TLS_CERT) != "" && Config.GetS(TLS_KEY) != ""
+}
+
+// IsTLSOnly returns true if instances don't listen plain TCP port
+func IsTLSOnly() bool {
+	return IsTLSEnabled() && Config.GetB(TLS_ONLY)
+}
+
+// IsTLSReplication returns true if replication between instances uses TLS
+func IsTLSReplication() bool {
+	return IsTLSEnabled() && (Config.GetB(TLS_REPLICATION) || Config.GetB(TLS_ONLY))
+}
+
+// GetInstanceTLSPort returns TLS port for instance with given ID
+func GetInstanceTLSPort(id int) int {
+	return Config.GetI(TLS_START_PORT) + id
+}
+
+// GetInstanceReplicationPort returns port used by replicas and Sentinel for
+// connecting to instance with given ID
+func GetInstanceReplicationPort(id int) int {
+	if IsTLSReplication() {
+		return GetInstanceTLSPort(id)
+	}
+
+	return GetInstancePort(id)
+}
+
+// GetTLSConfig returns TLS configuration for connections to instances
+func GetTLSConfig() (*tls.Config, error) {
+	if !IsTLSEnabled() {
+		return nil, errors.New("TLS is not configured")
+	}
+
+	if tlsConfig != nil {
+		return tlsConfig, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName: Config.GetS(TLS_SERVER_NAME, Config.GetS(MAIN_HOSTNAME, "localhost")),
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if Config.GetS(TLS_CA_CERT) != "" {
+		caData, err := os.ReadFile(Config.GetS(TLS_CA_CERT))
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read CA certificate: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+
+		if !cfg.RootCAs.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("Can't parse CA certificate %s", Config.GetS(TLS_CA_CERT))
+		}
+	}
+
+	// Instances require client certificate if tls-auth-clients is not set to "no"
+	if Config.GetS(TLS_AUTH_CLIENTS, TLS_AUTH_CLIENTS_YES) != TLS_AUTH_CLIENTS_NO {
+		cert, err := tls.LoadX509KeyPair(Config.GetS(TLS_CERT), Config.GetS(TLS_KEY))
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't load TLS certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	tlsConfig = cfg
+
+	return tlsConfig, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getTLSConfigData returns TLS data for configuration templates
+func getTLSConfigData() *instanceConfigTLSData {
+	if !IsTLSEnabled() {
+		return &instanceConfigTLSData{}
+	}
+
+	return &instanceConfigTLSData{
+		IsEnabled:     true,
+		IsOnly:        IsTLSOnly(),
+		IsReplication: IsTLSReplication(),
+		CACertFile:    Config.GetS(TLS_CA_CERT),
+		CertFile:      Config.GetS(TLS_CERT),
+		KeyFile:       Config.GetS(TLS_KEY),
+		AuthClients:   Config.GetS(TLS_AUTH_CLIENTS, TLS_AUTH_CLIENTS_YES),
+	}
+}
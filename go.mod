@@ -3,13 +3,18 @@ module github.com/essentialkaos/rds
 go 1.22.8
 
 require (
+	github.com/essentialkaos/check v1.4.1
 	github.com/essentialkaos/ek/v13 v13.10.0
 	github.com/essentialkaos/go-linenoise/v3 v3.6.1
 	github.com/essentialkaos/redy/v4 v4.4.0
+	golang.org/x/crypto v0.28.0
+	golang.org/x/sys v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/essentialkaos/depsy v1.3.1 // indirect
-	golang.org/x/crypto v0.28.0 // indirect
-	golang.org/x/sys v0.26.0 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/rogpeppe/go-internal v1.13.1 // indirect
 )
@@ -9,6 +9,7 @@ package client
 
 import (
 	"bufio"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -40,6 +41,9 @@ type Config struct {
 	Timeout        int      // Connection timeout
 	DisableMonitor bool     // Disable MONITOR command flag
 	RawOutput      bool     // Raw output flag
+
+	TLS    *tls.Config // TLS configuration
+	Socket string      // Path to unix socket
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -96,7 +100,7 @@ func ExecRedisCmd(cfg *Config) error {
 // RunRedisCli run interactive cli
 func RunRedisCli(cfg *Config) error {
 	prompt := getPrompt(cfg.ID, cfg.Port, cfg.DB)
-	client := getClient(cfg.Port, time.Second*time.Duration(cfg.Timeout))
+	client := getClient(cfg, time.Second*time.Duration(cfg.Timeout))
 
 	err := client.Connect()
 
@@ -170,7 +174,7 @@ func RunRedisCli(cfg *Config) error {
 
 // execCommand execs one command
 func execCommand(cfg *Config) error {
-	client := getClient(cfg.Port, time.Second*time.Duration(cfg.Timeout))
+	client := getClient(cfg, time.Second*time.Duration(cfg.Timeout))
 
 	err := client.Connect()
 
@@ -201,12 +205,19 @@ func execCommand(cfg *Config) error {
 }
 
 // getClient return Redy client
-func getClient(port int, timeout time.Duration) *redy.Client {
+func getClient(cfg *Config, timeout time.Duration) *redy.Client {
 	if client == nil {
 		client = &redy.Client{}
 	}
 
-	client.Addr = "127.0.0.1:" + strconv.Itoa(port)
+	if cfg.Socket != "" {
+		client.Network = "unix"
+		client.Addr = cfg.Socket
+	} else {
+		client.Network = "tcp"
+		client.Addr = "127.0.0.1:" + strconv.Itoa(cfg.Port)
+		client.TLSConfig = cfg.TLS
+	}
 
 	if timeout > 0 {
 		client.WriteTimeout = timeout
@@ -244,10 +255,20 @@ func configureClient(client *redy.Client, cfg *Config) error {
 
 // execMonitor exec monitor command (connection not be closed)
 func execMonitor(cfg *Config, cmd string) error {
-	conn, err := net.DialTimeout(
-		"tcp", "127.0.0.1:"+strconv.Itoa(cfg.Port),
-		time.Second*time.Duration(cfg.Timeout),
-	)
+	var conn net.Conn
+	var err error
+
+	addr := "127.0.0.1:" + strconv.Itoa(cfg.Port)
+	dialer := &net.Dialer{Timeout: time.Second * time.Duration(cfg.Timeout)}
+
+	switch {
+	case cfg.Socket != "":
+		conn, err = dialer.Dial("unix", cfg.Socket)
+	case cfg.TLS != nil:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, cfg.TLS)
+	default:
+		conn, err = dialer.Dial("tcp", addr)
+	}
 
 	if err != nil {
 		return err
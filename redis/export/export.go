@@ -0,0 +1,1154 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/essentialkaos/redy/v4"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MAGIC is export stream magic string
+const MAGIC = "RDSEXPORT"
+
+// VERSION is current version of export stream format. Version 2 stores
+// absolute expiration time of keys instead of remaining TTL.
+const VERSION = 2
+
+// VERSION_TTL is version of export stream format with remaining TTL of keys
+const VERSION_TTL = 1
+
+const (
+	FORMAT_DUMP = "dump" // Binary stream with DUMP payloads
+	FORMAT_JSON = "json" // JSON Lines with human-readable values
+)
+
+const (
+	TYPE_STRING = "string"
+	TYPE_LIST   = "list"
+	TYPE_SET    = "set"
+	TYPE_ZSET   = "zset"
+	TYPE_HASH   = "hash"
+)
+
+const (
+	RECORD_KEY = 'K'
+	RECORD_END = 'E'
+)
+
+// SCAN_COUNT is number of keys requested by one SCAN call
+const SCAN_COUNT = 1000
+
+// MAX_ARGS is max number of values sent by one command on import
+const MAX_ARGS = 1000
+
+// MAX_DATA_SIZE is max size of key name or payload in binary stream (512MB,
+// same as max size of string value in Redis)
+const MAX_DATA_SIZE = 512 * 1024 * 1024
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Options contains export and import options
+type Options struct {
+	Match   string // Key pattern
+	Format  string // Stream format (export only)
+	Replace bool   // Replace existing keys (import only)
+}
+
+// Stats contains export or import statistics
+type Stats struct {
+	Keys    int   // Number of exported or imported keys
+	Skipped int   // Number of skipped keys
+	Size    int64 // Size of exported or imported data
+}
+
+// ProgressHandler is export or import progress handler
+type ProgressHandler func(stats Stats)
+
+// Record is JSON export record
+type Record struct {
+	Key      string `json:"key,omitempty"`
+	KeyRaw   []byte `json:"key_raw,omitempty"` // Key which is not a valid UTF-8 string
+	Type     string `json:"type"`
+	TTL      int64  `json:"ttl,omitempty"`       // Remaining TTL in milliseconds (exports created by previous versions)
+	ExpireAt int64  `json:"expire_at,omitempty"` // Expiration time (Unix time in milliseconds)
+	Value    any    `json:"value,omitempty"`     // Human-readable value
+	Dump     []byte `json:"dump,omitempty"`      // DUMP payload for other types and binary values
+}
+
+// Score is sorted set member with score
+type Score struct {
+	Member string `json:"member"`
+	Score  string `json:"score"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// exportWriter is writer which counts written data
+type exportWriter struct {
+	w     *bufio.Writer
+	stats *Stats
+	buf   []byte
+}
+
+// importReader is reader which counts read data
+type importReader struct {
+	r     *bufio.Reader
+	stats *Stats
+	size  int64 // Total size of input data (-1 if unknown)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	ErrWrongFormat    = errors.New("Data is not an RDS export stream")
+	ErrUnexpectedEOF  = errors.New("Export stream is truncated")
+	ErrUnknownVersion = errors.New("Unsupported export stream version")
+	ErrUnknownFormat  = errors.New("Unknown export format")
+	ErrDataTooLarge   = errors.New("Export stream contains data chunk larger than 512MB")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Export writes keys from DB to given writer
+func Export(rc *redy.Client, w io.Writer, opts Options, handler ProgressHandler) (Stats, error) {
+	stats := Stats{}
+	ew := &exportWriter{w: bufio.NewWriterSize(w, 64*1024), stats: &stats}
+
+	var err error
+
+	switch opts.Format {
+	case FORMAT_DUMP, "":
+		err = ew.writeHeader()
+	case FORMAT_JSON:
+		// nop
+	default:
+		return stats, ErrUnknownFormat
+	}
+
+	if err != nil {
+		return stats, err
+	}
+
+	cursor := "0"
+	match := opts.Match
+
+	if match == "" {
+		match = "*"
+	}
+
+	for {
+		var keys [][]byte
+
+		cursor, keys, err = scanKeys(rc, cursor, match)
+
+		if err != nil {
+			return stats, err
+		}
+
+		if opts.Format == FORMAT_JSON {
+			err = exportJSON(rc, ew, keys)
+		} else {
+			err = exportDump(rc, ew, keys)
+		}
+
+		if err != nil {
+			return stats, err
+		}
+
+		if handler != nil {
+			handler(stats)
+		}
+
+		if cursor == "0" {
+			break
+		}
+	}
+
+	if opts.Format != FORMAT_JSON {
+		err = ew.writeEnd()
+
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, ew.w.Flush()
+}
+
+// Import reads keys from given reader and restores them to DB
+func Import(rc *redy.Client, r io.Reader, opts Options, handler ProgressHandler) (Stats, error) {
+	stats := Stats{}
+	ir := newImportReader(r, &stats)
+
+	format, err := ir.detectFormat()
+
+	if err != nil {
+		return stats, err
+	}
+
+	switch format {
+	case FORMAT_JSON:
+		return stats, importJSON(rc, ir, opts, handler)
+	default:
+		return stats, importDump(rc, ir, opts, handler)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// scanKeys returns next batch of keys
+func scanKeys(rc *redy.Client, cursor, match string) (string, [][]byte, error) {
+	resp := rc.Cmd("SCAN", cursor, "MATCH", match, "COUNT", SCAN_COUNT)
+
+	if resp.Err != nil {
+		return "", nil, fmt.Errorf("Can't scan keys: %w", resp.Err)
+	}
+
+	items, err := resp.Array()
+
+	if err != nil || len(items) != 2 {
+		return "", nil, fmt.Errorf("Can't scan keys: wrong SCAN response")
+	}
+
+	next, err := items[0].Str()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("Can't scan keys: %w", err)
+	}
+
+	keys, err := items[1].ListBytes()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("Can't scan keys: %w", err)
+	}
+
+	return next, keys, nil
+}
+
+// exportDump exports keys as DUMP payloads
+func exportDump(rc *redy.Client, ew *exportWriter, keys [][]byte) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		rc.PipeAppend("PTTL", key)
+		rc.PipeAppend("DUMP", key)
+	}
+
+	for _, key := range keys {
+		expireAt, payload, err := readDumpResp(rc)
+
+		if err != nil {
+			rc.PipeClear()
+			return fmt.Errorf("Can't dump key %q: %w", key, err)
+		}
+
+		// Key was removed or expired after scan
+		if payload == nil {
+			ew.stats.Skipped++
+			continue
+		}
+
+		err = ew.writeRecord(key, expireAt, payload)
+
+		if err != nil {
+			rc.PipeClear()
+			return err
+		}
+
+		ew.stats.Keys++
+	}
+
+	return nil
+}
+
+// exportJSON exports keys as JSON records
+func exportJSON(rc *redy.Client, ew *exportWriter, keys [][]byte) error {
+	for _, key := range keys {
+		rec, err := readRecord(rc, key)
+
+		if err != nil {
+			return fmt.Errorf("Can't read key %q: %w", key, err)
+		}
+
+		if rec == nil {
+			ew.stats.Skipped++
+			continue
+		}
+
+		err = ew.writeJSON(rec)
+
+		if err != nil {
+			return err
+		}
+
+		ew.stats.Keys++
+	}
+
+	return nil
+}
+
+// readDumpResp reads PTTL and DUMP responses from pipeline and returns key
+// expiration time and DUMP payload
+func readDumpResp(rc *redy.Client) (int64, []byte, error) {
+	ttlResp, dumpResp := rc.PipeResp(), rc.PipeResp()
+
+	if ttlResp.Err != nil {
+		return 0, nil, ttlResp.Err
+	}
+
+	if dumpResp.Err != nil {
+		return 0, nil, dumpResp.Err
+	}
+
+	if dumpResp.HasType(redy.NIL) {
+		return 0, nil, nil
+	}
+
+	ttl, err := ttlResp.Int64()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	payload, err := dumpResp.Bytes()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return getExpireAt(ttl), payload, nil
+}
+
+// readRecord reads key with given name as JSON record
+func readRecord(rc *redy.Client, key []byte) (*Record, error) {
+	rc.PipeAppend("TYPE", key)
+	rc.PipeAppend("PTTL", key)
+
+	keyType, err := rc.PipeResp().Str()
+
+	if err != nil {
+		rc.PipeClear()
+		return nil, err
+	}
+
+	ttl, err := rc.PipeResp().Int64()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if keyType == "none" || ttl == -2 {
+		return nil, nil
+	}
+
+	rec := &Record{Type: keyType, ExpireAt: getExpireAt(ttl)}
+
+	if utf8.Valid(key) {
+		rec.Key = string(key)
+	} else {
+		rec.KeyRaw = key
+	}
+
+	var resp *redy.Resp
+
+	switch keyType {
+	case TYPE_STRING:
+		resp = rc.Cmd("GET", key)
+	case TYPE_LIST:
+		resp = rc.Cmd("LRANGE", key, 0, -1)
+	case TYPE_SET:
+		resp = rc.Cmd("SMEMBERS", key)
+	case TYPE_ZSET:
+		resp = rc.Cmd("ZRANGE", key, 0, -1, "WITHSCORES")
+	case TYPE_HASH:
+		resp = rc.Cmd("HGETALL", key)
+	}
+
+	if resp != nil {
+		if resp.HasType(redy.NIL) {
+			return nil, nil
+		}
+
+		ok, err := rec.setValue(resp)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			if rec.Value == nil {
+				return nil, nil // Key was removed after scan
+			}
+
+			return rec, nil
+		}
+	}
+
+	// Streams, modules data and binary values are stored as DUMP payloads
+	resp = rc.Cmd("DUMP", key)
+
+	if resp.HasType(redy.NIL) {
+		return nil, nil
+	}
+
+	rec.Value = nil
+	rec.Dump, err = resp.Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// importDump imports keys from binary stream
+func importDump(rc *redy.Client, ir *importReader, opts Options, handler ProgressHandler) error {
+	version, err := ir.readByte()
+
+	if err != nil {
+		return err
+	}
+
+	if version != VERSION && version != VERSION_TTL {
+		return ErrUnknownVersion
+	}
+
+	for {
+		recType, err := ir.readByte()
+
+		if err != nil {
+			return err
+		}
+
+		switch recType {
+		case RECORD_KEY:
+			// continue
+		case RECORD_END:
+			total, err := ir.readUvarint()
+
+			if err != nil {
+				return err
+			}
+
+			if int(total) != ir.stats.Keys+ir.stats.Skipped {
+				return ErrUnexpectedEOF
+			}
+
+			return nil
+		default:
+			return ErrWrongFormat
+		}
+
+		key, err := ir.readBytes()
+
+		if err != nil {
+			return err
+		}
+
+		expireAt, err := ir.readUvarint()
+
+		if err != nil {
+			return err
+		}
+
+		payload, err := ir.readBytes()
+
+		if err != nil {
+			return err
+		}
+
+		if version == VERSION_TTL {
+			expireAt = uint64(getExpireAt(int64(expireAt)))
+		}
+
+		switch {
+		case opts.Match != "" && !matchKey(opts.Match, key),
+			isExpired(int64(expireAt)):
+			ir.stats.Skipped++
+		default:
+			err = restoreKey(rc, ir.stats, key, int64(expireAt), payload, opts.Replace)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if handler != nil && (ir.stats.Keys+ir.stats.Skipped)%SCAN_COUNT == 0 {
+			handler(*ir.stats)
+		}
+	}
+}
+
+// importJSON imports keys from JSON records
+func importJSON(rc *redy.Client, ir *importReader, opts Options, handler ProgressHandler) error {
+	var line int
+
+	for {
+		data, err := ir.r.ReadBytes('\n')
+
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if len(bytes.TrimSpace(data)) != 0 {
+			line++
+			ir.stats.Size += int64(len(data))
+
+			rec := &Record{}
+			jsonErr := json.Unmarshal(data, rec)
+
+			if jsonErr != nil {
+				return fmt.Errorf("Can't parse record on line %d: %w", line, jsonErr)
+			}
+
+			key := rec.key()
+
+			if rec.ExpireAt == 0 {
+				rec.ExpireAt = getExpireAt(rec.TTL)
+			}
+
+			switch {
+			case opts.Match != "" && !matchKey(opts.Match, key),
+				isExpired(rec.ExpireAt):
+				ir.stats.Skipped++
+			default:
+				importErr := importRecord(rc, ir.stats, rec, opts.Replace)
+
+				if importErr != nil {
+					return fmt.Errorf("Can't import record on line %d: %w", line, importErr)
+				}
+			}
+
+			if handler != nil && line%SCAN_COUNT == 0 {
+				handler(*ir.stats)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// restoreKey restores key from DUMP payload with given expiration time
+// (0 if key has no expiration)
+func restoreKey(rc *redy.Client, stats *Stats, key []byte, expireAt int64, payload []byte, replace bool) error {
+	var resp *redy.Resp
+
+	if replace {
+		resp = rc.Cmd("RESTORE", key, expireAt, payload, "ABSTTL", "REPLACE")
+	} else {
+		resp = rc.Cmd("RESTORE", key, expireAt, payload, "ABSTTL")
+	}
+
+	if resp.Err != nil {
+		if resp.HasType(redy.ERR_REDIS) && strings.HasPrefix(resp.Err.Error(), "BUSYKEY") {
+			stats.Skipped++
+			return nil
+		}
+
+		return fmt.Errorf("Can't restore key %q: %w", key, resp.Err)
+	}
+
+	stats.Keys++
+
+	return nil
+}
+
+// importRecord imports JSON record
+func importRecord(rc *redy.Client, stats *Stats, rec *Record, replace bool) error {
+	key := rec.key()
+
+	if len(key) == 0 {
+		return fmt.Errorf("Record doesn't contain key name")
+	}
+
+	if rec.Dump != nil {
+		return restoreKey(rc, stats, key, rec.ExpireAt, rec.Dump, replace)
+	}
+
+	cmds, err := rec.commands()
+
+	if err != nil {
+		return fmt.Errorf("Can't import key %q: %w", key, err)
+	}
+
+	if !replace {
+		exists, err := rc.Cmd("EXISTS", key).Int()
+
+		if err != nil {
+			return fmt.Errorf("Can't check key %q: %w", key, err)
+		}
+
+		if exists != 0 {
+			stats.Skipped++
+			return nil
+		}
+	}
+
+	rc.PipeAppend("MULTI")
+	rc.PipeAppend("DEL", key)
+
+	for _, cmd := range cmds {
+		rc.PipeAppend(cmd[0].(string), cmd[1:]...)
+	}
+
+	queued := len(cmds) + 2
+
+	if rec.ExpireAt > 0 {
+		rc.PipeAppend("PEXPIREAT", key, rec.ExpireAt)
+		queued++
+	}
+
+	rc.PipeAppend("EXEC")
+
+	for range queued {
+		rc.PipeResp()
+	}
+
+	results, err := rc.PipeResp().Array()
+
+	if err == nil {
+		for _, resp := range results {
+			if resp.Err != nil {
+				err = resp.Err
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't import key %q: %w", key, err)
+	}
+
+	stats.Keys++
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// key returns record key name
+func (r *Record) key() []byte {
+	if r.KeyRaw != nil {
+		return r.KeyRaw
+	}
+
+	return []byte(r.Key)
+}
+
+// setValue sets record value from command response
+func (r *Record) setValue(resp *redy.Resp) (bool, error) {
+	switch r.Type {
+	case TYPE_STRING:
+		value, err := resp.Str()
+
+		if err != nil || !utf8.ValidString(value) {
+			return false, err
+		}
+
+		r.Value = value
+
+	case TYPE_LIST, TYPE_SET:
+		values, err := resp.List()
+
+		if err != nil || !isValidUTF8(values) {
+			return false, err
+		}
+
+		if len(values) != 0 {
+			r.Value = values
+		}
+
+	case TYPE_ZSET:
+		values, err := resp.List()
+
+		if err != nil || !isValidUTF8(values) {
+			return false, err
+		}
+
+		var scores []Score
+
+		for i := 0; i+1 < len(values); i += 2 {
+			scores = append(scores, Score{values[i], values[i+1]})
+		}
+
+		if len(scores) != 0 {
+			r.Value = scores
+		}
+
+	case TYPE_HASH:
+		values, err := resp.List()
+
+		if err != nil || !isValidUTF8(values) {
+			return false, err
+		}
+
+		fields := make(map[string]string, len(values)/2)
+
+		for i := 0; i+1 < len(values); i += 2 {
+			fields[values[i]] = values[i+1]
+		}
+
+		if len(fields) != 0 {
+			r.Value = fields
+		}
+
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// commands returns commands for creating key from record
+func (r *Record) commands() ([][]any, error) {
+	var result [][]any
+
+	key := r.key()
+
+	switch r.Type {
+	case TYPE_STRING:
+		var value string
+
+		err := decodeValue(r.Value, &value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, []any{"SET", key, value})
+
+	case TYPE_LIST, TYPE_SET:
+		var values []string
+
+		err := decodeValue(r.Value, &values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cmd := "RPUSH"
+
+		if r.Type == TYPE_SET {
+			cmd = "SADD"
+		}
+
+		for _, chunk := range chunkArgs(len(values)) {
+			args := []any{cmd, key}
+
+			for _, v := range values[chunk[0]:chunk[1]] {
+				args = append(args, v)
+			}
+
+			result = append(result, args)
+		}
+
+	case TYPE_ZSET:
+		var scores []Score
+
+		err := decodeValue(r.Value, &scores)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, chunk := range chunkArgs(len(scores)) {
+			args := []any{"ZADD", key}
+
+			for _, s := range scores[chunk[0]:chunk[1]] {
+				args = append(args, s.Score, s.Member)
+			}
+
+			result = append(result, args)
+		}
+
+	case TYPE_HASH:
+		var fields map[string]string
+
+		err := decodeValue(r.Value, &fields)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args := []any{"HMSET", key}
+
+		for field, value := range fields {
+			args = append(args, field, value)
+
+			if len(args) >= MAX_ARGS*2 {
+				result = append(result, args)
+				args = []any{"HMSET", key}
+			}
+		}
+
+		if len(args) > 2 {
+			result = append(result, args)
+		}
+
+	default:
+		return nil, fmt.Errorf("Unsupported key type %q", r.Type)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("Value is empty")
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeHeader writes stream header
+func (w *exportWriter) writeHeader() error {
+	return w.write(append([]byte(MAGIC), VERSION))
+}
+
+// writeRecord writes key record with key expiration time
+func (w *exportWriter) writeRecord(key []byte, expireAt int64, payload []byte) error {
+	w.buf = append(w.buf[:0], RECORD_KEY)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(key)))
+	w.buf = append(w.buf, key...)
+	w.buf = binary.AppendUvarint(w.buf, uint64(expireAt))
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(payload)))
+
+	err := w.write(w.buf)
+
+	if err != nil {
+		return err
+	}
+
+	return w.write(payload)
+}
+
+// writeEnd writes stream end record with number of records
+func (w *exportWriter) writeEnd() error {
+	w.buf = append(w.buf[:0], RECORD_END)
+	w.buf = binary.AppendUvarint(w.buf, uint64(w.stats.Keys))
+
+	return w.write(w.buf)
+}
+
+// writeJSON writes JSON record
+func (w *exportWriter) writeJSON(rec *Record) error {
+	data, err := json.Marshal(rec)
+
+	if err != nil {
+		return err
+	}
+
+	return w.write(append(data, '\n'))
+}
+
+// write writes data to stream
+func (w *exportWriter) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.stats.Size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("Can't write data: %w", err)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newImportReader creates new import reader
+func newImportReader(r io.Reader, stats *Stats) *importReader {
+	return &importReader{
+		r:     bufio.NewReaderSize(r, 64*1024),
+		stats: stats,
+		size:  getInputSize(r),
+	}
+}
+
+// detectFormat detects stream format
+func (r *importReader) detectFormat() (string, error) {
+	data, err := r.r.Peek(len(MAGIC))
+
+	if err != nil && len(data) == 0 {
+		if err == io.EOF {
+			return "", ErrWrongFormat
+		}
+
+		return "", err
+	}
+
+	switch {
+	case string(data) == MAGIC:
+		r.r.Discard(len(MAGIC))
+		r.stats.Size += int64(len(MAGIC))
+		return FORMAT_DUMP, nil
+	case data[0] == '{':
+		return FORMAT_JSON, nil
+	}
+
+	return "", ErrWrongFormat
+}
+
+// readByte reads one byte
+func (r *importReader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	r.stats.Size++
+
+	return b, nil
+}
+
+// readUvarint reads unsigned varint
+func (r *importReader) readUvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(r)
+
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	return v, nil
+}
+
+// readBytes reads length-prefixed data
+func (r *importReader) readBytes() ([]byte, error) {
+	size, err := r.readUvarint()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case size > MAX_DATA_SIZE:
+		return nil, ErrDataTooLarge
+	case r.size >= 0 && int64(size) > r.size-r.stats.Size:
+		return nil, ErrUnexpectedEOF
+	}
+
+	data := make([]byte, size)
+	n, err := io.ReadFull(r.r, data)
+	r.stats.Size += int64(n)
+
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return data, nil
+}
+
+// ReadByte implements io.ByteReader
+func (r *importReader) ReadByte() (byte, error) {
+	return r.readByte()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// decodeValue decodes generic JSON value to given type
+func decodeValue(value, target any) error {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, target)
+
+	if err != nil {
+		return fmt.Errorf("Value has wrong format: %w", err)
+	}
+
+	return nil
+}
+
+// getExpireAt converts remaining TTL in milliseconds to expiration time
+// (0 if key has no expiration)
+func getExpireAt(ttl int64) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return time.Now().UnixMilli() + ttl
+}
+
+// isExpired returns true if key with given expiration time already expired
+func isExpired(expireAt int64) bool {
+	return expireAt > 0 && expireAt <= time.Now().UnixMilli()
+}
+
+// chunkArgs splits given number of values into chunks
+func chunkArgs(total int) [][2]int {
+	var result [][2]int
+
+	for i := 0; i < total; i += MAX_ARGS {
+		result = append(result, [2]int{i, min(i+MAX_ARGS, total)})
+	}
+
+	return result
+}
+
+// isValidUTF8 returns true if all values are valid UTF-8 strings
+func isValidUTF8(values []string) bool {
+	for _, v := range values {
+		if !utf8.ValidString(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchKey returns true if key matches glob-style pattern
+func matchKey(pattern string, key []byte) bool {
+	return matchGlob([]byte(pattern), key)
+}
+
+// matchGlob matches data with Redis glob-style pattern
+func matchGlob(pattern, data []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := 0; i <= len(data); i++ {
+				if matchGlob(pattern[1:], data[i:]) {
+					return true
+				}
+			}
+
+			return false
+
+		case '?':
+			if len(data) == 0 {
+				return false
+			}
+
+			data = data[1:]
+
+		case '[':
+			if len(data) == 0 {
+				return false
+			}
+
+			end := bytes.IndexByte(pattern[1:], ']')
+
+			if end == -1 {
+				return false
+			}
+
+			class := pattern[1 : end+1]
+			negate := len(class) > 0 && class[0] == '^'
+
+			if negate {
+				class = class[1:]
+			}
+
+			if matchClass(class, data[0]) == negate {
+				return false
+			}
+
+			pattern = pattern[end+1:]
+			data = data[1:]
+
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+
+		default:
+			if len(data) == 0 || pattern[0] != data[0] {
+				return false
+			}
+
+			data = data[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(data) == 0
+}
+
+// matchClass returns true if char matches character class
+func matchClass(class []byte, c byte) bool {
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if c >= class[i] && c <= class[i+2] {
+				return true
+			}
+
+			i += 2
+			continue
+		}
+
+		if class[i] == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getInputSize returns size of input data if it's known
+func getInputSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+
+		offset, err := v.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return -1
+		}
+
+		return info.Size() - offset
+	}
+
+	return -1
+}
+
+// unexpectedEOF converts EOF errors to ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,266 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type ExportSuite struct{}
+
+type testRecord struct {
+	key      []byte
+	expireAt int64
+	payload  []byte
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+var _ = Suite(&ExportSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *ExportSuite) TestDumpRoundTrip(c *C) {
+	records := []testRecord{
+		{[]byte("user:1"), 0, []byte("\x00\x03ABC\x0b\x00")},
+		{[]byte("\xff\xfe binary"), 1893456000000, bytes.Repeat([]byte("X"), 70000)},
+		{[]byte("empty"), 1, nil},
+	}
+
+	data := writeDumpStream(c, records)
+
+	stats := Stats{}
+	ir := newImportReader(bytes.NewReader(data), &stats)
+
+	c.Assert(ir.size, Equals, int64(len(data)))
+
+	format, err := ir.detectFormat()
+	c.Assert(err, IsNil)
+	c.Assert(format, Equals, FORMAT_DUMP)
+
+	version, err := ir.readByte()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, byte(VERSION))
+
+	for _, rec := range records {
+		recType, err := ir.readByte()
+		c.Assert(err, IsNil)
+		c.Assert(recType, Equals, byte(RECORD_KEY))
+
+		key, err := ir.readBytes()
+		c.Assert(err, IsNil)
+		c.Assert(key, DeepEquals, rec.key)
+
+		expireAt, err := ir.readUvarint()
+		c.Assert(err, IsNil)
+		c.Assert(int64(expireAt), Equals, rec.expireAt)
+
+		payload, err := ir.readBytes()
+		c.Assert(err, IsNil)
+		c.Assert(payload, HasLen, len(rec.payload))
+		c.Assert(bytes.Equal(payload, rec.payload), Equals, true)
+	}
+
+	recType, err := ir.readByte()
+	c.Assert(err, IsNil)
+	c.Assert(recType, Equals, byte(RECORD_END))
+
+	total, err := ir.readUvarint()
+	c.Assert(err, IsNil)
+	c.Assert(total, Equals, uint64(len(records)))
+
+	c.Assert(stats.Size, Equals, int64(len(data)))
+
+	_, err = ir.readByte()
+	c.Assert(err, Equals, ErrUnexpectedEOF)
+}
+
+func (s *ExportSuite) TestDumpLimits(c *C) {
+	// Chunk size is greater than max data size
+	data := binary.AppendUvarint(nil, MAX_DATA_SIZE+1)
+	_, err := newImportReader(bytes.NewReader(data), &Stats{}).readBytes()
+	c.Assert(err, Equals, ErrDataTooLarge)
+
+	// Chunk size is greater than size of remaining input
+	data = binary.AppendUvarint(nil, 256*1024*1024)
+	data = append(data, "ABCD"...)
+	_, err = newImportReader(bytes.NewReader(data), &Stats{}).readBytes()
+	c.Assert(err, Equals, ErrUnexpectedEOF)
+
+	// Input size is unknown, so truncated data is detected on reading
+	data = binary.AppendUvarint(nil, 16)
+	data = append(data, "ABCD"...)
+	ir := newImportReader(io.MultiReader(bytes.NewReader(data)), &Stats{})
+	c.Assert(ir.size, Equals, int64(-1))
+	_, err = ir.readBytes()
+	c.Assert(err, Equals, ErrUnexpectedEOF)
+
+	// Truncated varint
+	_, err = newImportReader(bytes.NewReader([]byte{0xFF}), &Stats{}).readUvarint()
+	c.Assert(err, Equals, ErrUnexpectedEOF)
+}
+
+func (s *ExportSuite) TestFileInput(c *C) {
+	records := []testRecord{{[]byte("key"), 0, []byte("value")}}
+	data := writeDumpStream(c, records)
+	file := filepath.Join(c.MkDir(), "export.rdx")
+
+	c.Assert(os.WriteFile(file, data, 0644), IsNil)
+
+	fd, err := os.Open(file)
+	c.Assert(err, IsNil)
+	defer fd.Close()
+
+	fd.Seek(int64(len(MAGIC)), io.SeekStart)
+
+	c.Assert(getInputSize(fd), Equals, int64(len(data)-len(MAGIC)))
+
+	fd, err = os.Open(os.DevNull)
+	c.Assert(err, IsNil)
+	defer fd.Close()
+
+	c.Assert(getInputSize(fd), Equals, int64(-1))
+}
+
+func (s *ExportSuite) TestWrongFormat(c *C) {
+	_, err := newImportReader(bytes.NewReader(nil), &Stats{}).detectFormat()
+	c.Assert(err, Equals, ErrWrongFormat)
+
+	_, err = newImportReader(bytes.NewReader([]byte("REDIS0011")), &Stats{}).detectFormat()
+	c.Assert(err, Equals, ErrWrongFormat)
+
+	format, err := newImportReader(bytes.NewReader([]byte(`{"key":"A"}`)), &Stats{}).detectFormat()
+	c.Assert(err, IsNil)
+	c.Assert(format, Equals, FORMAT_JSON)
+}
+
+func (s *ExportSuite) TestJSONRoundTrip(c *C) {
+	records := []*Record{
+		{Key: "string", Type: TYPE_STRING, ExpireAt: 1893456000000, Value: "test"},
+		{KeyRaw: []byte("\xff list"), Type: TYPE_LIST, Value: []string{"A", "B", "C"}},
+		{Key: "set", Type: TYPE_SET, Value: []string{"A"}},
+		{Key: "zset", Type: TYPE_ZSET, Value: []Score{{"A", "1.5"}, {"B", "2"}}},
+		{Key: "hash", Type: TYPE_HASH, Value: map[string]string{"field": "value"}},
+		{Key: "stream", Type: "stream", Dump: []byte("\x15\x00")},
+	}
+
+	var buf bytes.Buffer
+
+	ew := &exportWriter{w: bufio.NewWriter(&buf), stats: &Stats{}}
+
+	for _, rec := range records {
+		c.Assert(ew.writeJSON(rec), IsNil)
+	}
+
+	c.Assert(ew.w.Flush(), IsNil)
+
+	ir := newImportReader(bytes.NewReader(buf.Bytes()), &Stats{})
+	format, err := ir.detectFormat()
+	c.Assert(err, IsNil)
+	c.Assert(format, Equals, FORMAT_JSON)
+
+	var decoded []*Record
+
+	for {
+		line, err := ir.r.ReadBytes('\n')
+
+		if err == io.EOF {
+			break
+		}
+
+		rec := &Record{}
+		c.Assert(json.Unmarshal(line, rec), IsNil)
+		decoded = append(decoded, rec)
+	}
+
+	c.Assert(decoded, HasLen, len(records))
+
+	cmds, err := decoded[0].commands()
+	c.Assert(err, IsNil)
+	c.Assert(decoded[0].ExpireAt, Equals, int64(1893456000000))
+	c.Assert(cmds, DeepEquals, [][]any{{"SET", []byte("string"), "test"}})
+
+	cmds, err = decoded[1].commands()
+	c.Assert(err, IsNil)
+	c.Assert(cmds, DeepEquals, [][]any{{"RPUSH", []byte("\xff list"), "A", "B", "C"}})
+
+	cmds, err = decoded[2].commands()
+	c.Assert(err, IsNil)
+	c.Assert(cmds, DeepEquals, [][]any{{"SADD", []byte("set"), "A"}})
+
+	cmds, err = decoded[3].commands()
+	c.Assert(err, IsNil)
+	c.Assert(cmds, DeepEquals, [][]any{{"ZADD", []byte("zset"), "1.5", "A", "2", "B"}})
+
+	cmds, err = decoded[4].commands()
+	c.Assert(err, IsNil)
+	c.Assert(cmds, DeepEquals, [][]any{{"HMSET", []byte("hash"), "field", "value"}})
+
+	c.Assert(decoded[5].Dump, DeepEquals, []byte("\x15\x00"))
+	_, err = decoded[5].commands()
+	c.Assert(err, ErrorMatches, `Unsupported key type "stream"`)
+}
+
+func (s *ExportSuite) TestExpiration(c *C) {
+	now := time.Now().UnixMilli()
+
+	c.Assert(getExpireAt(-1), Equals, int64(0))
+	c.Assert(getExpireAt(0), Equals, int64(0))
+	c.Assert(getExpireAt(60000) >= now+60000, Equals, true)
+
+	c.Assert(isExpired(0), Equals, false)
+	c.Assert(isExpired(now-1), Equals, true)
+	c.Assert(isExpired(now+60000), Equals, false)
+
+	// Records created by previous versions contain remaining TTL
+	rec := &Record{}
+	c.Assert(json.Unmarshal([]byte(`{"key":"A","type":"string","ttl":1000,"value":"B"}`), rec), IsNil)
+	c.Assert(rec.TTL, Equals, int64(1000))
+	c.Assert(rec.ExpireAt, Equals, int64(0))
+
+	data, err := json.Marshal(&Record{Key: "A", Type: TYPE_STRING, ExpireAt: 1893456000000, Value: "B"})
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"key":"A","type":"string","expire_at":1893456000000,"value":"B"}`)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeDumpStream writes records to binary export stream
+func writeDumpStream(c *C, records []testRecord) []byte {
+	var buf bytes.Buffer
+
+	stats := Stats{}
+	ew := &exportWriter{w: bufio.NewWriter(&buf), stats: &stats}
+
+	c.Assert(ew.writeHeader(), IsNil)
+
+	for _, rec := range records {
+		c.Assert(ew.writeRecord(rec.key, rec.expireAt, rec.payload), IsNil)
+		stats.Keys++
+	}
+
+	c.Assert(ew.writeEnd(), IsNil)
+	c.Assert(ew.w.Flush(), IsNil)
+	c.Assert(stats.Size, Equals, int64(buf.Len()))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,56 @@
+package rdb
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"math/bits"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// CRC64_POLY is Jones polynomial used by Redis for RDB checksum
+const CRC64_POLY = 0xAD93D23594C935A9
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// crc64Table is lookup table for reflected CRC64 Jones
+var crc64Table = makeCRC64Table()
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// crc64Update updates CRC64 checksum with given data. Redis uses CRC64 Jones
+// without initial and final inversion, so hash/crc64 can't be used.
+func crc64Update(crc uint64, data []byte) uint64 {
+	for _, b := range data {
+		crc = crc64Table[byte(crc)^b] ^ (crc >> 8)
+	}
+
+	return crc
+}
+
+// makeCRC64Table creates lookup table for reflected polynomial
+func makeCRC64Table() *[256]uint64 {
+	table := &[256]uint64{}
+	poly := bits.Reverse64(CRC64_POLY)
+
+	for i := range 256 {
+		crc := uint64(i)
+
+		for range 8 {
+			if crc&1 == 1 {
+				crc = (crc >> 1) ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+
+		table[i] = crc
+	}
+
+	return table
+}
@@ -0,0 +1,989 @@
+package rdb
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MAGIC is RDB file magic string
+const MAGIC = "REDIS"
+
+// MIN_CHECKSUM_VERSION is minimal RDB version with checksum
+const MIN_CHECKSUM_VERSION = 5
+
+// MAX_STRING_SIZE is maximum size of string read to memory (default value
+// of proto-max-bulk-len)
+const MAX_STRING_SIZE = 512 * 1024 * 1024
+
+const (
+	OPCODE_SLOT_INFO       = 244
+	OPCODE_FUNCTION2       = 245
+	OPCODE_FUNCTION_PRE_GA = 246
+	OPCODE_MODULE_AUX      = 247
+	OPCODE_IDLE            = 248
+	OPCODE_FREQ            = 249
+	OPCODE_AUX             = 250
+	OPCODE_RESIZEDB        = 251
+	OPCODE_EXPIRETIME_MS   = 252
+	OPCODE_EXPIRETIME      = 253
+	OPCODE_SELECTDB        = 254
+	OPCODE_EOF             = 255
+)
+
+const (
+	TYPE_STRING                  = 0
+	TYPE_LIST                    = 1
+	TYPE_SET                     = 2
+	TYPE_ZSET                    = 3
+	TYPE_HASH                    = 4
+	TYPE_ZSET_2                  = 5
+	TYPE_MODULE_PRE_GA           = 6
+	TYPE_MODULE_2                = 7
+	TYPE_HASH_ZIPMAP             = 9
+	TYPE_LIST_ZIPLIST            = 10
+	TYPE_SET_INTSET              = 11
+	TYPE_ZSET_ZIPLIST            = 12
+	TYPE_HASH_ZIPLIST            = 13
+	TYPE_LIST_QUICKLIST          = 14
+	TYPE_STREAM_LISTPACKS        = 15
+	TYPE_HASH_LISTPACK           = 16
+	TYPE_ZSET_LISTPACK           = 17
+	TYPE_LIST_QUICKLIST_2        = 18
+	TYPE_STREAM_LISTPACKS_2      = 19
+	TYPE_SET_LISTPACK            = 20
+	TYPE_STREAM_LISTPACKS_3      = 21
+	TYPE_HASH_METADATA_PRE_GA    = 22
+	TYPE_HASH_LISTPACK_EX_PRE_GA = 23
+	TYPE_HASH_METADATA           = 24
+	TYPE_HASH_LISTPACK_EX        = 25
+)
+
+const (
+	MODULE_OPCODE_EOF    = 0
+	MODULE_OPCODE_SINT   = 1
+	MODULE_OPCODE_UINT   = 2
+	MODULE_OPCODE_FLOAT  = 3
+	MODULE_OPCODE_DOUBLE = 4
+	MODULE_OPCODE_STRING = 5
+)
+
+const (
+	ENC_INT8  = 0
+	ENC_INT16 = 1
+	ENC_INT32 = 2
+	ENC_LZF   = 3
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Info contains info about RDB file
+type Info struct {
+	Version    int               `json:"version"`     // RDB format version
+	Aux        map[string]string `json:"aux"`         // Auxiliary fields
+	Keys       map[int]int       `json:"keys"`        // Number of keys per DB
+	Expires    map[int]int       `json:"expires"`     // Number of keys with TTL per DB
+	Checksum   uint64            `json:"checksum"`    // CRC64 checksum (0 if disabled)
+	Created    time.Time         `json:"created"`     // Creation date (from ctime aux field)
+	Size       int64             `json:"size"`        // File size
+	HasModules bool              `json:"has_modules"` // Contains data of modules
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// reader is RDB reader which calculates CRC64 checksum of read data
+type reader struct {
+	r      *bufio.Reader
+	crc    uint64
+	buf    []byte
+	size   int64 // Data size (-1 if unknown)
+	offset int64 // Number of read bytes
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	ErrWrongMagic       = errors.New("File is not an RDB file (wrong magic string)")
+	ErrChecksumMismatch = errors.New("RDB checksum mismatch")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// minRedisVersions contains minimal Redis version which can load RDB file
+// with given version
+var minRedisVersions = map[int]string{
+	1: "1.0", 2: "2.4", 3: "2.4", 4: "2.4", 5: "2.6", 6: "2.6",
+	7: "3.2", 8: "4.0", 9: "5.0", 10: "7.0", 11: "7.2", 12: "7.4",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Read reads and validates RDB file
+func Read(file string) (*Info, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	stat, err := fd.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := parse(fd, stat.Size())
+
+	if err != nil {
+		return nil, err
+	}
+
+	info.Size = stat.Size()
+
+	return info, nil
+}
+
+// ReadVersion reads RDB format version from file header without reading
+// the whole file
+func ReadVersion(file string) (int, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer fd.Close()
+
+	header := make([]byte, 9)
+	_, err = io.ReadFull(fd, header)
+
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, ErrWrongMagic
+		}
+
+		return 0, fmt.Errorf("Can't read RDB header: %w", err)
+	}
+
+	return parseHeader(header)
+}
+
+// Parse reads and validates RDB data
+func Parse(r io.Reader) (*Info, error) {
+	return parse(r, -1)
+}
+
+// MinRedisVersion returns minimal Redis version which can load RDB file with
+// given version
+func MinRedisVersion(rdbVersion int) string {
+	return minRedisVersions[rdbVersion]
+}
+
+// MaxSupportedVersion returns maximum RDB version supported by parser
+func MaxSupportedVersion() int {
+	return len(minRedisVersions)
+}
+
+// VersionForRedis returns maximum RDB version which can be loaded by Redis
+// with given major and minor version
+func VersionForRedis(major, minor int) int {
+	var result int
+
+	for rdbVer, redisVer := range minRedisVersions {
+		rMajor, rMinor := parseSimpleVersion(redisVer)
+
+		if rMajor < major || (rMajor == major && rMinor <= minor) {
+			result = max(result, rdbVer)
+		}
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TotalKeys returns total number of keys in all DBs
+func (i *Info) TotalKeys() int {
+	var result int
+
+	for _, num := range i.Keys {
+		result += num
+	}
+
+	return result
+}
+
+// RedisVersion returns version of Redis which created RDB file
+func (i *Info) RedisVersion() string {
+	return i.Aux["redis-ver"]
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseHeader parses RDB header and returns RDB format version
+func parseHeader(header []byte) (int, error) {
+	if string(header[:5]) != MAGIC {
+		return 0, ErrWrongMagic
+	}
+
+	version, err := strconv.Atoi(string(header[5:]))
+
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("Invalid RDB version %q", string(header[5:]))
+	}
+
+	if version > MaxSupportedVersion() {
+		return 0, fmt.Errorf("Unsupported RDB version %d", version)
+	}
+
+	return version, nil
+}
+
+// parse reads and validates RDB data with given size (-1 if size is unknown)
+func parse(r io.Reader, size int64) (*Info, error) {
+	rr := &reader{r: bufio.NewReaderSize(r, 64*1024), buf: make([]byte, 16), size: size}
+	info := &Info{
+		Aux:     make(map[string]string),
+		Keys:    make(map[int]int),
+		Expires: make(map[int]int),
+	}
+
+	header, err := rr.readBytes(9)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read RDB header: %w", err)
+	}
+
+	info.Version, err = parseHeader(header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = rr.readEntries(info)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Version < MIN_CHECKSUM_VERSION {
+		return info, nil
+	}
+
+	crc := rr.crc
+	checksum, err := rr.readBytes(8)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read RDB checksum: %w", err)
+	}
+
+	info.Checksum = binary.LittleEndian.Uint64(checksum)
+
+	if info.Checksum != 0 && info.Checksum != crc {
+		return nil, ErrChecksumMismatch
+	}
+
+	if ctime, ok := info.Aux["ctime"]; ok {
+		ts, _ := strconv.ParseInt(ctime, 10, 64)
+
+		if ts > 0 {
+			info.Created = time.Unix(ts, 0)
+		}
+	}
+
+	return info, nil
+}
+
+// readEntries reads all RDB entries until EOF opcode
+func (r *reader) readEntries(info *Info) error {
+	var db int
+	var hasExpire bool
+
+	for {
+		opcode, err := r.readByte()
+
+		if err != nil {
+			return fmt.Errorf("Can't read RDB entry: %w", err)
+		}
+
+		switch opcode {
+		case OPCODE_EOF:
+			return nil
+
+		case OPCODE_SELECTDB:
+			dbNum, err := r.readLen()
+
+			if err != nil {
+				return err
+			}
+
+			db = int(dbNum)
+
+		case OPCODE_RESIZEDB:
+			err = r.skipLen(2)
+
+		case OPCODE_AUX:
+			var key, value string
+
+			key, err = r.readString()
+
+			if err == nil {
+				value, err = r.readString()
+				info.Aux[key] = value
+			}
+
+		case OPCODE_EXPIRETIME:
+			_, err = r.readBytes(4)
+			hasExpire = true
+
+		case OPCODE_EXPIRETIME_MS:
+			_, err = r.readBytes(8)
+			hasExpire = true
+
+		case OPCODE_FREQ:
+			_, err = r.readByte()
+
+		case OPCODE_IDLE:
+			err = r.skipLen(1)
+
+		case OPCODE_SLOT_INFO:
+			err = r.skipLen(3)
+
+		case OPCODE_FUNCTION2:
+			err = r.skipString()
+
+		case OPCODE_MODULE_AUX:
+			info.HasModules = true
+			err = r.skipLen(3)
+
+			if err == nil {
+				err = r.skipModuleValue()
+			}
+
+		case OPCODE_FUNCTION_PRE_GA:
+			return fmt.Errorf("Pre-GA function format is not supported")
+
+		default:
+			err = r.skipString()
+
+			if err == nil {
+				err = r.skipValue(opcode, info)
+			}
+
+			if err == nil {
+				info.Keys[db]++
+
+				if hasExpire {
+					info.Expires[db]++
+				}
+			}
+
+			hasExpire = false
+		}
+
+		if err != nil {
+			return fmt.Errorf("Can't read RDB entry (opcode %d): %w", opcode, err)
+		}
+	}
+}
+
+// skipValue skips value with given type
+func (r *reader) skipValue(valueType byte, info *Info) error {
+	switch valueType {
+	case TYPE_STRING, TYPE_HASH_ZIPMAP, TYPE_LIST_ZIPLIST, TYPE_SET_INTSET,
+		TYPE_ZSET_ZIPLIST, TYPE_HASH_ZIPLIST, TYPE_HASH_LISTPACK,
+		TYPE_ZSET_LISTPACK, TYPE_SET_LISTPACK, TYPE_HASH_LISTPACK_EX_PRE_GA:
+		return r.skipString()
+
+	case TYPE_LIST, TYPE_SET, TYPE_LIST_QUICKLIST:
+		return r.skipStrings(1)
+
+	case TYPE_HASH:
+		return r.skipStrings(2)
+
+	case TYPE_ZSET, TYPE_ZSET_2:
+		num, err := r.readLen()
+
+		for i := uint64(0); err == nil && i < num; i++ {
+			err = r.skipString()
+
+			if err == nil && valueType == TYPE_ZSET_2 {
+				_, err = r.readBytes(8)
+			} else if err == nil {
+				err = r.skipDouble()
+			}
+		}
+
+		return err
+
+	case TYPE_LIST_QUICKLIST_2:
+		num, err := r.readLen()
+
+		for i := uint64(0); err == nil && i < num; i++ {
+			err = r.skipLen(1)
+
+			if err == nil {
+				err = r.skipString()
+			}
+		}
+
+		return err
+
+	case TYPE_HASH_METADATA, TYPE_HASH_METADATA_PRE_GA:
+		if valueType == TYPE_HASH_METADATA {
+			_, err := r.readBytes(8)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		num, err := r.readLen()
+
+		for i := uint64(0); err == nil && i < num; i++ {
+			if valueType == TYPE_HASH_METADATA {
+				err = r.skipLen(1)
+			} else {
+				_, err = r.readBytes(8)
+			}
+
+			if err == nil {
+				err = r.skipString()
+			}
+
+			if err == nil {
+				err = r.skipString()
+			}
+		}
+
+		return err
+
+	case TYPE_HASH_LISTPACK_EX:
+		_, err := r.readBytes(8)
+
+		if err != nil {
+			return err
+		}
+
+		return r.skipString()
+
+	case TYPE_STREAM_LISTPACKS, TYPE_STREAM_LISTPACKS_2, TYPE_STREAM_LISTPACKS_3:
+		return r.skipStream(valueType)
+
+	case TYPE_MODULE_2:
+		info.HasModules = true
+		err := r.skipLen(1)
+
+		if err != nil {
+			return err
+		}
+
+		return r.skipModuleValue()
+
+	case TYPE_MODULE_PRE_GA:
+		return fmt.Errorf("Pre-GA module format is not supported")
+	}
+
+	return fmt.Errorf("Unknown value type %d", valueType)
+}
+
+// skipStream skips stream value
+func (r *reader) skipStream(valueType byte) error {
+	err := r.skipStrings(2)
+
+	if err != nil {
+		return err
+	}
+
+	// Length + last ID
+	lens := 3
+
+	if valueType >= TYPE_STREAM_LISTPACKS_2 {
+		// First ID + max deleted ID + entries added
+		lens += 5
+	}
+
+	err = r.skipLen(lens)
+
+	if err != nil {
+		return err
+	}
+
+	groups, err := r.readLen()
+
+	for i := uint64(0); err == nil && i < groups; i++ {
+		err = r.skipString()
+
+		if err == nil {
+			if valueType >= TYPE_STREAM_LISTPACKS_2 {
+				err = r.skipLen(3)
+			} else {
+				err = r.skipLen(2)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Group PEL: raw ID + delivery time + delivery count
+		pel, err := r.readLen()
+
+		for j := uint64(0); err == nil && j < pel; j++ {
+			_, err = r.readBytes(16)
+
+			if err == nil {
+				_, err = r.readBytes(8)
+			}
+
+			if err == nil {
+				err = r.skipLen(1)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		consumers, err := r.readLen()
+
+		for j := uint64(0); err == nil && j < consumers; j++ {
+			err = r.skipString()
+
+			if err == nil {
+				_, err = r.readBytes(8)
+			}
+
+			if err == nil && valueType >= TYPE_STREAM_LISTPACKS_3 {
+				_, err = r.readBytes(8)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			// Consumer PEL contains only raw IDs
+			var cpel uint64
+
+			cpel, err = r.readLen()
+
+			for k := uint64(0); err == nil && k < cpel; k++ {
+				_, err = r.readBytes(16)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// skipModuleValue skips module value serialized with opcodes
+func (r *reader) skipModuleValue() error {
+	for {
+		opcode, err := r.readLen()
+
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case MODULE_OPCODE_EOF:
+			return nil
+		case MODULE_OPCODE_SINT, MODULE_OPCODE_UINT:
+			err = r.skipLen(1)
+		case MODULE_OPCODE_FLOAT:
+			_, err = r.readBytes(4)
+		case MODULE_OPCODE_DOUBLE:
+			_, err = r.readBytes(8)
+		case MODULE_OPCODE_STRING:
+			err = r.skipString()
+		default:
+			return fmt.Errorf("Unknown module opcode %d", opcode)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipDouble skips double value in string format
+func (r *reader) skipDouble() error {
+	size, err := r.readByte()
+
+	if err != nil {
+		return err
+	}
+
+	switch size {
+	case 253, 254, 255: // NaN, +Inf, -Inf
+		return nil
+	}
+
+	_, err = r.readBytes(int(size))
+
+	return err
+}
+
+// skipStrings reads number of items and skips strings
+func (r *reader) skipStrings(perItem int) error {
+	num, err := r.readLen()
+
+	for i := uint64(0); err == nil && i < num*uint64(perItem); i++ {
+		err = r.skipString()
+	}
+
+	return err
+}
+
+// skipLen skips given number of length-encoded values
+func (r *reader) skipLen(num int) error {
+	for range num {
+		_, err := r.readLen()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readString reads string value
+func (r *reader) readString() (string, error) {
+	value, err := r.readStringData(false)
+	return string(value), err
+}
+
+// skipString skips string value
+func (r *reader) skipString() error {
+	_, err := r.readStringData(true)
+	return err
+}
+
+// readStringData reads string data
+func (r *reader) readStringData(skip bool) ([]byte, error) {
+	size, isEncoded, err := r.readLenEnc()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEncoded {
+		if skip {
+			return nil, r.discard(size)
+		}
+
+		return r.readData(size)
+	}
+
+	switch size {
+	case ENC_INT8:
+		b, err := r.readBytes(1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(strconv.Itoa(int(int8(b[0])))), nil
+
+	case ENC_INT16:
+		b, err := r.readBytes(2)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b))))), nil
+
+	case ENC_INT32:
+		b, err := r.readBytes(4)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b))))), nil
+
+	case ENC_LZF:
+		compSize, err := r.readLen()
+
+		if err != nil {
+			return nil, err
+		}
+
+		dataSize, err := r.readLen()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if skip {
+			return nil, r.discard(compSize)
+		}
+
+		if dataSize > MAX_STRING_SIZE {
+			return nil, fmt.Errorf("Compressed string size (%d) is too big", dataSize)
+		}
+
+		data, err := r.readData(compSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return decompressLZF(data, int(dataSize))
+	}
+
+	return nil, fmt.Errorf("Unknown string encoding %d", size)
+}
+
+// readLen reads length-encoded value
+func (r *reader) readLen() (uint64, error) {
+	value, isEncoded, err := r.readLenEnc()
+
+	if err == nil && isEncoded {
+		return 0, fmt.Errorf("Unexpected encoded value")
+	}
+
+	return value, err
+}
+
+// readLenEnc reads length-encoded value and returns flag if value is encoded
+func (r *reader) readLenEnc() (uint64, bool, error) {
+	b, err := r.readByte()
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3F), false, nil
+
+	case 1:
+		next, err := r.readByte()
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		return uint64(b&0x3F)<<8 | uint64(next), false, nil
+
+	case 3:
+		return uint64(b & 0x3F), true, nil
+	}
+
+	switch b {
+	case 0x80:
+		data, err := r.readBytes(4)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		return uint64(binary.BigEndian.Uint32(data)), false, nil
+
+	case 0x81:
+		data, err := r.readBytes(8)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		return binary.BigEndian.Uint64(data), false, nil
+	}
+
+	return 0, false, fmt.Errorf("Unknown length encoding 0x%X", b)
+}
+
+// readByte reads one byte
+func (r *reader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	r.crc = crc64Update(r.crc, []byte{b})
+	r.offset++
+
+	return b, nil
+}
+
+// readBytes reads given number of bytes
+func (r *reader) readBytes(num int) ([]byte, error) {
+	buf := r.buf
+
+	if num > len(buf) {
+		buf = make([]byte, num)
+	}
+
+	_, err := io.ReadFull(r.r, buf[:num])
+
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	r.crc = crc64Update(r.crc, buf[:num])
+	r.offset += int64(num)
+
+	return buf[:num], nil
+}
+
+// readData reads string data with size from untrusted length encoding
+func (r *reader) readData(size uint64) ([]byte, error) {
+	err := r.checkSize(size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if size > MAX_STRING_SIZE {
+		return nil, fmt.Errorf("String size (%d) is too big", size)
+	}
+
+	return r.readBytes(int(size))
+}
+
+// discard reads and drops given number of bytes
+func (r *reader) discard(size uint64) error {
+	err := r.checkSize(size)
+
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 32*1024)
+
+	for size > 0 {
+		chunk := min(size, uint64(len(buf)))
+		_, err := io.ReadFull(r.r, buf[:chunk])
+
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		r.crc = crc64Update(r.crc, buf[:chunk])
+		r.offset += int64(chunk)
+		size -= chunk
+	}
+
+	return nil
+}
+
+// checkSize checks that data with given size fits the rest of data
+func (r *reader) checkSize(size uint64) error {
+	if size > math.MaxInt64 || (r.size >= 0 && int64(size) > r.size-r.offset) {
+		return fmt.Errorf("String size (%d) exceeds the size of the rest of data", size)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// decompressLZF decompresses LZF compressed data
+func decompressLZF(data []byte, size int) ([]byte, error) {
+	// Size is not trusted, so we don't preallocate more than compressed
+	// data can contain
+	result := make([]byte, 0, min(size, len(data)*4))
+
+	for i := 0; i < len(data); {
+		if len(result) > size {
+			return nil, fmt.Errorf("Invalid LZF data size")
+		}
+
+		ctrl := int(data[i])
+		i++
+
+		if ctrl < 32 {
+			ctrl++
+
+			if i+ctrl > len(data) {
+				return nil, fmt.Errorf("Invalid LZF data")
+			}
+
+			result = append(result, data[i:i+ctrl]...)
+			i += ctrl
+
+			continue
+		}
+
+		length := ctrl >> 5
+
+		if length == 7 {
+			if i >= len(data) {
+				return nil, fmt.Errorf("Invalid LZF data")
+			}
+
+			length += int(data[i])
+			i++
+		}
+
+		if i >= len(data) {
+			return nil, fmt.Errorf("Invalid LZF data")
+		}
+
+		ref := len(result) - ((ctrl & 0x1F) << 8) - int(data[i]) - 1
+		i++
+
+		if ref < 0 {
+			return nil, fmt.Errorf("Invalid LZF data")
+		}
+
+		for j := 0; j < length+2; j++ {
+			result = append(result, result[ref+j])
+		}
+	}
+
+	if len(result) != size {
+		return nil, fmt.Errorf("Invalid LZF data size")
+	}
+
+	return result, nil
+}
+
+// parseSimpleVersion parses version in "major.minor" format
+func parseSimpleVersion(v string) (int, int) {
+	for i := range v {
+		if v[i] == '.' {
+			major, _ := strconv.Atoi(v[:i])
+			minor, _ := strconv.Atoi(v[i+1:])
+			return major, minor
+		}
+	}
+
+	major, _ := strconv.Atoi(v)
+
+	return major, 0
+}
+
+// unexpectedEOF converts EOF error to unexpected EOF error
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,219 @@
+package rdb
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type RDBSuite struct {
+	dir string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+var _ = Suite(&RDBSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *RDBSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *RDBSuite) TestValid(c *C) {
+	info, err := Read(s.writeFixture(c, genRDB(nil, true)))
+
+	c.Assert(err, IsNil)
+	c.Assert(info.Version, Equals, 11)
+	c.Assert(info.RedisVersion(), Equals, "7.2.4")
+	c.Assert(info.Keys, DeepEquals, map[int]int{0: 2, 1: 1})
+	c.Assert(info.Expires, DeepEquals, map[int]int{0: 1})
+	c.Assert(info.TotalKeys(), Equals, 3)
+	c.Assert(info.Checksum, Not(Equals), uint64(0))
+
+	info, err = Parse(bytes.NewReader(genRDB(nil, true)))
+
+	c.Assert(err, IsNil)
+	c.Assert(info.TotalKeys(), Equals, 3)
+}
+
+func (s *RDBSuite) TestTruncated(c *C) {
+	data := genRDB(nil, true)
+
+	for size := 0; size < len(data); size++ {
+		_, err := Read(s.writeFixture(c, data[:size]))
+		c.Assert(err, NotNil, Commentf("size: %d", size))
+
+		_, err = Parse(bytes.NewReader(data[:size]))
+		c.Assert(err, NotNil, Commentf("size: %d", size))
+	}
+}
+
+func (s *RDBSuite) TestCorruptLength(c *C) {
+	fixtures := map[string][]byte{
+		// 64-bit length which is negative as int
+		"negative": {0x81, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		// 32-bit length bigger than file size
+		"huge-32": {0x80, 0x7F, 0xFF, 0xFF, 0xFF},
+		// 64-bit length bigger than file size
+		"huge-64": {0x81, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00},
+		// LZF string with huge decompressed size
+		"lzf": {0xC3, 0x02, 0x81, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		// Unknown length encoding
+		"unknown": {0x82},
+	}
+
+	for name, value := range fixtures {
+		// Corrupted aux field (read to memory)
+		aux := append([]byte{OPCODE_AUX, 0x01, 'a'}, value...)
+		_, err := Read(s.writeFixture(c, genRDB(aux, true)))
+		c.Assert(err, NotNil, Commentf("aux: %s", name))
+
+		_, err = Parse(bytes.NewReader(genRDB(aux, true)))
+		c.Assert(err, NotNil, Commentf("aux: %s", name))
+
+		// Corrupted key (skipped)
+		key := append([]byte{TYPE_STRING}, value...)
+		_, err = Read(s.writeFixture(c, genRDB(key, true)))
+		c.Assert(err, NotNil, Commentf("key: %s", name))
+	}
+}
+
+func (s *RDBSuite) TestBadChecksum(c *C) {
+	data := genRDB(nil, true)
+	data[len(data)-1] ^= 0xFF
+
+	_, err := Read(s.writeFixture(c, data))
+	c.Assert(err, Equals, ErrChecksumMismatch)
+
+	// Modified data with original checksum
+	data = genRDB(nil, true)
+	data[bytes.Index(data, []byte("value"))] = 'V'
+
+	_, err = Read(s.writeFixture(c, data))
+	c.Assert(err, Equals, ErrChecksumMismatch)
+
+	// Disabled checksum
+	info, err := Read(s.writeFixture(c, genRDB(nil, false)))
+	c.Assert(err, IsNil)
+	c.Assert(info.Checksum, Equals, uint64(0))
+}
+
+func (s *RDBSuite) TestWrongHeader(c *C) {
+	_, err := Read(s.writeFixture(c, []byte("RADIS0011")))
+	c.Assert(err, Equals, ErrWrongMagic)
+
+	_, err = Read(s.writeFixture(c, []byte("REDIS00AB")))
+	c.Assert(err, ErrorMatches, "Invalid RDB version .*")
+
+	_, err = Read(s.writeFixture(c, []byte("REDIS0099")))
+	c.Assert(err, ErrorMatches, "Unsupported RDB version 99")
+}
+
+func (s *RDBSuite) TestReadVersion(c *C) {
+	version, err := ReadVersion(s.writeFixture(c, genRDB(nil, true)))
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, 11)
+
+	_, err = ReadVersion(s.writeFixture(c, []byte("*2\r\n$6\r\nSELECT\r\n$1\r\n0\r\n")))
+	c.Assert(err, Equals, ErrWrongMagic)
+
+	_, err = ReadVersion(s.writeFixture(c, []byte("*2")))
+	c.Assert(err, Equals, ErrWrongMagic)
+
+	_, err = ReadVersion(s.writeFixture(c, []byte("REDIS0099")))
+	c.Assert(err, ErrorMatches, "Unsupported RDB version 99")
+}
+
+func (s *RDBSuite) TestLZF(c *C) {
+	data, err := decompressLZF([]byte{0x02, 'a', 'b', 'c', 0x20, 0x02}, 6)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "abcabc")
+
+	_, err = decompressLZF([]byte{0x02, 'a', 'b', 'c', 0x20, 0x02}, 5)
+	c.Assert(err, NotNil)
+
+	_, err = decompressLZF([]byte{0x20, 0x10}, 3)
+	c.Assert(err, NotNil)
+
+	_, err = decompressLZF([]byte{0x05, 'a'}, 6)
+	c.Assert(err, NotNil)
+}
+
+func (s *RDBSuite) TestVersions(c *C) {
+	c.Assert(MinRedisVersion(11), Equals, "7.2")
+	c.Assert(VersionForRedis(7, 0), Equals, 10)
+	c.Assert(VersionForRedis(7, 4), Equals, 12)
+	c.Assert(VersionForRedis(2, 8), Equals, 6)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// writeFixture writes RDB fixture to file
+func (s *RDBSuite) writeFixture(c *C, data []byte) string {
+	file := filepath.Join(s.dir, "dump.rdb")
+	c.Assert(os.WriteFile(file, data, 0644), IsNil)
+	return file
+}
+
+// genRDB generates RDB data with given extra entry placed before EOF opcode
+func genRDB(extra []byte, checksum bool) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("REDIS0011")
+
+	buf.Write([]byte{OPCODE_AUX, 9})
+	buf.WriteString("redis-ver")
+	buf.Write([]byte{5})
+	buf.WriteString("7.2.4")
+
+	buf.Write([]byte{OPCODE_AUX, 5})
+	buf.WriteString("ctime")
+	buf.Write([]byte{0xC2, 0x00, 0xF1, 0x53, 0x65})
+
+	buf.Write([]byte{OPCODE_SELECTDB, 0, OPCODE_RESIZEDB, 2, 1})
+
+	buf.Write([]byte{TYPE_STRING, 3})
+	buf.WriteString("key")
+	buf.Write([]byte{5})
+	buf.WriteString("value")
+
+	buf.Write([]byte{OPCODE_EXPIRETIME_MS, 0, 0, 0, 0, 0, 0, 0, 0})
+	buf.Write([]byte{TYPE_LIST_QUICKLIST_2, 4})
+	buf.WriteString("list")
+	buf.Write([]byte{1, 2, 3})
+	buf.WriteString("abc")
+
+	buf.Write([]byte{OPCODE_SELECTDB, 1})
+	buf.Write([]byte{TYPE_SET, 3})
+	buf.WriteString("set")
+	buf.Write([]byte{2, 1, 'a', 0xC0, 0x7F})
+
+	buf.Write(extra)
+	buf.WriteByte(OPCODE_EOF)
+
+	crc := make([]byte, 8)
+
+	if checksum {
+		binary.LittleEndian.PutUint64(crc, crc64Update(0, buf.Bytes()))
+	}
+
+	buf.Write(crc)
+
+	return buf.Bytes()
+}
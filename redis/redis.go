@@ -8,6 +8,7 @@ package redis
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"slices"
@@ -49,6 +50,8 @@ type Config = redy.Config
 
 type RespType = redy.RespType
 
+type Client = redy.Client
+
 type ConfigPropDiff struct {
 	PropName  string
 	FileValue string
@@ -63,6 +66,8 @@ type Request struct {
 	Port    int
 	DB      int
 	Timeout time.Duration
+	TLS     *tls.Config
+	Socket  string
 }
 
 type Auth struct {
@@ -97,6 +102,36 @@ func ExecCommand(req *Request) (*redy.Resp, error) {
 	return execCmd(req)
 }
 
+// Connect creates new authenticated connection to instance
+func Connect(req *Request) (*redy.Client, error) {
+	rc := configureClient(&redy.Client{}, req, req.Timeout)
+	err := rc.Connect()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Auth.IsEmpty() {
+		resp := rc.Cmd("AUTH", req.Auth.User, req.Auth.Password)
+
+		if resp.Err != nil {
+			rc.Close()
+			return nil, resp.Err
+		}
+	}
+
+	if req.DB != 0 {
+		resp := rc.Cmd("SELECT", req.DB)
+
+		if resp.Err != nil {
+			rc.Close()
+			return nil, resp.Err
+		}
+	}
+
+	return rc, nil
+}
+
 // ReadConfig read and parse redis config file
 func ReadConfig(file string) (*redy.Config, error) {
 	return redy.ReadConfig(file)
@@ -232,7 +267,7 @@ func (a Auth) IsEmpty() bool {
 
 // execCmd executes command on instance
 func execCmd(req *Request) (*redy.Resp, error) {
-	rc := getClient(req.Port, req.Timeout)
+	rc := getClient(req, req.Timeout)
 	err := rc.Connect()
 
 	if err != nil {
@@ -274,22 +309,35 @@ func execCmd(req *Request) (*redy.Resp, error) {
 }
 
 // getClient returns Redy client
-func getClient(port int, timeout time.Duration) *redy.Client {
+func getClient(req *Request, timeout time.Duration) *redy.Client {
 	if client == nil {
 		client = &redy.Client{}
 	}
 
-	client.Addr = "127.0.0.1:" + strconv.Itoa(port)
+	return configureClient(client, req, timeout)
+}
+
+// configureClient configures Redy client for given request
+func configureClient(rc *redy.Client, req *Request, timeout time.Duration) *redy.Client {
+	if req.Socket != "" {
+		rc.Network = "unix"
+		rc.Addr = req.Socket
+		rc.TLSConfig = nil
+	} else {
+		rc.Network = "tcp"
+		rc.Addr = "127.0.0.1:" + strconv.Itoa(req.Port)
+		rc.TLSConfig = req.TLS
+	}
 
 	if timeout > 0 {
-		client.WriteTimeout = timeout
-		client.ReadTimeout = timeout
+		rc.WriteTimeout = timeout
+		rc.ReadTimeout = timeout
 	} else {
-		client.WriteTimeout = 3 * time.Second
-		client.ReadTimeout = 3 * time.Second
+		rc.WriteTimeout = 3 * time.Second
+		rc.ReadTimeout = 3 * time.Second
 	}
 
-	return client
+	return rc
 }
 
 // getHumanSize returns size in human readable format
@@ -8,6 +8,7 @@ package sentinel
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"strconv"
@@ -39,6 +40,7 @@ type InstanceConfig struct {
 
 type SentinelConfig struct {
 	Port int
+	TLS  *tls.Config
 
 	Auth Auth
 }
@@ -73,7 +75,7 @@ var client *redy.Client
 
 // Monitor adds instance to Sentinel monitoring
 func Monitor(sCfg *SentinelConfig, iCfg *InstanceConfig) error {
-	rc := getClient(sCfg.Port, 3*time.Second)
+	rc := getClient(sCfg, 3*time.Second)
 	err := rc.Connect()
 
 	if err != nil {
@@ -193,7 +195,7 @@ func IsSentinelMonitors(sCfg *SentinelConfig, instanceID int) bool {
 
 // GetInfo returns info about master, replicas and sentinels
 func GetInfo(sCfg *SentinelConfig, instanceID int) (*Info, error) {
-	rc := getClient(sCfg.Port, 3*time.Second)
+	rc := getClient(sCfg, 3*time.Second)
 	err := rc.Connect()
 
 	if err != nil {
@@ -320,7 +322,7 @@ func configureFailover(rc *redy.Client, cfg *InstanceConfig) error {
 
 // execSentinelCommand executes command on sentinel
 func execSentinelCommand(cfg *SentinelConfig, command []any) (*redy.Resp, error) {
-	rc := getClient(cfg.Port, 3*time.Second)
+	rc := getClient(cfg, 3*time.Second)
 	err := rc.Connect()
 
 	if err != nil {
@@ -341,12 +343,13 @@ func execSentinelCommand(cfg *SentinelConfig, command []any) (*redy.Resp, error)
 }
 
 // getClient returns Redy client
-func getClient(port int, timeout time.Duration) *redy.Client {
+func getClient(cfg *SentinelConfig, timeout time.Duration) *redy.Client {
 	if client == nil {
 		client = &redy.Client{}
 	}
 
-	client.Addr = "127.0.0.1:" + strconv.Itoa(port)
+	client.Addr = "127.0.0.1:" + strconv.Itoa(cfg.Port)
+	client.TLSConfig = cfg.TLS
 
 	if timeout > 0 {
 		client.WriteTimeout = timeout
@@ -0,0 +1,136 @@
+package client
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/essentialkaos/ek/v13/req"
+
+	API "github.com/essentialkaos/rds/api"
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MigrationCreate creates replica of instance with given meta on target node
+func MigrationCreate(node string, meta *CORE.InstanceMeta, port int) (*API.MigrationCreateResponse, error) {
+	createResponse := &API.MigrationCreateResponse{}
+
+	err := sendMigrationRequest(
+		node, API.METHOD_MIGRATION_CREATE,
+		&API.MigrationCreateRequest{
+			Meta:      meta,
+			Port:      port,
+			Version:   CORE.VERSION,
+			Initiator: CORE.User.RealName,
+		},
+		createResponse, &createResponse.Status,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return createResponse, nil
+}
+
+// MigrationStatus returns replication status of migrated instance on target node
+func MigrationStatus(node string, id int, uuid string) (*API.MigrationInfo, error) {
+	statusResponse := &API.MigrationStatusResponse{}
+
+	err := sendMigrationRequest(
+		node, API.METHOD_MIGRATION_STATUS,
+		&API.MigrationRequest{ID: id, UUID: uuid},
+		statusResponse, &statusResponse.Status,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return statusResponse.Info, nil
+}
+
+// MigrationPromote promotes migrated instance on target node to master
+func MigrationPromote(node string, id int, uuid string) error {
+	defResponse := &API.DefaultResponse{}
+
+	return sendMigrationRequest(
+		node, API.METHOD_MIGRATION_PROMOTE,
+		&API.MigrationRequest{ID: id, UUID: uuid},
+		defResponse, &defResponse.Status,
+	)
+}
+
+// MigrationAbort destroys migrated instance on target node
+func MigrationAbort(node string, id int, uuid string) error {
+	defResponse := &API.DefaultResponse{}
+
+	return sendMigrationRequest(
+		node, API.METHOD_MIGRATION_ABORT,
+		&API.MigrationRequest{ID: id, UUID: uuid},
+		defResponse, &defResponse.Status,
+	)
+}
+
+// GetMigrationNodeHost returns host from node address
+func GetMigrationNodeHost(node string) string {
+	host, _, err := net.SplitHostPort(node)
+
+	if err != nil {
+		return node
+	}
+
+	return host
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// sendMigrationRequest sends request to migration API on given node
+func sendMigrationRequest(node string, method API.Method, body, response any, status *API.ResponseStatus) error {
+	resp, err := req.Request{
+		URL:         getMigrationURL(node, method),
+		Headers:     API.GetAuthHeader(CORE.Config.GetS(CORE.MIGRATION_AUTH_TOKEN)),
+		ContentType: req.CONTENT_TYPE_JSON,
+		AutoDiscard: true,
+		Body:        body,
+	}.Post()
+
+	if err != nil {
+		return fmt.Errorf("Error while sending request to node %s: %v", node, err)
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Node %s returned HTTP status code %d", node, resp.StatusCode)
+	}
+
+	err = resp.JSON(response)
+
+	if err != nil {
+		return fmt.Errorf("Error while decoding node %s response: %v", node, err)
+	}
+
+	if status.Code != API.STATUS_OK {
+		return fmt.Errorf("Node %s returned error: %s", node, status.Desc)
+	}
+
+	return nil
+}
+
+// getMigrationURL returns URL of migration API on given node
+func getMigrationURL(node string, method API.Method) string {
+	_, _, err := net.SplitHostPort(node)
+
+	if err != nil {
+		node = net.JoinHostPort(node, CORE.Config.GetS(CORE.MIGRATION_PORT))
+	}
+
+	return "http://" + node + "/" + string(method)
+}
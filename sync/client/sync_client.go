@@ -22,7 +22,7 @@ import (
 func PropagateCommand(command API.MasterCommand, id int, uuid string) error {
 	var err error
 
-	if !CORE.IsSyncDaemonActive() {
+	if CORE.Config.GetS(CORE.REPLICATION_ROLE) == "" || !CORE.IsSyncDaemonActive() {
 		return nil
 	}
 
@@ -0,0 +1,139 @@
+package history
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/system"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// cpuUsage contains info about instance CPU usage
+type cpuUsage struct {
+	Value float64 // Total CPU time in seconds
+	Date  time.Time
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// cpuUsageCache contains previous values of instances CPU usage
+var cpuUsageCache = make(map[int]cpuUsage)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Start starts metrics history collector
+func Start() {
+	if !CORE.IsHistoryEnabled() {
+		return
+	}
+
+	resolution := CORE.GetHistoryResolution()
+
+	log.Info(
+		"Metrics history collector started (resolution: %s, retention: %s)",
+		timeutil.PrettyDuration(resolution),
+		timeutil.PrettyDuration(CORE.GetHistoryRetention()),
+	)
+
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(resolution).Add(resolution).Sub(now))
+		collectMetrics(time.Now())
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// collectMetrics collects metrics of all instances and node
+func collectMetrics(now time.Time) {
+	nodeSample := CORE.HistorySample{}
+
+	for _, metric := range CORE.HistoryNodeMetrics {
+		nodeSample[metric] = 0
+	}
+
+	for _, id := range CORE.GetInstanceIDList() {
+		sample := getInstanceSample(id, now)
+
+		if sample == nil {
+			delete(cpuUsageCache, id)
+			continue
+		}
+
+		err := CORE.AddHistorySample(strconv.Itoa(id), now, sample)
+
+		if err != nil {
+			log.Error("(%3d) Can't save metrics sample: %v", id, err)
+		}
+
+		nodeSample["instances"]++
+
+		for _, metric := range []string{"ops", "clients", "keys", "input", "output"} {
+			nodeSample[metric] += sample[metric]
+		}
+	}
+
+	memUsage, err := system.GetMemUsage()
+
+	if err == nil {
+		nodeSample["mem"] = float64(memUsage.MemUsed)
+		nodeSample["swap"] = float64(memUsage.SwapUsed)
+	} else {
+		delete(nodeSample, "mem")
+		delete(nodeSample, "swap")
+	}
+
+	err = CORE.AddHistorySample(CORE.HISTORY_NODE, now, nodeSample)
+
+	if err != nil {
+		log.Error("Can't save node metrics sample: %v", err)
+	}
+}
+
+// getInstanceSample returns metrics sample for instance with given ID
+func getInstanceSample(id int, now time.Time) CORE.HistorySample {
+	state, err := CORE.GetInstanceState(id, false)
+
+	if err != nil || !state.IsWorks() {
+		return nil
+	}
+
+	info, err := CORE.GetInstanceInfo(id, time.Second, false)
+
+	if err != nil {
+		return nil
+	}
+
+	sample := CORE.HistorySample{
+		"mem":     info.GetF("memory", "used_memory"),
+		"rss":     info.GetF("memory", "used_memory_rss"),
+		"ops":     info.GetF("stats", "instantaneous_ops_per_sec"),
+		"clients": info.GetF("clients", "connected_clients") - 1, // Without our connection
+		"keys":    float64(info.Keyspace.Keys()),
+		"input":   info.GetF("stats", "instantaneous_input_kbps") * 1024,
+		"output":  info.GetF("stats", "instantaneous_output_kbps") * 1024,
+	}
+
+	cpuTime := info.GetF("cpu", "used_cpu_sys") + info.GetF("cpu", "used_cpu_user")
+	prevUsage, ok := cpuUsageCache[id]
+
+	if ok && cpuTime >= prevUsage.Value && now.After(prevUsage.Date) {
+		sample["cpu"] = (cpuTime - prevUsage.Value) / now.Sub(prevUsage.Date).Seconds() * 100
+	}
+
+	cpuUsageCache[id] = cpuUsage{cpuTime, now}
+
+	return sample
+}
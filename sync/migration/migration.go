@@ -0,0 +1,457 @@
+package migration
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/httputil"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/strutil"
+
+	API "github.com/essentialkaos/rds/api"
+	CORE "github.com/essentialkaos/rds/core"
+	SC "github.com/essentialkaos/rds/sync/client"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Exit codes
+const (
+	EC_OK    = 0
+	EC_ERROR = 1
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	statusOK                = API.ResponseStatus{"OK", 0}
+	statusArgError          = API.ResponseStatus{"Not enough arguments", API.STATUS_WRONG_ARGS}
+	statusTokenError        = API.ResponseStatus{"Token is invalid", API.STATUS_WRONG_AUTH_TOKEN}
+	statusWrongRequestError = API.ResponseStatus{"Wrong request", API.STATUS_WRONG_REQUEST}
+)
+
+// server is HTTP server
+var server *http.Server
+
+// daemonVersion is current daemon version
+var daemonVersion string
+
+// createLock is lock for instance creation
+var createLock sync.Mutex
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Start starts migration API server
+func Start(app, ver, rev string) int {
+	daemonVersion = ver
+
+	addr := CORE.Config.GetS(CORE.MIGRATION_IP) + ":" + CORE.Config.GetS(CORE.MIGRATION_PORT)
+
+	server = &http.Server{
+		Addr:           addr,
+		Handler:        http.NewServeMux(),
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   time.Duration(CORE.Config.GetI(CORE.DELAY_START)+60) * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+
+	registerAPIHandlers(server.Handler.(*http.ServeMux))
+
+	if rev == "" {
+		log.Aux("%s %s migration API started (%s)", app, ver, addr)
+	} else {
+		log.Aux("%s %s (git:%s) migration API started (%s)", app, ver, rev, addr)
+	}
+
+	err := server.ListenAndServe()
+
+	if err != nil && err != http.ErrServerClosed {
+		log.Crit("Migration API server error: %v", err)
+		return EC_ERROR
+	}
+
+	return EC_OK
+}
+
+// Stop gracefully stops migration API server
+func Stop() {
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// registerAPIHandlers register all handlers
+func registerAPIHandlers(mux *http.ServeMux) {
+	mux.HandleFunc(API.METHOD_MIGRATION_CREATE.Pattern(), createHandler)
+	mux.HandleFunc(API.METHOD_MIGRATION_STATUS.Pattern(), statusHandler)
+	mux.HandleFunc(API.METHOD_MIGRATION_PROMOTE.Pattern(), promoteHandler)
+	mux.HandleFunc(API.METHOD_MIGRATION_ABORT.Pattern(), abortHandler)
+	mux.HandleFunc("/", anyHandler)
+}
+
+// anyHandler handler for any unsupported command
+func anyHandler(w http.ResponseWriter, r *http.Request) {
+	appendHeader(w)
+	encodeAndWrite(w, &API.DefaultResponse{Status: statusWrongRequestError})
+}
+
+// createHandler is "migration-create" command handler
+func createHandler(w http.ResponseWriter, r *http.Request) {
+	appendHeader(w)
+
+	if !checkRequest(w, r, API.METHOD_MIGRATION_CREATE) {
+		return
+	}
+
+	createRequest := &API.MigrationCreateRequest{}
+	err := readAndDecode(r, createRequest)
+
+	if err != nil || createRequest.Meta == nil || createRequest.Port == 0 {
+		encodeAndWrite(w, &API.DefaultResponse{Status: statusArgError})
+		return
+	}
+
+	ip := httputil.GetRemoteHost(r)
+	meta := createRequest.Meta
+
+	log.Info(
+		"(%s) Got request for migration of instance %d (%s) from %s:%d",
+		strutil.Q(createRequest.Initiator, "—"), meta.ID, meta.UUID, ip, createRequest.Port,
+	)
+
+	createLock.Lock()
+	id, err := createInstance(meta)
+	createLock.Unlock()
+
+	if err != nil {
+		log.Error("Can't create instance for migration: %v", err)
+		encodeAndWrite(w, &API.DefaultResponse{Status: getErrorStatus(err)})
+		return
+	}
+
+	err = CORE.ReplicaOf(id, ip, createRequest.Port)
+
+	if err != nil {
+		log.Error("(%3d) Can't start replication from %s:%d: %v", id, ip, createRequest.Port, err)
+		destroyInstance(id, meta.UUID)
+		encodeAndWrite(w, &API.DefaultResponse{Status: getErrorStatus(err)})
+		return
+	}
+
+	log.Info("(%3d) Instance created and started replication from %s:%d", id, ip, createRequest.Port)
+
+	response := &API.MigrationCreateResponse{
+		Status: statusOK,
+		ID:     id,
+		Port:   CORE.GetInstancePort(id),
+	}
+
+	if CORE.IsTLSEnabled() {
+		response.TLSPort = CORE.GetInstanceTLSPort(id)
+	}
+
+	encodeAndWrite(w, response)
+}
+
+// statusHandler is "migration-status" command handler
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	appendHeader(w)
+
+	if !checkRequest(w, r, API.METHOD_MIGRATION_STATUS) {
+		return
+	}
+
+	id, ok := readMigrationRequest(w, r)
+
+	if !ok {
+		return
+	}
+
+	info, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
+
+	if err != nil {
+		encodeAndWrite(w, &API.DefaultResponse{Status: getErrorStatus(err)})
+		return
+	}
+
+	encodeAndWrite(w, &API.MigrationStatusResponse{
+		Status: statusOK,
+		Info: &API.MigrationInfo{
+			LinkStatus:     info.Get("replication", "master_link_status"),
+			SyncInProgress: info.Get("replication", "master_sync_in_progress") == "1",
+			SyncLeftBytes:  int64(info.GetI("replication", "master_sync_left_bytes")),
+			Offset:         int64(info.GetU("replication", "slave_repl_offset")),
+		},
+	})
+}
+
+// promoteHandler is "migration-promote" command handler
+func promoteHandler(w http.ResponseWriter, r *http.Request) {
+	appendHeader(w)
+
+	if !checkRequest(w, r, API.METHOD_MIGRATION_PROMOTE) {
+		return
+	}
+
+	id, ok := readMigrationRequest(w, r)
+
+	if !ok {
+		return
+	}
+
+	err := CORE.ReplicaOf(id, "", 0)
+
+	if err != nil {
+		log.Error("(%3d) Can't promote instance to master: %v", id, err)
+		encodeAndWrite(w, &API.DefaultResponse{Status: getErrorStatus(err)})
+		return
+	}
+
+	log.Info("(%3d) Instance promoted to master", id)
+
+	encodeAndWrite(w, &API.DefaultResponse{Status: statusOK})
+}
+
+// abortHandler is "migration-abort" command handler
+func abortHandler(w http.ResponseWriter, r *http.Request) {
+	appendHeader(w)
+
+	if !checkRequest(w, r, API.METHOD_MIGRATION_ABORT) {
+		return
+	}
+
+	id, ok := readMigrationRequest(w, r)
+
+	if !ok {
+		return
+	}
+
+	info, err := CORE.GetInstanceInfo(id, 3*time.Second, false)
+
+	// Only instance which is still a replica can be removed
+	if err == nil && info.Get("replication", "role") != "slave" {
+		encodeAndWrite(w, &API.DefaultResponse{
+			Status: API.ResponseStatus{
+				Code: API.STATUS_INCORRECT_REQUEST,
+				Desc: fmt.Sprintf("Instance %d is already promoted to master", id),
+			},
+		})
+
+		return
+	}
+
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err == nil {
+		err = destroyInstance(id, meta.UUID)
+	}
+
+	if err != nil {
+		encodeAndWrite(w, &API.DefaultResponse{Status: getErrorStatus(err)})
+		return
+	}
+
+	log.Info("(%3d) Migration aborted, instance destroyed", id)
+
+	encodeAndWrite(w, &API.DefaultResponse{Status: statusOK})
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// createInstance creates and starts instance with given meta
+func createInstance(meta *CORE.InstanceMeta) (int, error) {
+	if CORE.GetInstanceByUUID(meta.UUID) != -1 {
+		return -1, fmt.Errorf("Instance with UUID %s already exists", meta.UUID)
+	}
+
+	id := CORE.GetMigrationInstanceID(meta.ID)
+
+	if id == -1 {
+		return -1, fmt.Errorf("No available ID for usage")
+	}
+
+	meta.ID = id
+	meta.Config = &CORE.InstanceConfigInfo{}
+
+	if meta.Preferencies.TemplateProfile != "" &&
+		!CORE.IsTemplateProfileExist(meta.Preferencies.TemplateProfile) {
+		meta.Preferencies.TemplateProfile = ""
+	}
+
+	err := CORE.CreateInstance(meta)
+
+	if err != nil {
+		return -1, err
+	}
+
+	err = CORE.StartInstance(id, true)
+
+	if err != nil {
+		CORE.DestroyInstance(id)
+		return -1, fmt.Errorf("Can't start instance: %w", err)
+	}
+
+	propagateCommand(API.COMMAND_CREATE, id, meta.UUID)
+	propagateCommand(API.COMMAND_START, id, meta.UUID)
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		log.Error("Can't save instances states: %v", err)
+	}
+
+	return id, nil
+}
+
+// destroyInstance destroys instance created for migration
+func destroyInstance(id int, uuid string) error {
+	err := CORE.DestroyInstance(id)
+
+	if err != nil {
+		log.Error("(%3d) Can't destroy instance: %v", id, err)
+		return err
+	}
+
+	propagateCommand(API.COMMAND_DESTROY, id, uuid)
+
+	err = CORE.SaveStates(CORE.GetStatesFilePath())
+
+	if err != nil {
+		log.Error("Can't save instances states: %v", err)
+	}
+
+	return nil
+}
+
+// propagateCommand sends command to minions if node is RDS master
+func propagateCommand(command API.MasterCommand, id int, uuid string) {
+	if !CORE.IsMaster() {
+		return
+	}
+
+	err := SC.PropagateCommand(command, id, uuid)
+
+	if err != nil {
+		log.Error("(%3d) Can't propagate command %s: %v", id, command, err)
+	}
+}
+
+// readMigrationRequest reads request with instance ID and UUID and checks instance
+func readMigrationRequest(w http.ResponseWriter, r *http.Request) (int, bool) {
+	migrationRequest := &API.MigrationRequest{}
+	err := readAndDecode(r, migrationRequest)
+
+	if err != nil {
+		encodeAndWrite(w, &API.DefaultResponse{Status: statusArgError})
+		return -1, false
+	}
+
+	id := migrationRequest.ID
+
+	if !CORE.IsInstanceExist(id) {
+		encodeAndWrite(w, &API.DefaultResponse{
+			Status: API.ResponseStatus{
+				Code: API.STATUS_UNKNOWN_INSTANCE,
+				Desc: fmt.Sprintf("Instance with ID %d does not exist", id),
+			},
+		})
+
+		return -1, false
+	}
+
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil || meta.UUID != migrationRequest.UUID {
+		encodeAndWrite(w, &API.DefaultResponse{
+			Status: API.ResponseStatus{
+				Code: API.STATUS_UNKNOWN_INSTANCE,
+				Desc: fmt.Sprintf("Instance with ID %d has different UUID", id),
+			},
+		})
+
+		return -1, false
+	}
+
+	return id, true
+}
+
+// checkRequest checks request method and auth token and writes error to writer
+// if request is invalid
+func checkRequest(w http.ResponseWriter, r *http.Request, apiMethod API.Method) bool {
+	ip := httputil.GetRemoteHost(r)
+	token := CORE.Config.GetS(CORE.MIGRATION_AUTH_TOKEN)
+	header := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	if subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+		log.Error("{%s:%s:%s} Got request with unknown auth token", r.Method, ip, apiMethod)
+		encodeAndWrite(w, &API.DefaultResponse{Status: statusTokenError})
+		return false
+	}
+
+	if r.Method != "POST" {
+		log.Error(
+			"{%s:%s:%s} Got request with unsupported HTTP method (%s ≠ POST)",
+			r.Method, ip, apiMethod, r.Method,
+		)
+
+		encodeAndWrite(w, &API.DefaultResponse{
+			Status: API.ResponseStatus{
+				Code: API.STATUS_WRONG_METHOD,
+				Desc: fmt.Sprintf("Method %s is not supported", r.Method),
+			},
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// getErrorStatus returns response status for given error
+func getErrorStatus(err error) API.ResponseStatus {
+	return API.ResponseStatus{Code: API.STATUS_UNKNOWN_ERROR, Desc: err.Error()}
+}
+
+// appendHeader append header to response
+func appendHeader(w http.ResponseWriter) {
+	w.Header().Set("Server", "RDS-Sync/"+daemonVersion)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+// encodeAndWrite encode struct to json and write as response
+func encodeAndWrite(w http.ResponseWriter, data any) error {
+	jd, err := json.MarshalIndent(data, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	w.Write(jd)
+
+	return nil
+}
+
+// readAndDecode read json data from request and decode
+func readAndDecode(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+
+	return decoder.Decode(v)
+}
@@ -653,6 +653,19 @@ func editInstance(meta *CORE.InstanceMeta) bool {
 		)
 	}
 
+	if oldMeta.Preferencies.IsSaveDisabled != meta.Preferencies.IsSaveDisabled ||
+		oldMeta.Preferencies.TemplateProfile != meta.Preferencies.TemplateProfile ||
+		oldMeta.Storage.Get(CORE.META_CUSTOM_DIRECTIVES) != meta.Storage.Get(CORE.META_CUSTOM_DIRECTIVES) {
+		err = CORE.RegenerateInstanceConfig(id)
+
+		if err != nil {
+			log.Error("(%3d) Error while configuration regeneration: %v", id, err)
+			return false
+		}
+
+		log.Info("(%3d) Configuration regenerated", id)
+	}
+
 	return true
 }
 
@@ -1145,6 +1158,19 @@ func changeInstanceReplicationType(id int, replType CORE.ReplicationType) error
 		return nil
 	}
 
+	meta, err := CORE.GetInstanceMeta(id)
+
+	if err != nil {
+		return fmt.Errorf("Can't read instance meta: %v", err)
+	}
+
+	roleVar := "RDS_INSTANCE_ROLE=" + string(replType)
+	err = CORE.RunInstanceHooks(CORE.HOOK_STAGE_PRE, CORE.HOOK_EVENT_ROLE_CHANGE, meta, roleVar)
+
+	if err != nil {
+		return err
+	}
+
 	switch replType {
 	case CORE.REPL_TYPE_REPLICA:
 		err = changeInstanceToReplica(id)
@@ -1152,13 +1178,17 @@ func changeInstanceReplicationType(id int, replType CORE.ReplicationType) error
 		err = changeInstanceToStadby(id)
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return CORE.RunInstanceHooks(CORE.HOOK_STAGE_POST, CORE.HOOK_EVENT_ROLE_CHANGE, meta, roleVar)
 }
 
 // chengeInstanceToReplica changes instance replication type to "replica"
 func changeInstanceToReplica(id int) error {
 	masterHost := CORE.Config.GetS(CORE.REPLICATION_MASTER_IP)
-	masterPort := strconv.Itoa(CORE.GetInstancePort(id))
+	masterPort := strconv.Itoa(CORE.GetInstanceReplicationPort(id))
 
 	resp, err := CORE.ExecCommand(id, &REDIS.Request{
 		Command: []string{"REPLICAOF", masterHost, masterPort},
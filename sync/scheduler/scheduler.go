@@ -0,0 +1,155 @@
+package scheduler
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"time"
+
+	"github.com/essentialkaos/ek/v13/cron"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	CORE "github.com/essentialkaos/rds/core"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MAX_CATCH_UP_PERIOD is maximum period for checking missed schedule slots
+const MAX_CATCH_UP_PERIOD = 24 * time.Hour
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// schedules is cache with parsed cron expressions
+var schedules = make(map[string]*cron.Expr)
+
+// lastChecks contains time of the last schedule check for every instance
+var lastChecks = make(map[int]time.Time)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Start starts backup scheduler. Schedules are checked on every tick, so
+// scheduler starts creating backups as soon as any schedule is configured.
+func Start() {
+	log.Info("Backup scheduler started")
+
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		if !CORE.HasBackupSchedules() {
+			// Slots passed while schedules were disabled must not be caught up
+			clear(lastChecks)
+			continue
+		}
+
+		runScheduledBackups()
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// runScheduledBackups creates backups for all instances with schedule due
+// since the previous check. Backups are created one by one to avoid running BGSAVE
+// on many instances at the same time, so slots passed while previous backups
+// were created are caught up with one backup.
+func runScheduledBackups() {
+	for _, id := range CORE.GetInstanceIDList() {
+		now := time.Now().Truncate(time.Minute)
+		lastCheck := lastChecks[id]
+		lastChecks[id] = now
+
+		schedule := getSchedule(CORE.GetInstanceBackupSchedule(id))
+
+		if schedule == nil {
+			continue
+		}
+
+		slot := getLastDueSlot(schedule, lastCheck, now)
+
+		if slot.IsZero() {
+			continue
+		}
+
+		if slot.Before(now) {
+			log.Warn(
+				"(%3d) Scheduled backup for %s was delayed by other backups, creating it now",
+				id, timeutil.Format(slot, "%Y/%m/%d %H:%M"),
+			)
+		}
+
+		createBackup(id)
+	}
+}
+
+// getLastDueSlot returns the latest minute in period (lastCheck, now] when
+// schedule is due. If last check time is unknown, only current minute is
+// checked.
+func getLastDueSlot(schedule *cron.Expr, lastCheck, now time.Time) time.Time {
+	from := now
+
+	if !lastCheck.IsZero() {
+		from = lastCheck.Add(time.Minute)
+	}
+
+	if now.Sub(from) > MAX_CATCH_UP_PERIOD {
+		from = now.Add(-MAX_CATCH_UP_PERIOD)
+	}
+
+	for slot := now; !slot.Before(from); slot = slot.Add(-time.Minute) {
+		if schedule.IsDue(slot) {
+			return slot
+		}
+	}
+
+	return time.Time{}
+}
+
+// createBackup creates backup of instance with given ID and logs result
+func createBackup(id int) {
+	start := time.Now()
+	backup, err := CORE.CreateInstanceBackup(id, nil)
+
+	if backup == nil {
+		log.Error("(%3d) Can't create scheduled backup: %v", id, err)
+		return
+	}
+
+	log.Info(
+		"(%3d) Created scheduled backup %s (%s) in %s", id, backup.File,
+		fmtutil.PrettySize(backup.Size),
+		timeutil.PrettyDuration(time.Since(start)),
+	)
+
+	if err != nil {
+		log.Error("(%3d) Can't apply backups retention policy: %v", id, err)
+	}
+}
+
+// getSchedule returns parsed cron expression
+func getSchedule(expr string) *cron.Expr {
+	if expr == "" {
+		return nil
+	}
+
+	schedule, ok := schedules[expr]
+
+	if ok {
+		return schedule
+	}
+
+	schedule, err := cron.Parse(expr)
+
+	if err != nil {
+		log.Error("Can't parse backup schedule %q: %v", expr, err)
+	}
+
+	schedules[expr] = schedule
+
+	return schedule
+}
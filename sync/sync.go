@@ -29,8 +29,11 @@ import (
 	"github.com/essentialkaos/rds/support"
 
 	CORE "github.com/essentialkaos/rds/core"
+	HISTORY "github.com/essentialkaos/rds/sync/history"
 	MASTER "github.com/essentialkaos/rds/sync/master"
+	MIGRATION "github.com/essentialkaos/rds/sync/migration"
 	MINION "github.com/essentialkaos/rds/sync/minion"
+	SCHEDULER "github.com/essentialkaos/rds/sync/scheduler"
 	SENTINEL "github.com/essentialkaos/rds/sync/sentinel"
 )
 
@@ -280,7 +283,14 @@ func checkSystemConfiguration() error {
 
 // validateConfig validate sync specific configuration values
 func validateConfig() error {
-	if CORE.Config.GetS(CORE.REPLICATION_ROLE) == CORE.ROLE_MASTER {
+	role := CORE.Config.GetS(CORE.REPLICATION_ROLE)
+
+	// Standalone node doesn't require auth token
+	if role == "" {
+		return nil
+	}
+
+	if role == CORE.ROLE_MASTER {
 		ips := netutil.GetAllIP()
 		ips = append(ips, netutil.GetAllIP6()...)
 
@@ -313,12 +323,20 @@ func startSyncDaemon(gitRev string) int {
 	role := CORE.Config.GetS(CORE.REPLICATION_ROLE)
 
 	if role == "" {
-		log.Error("Replication is disabled. Shutdown…")
-		return EC_ERROR
+		return startStandaloneDaemon(gitRev)
+	}
+
+	if role != CORE.ROLE_SENTINEL {
+		go SCHEDULER.Start()
+		go HISTORY.Start()
 	}
 
 	switch role {
 	case CORE.ROLE_MASTER:
+		if CORE.IsMigrationEnabled() {
+			go MIGRATION.Start(APP, VER, gitRev)
+		}
+
 		ec = MASTER.Start(APP, VER, gitRev)
 	case CORE.ROLE_MINION:
 		ec = MINION.Start(APP, VER, gitRev)
@@ -332,6 +350,26 @@ func startSyncDaemon(gitRev string) int {
 	return ec
 }
 
+// startStandaloneDaemon starts sync daemon on node without replication
+func startStandaloneDaemon(gitRev string) int {
+	if !CORE.IsMigrationEnabled() && !CORE.HasBackupSchedules() && !CORE.IsHistoryEnabled() {
+		log.Error("Replication, migration, scheduled backups and metrics history are disabled. Shutdown…")
+		return EC_ERROR
+	}
+
+	go SCHEDULER.Start()
+	go HISTORY.Start()
+
+	if CORE.IsMigrationEnabled() {
+		return MIGRATION.Start(APP, VER, gitRev)
+	}
+
+	log.Aux("%s %s started in standalone mode", APP, VER)
+
+	// Block forever, daemon is stopped by TERM or INT signal handler
+	select {}
+}
+
 // renameProcess renames current daemon process
 func renameProcess() error {
 	var args []string
@@ -359,7 +397,10 @@ func renameProcess() error {
 // shutdown gracefully shutdown daemon
 func shutdown(code int) {
 	switch CORE.Config.GetS(CORE.REPLICATION_ROLE) {
+	case "":
+		MIGRATION.Stop()
 	case CORE.ROLE_MASTER:
+		MIGRATION.Stop()
 		MASTER.Stop()
 	case CORE.ROLE_MINION:
 		MINION.Stop()